@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// AccessConfig gives each client identity a role. Requests carry a
+// principal: the one an HTTP authenticator attaches, or Principal for a stdio
+// session, which has no other identity. A principal a role doesn't list gets
+// DefaultRole; with no roles configured there are no per-principal limits.
+type AccessConfig struct {
+	Principal   string       `toml:"principal"`
+	DefaultRole string       `toml:"default_role"`
+	Roles       []RoleConfig `toml:"roles"`
+}
+
+// RoleConfig limits what a role's principals may read. Schemas and Tables
+// ("db.table" glob patterns) together list what queries may reference; both
+// empty allows everything the read-only gate does. MaxRows lowers
+// mysql.max_rows, and QueriesPerMinute caps tool calls per principal.
+// MySQLRoles ("role" or "role@host") are activated with SET ROLE on the
+// connection for each of the principals' queries, so MySQL enforces their
+// grants as well.
+type RoleConfig struct {
+	Name             string   `toml:"name"`
+	Principals       []string `toml:"principals"`
+	Schemas          []string `toml:"schemas"`
+	Tables           []string `toml:"tables"`
+	MaxRows          int      `toml:"max_rows"`
+	QueriesPerMinute int      `toml:"queries_per_minute"`
+	MySQLRoles       []string `toml:"mysql_roles"`
+}
+
+// mysqlRoleRE matches a MySQL role name with an optional host part.
+var mysqlRoleRE = regexp.MustCompile(`^[A-Za-z0-9_$-]+(@[A-Za-z0-9_.%-]+)?$`)
+
+func validateAccessConfig(cfg AccessConfig) error {
+	names := make(map[string]bool)
+	principals := make(map[string]string)
+	for _, role := range cfg.Roles {
+		if role.Name == "" {
+			return fmt.Errorf("access.roles: every role needs a name")
+		}
+		if names[role.Name] {
+			return fmt.Errorf("access.roles %q: duplicate role", role.Name)
+		}
+		names[role.Name] = true
+		for _, principal := range role.Principals {
+			if other, ok := principals[principal]; ok {
+				return fmt.Errorf("access.roles %q: principal %q is already in role %q", role.Name, principal, other)
+			}
+			principals[principal] = role.Name
+		}
+		for _, pattern := range role.Tables {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("access.roles %q: tables %q: %w", role.Name, pattern, err)
+			}
+			if !strings.Contains(pattern, ".") {
+				return fmt.Errorf("access.roles %q: tables %q: patterns must be db.table", role.Name, pattern)
+			}
+		}
+		for _, name := range role.MySQLRoles {
+			if !mysqlRoleRE.MatchString(name) {
+				return fmt.Errorf("access.roles %q: mysql_roles %q: expected a role name, optionally with @host", role.Name, name)
+			}
+		}
+		if role.MaxRows < 0 || role.QueriesPerMinute < 0 {
+			return fmt.Errorf("access.roles %q: max_rows and queries_per_minute can't be negative", role.Name)
+		}
+	}
+	if cfg.DefaultRole != "" && !names[cfg.DefaultRole] {
+		return fmt.Errorf("access.default_role %q is not a configured role", cfg.DefaultRole)
+	}
+	return nil
+}
+
+type principalKey struct{}
+
+// withPrincipal records the authenticated identity of a request.
+func withPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// accessControl resolves principals to roles and rate limits their calls.
+type accessControl struct {
+	principal   string
+	defaultRole *RoleConfig
+	roles       map[string]*RoleConfig
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+// rateBucket is a token bucket refilled at the role's queries_per_minute.
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newAccessControl(cfg AccessConfig) *accessControl {
+	a := &accessControl{principal: cfg.Principal, roles: make(map[string]*RoleConfig), buckets: make(map[string]*rateBucket)}
+	for i := range cfg.Roles {
+		role := &cfg.Roles[i]
+		if role.Name == cfg.DefaultRole {
+			a.defaultRole = role
+		}
+		for _, principal := range role.Principals {
+			a.roles[principal] = role
+		}
+	}
+	return a
+}
+
+// role returns ctx's principal and its role, or nil if it has none.
+func (a *accessControl) role(ctx context.Context) (string, *RoleConfig) {
+	if a == nil {
+		return "", nil
+	}
+	principal, ok := ctx.Value(principalKey{}).(string)
+	if !ok {
+		principal = a.principal
+	}
+	if role, ok := a.roles[principal]; ok {
+		return principal, role
+	}
+	return principal, a.defaultRole
+}
+
+// principalFor returns the principal a tool call was made as: the one an
+// HTTP authenticator verified, or the configured stdio principal.
+func (a *accessControl) principalFor(req *mcp.CallToolRequest) string {
+	if req != nil {
+		if principal := requestPrincipal(req.Extra); principal != "" {
+			return principal
+		}
+	}
+	if a == nil {
+		return ""
+	}
+	return a.principal
+}
+
+// allow takes one call from the principal's rate limit, or returns an error
+// saying when to retry.
+func (a *accessControl) allow(ctx context.Context, now time.Time) error {
+	principal, role := a.role(ctx)
+	if role == nil || role.QueriesPerMinute == 0 {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rate := float64(role.QueriesPerMinute) / 60
+	b, ok := a.buckets[principal]
+	if !ok {
+		b = &rateBucket{tokens: float64(role.QueriesPerMinute), last: now}
+		a.buckets[principal] = b
+	}
+	b.tokens = min(float64(role.QueriesPerMinute), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return fmt.Errorf("rate limit of %d calls per minute for role %q exceeded; retry in %s", role.QueriesPerMinute, role.Name, wait.Round(time.Second))
+	}
+	b.tokens--
+	return nil
+}
+
+// restricted reports whether ctx's role limits the schemas and tables it
+// may read.
+func (a *accessControl) restricted(ctx context.Context) bool {
+	_, role := a.role(ctx)
+	return role != nil && (len(role.Schemas) > 0 || len(role.Tables) > 0)
+}
+
+// authorize returns a *gate.QueryRejection if stmt references a table or
+// database outside the schemas and tables of ctx's role.
+func (a *accessControl) authorize(ctx context.Context, stmt sqlparser.Statement, defaultSchema string) error {
+	if !a.restricted(ctx) {
+		return nil
+	}
+	_, role := a.role(ctx)
+	for _, ref := range statementReferences(stmt, defaultSchema) {
+		if err := role.check(ref.Schema, ref.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statementReferences lists what stmt reads by name: the base tables it
+// reads rows from, the tables SHOW and DESCRIBE name, and qualified table
+// and function names anywhere. A database that's named without a table,
+// as in SHOW TABLES FROM db or db.fn(), has an empty Name.
+func statementReferences(stmt sqlparser.Statement, defaultSchema string) []gate.Table {
+	refs := gate.QueryTables(stmt, defaultSchema)
+	table := func(name sqlparser.TableName, schema string) gate.Table {
+		if !name.Qualifier.IsEmpty() {
+			schema = name.Qualifier.String()
+		}
+		return gate.Table{Schema: schema, Name: name.Name.String()}
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case sqlparser.TableName:
+			if !n.Qualifier.IsEmpty() {
+				refs = append(refs, table(n, ""))
+			}
+		case *sqlparser.FuncExpr:
+			if !n.Qualifier.IsEmpty() {
+				refs = append(refs, gate.Table{Schema: n.Qualifier.String()})
+			}
+		case *sqlparser.ExplainTab:
+			refs = append(refs, table(n.Table, defaultSchema))
+		case *sqlparser.ShowCreate:
+			switch n.Command {
+			case sqlparser.CreateDb:
+				refs = append(refs, gate.Table{Schema: n.Op.Name.String()})
+			case sqlparser.CreateTbl, sqlparser.CreateV, sqlparser.CreateTr:
+				refs = append(refs, table(n.Op, defaultSchema))
+			default:
+				refs = append(refs, gate.Table{Schema: table(n.Op, defaultSchema).Schema})
+			}
+		case *sqlparser.ShowBasic:
+			schema := defaultSchema
+			if !n.DbName.IsEmpty() {
+				schema = n.DbName.String()
+			}
+			switch {
+			case !n.Tbl.Name.IsEmpty():
+				refs = append(refs, table(n.Tbl, schema))
+			case !n.DbName.IsEmpty(), n.Command == sqlparser.Table || n.Command == sqlparser.TableStatus:
+				refs = append(refs, gate.Table{Schema: schema})
+			}
+		}
+		return true, nil
+	}, stmt)
+	return refs
+}
+
+// authorizeTable returns a *gate.QueryRejection if schema.table, or with no
+// table the schema itself, is outside ctx's role. Catalog tools and
+// resources, whose queries aren't put through authorize, check the database
+// they're asked about this way.
+func (a *accessControl) authorizeTable(ctx context.Context, schema, table string) error {
+	if !a.restricted(ctx) {
+		return nil
+	}
+	_, role := a.role(ctx)
+	return role.check(schema, table)
+}
+
+// check returns a *gate.QueryRejection if schema.table, or with no table the
+// schema itself, is outside the role. A schema is inside it if the role
+// lists the schema or a table pattern in it.
+func (r *RoleConfig) check(schema, table string) error {
+	for _, s := range r.Schemas {
+		if strings.EqualFold(s, schema) {
+			return nil
+		}
+	}
+	name := schema
+	if table != "" {
+		name = schema + "." + table
+		if gate.TableMatches(r.Tables, name) {
+			return nil
+		}
+	} else {
+		for _, pattern := range r.Tables {
+			db, _, _ := strings.Cut(pattern, ".")
+			if ok, _ := path.Match(strings.ToLower(db), strings.ToLower(schema)); ok {
+				return nil
+			}
+		}
+	}
+	if schema == "" {
+		name = table
+	}
+	return &gate.QueryRejection{Construct: name, Reason: fmt.Sprintf("role %q may not read %s", r.Name, name)}
+}
+
+// checkRoleArguments rejects a tool call whose arguments name a database,
+// or a table of one, outside ctx's role.
+func (h *queryHandler) checkRoleArguments(ctx context.Context, req *mcp.CallToolRequest) error {
+	if req == nil || req.Params == nil {
+		return nil
+	}
+	var args map[string]any
+	if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+		return nil
+	}
+	for _, key := range databaseArguments {
+		name, ok := args[key].(string)
+		if !ok || name == "" {
+			continue
+		}
+		var table string
+		if key == "database" {
+			table, _ = args["table"].(string)
+		}
+		if err := h.access.authorizeTable(ctx, name, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorize parses query, which has passed the read-only gate, and checks it
+// against ctx's role.
+func (h *queryHandler) authorize(ctx context.Context, query string) error {
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return nil
+	}
+	return h.access.authorize(ctx, stmt, h.defaultSchema)
+}
+
+// mysqlRoles returns the MySQL roles ctx's role activates, if any.
+func (a *accessControl) mysqlRoles(ctx context.Context) []string {
+	if _, role := a.role(ctx); role != nil {
+		return role.MySQLRoles
+	}
+	return nil
+}
+
+// setRole activates the MySQL roles of ctx's role on conn. Call restore
+// before conn goes back to the pool: it reverts to the account's default
+// roles, or discards the connection if that fails, so the roles can't
+// carry over to another principal's query.
+func (h *queryHandler) setRole(ctx context.Context, conn *sql.Conn) (restore func(), err error) {
+	roles := h.access.mysqlRoles(ctx)
+	if len(roles) == 0 {
+		return func() {}, nil
+	}
+	quoted := make([]string, len(roles))
+	for i, role := range roles {
+		name, host, ok := strings.Cut(role, "@")
+		quoted[i] = "`" + name + "`"
+		if ok {
+			quoted[i] += "@`" + host + "`"
+		}
+	}
+	if _, err := conn.ExecContext(ctx, "SET ROLE "+strings.Join(quoted, ", ")); err != nil {
+		return nil, fmt.Errorf("failed to set MySQL roles: %w", err)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, "SET ROLE DEFAULT"); err != nil {
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}, nil
+}
+
+// maxRows returns maxRows lowered to ctx's role limit.
+func (a *accessControl) maxRows(ctx context.Context, maxRows int) int {
+	if _, role := a.role(ctx); role != nil && role.MaxRows > 0 && role.MaxRows < maxRows {
+		return role.MaxRows
+	}
+	return maxRows
+}
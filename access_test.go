@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestAccessControlRoles(t *testing.T) {
+	cfg := AccessConfig{
+		Principal:   "local",
+		DefaultRole: "guest",
+		Roles: []RoleConfig{
+			{Name: "analyst", Principals: []string{"team-a"}, Schemas: []string{"shop"}, Tables: []string{"hr.teams"}, MaxRows: 100},
+			{Name: "guest", Tables: []string{"shop.products"}},
+		},
+	}
+	require.NoError(t, validateAccessConfig(cfg))
+	a := newAccessControl(cfg)
+	analyst := withPrincipal(context.Background(), "team-a")
+
+	principal, role := a.role(context.Background())
+	require.Equal(t, "local", principal)
+	require.Equal(t, "guest", role.Name)
+	_, role = a.role(analyst)
+	require.Equal(t, "analyst", role.Name)
+
+	authorize := func(ctx context.Context, query string) error {
+		stmt, err := gate.ParseStatement(query)
+		require.NoError(t, err)
+		return a.authorize(ctx, stmt, "shop")
+	}
+	require.NoError(t, authorize(analyst, "SELECT * FROM orders o JOIN hr.teams t ON t.id = o.team_id"))
+	require.ErrorContains(t, authorize(analyst, "SELECT * FROM hr.salaries"), `role "analyst" may not read hr.salaries`)
+	require.NoError(t, authorize(context.Background(), "SELECT * FROM products"))
+	require.Error(t, authorize(context.Background(), "SELECT * FROM orders"))
+
+	require.Equal(t, 100, a.maxRows(analyst, 1000))
+	require.Equal(t, 50, a.maxRows(analyst, 50))
+	require.Equal(t, 1000, a.maxRows(context.Background(), 1000))
+
+	var none *accessControl
+	require.Equal(t, 1000, none.maxRows(analyst, 1000))
+	require.NoError(t, none.allow(analyst, time.Now()))
+}
+
+func TestAccessControlRateLimit(t *testing.T) {
+	a := newAccessControl(AccessConfig{Roles: []RoleConfig{{Name: "bot", Principals: []string{"bot"}, QueriesPerMinute: 2}}})
+	ctx := withPrincipal(context.Background(), "bot")
+	now := time.Now()
+
+	require.NoError(t, a.allow(ctx, now))
+	require.NoError(t, a.allow(ctx, now))
+	require.ErrorContains(t, a.allow(ctx, now), "retry in 30s")
+	require.NoError(t, a.allow(ctx, now.Add(30*time.Second)))
+	require.NoError(t, a.allow(context.Background(), now), "principals without a role aren't limited")
+}
+
+func TestValidateAccessConfig(t *testing.T) {
+	for _, cfg := range []AccessConfig{
+		{Roles: []RoleConfig{{}}},
+		{Roles: []RoleConfig{{Name: "a"}, {Name: "a"}}},
+		{Roles: []RoleConfig{{Name: "a", Principals: []string{"x"}}, {Name: "b", Principals: []string{"x"}}}},
+		{Roles: []RoleConfig{{Name: "a", Tables: []string{"orders"}}}},
+		{Roles: []RoleConfig{{Name: "a", MySQLRoles: []string{"ro`; DROP ROLE x"}}}},
+		{DefaultRole: "missing"},
+	} {
+		require.Error(t, validateAccessConfig(cfg), cfg)
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	cfg := AccessConfig{Roles: []RoleConfig{{Name: "analyst", Principals: []string{"team-a"}, MySQLRoles: []string{"analyst_ro", "reports@%"}}}}
+	require.NoError(t, validateAccessConfig(cfg))
+	conn := &recordingConn{}
+	h := &queryHandler{access: newAccessControl(cfg)}
+	setRole := func(ctx context.Context) func() {
+		c, err := sql.OpenDB(stubConnector{conn: conn}).Conn(ctx)
+		require.NoError(t, err)
+		restore, err := h.setRole(ctx, c)
+		require.NoError(t, err)
+		return restore
+	}
+
+	setRole(context.Background())()
+	require.Empty(t, conn.executed, "principals without MySQL roles keep the account's")
+
+	setRole(withPrincipal(context.Background(), "team-a"))()
+	require.Equal(t, []string{"SET ROLE `analyst_ro`, `reports`@`%`", "SET ROLE DEFAULT"}, conn.executed)
+
+	// A connection that can't drop the roles isn't reused.
+	conn.failOn = "SET ROLE DEFAULT"
+	setRole(withPrincipal(context.Background(), "team-a"))()
+	require.True(t, conn.closed)
+}
+
+func TestAuthorizeCatalogArguments(t *testing.T) {
+	cfg := AccessConfig{Roles: []RoleConfig{{Name: "team-a", Principals: []string{"alice"}, Schemas: []string{"shop"}, Tables: []string{"hr.teams"}}}}
+	h := &queryHandler{access: newAccessControl(cfg)}
+	alice := withPrincipal(context.Background(), "alice")
+
+	require.NoError(t, h.access.authorizeTable(alice, "SHOP", "orders"))
+	require.NoError(t, h.access.authorizeTable(alice, "hr", "teams"))
+	require.NoError(t, h.access.authorizeTable(alice, "hr", ""))
+	require.ErrorContains(t, h.access.authorizeTable(alice, "hr", "salaries"), `role "team-a" may not read hr.salaries`)
+	require.ErrorContains(t, h.access.authorizeTable(alice, "billing", ""), `may not read billing`)
+	require.NoError(t, h.access.authorizeTable(context.Background(), "billing", ""))
+
+	for args, ok := range map[string]bool{
+		`{"database":"shop","table":"orders"}`: true,
+		`{"database":"hr","table":"salaries"}`: false,
+		`{"source":"shop","target":"billing"}`: false,
+		`{"query":"SELECT 1"}`:                 true,
+	} {
+		req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: json.RawMessage(args)}}
+		require.Equal(t, ok, h.checkRoleArguments(alice, req) == nil, args)
+	}
+
+	// Resources are rate limited and checked against the role too.
+	h.access = newAccessControl(AccessConfig{Roles: []RoleConfig{{Name: "team-a", Principals: []string{"alice"}, Schemas: []string{"shop"}, QueriesPerMinute: 1}}})
+	read := func(uri string) error {
+		_, err := h.readResource(alice, &mcp.ReadResourceRequest{Params: &mcp.ReadResourceParams{URI: uri}})
+		return err
+	}
+	require.ErrorContains(t, read("mysql://ddl/billing/invoices"), "may not read billing.invoices")
+	require.ErrorContains(t, read("mysql://ddl/billing/invoices"), "rate limit")
+}
+
+func TestAuthorizeShowAndFunctions(t *testing.T) {
+	a := newAccessControl(AccessConfig{Principal: "local", DefaultRole: "app", Roles: []RoleConfig{{Name: "app", Schemas: []string{"app"}}}})
+	authorize := func(query string) error {
+		stmt, err := gate.ParseStatement(query)
+		require.NoError(t, err, query)
+		return a.authorize(context.Background(), stmt, "app")
+	}
+	for _, query := range []string{
+		"SHOW TABLES FROM secret",
+		"SHOW TABLE STATUS FROM secret",
+		"SHOW CREATE TABLE secret.t",
+		"SHOW CREATE DATABASE secret",
+		"DESCRIBE secret.t",
+		"SHOW COLUMNS FROM secret.t",
+		"SHOW COLUMNS FROM t FROM secret",
+		"SHOW INDEX FROM secret.t",
+		"SELECT secret.fn(1)",
+		"SELECT secret.t.id FROM t",
+	} {
+		var rejection *gate.QueryRejection
+		require.ErrorAs(t, authorize(query), &rejection, query)
+		require.Contains(t, rejection.Reason, "secret", query)
+	}
+	for _, query := range []string{
+		"SHOW TABLES",
+		"SHOW TABLES FROM app",
+		"SHOW CREATE TABLE t",
+		"DESCRIBE app.t",
+		"SHOW INDEX FROM t",
+		"SELECT app.fn(1), UPPER(name) FROM t",
+		"SHOW VARIABLES LIKE 'max_%'",
+	} {
+		require.NoError(t, authorize(query), query)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ActiveQuery describes a tool query that is currently executing. Only the
+// fingerprint is exposed, so other sessions can see what is running without
+// seeing the literal values in it.
+type ActiveQuery struct {
+	ID          string    `json:"id"`
+	Tool        string    `json:"tool"`
+	Session     string    `json:"session"`
+	Client      string    `json:"client,omitempty"`
+	Fingerprint string    `json:"fingerprint"`
+	StartedAt   time.Time `json:"startedAt"`
+	ElapsedMs   int64     `json:"elapsedMs"`
+}
+
+type ActiveQueriesOutput struct {
+	Queries []ActiveQuery `json:"queries"`
+}
+
+// activeQueries tracks in-flight tool queries across all sessions.
+type activeQueries struct {
+	mu      sync.Mutex
+	entries map[string]ActiveQuery
+}
+
+func newActiveQueries() *activeQueries {
+	return &activeQueries{entries: make(map[string]ActiveQuery)}
+}
+
+// begin registers a query as running and returns the function that removes
+// it again; callers defer it.
+func (a *activeQueries) begin(ctx context.Context, tool, query string) func() {
+	attr, _ := ctx.Value(attributionKey{}).(attribution)
+	entry := ActiveQuery{
+		ID:          newRandomID(),
+		Tool:        tool,
+		Session:     attr.session,
+		Client:      attr.client,
+		Fingerprint: queryFingerprint(query),
+		StartedAt:   time.Now(),
+	}
+
+	a.mu.Lock()
+	a.entries[entry.ID] = entry
+	a.mu.Unlock()
+	return func() {
+		a.mu.Lock()
+		delete(a.entries, entry.ID)
+		a.mu.Unlock()
+	}
+}
+
+// snapshot lists the running queries, oldest first.
+func (a *activeQueries) snapshot(now time.Time) []ActiveQuery {
+	a.mu.Lock()
+	out := make([]ActiveQuery, 0, len(a.entries))
+	for _, entry := range a.entries {
+		entry.ElapsedMs = now.Sub(entry.StartedAt).Milliseconds()
+		out = append(out, entry)
+	}
+	a.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].StartedAt.Before(out[j].StartedAt)
+	})
+	return out
+}
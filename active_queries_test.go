@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveQueries(t *testing.T) {
+	active := newActiveQueries()
+	ctx := context.WithValue(context.Background(), attributionKey{}, attribution{client: "cli", session: "s1"})
+
+	done := active.begin(ctx, "mysql_query", "SELECT * FROM orders WHERE id = 42")
+	second := active.begin(ctx, "recent_orders", "SELECT 1")
+
+	running := active.snapshot(time.Now().Add(time.Second))
+	require.Len(t, running, 2)
+	require.Equal(t, "mysql_query", running[0].Tool)
+	require.Equal(t, "s1", running[0].Session)
+	require.Equal(t, "cli", running[0].Client)
+	require.Equal(t, queryFingerprint("SELECT * FROM orders WHERE id = 7"), running[0].Fingerprint)
+	require.GreaterOrEqual(t, running[0].ElapsedMs, int64(1000))
+
+	done()
+	second()
+	require.Empty(t, active.snapshot(time.Now()))
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// AnalyticsConfig points the analytics_query tool at a ClickHouse server
+// holding extracts exported from MySQL, so heavy aggregation never runs on
+// the MySQL server itself.
+type AnalyticsConfig struct {
+	URL            string             `toml:"url"`
+	User           string             `toml:"user"`
+	Password       string             `toml:"password"`
+	Database       string             `toml:"database"`
+	TimeoutSeconds int                `toml:"timeout_seconds"`
+	MaxRows        int                `toml:"max_rows"`
+	Extracts       []AnalyticsExtract `toml:"extracts"`
+}
+
+// AnalyticsExtract registers a table of the analytics database with the
+// tool, so agents know what they can query.
+type AnalyticsExtract struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+}
+
+type AnalyticsQueryInput struct {
+	Query string `json:"query" jsonschema:"Read-only ClickHouse SQL over the registered extracts."`
+}
+
+var analyticsStatementPrefixes = []string{"select", "with", "show", "describe", "desc", "explain"}
+
+// clickhouseClient runs queries over ClickHouse's HTTP interface. Every
+// request sets readonly=2, which rejects writes and DDL server-side while
+// still allowing the per-query limits below.
+type clickhouseClient struct {
+	endpoint string
+	user     string
+	password string
+	database string
+	maxRows  int
+	client   *http.Client
+}
+
+func newClickhouseClient(cfg AnalyticsConfig) (*clickhouseClient, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("analytics.url must be an http(s) URL")
+	}
+	for _, extract := range cfg.Extracts {
+		if !mysqlIdentifierRE.MatchString(extract.Name) {
+			return nil, fmt.Errorf("analytics extract %q: name must be a plain identifier", extract.Name)
+		}
+	}
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	maxRows := cfg.MaxRows
+	if maxRows <= 0 {
+		maxRows = 10000
+	}
+	return &clickhouseClient{
+		endpoint: cfg.URL,
+		user:     cfg.User,
+		password: cfg.Password,
+		database: cfg.Database,
+		maxRows:  maxRows,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// validateAnalyticsQuery is a coarse first gate; readonly=2 on the server is
+// the real enforcement, since ClickHouse SQL isn't MySQL SQL.
+func validateAnalyticsQuery(query string) error {
+	trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if trimmed == "" {
+		return fmt.Errorf("query is empty")
+	}
+	if strings.Contains(trimmed, ";") {
+		return fmt.Errorf("multiple statements are not allowed")
+	}
+	first := strings.ToLower(strings.Fields(trimmed)[0])
+	for _, prefix := range analyticsStatementPrefixes {
+		if first == prefix {
+			return nil
+		}
+	}
+	return fmt.Errorf("only SELECT, WITH, SHOW, DESCRIBE, and EXPLAIN statements are allowed")
+}
+
+type clickhouseCompact struct {
+	Meta []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"meta"`
+	Data [][]any `json:"data"`
+}
+
+func (c *clickhouseClient) query(ctx context.Context, query string) (QueryOutput, error) {
+	params := url.Values{}
+	params.Set("readonly", "2")
+	params.Set("default_format", "JSONCompact")
+	// One extra row tells us the result was cut off.
+	params.Set("max_result_rows", strconv.Itoa(c.maxRows+1))
+	params.Set("result_overflow_mode", "break")
+	if c.database != "" {
+		params.Set("database", c.database)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/?"+params.Encode(),
+		strings.NewReader(strings.TrimSuffix(strings.TrimSpace(query), ";")))
+	if err != nil {
+		return QueryOutput{}, err
+	}
+	if c.user != "" {
+		req.Header.Set("X-ClickHouse-User", c.user)
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return QueryOutput{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return QueryOutput{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return QueryOutput{}, fmt.Errorf("analytics query failed: %s", strings.TrimSpace(string(body)))
+	}
+
+	var result clickhouseCompact
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&result); err != nil {
+		return QueryOutput{}, fmt.Errorf("unexpected analytics response: %w", err)
+	}
+
+	output := QueryOutput{Columns: make([]string, 0, len(result.Meta)), Rows: make([][]interface{}, 0, len(result.Data))}
+	for _, col := range result.Meta {
+		output.Columns = append(output.Columns, col.Name)
+		output.ColumnTypes = append(output.ColumnTypes, ColumnType{Type: col.Type})
+	}
+	for _, row := range result.Data {
+		if len(output.Rows) >= c.maxRows {
+			output.Truncated = true
+			break
+		}
+		for i, value := range row {
+			if n, ok := value.(json.Number); ok {
+				if v, err := n.Int64(); err == nil {
+					row[i] = v
+				} else if v, err := n.Float64(); err == nil {
+					row[i] = v
+				}
+			}
+		}
+		output.Rows = append(output.Rows, row)
+	}
+	output.RowCount = len(output.Rows)
+	return output, nil
+}
+
+func analyticsToolDescription(extracts []AnalyticsExtract) string {
+	var b strings.Builder
+	b.WriteString("Run a read-only ClickHouse SQL query against the analytics extracts, keeping heavy aggregation off MySQL.")
+	if len(extracts) > 0 {
+		b.WriteString(" Extracts:")
+		for _, extract := range extracts {
+			b.WriteString(" ")
+			b.WriteString(extract.Name)
+			if extract.Description != "" {
+				fmt.Fprintf(&b, " (%s)", extract.Description)
+			}
+			b.WriteString(";")
+		}
+	}
+	return strings.TrimSuffix(b.String(), ";") + "."
+}
+
+func (h *queryHandler) analyticsQuery(ctx context.Context, req *mcp.CallToolRequest, input AnalyticsQueryInput) (result *mcp.CallToolResult, output QueryOutput, err error) {
+	start := time.Now()
+	rejected := false
+	defer func() {
+		h.auditToolCall(req, "analytics_query", input.Query, rejected, start, result, output)
+	}()
+
+	// Extracts aren't MySQL tables a role's schemas and tables could list,
+	// so a role limited to some can't use them.
+	if _, role := h.access.role(ctx); role != nil && (len(role.Schemas) > 0 || len(role.Tables) > 0) {
+		rejected = true
+		result, output := toolErrorResultf("query not allowed: role %q is limited to its schemas and tables, which don't include analytics extracts", role.Name)
+		return result, output, nil
+	}
+	if err := validateAnalyticsQuery(input.Query); err != nil {
+		rejected = true
+		result, output := toolErrorResultf("only read-only queries are allowed: %v", err)
+		return result, output, nil
+	}
+	output, err = h.analytics.query(ctx, input.Query)
+	if err != nil {
+		result, output := toolErrorResultf("%v", err)
+		return result, output, nil
+	}
+	// Extracts aren't MySQL tables, so none of their columns are
+	// pseudonymized.
+	output.PII = scanPII(h.snapshot().config.PII, output.Columns, output.Rows, nil)
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{&mcp.TextContent{Text: "ok"}},
+		StructuredContent: queryOutputToStructuredContent(output),
+	}, output, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAnalyticsQuery(t *testing.T) {
+	require.NoError(t, validateAnalyticsQuery("SELECT count() FROM orders_daily;"))
+	require.NoError(t, validateAnalyticsQuery("  with t AS (SELECT 1) SELECT * FROM t"))
+	require.Error(t, validateAnalyticsQuery(""))
+	require.Error(t, validateAnalyticsQuery("INSERT INTO t VALUES (1)"))
+	require.Error(t, validateAnalyticsQuery("SELECT 1; DROP TABLE t"))
+}
+
+func TestClickhouseClientQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "2", r.URL.Query().Get("readonly"))
+		require.Equal(t, "3", r.URL.Query().Get("max_result_rows"))
+		require.Equal(t, "extracts", r.URL.Query().Get("database"))
+		require.Equal(t, "analyst", r.Header.Get("X-ClickHouse-User"))
+		body, _ := io.ReadAll(r.Body)
+		require.Equal(t, "SELECT day, orders FROM orders_daily", string(body))
+		_, _ = w.Write([]byte(`{"meta":[{"name":"day","type":"Date"},{"name":"orders","type":"UInt64"}],
+			"data":[["2025-01-01","120"],["2025-01-02",98],["2025-01-03",1.5]],"rows":3}`))
+	}))
+	defer srv.Close()
+
+	c, err := newClickhouseClient(AnalyticsConfig{URL: srv.URL, User: "analyst", Database: "extracts", MaxRows: 2})
+	require.NoError(t, err)
+	out, err := c.query(context.Background(), "SELECT day, orders FROM orders_daily;")
+	require.NoError(t, err)
+	require.Equal(t, []string{"day", "orders"}, out.Columns)
+	require.Equal(t, []ColumnType{{Type: "Date"}, {Type: "UInt64"}}, out.ColumnTypes)
+	require.Equal(t, [][]interface{}{{"2025-01-01", "120"}, {"2025-01-02", int64(98)}}, out.Rows)
+	require.True(t, out.Truncated)
+	require.Equal(t, 2, out.RowCount)
+}
+
+func TestClickhouseClientErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Code: 164. DB::Exception: Cannot execute query in readonly mode", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c, err := newClickhouseClient(AnalyticsConfig{URL: srv.URL})
+	require.NoError(t, err)
+	_, err = c.query(context.Background(), "SELECT 1")
+	require.ErrorContains(t, err, "readonly mode")
+
+	_, err = newClickhouseClient(AnalyticsConfig{URL: "clickhouse:8123"})
+	require.Error(t, err)
+}
+
+func TestAnalyticsToolDescription(t *testing.T) {
+	require.Contains(t, analyticsToolDescription([]AnalyticsExtract{
+		{Name: "orders_daily", Description: "orders per day"},
+		{Name: "customers"},
+	}), "Extracts: orders_daily (orders per day); customers.")
+}
+
+func TestAnalyticsQueryMasksPII(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"meta":[{"name":"email","type":"String"}],"data":[["jane.doe@example.com"]],"rows":1}`))
+	}))
+	defer srv.Close()
+
+	c, err := newClickhouseClient(AnalyticsConfig{URL: srv.URL})
+	require.NoError(t, err)
+	h := &queryHandler{config: Config{PII: PIIConfig{Mode: piiModeMask}}, analytics: c, access: newAccessControl(AccessConfig{})}
+	result, output, err := h.analyticsQuery(context.Background(), nil, AnalyticsQueryInput{Query: "SELECT email FROM signups"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Equal(t, [][]interface{}{{"j***@example.com"}}, output.Rows)
+	require.Equal(t, []PIIDetection{{Column: "email", Types: []string{piiEmail}, Cells: 1, Masked: true}}, output.PII)
+}
+
+func TestAnalyticsQueryRoleLimits(t *testing.T) {
+	h := &queryHandler{access: newAccessControl(AccessConfig{Roles: []RoleConfig{{Name: "team-a", Principals: []string{"alice"}, Schemas: []string{"shop"}}}})}
+	result, _, err := h.analyticsQuery(withPrincipal(context.Background(), "alice"), nil, AnalyticsQueryInput{Query: "SELECT 1"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, resultText(result), `role "team-a" is limited to its schemas and tables`)
+}
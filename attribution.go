@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// processSessionID stands in for the MCP session ID on transports that don't
+// assign one (stdio serves exactly one session per process).
+var processSessionID = newRandomID()
+
+type attribution struct {
+	client  string
+	session string
+	ss      *mcp.ServerSession
+}
+
+type attributionKey struct{}
+
+// toolCall identifies one tool call, so the statements it runs can be
+// told apart from those of other calls in the same session.
+type toolCall struct {
+	tool    string
+	request string
+}
+
+type toolCallKey struct{}
+
+func newRandomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func sessionIDFor(ss *mcp.ServerSession) string {
+	if ss == nil || ss.ID() == "" {
+		return processSessionID
+	}
+	return ss.ID()
+}
+
+// withAttribution records the calling client and session on ctx so that
+// queries executed on its behalf can be attributed in server-side logs.
+func withAttribution(ctx context.Context, ss *mcp.ServerSession) context.Context {
+	a := attribution{session: sessionIDFor(ss), ss: ss}
+	if ss != nil {
+		if params := ss.InitializeParams(); params != nil && params.ClientInfo != nil {
+			a.client = params.ClientInfo.Name
+		}
+	}
+	return context.WithValue(ctx, attributionKey{}, a)
+}
+
+// withToolCall records the tool being called on ctx, with a new request ID.
+func withToolCall(ctx context.Context, tool string) context.Context {
+	return context.WithValue(ctx, toolCallKey{}, toolCall{tool: tool, request: newRandomID()})
+}
+
+// annotateQuery prepends the attribution comment to an already validated
+// query. It runs after the read-only gate, so the comment never influences
+// validation, and its values are sanitized so it can't terminate early or
+// turn into an executable (/*! */) or hint (/*+ */) comment.
+func (h *queryHandler) annotateQuery(ctx context.Context, query string) string {
+	if !h.snapshot().config.MySQL.AttributionComments {
+		return query
+	}
+	a, _ := ctx.Value(attributionKey{}).(attribution)
+	call, _ := ctx.Value(toolCallKey{}).(toolCall)
+	return attributionComment(a, call, queryFingerprint(query)) + " " + query
+}
+
+// attributionComment is leading rather than trailing so that it survives
+// the truncation of SHOW PROCESSLIST (without FULL) to 100 characters.
+func attributionComment(a attribution, call toolCall, fingerprint string) string {
+	fields := []string{"mcp:"}
+	if a.client != "" {
+		fields = append(fields, "client="+sanitizeCommentValue(a.client))
+	}
+	if a.session != "" {
+		fields = append(fields, "session="+sanitizeCommentValue(a.session))
+	}
+	if call.tool != "" {
+		fields = append(fields, "tool="+sanitizeCommentValue(call.tool), "req="+sanitizeCommentValue(call.request))
+	}
+	fields = append(fields, "fingerprint="+sanitizeCommentValue(fingerprint))
+	return "/* " + fields[0] + strings.Join(fields[1:], " ") + " */"
+}
+
+func sanitizeCommentValue(value string) string {
+	const maxLen = 64
+	var b strings.Builder
+	for _, r := range value {
+		if b.Len() >= maxLen {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
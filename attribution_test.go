@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributionComment(t *testing.T) {
+	got := attributionComment(attribution{client: "claude", session: "abc"}, toolCall{}, "0123456789abcdef")
+	require.Equal(t, "/* mcp:client=claude session=abc fingerprint=0123456789abcdef */", got)
+
+	got = attributionComment(attribution{session: "abc"}, toolCall{}, "ff")
+	require.Equal(t, "/* mcp:session=abc fingerprint=ff */", got)
+
+	got = attributionComment(attribution{session: "abc"}, toolCall{tool: "mysql_query", request: "9f2c"}, "ff")
+	require.Equal(t, "/* mcp:session=abc tool=mysql_query req=9f2c fingerprint=ff */", got)
+}
+
+func TestSanitizeCommentValue(t *testing.T) {
+	require.Equal(t, "evil_____DROP_TABLE_x", sanitizeCommentValue("evil */; DROP TABLE x"))
+	require.Equal(t, "Claude-Desktop_1.2", sanitizeCommentValue("Claude-Desktop 1.2"))
+	require.Len(t, sanitizeCommentValue(string(make([]byte, 200))), 64)
+}
+
+func TestAnnotateQuery(t *testing.T) {
+	h := &queryHandler{}
+	ctx := context.WithValue(context.Background(), attributionKey{}, attribution{client: "claude", session: "s1"})
+	require.Equal(t, "SELECT 1", h.annotateQuery(ctx, "SELECT 1"))
+
+	h.config.MySQL.AttributionComments = true
+	annotated := h.annotateQuery(ctx, "SELECT 1")
+	require.Equal(t, "/* mcp:client=claude session=s1 fingerprint="+queryFingerprint("SELECT 1")+" */ SELECT 1", annotated)
+	require.True(t, isReadOnlyQuery(annotated, nil))
+
+	ctx = withToolCall(ctx, "mysql_query")
+	call := ctx.Value(toolCallKey{}).(toolCall)
+	require.NotEmpty(t, call.request)
+	require.Equal(t, "/* mcp:client=claude session=s1 tool=mysql_query req="+call.request+" fingerprint="+queryFingerprint("SELECT 1")+" */ SELECT 1", h.annotateQuery(ctx, "SELECT 1"))
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	auditQueryExecuted = "query_executed"
+	auditQueryFailed   = "query_failed"
+	auditQueryRejected = "query_rejected"
+)
+
+// AuditEvent is a single audit record delivered to every configured sink.
+type AuditEvent struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Server     string    `json:"server"`
+	Session    string    `json:"session,omitempty"`
+	Principal  string    `json:"principal,omitempty"`
+	Tenant     string    `json:"tenant,omitempty"`
+	Tool       string    `json:"tool"`
+	Query      string    `json:"query,omitempty"`
+	RowCount   int       `json:"rowCount"`
+	DurationMs int64     `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+	// PII lists the result columns that held personal data, with pii.mode
+	// set.
+	PII []PIIDetection `json:"pii,omitempty"`
+}
+
+type AuditSinkConfig struct {
+	// Type is one of "webhook", "syslog", or "kafka".
+	Type string `toml:"type"`
+
+	// webhook
+	URL            string            `toml:"url"`
+	Headers        map[string]string `toml:"headers"`
+	TimeoutSeconds int               `toml:"timeout_seconds"`
+
+	// syslog; an empty network and address use the local syslog daemon.
+	Network string `toml:"network"`
+	Address string `toml:"address"`
+	Tag     string `toml:"tag"`
+
+	// kafka
+	Brokers []string `toml:"brokers"`
+	Topic   string   `toml:"topic"`
+}
+
+// auditSink delivers a batch of events. Implementations need not be safe for
+// concurrent use; each sink is driven by a single goroutine.
+type auditSink interface {
+	send(ctx context.Context, events []AuditEvent) error
+	close() error
+}
+
+type auditor struct {
+	server string
+	sinks  []*bufferedSink
+}
+
+type bufferedSink struct {
+	name          string
+	sink          auditSink
+	events        chan AuditEvent
+	done          chan struct{}
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+
+	dropMu  sync.Mutex
+	dropped int
+}
+
+func newAuditor(cfg Config) (*auditor, error) {
+	a := &auditor{server: cfg.Server.Name}
+	for i, sinkCfg := range cfg.Audit.Sinks {
+		sink, err := newAuditSink(sinkCfg)
+		if err != nil {
+			a.close(context.Background())
+			return nil, fmt.Errorf("audit.sinks[%d]: %w", i, err)
+		}
+		b := &bufferedSink{
+			name:          sinkCfg.Type,
+			sink:          sink,
+			events:        make(chan AuditEvent, cfg.Audit.BufferSize),
+			done:          make(chan struct{}),
+			batchSize:     cfg.Audit.BatchSize,
+			flushInterval: time.Duration(cfg.Audit.FlushIntervalMs) * time.Millisecond,
+			maxRetries:    cfg.Audit.MaxRetries,
+			retryBackoff:  time.Duration(cfg.Audit.RetryBackoffMs) * time.Millisecond,
+		}
+		go b.run()
+		a.sinks = append(a.sinks, b)
+	}
+	return a, nil
+}
+
+func newAuditSink(cfg AuditSinkConfig) (auditSink, error) {
+	switch strings.ToLower(cfg.Type) {
+	case "webhook":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("webhook sink requires url")
+		}
+		timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		return &webhookSink{url: cfg.URL, headers: cfg.Headers, client: &http.Client{Timeout: timeout}}, nil
+	case "syslog":
+		return newSyslogSink(cfg)
+	case "kafka":
+		if len(cfg.Brokers) == 0 || cfg.Topic == "" {
+			return nil, fmt.Errorf("kafka sink requires brokers and topic")
+		}
+		return &kafkaSink{writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+	}
+}
+
+// emit queues an event on every sink without blocking the caller. Events are
+// dropped (and counted) when a sink's buffer is full.
+func (a *auditor) emit(event AuditEvent) {
+	if a == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	event.Server = a.server
+	for _, b := range a.sinks {
+		select {
+		case b.events <- event:
+		default:
+			b.dropMu.Lock()
+			b.dropped++
+			dropped := b.dropped
+			b.dropMu.Unlock()
+			log.Printf("audit sink %s: buffer full, %d events dropped so far", b.name, dropped)
+		}
+	}
+}
+
+// close flushes buffered events and releases sink resources, giving up when
+// ctx is done.
+func (a *auditor) close(ctx context.Context) {
+	if a == nil {
+		return
+	}
+	for _, b := range a.sinks {
+		close(b.events)
+	}
+	for _, b := range a.sinks {
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			log.Printf("audit sink %s: shutdown before buffered events were delivered", b.name)
+		}
+		if err := b.sink.close(); err != nil {
+			log.Printf("audit sink %s: close: %v", b.name, err)
+		}
+	}
+}
+
+func (b *bufferedSink) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]AuditEvent, 0, b.batchSize)
+	for {
+		select {
+		case event, ok := <-b.events:
+			if !ok {
+				b.deliver(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= b.batchSize {
+				b.deliver(batch)
+				batch = make([]AuditEvent, 0, b.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				b.deliver(batch)
+				batch = make([]AuditEvent, 0, b.batchSize)
+			}
+		}
+	}
+}
+
+func (b *bufferedSink) deliver(batch []AuditEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	var err error
+	backoff := b.retryBackoff
+	for attempt := 0; attempt <= b.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err = b.sink.send(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+	}
+	log.Printf("audit sink %s: dropping %d events after %d attempts: %v", b.name, len(batch), b.maxRetries+1, err)
+}
+
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (s *webhookSink) send(ctx context.Context, events []AuditEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *webhookSink) close() error {
+	return nil
+}
+
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func (s *kafkaSink) send(ctx context.Context, events []AuditEvent) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, kafka.Message{Key: []byte(event.Session), Value: value})
+	}
+	return s.writer.WriteMessages(ctx, messages...)
+}
+
+func (s *kafkaSink) close() error {
+	return s.writer.Close()
+}
+
+// auditToolCall records the outcome of a query tool call.
+func (h *queryHandler) auditToolCall(req *mcp.CallToolRequest, tool, query string, rejected bool, start time.Time, result *mcp.CallToolResult, output QueryOutput) {
+	event := AuditEvent{
+		Type:       auditQueryExecuted,
+		Session:    sessionID(req),
+		Principal:  h.access.principalFor(req),
+		Tool:       tool,
+		Query:      query,
+		RowCount:   output.RowCount,
+		DurationMs: time.Since(start).Milliseconds(),
+		PII:        output.PII,
+	}
+	if h.tenant != nil {
+		event.Tenant = h.tenant.Name
+	}
+	if result != nil && result.IsError {
+		event.Type = auditQueryFailed
+		if rejected {
+			event.Type = auditQueryRejected
+		}
+		event.Error = resultText(result)
+	}
+	h.audit.emit(event)
+}
+
+func sessionID(req *mcp.CallToolRequest) string {
+	if req == nil {
+		return ""
+	}
+	return sessionIDFor(req.Session)
+}
+
+func resultText(result *mcp.CallToolResult) string {
+	for _, c := range result.Content {
+		if t, ok := c.(*mcp.TextContent); ok {
+			return t.Text
+		}
+	}
+	return ""
+}
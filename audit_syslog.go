@@ -0,0 +1,42 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/syslog"
+)
+
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func newSyslogSink(cfg AuditSinkConfig) (auditSink, error) {
+	tag := cfg.Tag
+	if tag == "" {
+		tag = "mysqlmcp"
+	}
+	writer, err := syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) send(ctx context.Context, events []AuditEvent) error {
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if err := s.writer.Info(string(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) close() error {
+	return s.writer.Close()
+}
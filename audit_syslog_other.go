@@ -0,0 +1,9 @@
+//go:build windows || plan9
+
+package main
+
+import "fmt"
+
+func newSyslogSink(cfg AuditSinkConfig) (auditSink, error) {
+	return nil, fmt.Errorf("syslog sink is not supported on this platform")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditorWebhookRetriesAndFlushes(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	received := make([]AuditEvent, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		require.Equal(t, "secret", r.Header.Get("X-Token"))
+		var events []AuditEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&events))
+		received = append(received, events...)
+	}))
+	defer srv.Close()
+
+	var cfg Config
+	cfg.Server.Name = "test-server"
+	cfg.Audit.BufferSize = 10
+	cfg.Audit.BatchSize = 10
+	cfg.Audit.FlushIntervalMs = 1000
+	cfg.Audit.MaxRetries = 2
+	cfg.Audit.RetryBackoffMs = 1
+	cfg.Audit.Sinks = []AuditSinkConfig{{Type: "webhook", URL: srv.URL, Headers: map[string]string{"X-Token": "secret"}}}
+
+	a, err := newAuditor(cfg)
+	require.NoError(t, err)
+	a.emit(AuditEvent{Type: auditQueryExecuted, Tool: "mysql_query", Query: "SELECT 1", RowCount: 1})
+	a.emit(AuditEvent{Type: auditQueryRejected, Tool: "mysql_query", Query: "DROP TABLE t"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	a.close(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 2, attempts)
+	require.Len(t, received, 2)
+	require.Equal(t, "test-server", received[0].Server)
+	require.Equal(t, auditQueryRejected, received[1].Type)
+}
+
+func TestNewAuditorRejectsInvalidSinks(t *testing.T) {
+	cases := []AuditSinkConfig{
+		{Type: "webhook"},
+		{Type: "kafka", Topic: "audit"},
+		{Type: "carrier-pigeon"},
+	}
+	for _, sink := range cases {
+		var cfg Config
+		cfg.Audit.Sinks = []AuditSinkConfig{sink}
+		_, err := newAuditor(cfg)
+		require.Error(t, err, sink.Type)
+	}
+}
+
+func TestNilAuditorIsNoop(t *testing.T) {
+	var a *auditor
+	a.emit(AuditEvent{Type: auditQueryExecuted})
+	a.close(context.Background())
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// QueryBlocked explains a failure caused by a lock another session holds,
+// rather than by the query itself.
+type QueryBlocked struct {
+	Reason string `json:"reason" jsonschema:"Why the query was blocked; currently always backup_in_progress."`
+	Detail string `json:"detail" jsonschema:"The locks that were held and by which connections."`
+}
+
+const blockedBackupInProgress = "backup_in_progress"
+
+// Server errors a query gets when it waited on a lock until it gave up.
+const (
+	erLockWaitTimeout  = 1205
+	erQueryInterrupted = 1317
+	erQueryTimeout     = 3024
+)
+
+// backupLocksQuery finds global read locks (FLUSH TABLES WITH READ LOCK)
+// and backup locks (LOCK INSTANCE FOR BACKUP) held by any connection.
+const backupLocksQuery = `SELECT t.PROCESSLIST_ID AS id, m.OBJECT_TYPE AS object_type
+FROM performance_schema.metadata_locks m
+JOIN performance_schema.threads t ON t.THREAD_ID = m.OWNER_THREAD_ID
+WHERE m.LOCK_STATUS = 'GRANTED'
+  AND ((m.OBJECT_TYPE = 'GLOBAL' AND m.LOCK_TYPE = 'SHARED') OR m.OBJECT_TYPE = 'BACKUP LOCK')`
+
+// isLockWaitFailure reports whether err is what a query sees after waiting
+// on a lock: a lock wait timeout, or the query timing out or being killed.
+func isLockWaitFailure(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case erLockWaitTimeout, erQueryInterrupted, erQueryTimeout:
+		return true
+	}
+	return false
+}
+
+// describeBackupLocks summarizes backupLocksQuery's rows, or "" if there
+// are none.
+func describeBackupLocks(out QueryOutput) string {
+	var parts []string
+	for _, row := range out.Rows {
+		if len(row) < 2 {
+			continue
+		}
+		kind := "backup lock (LOCK INSTANCE FOR BACKUP)"
+		if strings.EqualFold(valueString(row[1]), "GLOBAL") {
+			kind = "global read lock (FLUSH TABLES WITH READ LOCK)"
+		}
+		parts = append(parts, fmt.Sprintf("%s held by connection %s", kind, valueString(row[0])))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// blockedBy checks, after a query failed with err, whether a backup is
+// holding a global lock. The check gets its own short deadline since ctx
+// has usually expired by then; failures (for example, no access to
+// performance_schema) count as "no".
+func (h *queryHandler) blockedBy(ctx context.Context, err error) *QueryBlocked {
+	if h.db == nil || !isLockWaitFailure(err) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+	out, checkErr := h.runMetadataQuery(ctx, backupLocksQuery)
+	if checkErr != nil {
+		return nil
+	}
+	detail := describeBackupLocks(out)
+	if detail == "" {
+		return nil
+	}
+	return &QueryBlocked{Reason: blockedBackupInProgress, Detail: detail}
+}
+
+// queryFailure is the tool error for a failed query. It explains
+// ONLY_FULL_GROUP_BY errors from the query's AST, and marks failures a
+// backup lock explains as blocked.
+func (h *queryHandler) queryFailure(ctx context.Context, prefix, query string, err error) (*mcp.CallToolResult, QueryOutput) {
+	if isGroupByError(err) {
+		if advice, issues := groupByAdvice(query); advice != "" {
+			result, output := toolErrorResultf("%s: %s (%v)", prefix, advice, err)
+			output.GroupByIssues = issues
+			result.StructuredContent = queryOutputToStructuredContent(output)
+			return result, output
+		}
+	}
+	blocked := h.blockedBy(ctx, err)
+	if blocked == nil {
+		return toolErrorResultf("%s: %v", prefix, err)
+	}
+	result, output := toolErrorResultf("%s: a backup is in progress (%s); retry after it finishes: %v", prefix, blocked.Detail, err)
+	output.Blocked = blocked
+	result.StructuredContent = queryOutputToStructuredContent(output)
+	return result, output
+}
+
+// retryBlocked runs attempt again while it fails because of a backup lock,
+// up to retries more times, waiting backoff in between.
+func retryBlocked(ctx context.Context, retries int, backoff time.Duration, attempt func() (*mcp.CallToolResult, QueryOutput, error)) (*mcp.CallToolResult, QueryOutput, error) {
+	for i := 0; ; i++ {
+		result, output, err := attempt()
+		if err != nil || output.Blocked == nil || i >= retries {
+			return result, output, err
+		}
+		if sleepContext(ctx, backoff) != nil {
+			return result, output, err
+		}
+	}
+}
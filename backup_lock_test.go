@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLockWaitFailure(t *testing.T) {
+	require.True(t, isLockWaitFailure(&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}))
+	require.True(t, isLockWaitFailure(fmt.Errorf("query: %w", &mysql.MySQLError{Number: 3024})))
+	require.True(t, isLockWaitFailure(&mysql.MySQLError{Number: 1317}))
+	require.True(t, isLockWaitFailure(context.DeadlineExceeded))
+	require.False(t, isLockWaitFailure(&mysql.MySQLError{Number: 1054, Message: "Unknown column"}))
+	require.False(t, isLockWaitFailure(errors.New("connection refused")))
+}
+
+func TestDescribeBackupLocks(t *testing.T) {
+	require.Empty(t, describeBackupLocks(QueryOutput{Rows: [][]any{}}))
+	detail := describeBackupLocks(QueryOutput{Rows: [][]any{{int64(812), "GLOBAL"}, {int64(813), "BACKUP LOCK"}}})
+	require.Equal(t, "global read lock (FLUSH TABLES WITH READ LOCK) held by connection 812; backup lock (LOCK INSTANCE FOR BACKUP) held by connection 813", detail)
+}
+
+func TestRetryBlocked(t *testing.T) {
+	blocked := QueryOutput{Blocked: &QueryBlocked{Reason: blockedBackupInProgress}}
+	attempts := 0
+	_, output, err := retryBlocked(context.Background(), 2, time.Millisecond, func() (*mcp.CallToolResult, QueryOutput, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, blocked, nil
+		}
+		return nil, QueryOutput{RowCount: 1}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, 1, output.RowCount)
+
+	attempts = 0
+	_, output, _ = retryBlocked(context.Background(), 1, time.Millisecond, func() (*mcp.CallToolResult, QueryOutput, error) {
+		attempts++
+		return nil, blocked, nil
+	})
+	require.Equal(t, 2, attempts, "gives up after the configured retries")
+	require.NotNil(t, output.Blocked)
+
+	attempts = 0
+	retryBlocked(context.Background(), 5, time.Millisecond, func() (*mcp.CallToolResult, QueryOutput, error) {
+		attempts++
+		return nil, QueryOutput{}, nil
+	})
+	require.Equal(t, 1, attempts, "other failures aren't retried")
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+)
+
+// batchMaxStatements caps the statements in one mysql_batch call.
+const batchMaxStatements = 10
+
+type BatchInput struct {
+	Queries        string  `json:"queries" jsonschema:"Read-only statements separated by semicolons, run in order."`
+	Params         [][]any `json:"params,omitempty" jsonschema:"Values for each statement's ? placeholders: one array per statement, in order."`
+	TimeoutSeconds int     `json:"timeoutSeconds,omitempty" jsonschema:"Timeout for the whole batch, capped by the server's maximum."`
+	IncludeDeleted bool    `json:"includeDeleted,omitempty" jsonschema:"Also return soft-deleted rows."`
+}
+
+type BatchResult struct {
+	Statement string          `json:"statement"`
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	RowCount  int             `json:"rowCount"`
+	Truncated bool            `json:"truncated" jsonschema:"True if rows were cut off at max_rows or max_result_bytes."`
+	// BytesTruncated is set once the batch's rows reach max_result_bytes;
+	// later statements return no rows.
+	BytesTruncated bool         `json:"bytesTruncated,omitempty" jsonschema:"True if rows were cut off because the batch's results reached the server's max_result_bytes."`
+	ColumnTypes    []ColumnType `json:"columnTypes,omitempty"`
+	// TruncatedCells lists, per row, the values max_cell_chars cut.
+	TruncatedCells []CellTruncation `json:"truncatedCells,omitempty" jsonschema:"Rows with text values cut at the server's max_cell_chars, marked with a trailing ellipsis, and which columns were cut."`
+	PII            []PIIDetection   `json:"pii,omitempty" jsonschema:"Columns whose values look like personal data, and whether they were masked."`
+}
+
+type BatchOutput struct {
+	Results   []BatchResult        `json:"results" jsonschema:"One result set per statement, in order."`
+	Rejection *gate.QueryRejection `json:"rejection,omitempty" jsonschema:"Why the read-only gate rejected a statement."`
+	Failed    int                  `json:"failed,omitempty" jsonschema:"1-based number of the statement that was rejected or failed."`
+}
+
+// splitBatch splits batch into statements with the SQL tokenizer, so
+// semicolons inside strings and comments don't split it.
+func splitBatch(batch string) ([]string, error) {
+	dialect, err := gate.CurrentDialect()
+	if err != nil {
+		return nil, err
+	}
+	pieces, err := dialect.SplitStatements(batch)
+	if err != nil {
+		return nil, err
+	}
+	statements := make([]string, 0, len(pieces))
+	for _, piece := range pieces {
+		if piece = strings.TrimSpace(piece); piece != "" {
+			statements = append(statements, piece)
+		}
+	}
+	return statements, nil
+}
+
+// batch runs several read-only statements in one read-only transaction, on
+// one connection, so under REPEATABLE READ (InnoDB's default) they all read
+// the same snapshot. Every statement passes the read-only gate before any
+// runs, and the first failure stops the batch.
+func (h *queryHandler) batch(ctx context.Context, req *mcp.CallToolRequest, input BatchInput) (result *mcp.CallToolResult, output BatchOutput, err error) {
+	start := time.Now()
+	rejected := false
+	defer func() {
+		var total QueryOutput
+		for _, r := range output.Results {
+			total.RowCount += r.RowCount
+			total.PII = append(total.PII, r.PII...)
+		}
+		h.auditToolCall(req, "mysql_batch", input.Queries, rejected, start, result, total)
+	}()
+
+	ctx = withAttribution(ctx, req.Session)
+	empty := BatchOutput{Results: []BatchResult{}}
+	live := h.snapshot()
+	statements, err := splitBatch(input.Queries)
+	if err != nil {
+		return toolErrorf(empty, "failed to split queries: %v", err)
+	}
+	if len(statements) == 0 {
+		return toolErrorf(empty, "queries is empty")
+	}
+	if len(statements) > batchMaxStatements {
+		return toolErrorf(empty, "a batch may hold at most %d statements, got %d", batchMaxStatements, len(statements))
+	}
+	if len(input.Params) > 0 && len(input.Params) != len(statements) {
+		return toolErrorf(empty, "params has %d arrays for %d statements; pass one per statement", len(input.Params), len(statements))
+	}
+
+	// queries holds the statements as run, with row filters applied.
+	queries := make([]string, len(statements))
+	args := make([][]any, len(statements))
+	recursive := false
+	for i, statement := range statements {
+		failed := BatchOutput{Results: []BatchResult{}, Failed: i + 1}
+		if err := live.validator.Validate(statement); err != nil {
+			rejected = true
+			failed.Rejection, _ = err.(*gate.QueryRejection)
+			return toolErrorf(failed, "statement %d: only read-only queries are allowed: %v", i+1, err)
+		}
+		if err := h.authorize(ctx, statement); err != nil {
+			rejected = true
+			failed.Rejection, _ = err.(*gate.QueryRejection)
+			return toolErrorf(failed, "statement %d not allowed: %v", i+1, err)
+		}
+		if len(input.Params) > 0 {
+			if args[i], err = queryParams(input.Params[i]); err != nil {
+				return toolErrorf(failed, "statement %d: invalid params: %v", i+1, err)
+			}
+		}
+		if err := h.checkPolicy(ctx, statement, args[i]...); err != nil {
+			rejected = true
+			failed.Rejection, _ = err.(*gate.QueryRejection)
+			return toolErrorf(failed, "statement %d not allowed: %v", i+1, err)
+		}
+		if stmt, err := gate.ParseStatement(statement); err == nil && readsRows(stmt) {
+			if err := h.confirmSensitive(ctx, statement, stmt, false); err != nil {
+				rejected = true
+				return toolErrorf(failed, "statement %d not run: %v", i+1, err)
+			}
+		}
+		if queries[i], err = live.applyFilters(statement, input.IncludeDeleted); err != nil {
+			return toolErrorf(failed, "statement %d: failed to apply row filters: %v", i+1, err)
+		}
+		recursive = recursive || isRecursiveQuery(statement)
+	}
+	defer h.active.begin(ctx, "mysql_batch", input.Queries)()
+
+	timeout := h.queryTimeout(input.TimeoutSeconds)
+	var recursionDepth int
+	if recursive {
+		recursionDepth, timeout = h.recursiveLimits(timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	db, release := h.readDB(ctx)
+	defer release()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return toolErrorf(empty, "failed to acquire connection: %v", err)
+	}
+	defer conn.Close()
+	connID, err := h.connectionID(ctx, db, conn)
+	if err != nil {
+		return toolErrorf(empty, "failed to read connection id: %v", err)
+	}
+	restoreRole, err := h.setRole(ctx, conn)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	defer restoreRole()
+	if recursive {
+		restore, err := limitRecursion(ctx, conn, recursionDepth)
+		if err != nil {
+			return toolErrorf(empty, "%v", err)
+		}
+		defer restore()
+	}
+	defer h.killOnCancel(ctx, db, connID)()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return toolErrorf(empty, "failed to start read-only transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	maxRows := h.maxRows(ctx)
+	output = BatchOutput{Results: make([]BatchResult, 0, len(statements))}
+	limit := newByteLimit(live.config.MySQL.MaxResultBytes, live.config.MySQL.MaxCellChars)
+	for i, query := range queries {
+		out, err := h.batchStatement(ctx, tx, query, args[i], maxRows, limit, live.config.MySQL.OmitBlobs)
+		if err != nil {
+			output.Failed = i + 1
+			return toolErrorf(output, "statement %d failed: %v", i+1, err)
+		}
+		out = live.finishOutput(statements[i], out, false)
+		output.Results = append(output.Results, BatchResult{
+			Statement:      statements[i],
+			Columns:        out.Columns,
+			Rows:           out.Rows,
+			RowCount:       out.RowCount,
+			Truncated:      out.Truncated,
+			BytesTruncated: out.BytesTruncated,
+			ColumnTypes:    out.ColumnTypes,
+			TruncatedCells: out.TruncatedCells,
+			PII:            out.PII,
+		})
+	}
+	if err := tx.Commit(); err != nil {
+		return toolErrorf(empty, "failed to finish transaction: %v", err)
+	}
+	return nil, output, nil
+}
+
+// batchStatement runs one statement of a batch and reads up to maxRows rows,
+// within what's left of the batch's byte limit.
+func (h *queryHandler) batchStatement(ctx context.Context, tx *sql.Tx, query string, args []any, maxRows int, limit *byteLimit, omitBlobs bool) (QueryOutput, error) {
+	rows, err := tx.QueryContext(ctx, h.annotateQuery(ctx, query), args...)
+	if err != nil {
+		return QueryOutput{}, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return QueryOutput{}, fmt.Errorf("failed to fetch columns: %w", err)
+	}
+	if columns == nil {
+		columns = []string{}
+	}
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return QueryOutput{}, fmt.Errorf("failed to fetch column types: %w", err)
+	}
+	typeInfo := buildColumnTypes(colTypes)
+
+	r := QueryOutput{Columns: columns, Rows: newResultRows(maxRows), ColumnTypes: typeInfo}
+	scanner := newRowScanner(typeInfo, omitBlobs)
+	for rows.Next() {
+		if r.RowCount >= maxRows {
+			r.Truncated = true
+			break
+		}
+		values, err := scanner.scan(rows)
+		if err != nil {
+			return QueryOutput{}, fmt.Errorf("failed to read row: %w", err)
+		}
+		if !limit.fits(values) {
+			r.Truncated, r.BytesTruncated = true, true
+			break
+		}
+		r.Rows = append(r.Rows, values)
+		r.RowCount++
+	}
+	if err := rows.Err(); err != nil {
+		return QueryOutput{}, err
+	}
+	return r, nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitBatch(t *testing.T) {
+	statements, err := splitBatch("SELECT 1; SELECT ';' AS s;\n-- trailing comment\nSELECT 2 /* ; */;")
+	require.NoError(t, err)
+	require.Equal(t, []string{"SELECT 1", "SELECT ';' AS s", "-- trailing comment\nSELECT 2 /* ; */"}, statements)
+
+	statements, err = splitBatch("SELECT 1;;  ;")
+	require.NoError(t, err)
+	require.Equal(t, []string{"SELECT 1"}, statements)
+
+	statements, err = splitBatch("  ")
+	require.NoError(t, err)
+	require.Empty(t, statements)
+}
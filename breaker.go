@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// errDatabaseUnavailable is returned without contacting MySQL while the
+// circuit breaker is open.
+var errDatabaseUnavailable = errors.New("database unavailable")
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half_open"
+)
+
+// BreakerStatus is the circuit breaker's state, for mysql://server_info.
+type BreakerStatus struct {
+	State               string `json:"state" jsonschema:"closed, open (calls fail fast), or half_open (one trial connection may go ahead)."`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	LastError           string `json:"lastError,omitempty"`
+}
+
+// circuitBreaker stops new connection attempts after threshold consecutive
+// failures, so tool calls fail fast instead of each waiting out a connect or
+// query timeout while the database is down. After openFor it half-opens and
+// lets one connection attempt through: success closes it, failure reopens it
+// for another openFor.
+type circuitBreaker struct {
+	threshold int
+	openFor   time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // zero while closed
+	trial    bool      // a half-open trial attempt is in flight
+	lastErr  error
+}
+
+// newCircuitBreaker returns nil, which never trips, if threshold is 0.
+func newCircuitBreaker(threshold int, openFor time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{threshold: threshold, openFor: openFor, now: time.Now}
+}
+
+// check returns an errDatabaseUnavailable error while the breaker is open.
+// It lets calls through once the breaker half-opens; the connector then
+// admits only the trial attempt.
+func (b *circuitBreaker) check() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	if wait := b.openedAt.Add(b.openFor).Sub(b.now()); wait > 0 {
+		return b.unavailable(wait)
+	}
+	return nil
+}
+
+// begin reserves a connection attempt: always while closed, and only the one
+// trial attempt while half-open.
+func (b *circuitBreaker) begin() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	if wait := b.openedAt.Add(b.openFor).Sub(b.now()); wait > 0 {
+		return b.unavailable(wait)
+	}
+	if b.trial {
+		return fmt.Errorf("%w: checking whether it has recovered", errDatabaseUnavailable)
+	}
+	b.trial = true
+	return nil
+}
+
+// record updates the breaker with the outcome of an attempt begin allowed.
+// Attempts abandoned by their caller don't count either way.
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	wasOpen := !b.openedAt.IsZero()
+	switch {
+	case errors.Is(err, context.Canceled):
+		return
+	case err == nil:
+		if wasOpen {
+			log.Printf("database reachable again; closing the circuit breaker")
+		}
+		b.failures, b.openedAt, b.lastErr = 0, time.Time{}, nil
+		return
+	}
+	b.failures++
+	b.lastErr = err
+	if !wasOpen && b.failures >= b.threshold {
+		log.Printf("opening the circuit breaker after %d consecutive connection failures: %v", b.failures, err)
+	}
+	if wasOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+func (b *circuitBreaker) unavailable(wait time.Duration) error {
+	return fmt.Errorf("%w: %d consecutive connection failures (last: %v); next attempt in %s",
+		errDatabaseUnavailable, b.failures, b.lastErr, wait.Round(time.Second))
+}
+
+func (b *circuitBreaker) status() *BreakerStatus {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := &BreakerStatus{State: breakerClosed, ConsecutiveFailures: b.failures}
+	switch {
+	case b.openedAt.IsZero():
+	case b.now().Before(b.openedAt.Add(b.openFor)):
+		status.State = breakerOpen
+	default:
+		status.State = breakerHalfOpen
+	}
+	if b.lastErr != nil {
+		status.LastError = b.lastErr.Error()
+	}
+	return status
+}
+
+// breakerConnector guards new pooled connections with a circuit breaker.
+type breakerConnector struct {
+	driver.Connector
+	breaker *circuitBreaker
+}
+
+func newBreakerConnector(inner driver.Connector, breaker *circuitBreaker) driver.Connector {
+	if breaker == nil {
+		return inner
+	}
+	return &breakerConnector{Connector: inner, breaker: breaker}
+}
+
+func (c *breakerConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := c.breaker.begin(); err != nil {
+		return nil, err
+	}
+	conn, err := c.Connector.Connect(ctx)
+	c.breaker.record(err)
+	return conn, err
+}
+
+// dbAvailable returns nil if MySQL-backed tools and resources may run, or
+// why they can't: the database hasn't been reached yet (lazy_connect), or the
+// circuit breaker is open.
+func (h *queryHandler) dbAvailable() error {
+	if err := h.dbReady.check(); err != nil {
+		return err
+	}
+	return h.breaker.check()
+}
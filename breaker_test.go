@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(3, 30*time.Second)
+	b.now = func() time.Time { return now }
+	refused := errors.New("connection refused")
+
+	for range 2 {
+		require.NoError(t, b.begin())
+		b.record(refused)
+	}
+	require.NoError(t, b.check(), "stays closed below the threshold")
+	require.Equal(t, breakerClosed, b.status().State)
+
+	require.NoError(t, b.begin())
+	b.record(refused)
+	require.ErrorIs(t, b.check(), errDatabaseUnavailable)
+	require.ErrorIs(t, b.begin(), errDatabaseUnavailable)
+	require.Equal(t, &BreakerStatus{State: breakerOpen, ConsecutiveFailures: 3, LastError: "connection refused"}, b.status())
+
+	// Half-open: one trial at a time, and a failed trial reopens it.
+	now = now.Add(31 * time.Second)
+	require.NoError(t, b.check())
+	require.Equal(t, breakerHalfOpen, b.status().State)
+	require.NoError(t, b.begin())
+	require.ErrorIs(t, b.begin(), errDatabaseUnavailable)
+	b.record(refused)
+	require.ErrorIs(t, b.check(), errDatabaseUnavailable)
+
+	// A successful trial closes it.
+	now = now.Add(31 * time.Second)
+	require.NoError(t, b.begin())
+	b.record(nil)
+	require.NoError(t, b.check())
+	require.Equal(t, &BreakerStatus{State: breakerClosed}, b.status())
+
+	// Abandoned attempts don't count.
+	for range 5 {
+		require.NoError(t, b.begin())
+		b.record(context.Canceled)
+	}
+	require.NoError(t, b.check())
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, time.Second)
+	require.Nil(t, b)
+	require.NoError(t, b.check())
+	require.NoError(t, b.begin())
+	b.record(errors.New("connection refused"))
+	require.Nil(t, b.status())
+}
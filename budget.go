@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// bytesPerToken is a rough conversion for clients that state their budget in
+// tokens rather than bytes.
+const bytesPerToken = 4
+
+// cellLimits are the successively tighter per-cell limits tried before rows
+// are dropped.
+var cellLimits = []int{1024, 256, 64}
+
+// budgetBytes combines the client's byte and token budgets into one byte
+// limit; 0 means no budget.
+func budgetBytes(maxBytes, maxTokens int) int {
+	budget := 0
+	if maxBytes > 0 {
+		budget = maxBytes
+	}
+	if maxTokens > 0 && (budget == 0 || maxTokens*bytesPerToken < budget) {
+		budget = maxTokens * bytesPerToken
+	}
+	return budget
+}
+
+// fitToBudget shrinks output until its structured content plus its text
+// rendering fit in budget bytes: first by truncating long cells, then by
+// dropping trailing rows. Without an explicit format it renders markdown,
+// the most compact of the text formats. The output always keeps its
+// columns, even if no row fits.
+func fitToBudget(output QueryOutput, format string, budget int) (QueryOutput, string) {
+	if format == "" {
+		format = formatMarkdown
+	}
+	if budget <= 0 || payloadSize(output, format) <= budget {
+		return output, format
+	}
+
+	for _, limit := range cellLimits {
+		output = truncateCells(output, limit)
+		if payloadSize(output, format) <= budget {
+			return output, format
+		}
+	}
+
+	// Binary search for the largest row prefix that fits.
+	rows := output.Rows
+	lo, hi := 0, len(rows)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if payloadSize(withRows(output, rows[:mid]), format) <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	output = withRows(output, rows[:lo])
+	output.Truncated = true
+	return output, format
+}
+
+func withRows(output QueryOutput, rows [][]interface{}) QueryOutput {
+	output.Rows = rows
+	output.RowCount = len(rows)
+	// Rows are only dropped from the end.
+	for i, c := range output.TruncatedCells {
+		if c.Row >= len(rows) {
+			output.TruncatedCells = output.TruncatedCells[:i]
+			break
+		}
+	}
+	return output
+}
+
+func payloadSize(output QueryOutput, format string) int {
+	encoded, _ := json.Marshal(output)
+	text, _ := renderText(output, format)
+	return len(encoded) + len(text)
+}
+
+// truncateCells shortens string values longer than limit bytes (on a rune
+// boundary, marked with an ellipsis) and omits binary values larger than
+// limit. It copies rows rather than modifying output in place.
+func truncateCells(output QueryOutput, limit int) QueryOutput {
+	rows := make([][]interface{}, len(output.Rows))
+	for i, row := range output.Rows {
+		copied := make([]interface{}, len(row))
+		for j, value := range row {
+			switch v := value.(type) {
+			case string:
+				if len(v) > limit {
+					cut := limit
+					for cut > 0 && !utf8.RuneStart(v[cut]) {
+						cut--
+					}
+					value = v[:cut] + "…"
+					output.CellsTruncated = true
+				}
+			case BinaryValue:
+				if !v.Omitted && len(v.Base64) > limit {
+					value = BinaryValue{Bytes: v.Bytes, Omitted: true}
+					output.CellsTruncated = true
+				}
+			}
+			copied[j] = value
+		}
+		rows[i] = copied
+	}
+	output.Rows = rows
+	return output
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetBytes(t *testing.T) {
+	require.Equal(t, 0, budgetBytes(0, 0))
+	require.Equal(t, 1000, budgetBytes(1000, 0))
+	require.Equal(t, 400, budgetBytes(0, 100))
+	require.Equal(t, 400, budgetBytes(1000, 100))
+	require.Equal(t, 300, budgetBytes(300, 100))
+}
+
+func TestFitToBudget(t *testing.T) {
+	output := QueryOutput{Columns: []string{"id", "body"}, Rows: [][]interface{}{}}
+	for i := 0; i < 50; i++ {
+		output.Rows = append(output.Rows, []interface{}{int64(i), strings.Repeat("é", 600)})
+	}
+	output.RowCount = len(output.Rows)
+
+	fitted, format := fitToBudget(output, "", 0)
+	require.Equal(t, formatMarkdown, format)
+	require.Equal(t, output, fitted)
+
+	fitted, format = fitToBudget(output, formatCSV, 4000)
+	require.Equal(t, formatCSV, format)
+	require.True(t, fitted.CellsTruncated)
+	require.True(t, fitted.Truncated)
+	require.Less(t, fitted.RowCount, 50)
+	require.Greater(t, fitted.RowCount, 0)
+	require.LessOrEqual(t, payloadSize(fitted, formatCSV), 4000)
+	body := fitted.Rows[0][1].(string)
+	require.True(t, strings.HasSuffix(body, "…"))
+	require.LessOrEqual(t, len(body), 64+len("…"))
+	require.Len(t, output.Rows[0][1], 1200, "input rows are not modified")
+
+	fitted, _ = fitToBudget(output, formatJSON, 10)
+	require.Equal(t, 0, fitted.RowCount)
+	require.Equal(t, []string{"id", "body"}, fitted.Columns)
+}
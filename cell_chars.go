@@ -0,0 +1,74 @@
+package main
+
+import "unicode/utf8"
+
+// CellTruncation lists the cells of one row that max_cell_chars shortened.
+type CellTruncation struct {
+	Row     int      `json:"row" jsonschema:"0-based index of the row in rows."`
+	Columns []string `json:"columns" jsonschema:"Columns whose values in the row were cut and end with an ellipsis."`
+}
+
+// textTypeNames are the column types whose values max_cell_chars cuts.
+var textTypeNames = map[string]bool{
+	"CHAR":       true,
+	"VARCHAR":    true,
+	"TINYTEXT":   true,
+	"TEXT":       true,
+	"MEDIUMTEXT": true,
+	"LONGTEXT":   true,
+	"JSON":       true,
+	"ENUM":       true,
+	"SET":        true,
+}
+
+// cutLongCells cuts text values longer than maxChars characters, per
+// mysql.max_cell_chars, ending them with an ellipsis, and returns the cells
+// it cut by row. It runs after PII scanning, so a cut can't leave part of a
+// match unmasked, and leaves the columns pii pseudonymized whole, since
+// pseudonyms only join when they're complete.
+func cutLongCells(columns []string, types []ColumnType, rows [][]interface{}, maxChars int, pii []PIIDetection) []CellTruncation {
+	if maxChars <= 0 {
+		return nil
+	}
+	keep := make(map[string]bool)
+	for _, d := range pii {
+		if d.Pseudonymized {
+			keep[d.Column] = true
+		}
+	}
+	var cells []CellTruncation
+	for i, row := range rows {
+		var cut []string
+		for j, value := range row {
+			s, ok := value.(string)
+			if !ok || j >= len(columns) || j >= len(types) || !textTypeNames[types[j].Type] || keep[columns[j]] {
+				continue
+			}
+			if end, ok := charPrefix(s, maxChars); ok {
+				row[j] = s[:end] + "…"
+				cut = append(cut, columns[j])
+			}
+		}
+		if cut != nil {
+			cells = append(cells, CellTruncation{Row: i, Columns: cut})
+		}
+	}
+	return cells
+}
+
+// charPrefix returns the length in bytes of the first n characters of s, or
+// false if s has no more than n.
+func charPrefix(s string, n int) (int, bool) {
+	if len(s) <= n {
+		return 0, false
+	}
+	end := 0
+	for range n {
+		if end >= len(s) {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(s[end:])
+		end += size
+	}
+	return end, end < len(s)
+}
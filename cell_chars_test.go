@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// contactRows serves one row with an email address and a card number, as
+// text or, like CAST(... AS BINARY), as bytes.
+type contactRows struct{ done, binary bool }
+
+func (r *contactRows) Columns() []string { return []string{"id", "email", "card"} }
+func (r *contactRows) Close() error      { return nil }
+func (r *contactRows) ColumnTypeDatabaseTypeName(i int) string {
+	if r.binary {
+		return []string{"BIGINT", "VARBINARY", "VARBINARY"}[i]
+	}
+	return []string{"BIGINT", "VARCHAR", "CHAR"}[i]
+}
+func (r *contactRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	dest[1] = []byte("jane.doe@example.com")
+	dest[2] = []byte("4111 1111 1111 1111")
+	return nil
+}
+
+func TestCutLongCells(t *testing.T) {
+	columns := []string{"id", "body", "data", "customer", "created"}
+	types := []ColumnType{{Type: "BIGINT"}, {Type: "TEXT"}, {Type: "BLOB"}, {Type: "VARCHAR"}, {Type: "DATETIME"}}
+	rows := [][]interface{}{
+		{int64(1), "héllo world", BinaryValue{Base64: "aGVsbG8gd29ybGQ=", Bytes: 11}, "pn_0123456789abcdef", "2024-01-15T10:30:00Z"},
+		{int64(2), "short", nil, "pn_fedcba9876543210", nil},
+	}
+	pii := []PIIDetection{{Column: "customer", Types: []string{}, Cells: 2, Masked: true, Pseudonymized: true}}
+	cells := cutLongCells(columns, types, rows, 5, pii)
+	require.Equal(t, []CellTruncation{{Row: 0, Columns: []string{"body"}}}, cells)
+	// Limits count characters, not bytes, and only cut text that isn't a
+	// pseudonym.
+	require.Equal(t, []interface{}{int64(1), "héllo…", BinaryValue{Base64: "aGVsbG8gd29ybGQ=", Bytes: 11}, "pn_0123456789abcdef", "2024-01-15T10:30:00Z"}, rows[0])
+	require.Equal(t, "short", rows[1][1])
+	require.Nil(t, cutLongCells(columns, types, rows, 0, nil))
+}
+
+func TestCellsCutAfterMasking(t *testing.T) {
+	cfg := Config{PII: PIIConfig{Mode: piiModeMask}}
+	cfg.MySQL.MaxCellChars = 12
+	v, err := newValidator(cfg, "shop")
+	require.NoError(t, err)
+	h := &queryHandler{
+		db:          sql.OpenDB(execConnector{}),
+		config:      cfg,
+		validator:   v,
+		access:      newAccessControl(AccessConfig{}),
+		connections: newConnectionSet(),
+	}
+
+	// Cut first, neither value would match a pattern any more, and the
+	// rest of each would be returned unmasked.
+	output, err := h.executeReadOnly(context.Background(), "SELECT * FROM contacts", readOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{int64(1), "j***@example…", "**** **** **…"}, output.Rows[0])
+	require.Equal(t, []CellTruncation{{Row: 0, Columns: []string{"email", "card"}}}, output.TruncatedCells)
+	require.Len(t, output.PII, 2)
+}
+
+func TestTruncatedCellsInStructuredContent(t *testing.T) {
+	cells := []CellTruncation{{Row: 3, Columns: []string{"body"}}}
+	structured := queryOutputToStructuredContent(QueryOutput{Columns: []string{}, Rows: [][]interface{}{}, TruncatedCells: cells})
+	require.Equal(t, cells, structured["truncatedCells"])
+}
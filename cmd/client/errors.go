@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Exit codes, so scripts can tell why a call failed.
+const (
+	exitOK        = 0
+	exitInternal  = 1
+	exitUsage     = 2
+	exitRejected  = 3
+	exitToolError = 4
+	exitTransport = 5
+)
+
+// cliError classifies a failure for the exit code and -json output.
+type cliError struct {
+	kind    string
+	code    int
+	err     error
+	details any
+	json    bool
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+func (e *cliError) Unwrap() error { return e.err }
+
+func usageError(err error) error {
+	return &cliError{kind: "usage", code: exitUsage, err: err}
+}
+
+func transportError(err error) error {
+	return &cliError{kind: "transport", code: exitTransport, err: err}
+}
+
+// toolFailure is a tool result with isError set. The server reports a
+// read-only gate rejection in structured content, which gets its own code.
+func toolFailure(err error, structured any) error {
+	e := &cliError{kind: "tool_error", code: exitToolError, err: err, details: structured}
+	if m, ok := structured.(map[string]any); ok && m["rejection"] != nil {
+		e.kind, e.code = "rejected", exitRejected
+	}
+	return e
+}
+
+// reportError writes err to w, as a JSON object when -json was given, and
+// returns the process exit code.
+func reportError(w io.Writer, err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var ce *cliError
+	if !errors.As(err, &ce) {
+		ce = &cliError{kind: "internal", code: exitInternal, err: err}
+	}
+	if !ce.json {
+		fmt.Fprintln(w, err)
+		return ce.code
+	}
+	payload := struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+		Code    int    `json:"exitCode"`
+		Details any    `json:"details,omitempty"`
+	}{ce.kind, err.Error(), ce.code, ce.details}
+	b, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		fmt.Fprintln(w, err)
+		return ce.code
+	}
+	fmt.Fprintln(w, string(b))
+	return ce.code
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func runWithResult(t *testing.T, args []string, result *mcp.CallToolResult, callErr error) error {
+	t.Helper()
+	sess := &fakeSession{callTool: func(ctx context.Context, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
+		return result, callErr
+	}}
+	var buf bytes.Buffer
+	return run(context.Background(), args, func() mcpClient {
+		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
+			return sess, nil
+		}}
+	}, func(serverCommand) mcp.Transport {
+		return nil
+	}, log.New(&buf, "", 0))
+}
+
+func TestReportErrorExitCodes(t *testing.T) {
+	rejected := &mcp.CallToolResult{IsError: true, StructuredContent: map[string]any{
+		"rejection": map[string]any{"construct": "INSERT", "reason": "only SELECT is allowed"},
+	}}
+	toolErr := &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "query timed out"}}}
+
+	cases := map[string]struct {
+		err  error
+		code int
+	}{
+		"ok":        {runWithResult(t, nil, &mcp.CallToolResult{StructuredContent: map[string]any{"ok": true}}, nil), exitOK},
+		"usage":     {runWithResult(t, []string{"-nope"}, nil, nil), exitUsage},
+		"rejected":  {runWithResult(t, nil, rejected, nil), exitRejected},
+		"tool":      {runWithResult(t, nil, toolErr, nil), exitToolError},
+		"transport": {runWithResult(t, nil, nil, errors.New("broken pipe")), exitTransport},
+		"internal":  {errors.New("unexpected"), exitInternal},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			require.Equal(t, tc.code, reportError(&stderr, tc.err))
+			if tc.err != nil {
+				require.Equal(t, tc.err.Error()+"\n", stderr.String())
+			}
+		})
+	}
+}
+
+func TestReportErrorJSON(t *testing.T) {
+	err := runWithResult(t, []string{"-json", "-query", "DELETE FROM t"}, &mcp.CallToolResult{IsError: true, StructuredContent: map[string]any{
+		"rejection": map[string]any{"construct": "DELETE"},
+	}}, nil)
+
+	var stderr bytes.Buffer
+	require.Equal(t, exitRejected, reportError(&stderr, err))
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(stderr.Bytes(), &payload))
+	require.Equal(t, "rejected", payload["error"])
+	require.Equal(t, float64(exitRejected), payload["exitCode"])
+	require.Contains(t, payload["message"], "tool failed:")
+	require.Equal(t, map[string]any{"rejection": map[string]any{"construct": "DELETE"}}, payload["details"])
+
+	stderr.Reset()
+	err = runWithResult(t, []string{"-json", "-query", ""}, nil, nil)
+	require.Equal(t, exitUsage, reportError(&stderr, err))
+	require.JSONEq(t, `{"error":"usage","message":"-query is required","exitCode":2}`, stderr.String())
+}
@@ -10,6 +10,7 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -47,45 +48,86 @@ func (s *realSession) Close() error {
 	return s.inner.Close()
 }
 
-func run(ctx context.Context, args []string, newClient func() mcpClient, newTransport func() mcp.Transport, logger *log.Logger) error {
+func run(ctx context.Context, args []string, newClient func() mcpClient, newTransport func(serverCommand) mcp.Transport, logger *log.Logger) (err error) {
 	fs := flag.NewFlagSet("mcp-client", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	query := fs.String("query", "SELECT 1", "Read-only SQL query to run")
+	tool := fs.String("tool", "mysql_query", "Name of the tool to call")
+	queryFile := fs.String("query-file", "", "Read the SQL query from this file instead of -query")
+	toolArgs := fs.String("args", "", "Tool arguments as a JSON object; replaces -query")
+	var bindValues paramList
+	fs.Var(&bindValues, "param", "Value for the next ? placeholder (repeatable); parsed as JSON if possible, otherwise a string")
+	jsonErrors := fs.Bool("json", false, "Print errors as a JSON object on stderr")
+	serverCmd := fs.String("server-cmd", "bin/mysqlmcp", "Server command to spawn, with any arguments separated by spaces")
+	serverConfig := fs.String("server-config", "", "Config file passed to the server as -config")
+	var serverEnv envList
+	fs.Var(&serverEnv, "env", "KEY=VALUE added to the server's environment (repeatable); the client's environment is inherited")
+	defer func() {
+		var ce *cliError
+		if errors.As(err, &ce) {
+			ce.json = *jsonErrors
+		}
+	}()
 	if err := fs.Parse(args); err != nil {
-		return err
+		return usageError(err)
+	}
+	if *tool == "" {
+		return usageError(errors.New("-tool is required"))
 	}
-	if *query == "" {
-		return errors.New("-query is required")
+	if *queryFile != "" {
+		if flagSet(fs, "query") {
+			return usageError(errors.New("-query and -query-file can't be combined"))
+		}
+		sqlText, err := os.ReadFile(*queryFile)
+		if err != nil {
+			return usageError(fmt.Errorf("-query-file: %w", err))
+		}
+		*query = strings.TrimSpace(string(sqlText))
+	}
+	arguments, err := toolArguments(fs, *tool, *query, *toolArgs)
+	if err != nil {
+		return usageError(err)
+	}
+	if len(bindValues) > 0 {
+		if *toolArgs != "" || *tool != "mysql_query" {
+			return usageError(errors.New("-param only applies to mysql_query without -args"))
+		}
+		arguments["params"] = []any(bindValues)
+	}
+
+	server, err := newServerCommand(*serverCmd, *serverConfig, serverEnv)
+	if err != nil {
+		return usageError(err)
 	}
 
 	client := newClient()
-	session, err := client.Connect(ctx, newTransport(), nil)
+	session, err := client.Connect(ctx, newTransport(server), nil)
 	if err != nil {
-		return err
+		return transportError(err)
 	}
 	defer session.Close()
 
 	params := &mcp.CallToolParams{
-		Name:      "mysql_query",
-		Arguments: map[string]any{"query": *query},
+		Name:      *tool,
+		Arguments: arguments,
 	}
 	res, err := session.CallTool(ctx, params)
 	if err != nil {
-		return fmt.Errorf("CallTool failed: %w", err)
+		return transportError(fmt.Errorf("CallTool failed: %w", err))
 	}
 	if res.IsError {
 		if res.StructuredContent != nil {
 			b, err := json.MarshalIndent(res.StructuredContent, "", "  ")
 			if err == nil {
-				return fmt.Errorf("tool failed: %s", string(b))
+				return toolFailure(fmt.Errorf("tool failed: %s", string(b)), res.StructuredContent)
 			}
 		}
 		for _, c := range res.Content {
 			if t, ok := c.(*mcp.TextContent); ok {
-				return fmt.Errorf("tool failed: %s", t.Text)
+				return toolFailure(fmt.Errorf("tool failed: %s", t.Text), nil)
 			}
 		}
-		return errors.New("tool failed")
+		return toolFailure(errors.New("tool failed"), nil)
 	}
 	if res.StructuredContent != nil {
 		b, err := json.MarshalIndent(res.StructuredContent, "", "  ")
@@ -110,6 +152,107 @@ func run(ctx context.Context, args []string, newClient func() mcpClient, newTran
 	return nil
 }
 
+// serverCommand is how the client spawns the server over stdio.
+type serverCommand struct {
+	path string
+	args []string
+	env  []string
+}
+
+func newServerCommand(command, config string, env []string) (serverCommand, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return serverCommand{}, errors.New("-server-cmd is required")
+	}
+	server := serverCommand{path: fields[0], args: fields[1:], env: env}
+	if config != "" {
+		server.args = append(server.args, "-config", config)
+	}
+	return server, nil
+}
+
+func (s serverCommand) command() *exec.Cmd {
+	cmd := exec.Command(s.path, s.args...)
+	if len(s.env) > 0 {
+		cmd.Env = append(os.Environ(), s.env...)
+	}
+	return cmd
+}
+
+// envList collects repeated -env KEY=VALUE flags.
+type envList []string
+
+func (e *envList) String() string {
+	return strings.Join(*e, " ")
+}
+
+func (e *envList) Set(value string) error {
+	if key, _, ok := strings.Cut(value, "="); !ok || key == "" {
+		return fmt.Errorf("-env %q: expected KEY=VALUE", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
+
+// paramList collects repeated -param flags. Each value is decoded as JSON
+// when it is a JSON scalar (42, true, null, "42"), and sent as a string
+// otherwise.
+type paramList []any
+
+func (p *paramList) String() string {
+	return fmt.Sprint([]any(*p))
+}
+
+func (p *paramList) Set(value string) error {
+	var decoded any
+	if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+		switch decoded.(type) {
+		case map[string]any, []any:
+			return fmt.Errorf("-param %s: objects and arrays can't be bound", value)
+		}
+		*p = append(*p, decoded)
+		return nil
+	}
+	*p = append(*p, value)
+	return nil
+}
+
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// toolArguments returns the -args object, or {"query": -query} for
+// mysql_query so the original -query shorthand keeps working.
+func toolArguments(fs *flag.FlagSet, tool, query, rawArgs string) (map[string]any, error) {
+	querySet := flagSet(fs, "query") || flagSet(fs, "query-file")
+	if rawArgs != "" {
+		if querySet {
+			return nil, errors.New("-query or -query-file can't be combined with -args")
+		}
+		var arguments map[string]any
+		if err := json.Unmarshal([]byte(rawArgs), &arguments); err != nil || arguments == nil {
+			return nil, fmt.Errorf("-args must be a JSON object: %s", rawArgs)
+		}
+		return arguments, nil
+	}
+	if tool != "mysql_query" {
+		if querySet {
+			return nil, fmt.Errorf("-query only applies to mysql_query; use -args for %s", tool)
+		}
+		return map[string]any{}, nil
+	}
+	if query == "" {
+		return nil, errors.New("-query is required")
+	}
+	return map[string]any{"query": query}, nil
+}
+
 func main() {
 	ctx := context.Background()
 	logger := log.Default()
@@ -120,12 +263,10 @@ func main() {
 			c := mcp.NewClient(&mcp.Implementation{Name: "mcp-client", Version: "v1.0.0"}, nil)
 			return &realClient{inner: c}
 		},
-		func() mcp.Transport {
-			return &mcp.CommandTransport{Command: exec.Command("bin/mysqlmcp")}
+		func(server serverCommand) mcp.Transport {
+			return &mcp.CommandTransport{Command: server.command()}
 		},
 		logger,
 	)
-	if err != nil {
-		log.Fatal(err)
-	}
+	os.Exit(reportError(os.Stderr, err))
 }
@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"log"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -46,7 +48,7 @@ func TestRun_EmptyQueryErrors(t *testing.T) {
 
 	err := run(ctx, []string{"-query", ""}, func() mcpClient {
 		return &fakeClient{}
-	}, func() mcp.Transport {
+	}, func(serverCommand) mcp.Transport {
 		return nil
 	}, logger)
 
@@ -64,7 +66,7 @@ func TestRun_ConnectErrorPropagates(t *testing.T) {
 		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
 			return nil, boom
 		}}
-	}, func() mcp.Transport {
+	}, func(serverCommand) mcp.Transport {
 		return nil
 	}, logger)
 
@@ -87,7 +89,7 @@ func TestRun_CallToolErrorWraps(t *testing.T) {
 		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
 			return sess, nil
 		}}
-	}, func() mcp.Transport {
+	}, func(serverCommand) mcp.Transport {
 		return nil
 	}, logger)
 
@@ -113,7 +115,7 @@ func TestRun_ToolIsError_PrefersStructuredContent(t *testing.T) {
 		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
 			return sess, nil
 		}}
-	}, func() mcp.Transport {
+	}, func(serverCommand) mcp.Transport {
 		return nil
 	}, logger)
 
@@ -139,7 +141,7 @@ func TestRun_ToolIsError_UsesTextContentWhenNoStructured(t *testing.T) {
 		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
 			return sess, nil
 		}}
-	}, func() mcp.Transport {
+	}, func(serverCommand) mcp.Transport {
 		return nil
 	}, logger)
 
@@ -164,7 +166,7 @@ func TestRun_StructuredContentSuccess_LogsJSON(t *testing.T) {
 		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
 			return sess, nil
 		}}
-	}, func() mcp.Transport {
+	}, func(serverCommand) mcp.Transport {
 		return nil
 	}, logger)
 
@@ -188,7 +190,7 @@ func TestRun_TextContentSuccess_LogsText(t *testing.T) {
 		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
 			return sess, nil
 		}}
-	}, func() mcp.Transport {
+	}, func(serverCommand) mcp.Transport {
 		return nil
 	}, logger)
 
@@ -196,3 +198,179 @@ func TestRun_TextContentSuccess_LogsText(t *testing.T) {
 	require.True(t, sess.closeCalled)
 	require.Contains(t, buf.String(), "hello")
 }
+
+func TestRun_ToolAndArgs(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+
+	sess := &fakeSession{callTool: func(ctx context.Context, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
+		require.Equal(t, "mysql_show_create", params.Name)
+		require.Equal(t, map[string]any{"table": "orders", "database": "shop"}, params.Arguments)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "CREATE TABLE orders"}}}, nil
+	}}
+
+	err := run(ctx, []string{"-tool", "mysql_show_create", "-args", `{"table":"orders","database":"shop"}`}, func() mcpClient {
+		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
+			return sess, nil
+		}}
+	}, func(serverCommand) mcp.Transport {
+		return nil
+	}, logger)
+
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "CREATE TABLE orders")
+}
+
+func TestRun_ToolWithoutArgsSendsEmptyObject(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+
+	sess := &fakeSession{callTool: func(ctx context.Context, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
+		require.Equal(t, "mysql_processlist", params.Name)
+		require.Equal(t, map[string]any{}, params.Arguments)
+		return &mcp.CallToolResult{StructuredContent: map[string]any{"ok": true}}, nil
+	}}
+
+	err := run(ctx, []string{"-tool", "mysql_processlist"}, func() mcpClient {
+		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
+			return sess, nil
+		}}
+	}, func(serverCommand) mcp.Transport {
+		return nil
+	}, logger)
+
+	require.NoError(t, err)
+}
+
+func TestRun_ArgsValidation(t *testing.T) {
+	ctx := context.Background()
+	cases := map[string]struct {
+		args []string
+		err  string
+	}{
+		"not an object":   {[]string{"-args", `["x"]`}, "-args must be a JSON object"},
+		"invalid json":    {[]string{"-args", `{`}, "-args must be a JSON object"},
+		"query with args": {[]string{"-query", "SELECT 1", "-args", `{"query":"SELECT 2"}`}, "can't be combined"},
+		"query elsewhere": {[]string{"-tool", "mysql_processlist", "-query", "SELECT 1"}, "-query only applies to mysql_query"},
+		"empty tool":      {[]string{"-tool", ""}, "-tool is required"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := run(ctx, tc.args, func() mcpClient {
+				return &fakeClient{}
+			}, func(serverCommand) mcp.Transport {
+				return nil
+			}, log.New(&buf, "", 0))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestRun_QueryFileWithParams(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+
+	path := filepath.Join(t.TempDir(), "orders.sql")
+	require.NoError(t, os.WriteFile(path, []byte("SELECT id FROM orders\nWHERE customer_id = ? AND status = ?;\n"), 0o600))
+
+	sess := &fakeSession{callTool: func(ctx context.Context, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
+		require.Equal(t, "mysql_query", params.Name)
+		require.Equal(t, map[string]any{
+			"query":  "SELECT id FROM orders\nWHERE customer_id = ? AND status = ?;",
+			"params": []any{float64(42), "shipped"},
+		}, params.Arguments)
+		return &mcp.CallToolResult{StructuredContent: map[string]any{"ok": true}}, nil
+	}}
+
+	err := run(ctx, []string{"-query-file", path, "-param", "42", "-param", "shipped"}, func() mcpClient {
+		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
+			return sess, nil
+		}}
+	}, func(serverCommand) mcp.Transport {
+		return nil
+	}, logger)
+
+	require.NoError(t, err)
+}
+
+func TestParamList(t *testing.T) {
+	var params paramList
+	for _, value := range []string{"42", "true", "null", `"42"`, "shipped", "2024-01-01"} {
+		require.NoError(t, params.Set(value))
+	}
+	require.Equal(t, paramList{float64(42), true, nil, "42", "shipped", "2024-01-01"}, params)
+	require.ErrorContains(t, params.Set(`{"a":1}`), "can't be bound")
+}
+
+func TestRun_QueryFileErrors(t *testing.T) {
+	ctx := context.Background()
+	cases := map[string]struct {
+		args []string
+		err  string
+	}{
+		"missing file":         {[]string{"-query-file", "/nonexistent.sql"}, "-query-file"},
+		"query and file":       {[]string{"-query", "SELECT 1", "-query-file", "/nonexistent.sql"}, "can't be combined"},
+		"param with args":      {[]string{"-args", `{"query":"SELECT ?"}`, "-param", "1"}, "-param only applies"},
+		"param for other tool": {[]string{"-tool", "mysql_processlist", "-param", "1"}, "-param only applies"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := run(ctx, tc.args, func() mcpClient {
+				return &fakeClient{}
+			}, func(serverCommand) mcp.Transport {
+				return nil
+			}, log.New(&buf, "", 0))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestRun_ServerCommandFlags(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+
+	sess := &fakeSession{callTool: func(ctx context.Context, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{StructuredContent: map[string]any{"ok": true}}, nil
+	}}
+	var got serverCommand
+	err := run(ctx, []string{"-server-cmd", "go run ./cmd/server", "-server-config", "ci.toml", "-env", "MYSQL_PASSWORD=secret"}, func() mcpClient {
+		return &fakeClient{connect: func(ctx context.Context, t mcp.Transport, opts *mcp.ClientSessionOptions) (mcpSession, error) {
+			return sess, nil
+		}}
+	}, func(server serverCommand) mcp.Transport {
+		got = server
+		return nil
+	}, logger)
+
+	require.NoError(t, err)
+	require.Equal(t, serverCommand{
+		path: "go",
+		args: []string{"run", "./cmd/server", "-config", "ci.toml"},
+		env:  []string{"MYSQL_PASSWORD=secret"},
+	}, got)
+
+	cmd := got.command()
+	require.Equal(t, []string{"go", "run", "./cmd/server", "-config", "ci.toml"}, cmd.Args)
+	require.Contains(t, cmd.Env, "MYSQL_PASSWORD=secret")
+	require.Greater(t, len(cmd.Env), 1, "the client's environment is inherited")
+}
+
+func TestServerCommandDefaults(t *testing.T) {
+	server, err := newServerCommand("bin/mysqlmcp", "", nil)
+	require.NoError(t, err)
+	cmd := server.command()
+	require.Equal(t, []string{"bin/mysqlmcp"}, cmd.Args)
+	require.Nil(t, cmd.Env)
+
+	_, err = newServerCommand("  ", "", nil)
+	require.ErrorContains(t, err, "-server-cmd is required")
+
+	var env envList
+	require.ErrorContains(t, env.Set("NOVALUE"), "expected KEY=VALUE")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// referenceCollation is the MySQL 8 default, compared against alongside byte
+// order.
+const referenceCollation = "utf8mb4_0900_ai_ci"
+
+type CollationOrderInput struct {
+	Database  string `json:"database" jsonschema:"Database containing the table."`
+	Table     string `json:"table" jsonschema:"Table to sample."`
+	Column    string `json:"column" jsonschema:"Column whose values are sorted."`
+	Collation string `json:"collation" jsonschema:"Collation to sort by, e.g. utf8mb4_sv_0900_ai_ci or latin1_german2_ci."`
+	Limit     int    `json:"limit,omitempty" jsonschema:"Number of distinct values to sample (default 20, max 200)."`
+}
+
+// CollationRank gives one sampled value's position under each ordering.
+// Values that compare equal share a rank.
+type CollationRank struct {
+	Value         string `json:"value"`
+	Rank          int64  `json:"rank" jsonschema:"Position under the requested collation."`
+	BinaryRank    int64  `json:"binaryRank" jsonschema:"Position in byte order."`
+	ReferenceRank int64  `json:"referenceRank" jsonschema:"Position under utf8mb4_0900_ai_ci."`
+}
+
+type CollationOrderOutput struct {
+	Collation          string          `json:"collation"`
+	Charset            string          `json:"charset"`
+	Values             []CollationRank `json:"values" jsonschema:"Sampled values in the requested collation's order."`
+	DiffersFromBinary  bool            `json:"differsFromBinary"`
+	DiffersFromDefault bool            `json:"differsFromDefault" jsonschema:"True if the order differs from utf8mb4_0900_ai_ci."`
+}
+
+const collationCharsetQuery = "SELECT CHARACTER_SET_NAME AS charset FROM information_schema.COLLATIONS WHERE COLLATION_NAME = ?"
+
+// collationOrderQuery ranks a sample of distinct values under three orderings
+// in one statement, so every ordering sees the same sample. Identifiers must
+// already be validated.
+func collationOrderQuery(db, table, column, collation, charset string) string {
+	return fmt.Sprintf(`SELECT v,
+	RANK() OVER (ORDER BY CONVERT(v USING %[4]s) COLLATE %[5]s) AS requested_rank,
+	RANK() OVER (ORDER BY CAST(v AS BINARY)) AS binary_rank,
+	RANK() OVER (ORDER BY CONVERT(v USING utf8mb4) COLLATE %[6]s) AS reference_rank
+FROM (SELECT DISTINCT CAST(%[3]s AS CHAR) AS v FROM %[1]s.%[2]s WHERE %[3]s IS NOT NULL LIMIT ?) AS sample
+ORDER BY requested_rank, v`,
+		quoteIdentifier(db), quoteIdentifier(table), quoteIdentifier(column), charset, collation, referenceCollation)
+}
+
+func buildCollationOrder(out QueryOutput, collation, charset string) CollationOrderOutput {
+	valueCol := columnIndex(out.Columns, "v")
+	rankCol := columnIndex(out.Columns, "requested_rank")
+	binaryCol := columnIndex(out.Columns, "binary_rank")
+	referenceCol := columnIndex(out.Columns, "reference_rank")
+
+	output := CollationOrderOutput{Collation: collation, Charset: charset, Values: []CollationRank{}}
+	for _, row := range out.Rows {
+		rank, _ := valueInt64(rowValue(row, rankCol))
+		binaryRank, _ := valueInt64(rowValue(row, binaryCol))
+		referenceRank, _ := valueInt64(rowValue(row, referenceCol))
+		output.Values = append(output.Values, CollationRank{
+			Value:         valueString(rowValue(row, valueCol)),
+			Rank:          rank,
+			BinaryRank:    binaryRank,
+			ReferenceRank: referenceRank,
+		})
+		output.DiffersFromBinary = output.DiffersFromBinary || binaryRank != rank
+		output.DiffersFromDefault = output.DiffersFromDefault || referenceRank != rank
+	}
+	return output
+}
+
+func (h *queryHandler) collationOrder(ctx context.Context, req *mcp.CallToolRequest, input CollationOrderInput) (*mcp.CallToolResult, CollationOrderOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := CollationOrderOutput{Collation: input.Collation, Values: []CollationRank{}}
+	for _, name := range []string{input.Database, input.Table, input.Column, input.Collation} {
+		if !mysqlIdentifierRE.MatchString(name) {
+			return toolErrorf(empty, "database, table, column, and collation must be plain identifiers")
+		}
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	limit = min(limit, 200)
+
+	charsets, err := h.runMetadataQuery(ctx, collationCharsetQuery, input.Collation)
+	if err != nil {
+		return toolErrorf(empty, "failed to look up collation: %v", err)
+	}
+	if len(charsets.Rows) == 0 {
+		return toolErrorf(empty, "unknown collation %q", input.Collation)
+	}
+	charset := valueString(rowValue(charsets.Rows[0], 0))
+	if !mysqlIdentifierRE.MatchString(charset) {
+		return toolErrorf(empty, "unexpected character set %q for collation %q", charset, input.Collation)
+	}
+
+	out, err := h.runQueryForResource(ctx, collationOrderQuery(input.Database, input.Table, input.Column, input.Collation, charset), limit)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	return nil, buildCollationOrder(out, input.Collation, charset), nil
+}
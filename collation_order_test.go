@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestCollationOrderQueryPassesGate(t *testing.T) {
+	query := collationOrderQuery("shop", "customers", "last_name", "utf8mb4_sv_0900_ai_ci", "utf8mb4")
+	require.NoError(t, testValidator(t, gate.Options{}).Validate(query))
+	require.Contains(t, query, "COLLATE utf8mb4_sv_0900_ai_ci")
+	require.Contains(t, query, "FROM `shop`.`customers`")
+}
+
+func TestBuildCollationOrder(t *testing.T) {
+	out := QueryOutput{
+		Columns: []string{"v", "requested_rank", "binary_rank", "reference_rank"},
+		Rows: [][]interface{}{
+			{"apple", int64(1), int64(2), int64(1)},
+			{"Zebra", int64(2), int64(1), int64(2)},
+		},
+	}
+	got := buildCollationOrder(out, "utf8mb4_0900_ai_ci", "utf8mb4")
+	require.Equal(t, []CollationRank{
+		{Value: "apple", Rank: 1, BinaryRank: 2, ReferenceRank: 1},
+		{Value: "Zebra", Rank: 2, BinaryRank: 1, ReferenceRank: 2},
+	}, got.Values)
+	require.True(t, got.DiffersFromBinary)
+	require.False(t, got.DiffersFromDefault)
+}
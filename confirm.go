@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// confirmTokenTTL is how long a confirmation token stays valid.
+const confirmTokenTTL = 5 * time.Minute
+
+// QueryConfirmation is returned instead of rows when a query's estimated
+// cost is over confirm_cost_threshold.
+type QueryConfirmation struct {
+	EstimatedCost    float64        `json:"estimatedCost"`
+	Threshold        float64        `json:"threshold"`
+	Tables           []PlannedTable `json:"tables,omitempty"`
+	Token            string         `json:"token" jsonschema:"Pass as confirmToken with the same query and params to run it."`
+	ExpiresInSeconds int            `json:"expiresInSeconds"`
+}
+
+// confirmationStore holds issued tokens, each bound to one session, query,
+// and parameter list until it expires.
+type confirmationStore struct {
+	mu     sync.Mutex
+	tokens map[string]pendingConfirmation
+}
+
+type pendingConfirmation struct {
+	key     string
+	expires time.Time
+}
+
+func newConfirmationStore() *confirmationStore {
+	return &confirmationStore{tokens: make(map[string]pendingConfirmation)}
+}
+
+// confirmationKey identifies what a token confirms.
+func confirmationKey(session, query string, params []any) string {
+	encoded, _ := json.Marshal(params)
+	sum := sha256.Sum256([]byte(session + "\x00" + query + "\x00" + string(encoded)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *confirmationStore) issue(key string, now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, pending := range s.tokens {
+		if now.After(pending.expires) {
+			delete(s.tokens, token)
+		}
+	}
+	token := newRandomID() + newRandomID()
+	s.tokens[token] = pendingConfirmation{key: key, expires: now.Add(confirmTokenTTL)}
+	return token
+}
+
+// valid reports whether token was issued for key and hasn't expired. Tokens
+// aren't consumed, so a confirmed query survives backup lock retries.
+func (s *confirmationStore) valid(token, key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending, ok := s.tokens[token]
+	return ok && pending.key == key && !now.After(pending.expires)
+}
+
+// confirmationCheck decides whether mysql_query may run input now. If the
+// query needs confirmation, or its token is invalid, it returns the result
+// to send instead. Queries whose plan can't be estimated run unchecked and
+// fail, if they do, on their own.
+func (h *queryHandler) confirmationCheck(ctx context.Context, req *mcp.CallToolRequest, input QueryInput, args []any, threshold float64) (*mcp.CallToolResult, QueryOutput, bool) {
+	key := confirmationKey(sessionIDFor(req.Session), input.Query, input.Params)
+	if input.ConfirmToken != "" {
+		if h.confirmations.valid(input.ConfirmToken, key, time.Now()) {
+			return nil, QueryOutput{}, true
+		}
+		result, output := toolErrorResultf("confirmToken is invalid or expired, or was issued for a different query or params; run the query without it to get a new one")
+		return result, output, false
+	}
+	// Later pages of a cursor belong to a query that already ran.
+	if input.Cursor != "" || !isSelectQuery(input.Query) {
+		return nil, QueryOutput{}, true
+	}
+	plan, err := h.runQueryForResource(ctx, "EXPLAIN FORMAT=JSON "+input.Query, args...)
+	if err != nil || len(plan.Rows) == 0 {
+		return nil, QueryOutput{}, true
+	}
+	cost, tables, err := explainSummary(valueString(rowValue(plan.Rows[0], 0)))
+	if err != nil || cost == nil || *cost <= threshold {
+		return nil, QueryOutput{}, true
+	}
+
+	output := QueryOutput{
+		Columns: []string{},
+		Rows:    [][]interface{}{},
+		Confirmation: &QueryConfirmation{
+			EstimatedCost:    *cost,
+			Threshold:        threshold,
+			Tables:           tables,
+			Token:            h.confirmations.issue(key, time.Now()),
+			ExpiresInSeconds: int(confirmTokenTTL / time.Second),
+		},
+	}
+	text := fmt.Sprintf("Not run: the estimated cost %.1f is over the confirmation threshold %.1f. Call mysql_query again with the same query and params and confirmToken %q within %d minutes to run it.",
+		*cost, threshold, output.Confirmation.Token, int(confirmTokenTTL/time.Minute))
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{&mcp.TextContent{Text: text}},
+		StructuredContent: queryOutputToStructuredContent(output),
+	}, output, false
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmationStore(t *testing.T) {
+	store := newConfirmationStore()
+	now := time.Now()
+	key := confirmationKey("session-1", "SELECT * FROM orders WHERE id > ?", []any{float64(10)})
+	token := store.issue(key, now)
+
+	require.True(t, store.valid(token, key, now.Add(time.Minute)))
+	require.True(t, store.valid(token, key, now.Add(2*time.Minute)), "tokens survive use, for retries")
+	require.False(t, store.valid(token, key, now.Add(confirmTokenTTL+time.Second)))
+	require.False(t, store.valid("unknown", key, now))
+
+	require.False(t, store.valid(token, confirmationKey("session-2", "SELECT * FROM orders WHERE id > ?", []any{float64(10)}), now))
+	require.False(t, store.valid(token, confirmationKey("session-1", "SELECT * FROM orders WHERE id > ?", []any{float64(11)}), now))
+
+	// Issuing prunes expired tokens.
+	store.issue(key, now.Add(confirmTokenTTL+time.Second))
+	require.Len(t, store.tokens, 1)
+}
+
+func TestConfirmationInStructuredContent(t *testing.T) {
+	confirmation := &QueryConfirmation{EstimatedCost: 48210.5, Threshold: 10000, Token: "t"}
+	structured := queryOutputToStructuredContent(QueryOutput{Columns: []string{}, Rows: [][]interface{}{}, Confirmation: confirmation})
+	require.Same(t, confirmation, structured["confirmation"])
+}
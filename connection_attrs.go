@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Limits of performance_schema.session_connect_attrs columns.
+const (
+	maxConnectAttrName  = 32
+	maxConnectAttrValue = 1024
+)
+
+// applyConnectionAttributes sets the connection attributes MySQL records in
+// performance_schema.session_connect_attrs, so a DBA can tell which MCP
+// server owns a connection. program_name and mcp_server_version default to
+// the server's name and version; connectionAttributes in the DSN override
+// those, and mysql.connection_attributes overrides both. The driver adds its
+// own _client_name, _os, _pid, and so on.
+func applyConnectionAttributes(dsnConfig *mysql.Config, cfg Config) error {
+	attrs := map[string]string{
+		"program_name":       cfg.Server.Name,
+		"mcp_server_version": cfg.Server.Version,
+	}
+	for _, pair := range strings.Split(dsnConfig.ConnectionAttributes, ",") {
+		if name, value, ok := strings.Cut(pair, ":"); ok {
+			attrs[name] = value
+		}
+	}
+	for name, value := range cfg.MySQL.ConnectionAttributes {
+		switch {
+		case name == "" || strings.HasPrefix(name, "_"):
+			return fmt.Errorf("mysql.connection_attributes: %q is not allowed; names starting with _ are reserved for client libraries", name)
+		case len(name) > maxConnectAttrName:
+			return fmt.Errorf("mysql.connection_attributes: name %q is longer than %d bytes", name, maxConnectAttrName)
+		case len(value) > maxConnectAttrValue:
+			return fmt.Errorf("mysql.connection_attributes.%s: value is longer than %d bytes", name, maxConnectAttrValue)
+		case strings.ContainsAny(name+value, ",:"):
+			return fmt.Errorf("mysql.connection_attributes.%s: names and values can't contain ',' or ':'", name)
+		}
+		attrs[name] = value
+	}
+
+	pairs := make([]string, 0, len(attrs))
+	for name, value := range attrs {
+		if value != "" && !strings.ContainsAny(value, ",:") {
+			pairs = append(pairs, name+":"+value)
+		}
+	}
+	sort.Strings(pairs)
+	dsnConfig.ConnectionAttributes = strings.Join(pairs, ",")
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConnectionAttributes(t *testing.T) {
+	var cfg Config
+	cfg.Server.Name = "mysql-readonly"
+	cfg.Server.Version = "v1.0.0"
+
+	dsnConfig := mysql.NewConfig()
+	require.NoError(t, applyConnectionAttributes(dsnConfig, cfg))
+	require.Equal(t, "mcp_server_version:v1.0.0,program_name:mysql-readonly", dsnConfig.ConnectionAttributes)
+
+	dsnConfig = mysql.NewConfig()
+	dsnConfig.ConnectionAttributes = "program_name:from-dsn,region:eu"
+	cfg.MySQL.ConnectionAttributes = map[string]string{"region": "us", "instance": "agents-1"}
+	require.NoError(t, applyConnectionAttributes(dsnConfig, cfg))
+	require.Equal(t, "instance:agents-1,mcp_server_version:v1.0.0,program_name:from-dsn,region:us", dsnConfig.ConnectionAttributes)
+
+	for _, attrs := range []map[string]string{
+		{"_pid": "1"},
+		{"instance": "a:b"},
+		{"this_attribute_name_is_far_too_long": "x"},
+	} {
+		cfg.MySQL.ConnectionAttributes = attrs
+		require.Error(t, applyConnectionAttributes(mysql.NewConfig(), cfg), "%v", attrs)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+)
+
+// initConnector runs the configured init statements on every new pooled
+// connection, for session settings (time_zone, group_concat_max_len, ...)
+// that can't be set per query.
+type initConnector struct {
+	driver.Connector
+	statements []string
+}
+
+func newInitConnector(inner driver.Connector, statements []string) driver.Connector {
+	if len(statements) == 0 {
+		return inner
+	}
+	return &initConnector{Connector: inner, statements: statements}
+}
+
+func (c *initConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("driver connection does not support init statements")
+	}
+	for _, stmt := range c.statements {
+		if _, err := execer.ExecContext(ctx, stmt, nil); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("init statement %q: %w", stmt, err)
+		}
+	}
+	return conn, nil
+}
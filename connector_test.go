@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingConn struct {
+	executed []string
+	failOn   string
+	closed   bool
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("unsupported") }
+func (c *recordingConn) Close() error                        { c.closed = true; return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)           { return nil, errors.New("unsupported") }
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if query == c.failOn {
+		return nil, errors.New("boom")
+	}
+	c.executed = append(c.executed, query)
+	return driver.ResultNoRows, nil
+}
+
+type stubConnector struct{ conn *recordingConn }
+
+func (s stubConnector) Connect(context.Context) (driver.Conn, error) { return s.conn, nil }
+func (s stubConnector) Driver() driver.Driver                        { return nil }
+
+func TestInitConnector(t *testing.T) {
+	inner := stubConnector{conn: &recordingConn{}}
+	require.Equal(t, inner, newInitConnector(inner, nil))
+
+	statements := []string{"SET time_zone = '+00:00'", "SET group_concat_max_len = 1048576"}
+	conn, err := newInitConnector(inner, statements).Connect(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, statements, conn.(*recordingConn).executed)
+
+	failing := stubConnector{conn: &recordingConn{failOn: statements[1]}}
+	_, err = newInitConnector(failing, statements).Connect(context.Background())
+	require.ErrorContains(t, err, "group_concat_max_len")
+	require.True(t, failing.conn.closed)
+}
@@ -0,0 +1,49 @@
+package main
+
+import "strings"
+
+// stripDeniedColumns removes the columns in strip from output.
+func stripDeniedColumns(output QueryOutput, strip map[string]bool) QueryOutput {
+	keep := keptColumns(output.Columns, strip)
+	if keep == nil {
+		return output
+	}
+	output.Columns = pickColumns(output.Columns, keep)
+	output.ColumnTypes = pickColumns(output.ColumnTypes, keep)
+	output.ColumnSources = pickColumns(output.ColumnSources, keep)
+	for i, row := range output.Rows {
+		output.Rows[i] = pickColumns(row, keep)
+	}
+	return output
+}
+
+// keptColumns returns the indexes of columns not in strip, or nil if none
+// are stripped.
+func keptColumns(columns []string, strip map[string]bool) []int {
+	if len(strip) == 0 {
+		return nil
+	}
+	keep := make([]int, 0, len(columns))
+	for i, column := range columns {
+		if !strip[strings.ToLower(column)] {
+			keep = append(keep, i)
+		}
+	}
+	if len(keep) == len(columns) {
+		return nil
+	}
+	return keep
+}
+
+// pickColumns returns the elements of values at keep, or values itself if
+// keep is nil.
+func pickColumns[T any](values []T, keep []int) []T {
+	if keep == nil || len(values) == 0 {
+		return values
+	}
+	picked := make([]T, len(keep))
+	for i, j := range keep {
+		picked[i] = values[j]
+	}
+	return picked
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestDeniedColumnsReject(t *testing.T) {
+	var cfg Config
+	cfg.DeniedColumns = []gate.DeniedColumns{{Table: "customers", Columns: []string{"SSN"}}}
+	v, err := newValidator(cfg, "shop")
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"SELECT ssn FROM customers",
+		"SELECT c.id FROM shop.customers c WHERE c.ssn LIKE '1%'",
+		"SELECT id FROM orders WHERE customer_id IN (SELECT id FROM customers ORDER BY ssn)",
+		"SELECT x.ssn FROM (SELECT * FROM orders JOIN customers USING (id)) x",
+		"SELECT shop.customers.ssn FROM customers",
+		"SELECT * FROM customers",
+		"SELECT o.id, c.* FROM orders o JOIN customers c ON c.id = o.customer_id",
+	} {
+		var rejection *gate.QueryRejection
+		require.ErrorAs(t, v.Validate(query), &rejection, query)
+	}
+	for _, query := range []string{
+		"SELECT id, name FROM customers",
+		"SELECT o.ssn FROM orders o JOIN customers c ON c.id = o.customer_id",
+		"SELECT * FROM orders",
+		"SELECT o.* FROM orders o JOIN customers c ON c.id = o.customer_id",
+		"SELECT ssn FROM orders",
+		"SELECT COUNT(*) FROM customers",
+	} {
+		require.NoError(t, v.Validate(query), query)
+	}
+	require.Nil(t, v.StrippedColumns("SELECT id FROM customers"))
+}
+
+func TestDeniedColumnsStrip(t *testing.T) {
+	var cfg Config
+	cfg.DeniedColumns = []gate.DeniedColumns{{Table: "shop.customers", Columns: []string{"ssn", "password_hash"}, Action: "strip"}}
+	v, err := newValidator(cfg, "shop")
+	require.NoError(t, err)
+
+	require.NoError(t, v.Validate("SELECT * FROM customers WHERE id = 1"))
+	require.Equal(t, map[string]bool{"ssn": true, "password_hash": true}, v.StrippedColumns("SELECT * FROM customers WHERE id = 1"))
+	require.NoError(t, v.Validate("EXPLAIN SELECT * FROM customers"))
+	for query, reason := range map[string]string{
+		"SELECT ssn FROM customers":                                  "column ssn of shop.customers is denied",
+		"SELECT * FROM (SELECT * FROM customers) c":                  "outermost SELECT",
+		"SELECT id FROM orders UNION SELECT * FROM customers":        "outermost SELECT",
+		"WITH c (a, b) AS (SELECT * FROM customers) SELECT a FROM c": "outermost SELECT",
+		"SELECT * FROM customers ORDER BY 3":                         "column positions",
+	} {
+		require.ErrorContains(t, v.Validate(query), reason, query)
+	}
+
+	output := stripDeniedColumns(QueryOutput{
+		Columns:     []string{"id", "SSN", "name", "password_hash"},
+		ColumnTypes: []ColumnType{{Type: "INT"}, {Type: "CHAR"}, {Type: "VARCHAR"}, {Type: "BINARY"}},
+		Rows:        [][]any{{1, "123", "ada", "x"}},
+	}, v.StrippedColumns("SELECT * FROM customers"))
+	require.Equal(t, []string{"id", "name"}, output.Columns)
+	require.Equal(t, []ColumnType{{Type: "INT"}, {Type: "VARCHAR"}}, output.ColumnTypes)
+	require.Equal(t, [][]any{{1, "ada"}}, output.Rows)
+}
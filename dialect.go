@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+
+	"mysqlmcp/internal/gate"
+)
+
+// checkDialect warns when the server's sql_mode disagrees with the parser
+// settings, which makes the gate misread or reject valid queries.
+func checkDialect(ctx context.Context, db *sql.DB, cfg gate.ParserConfig) {
+	var mode string
+	if err := db.QueryRowContext(ctx, "SELECT @@SESSION.sql_mode").Scan(&mode); err != nil {
+		log.Printf("failed to read sql_mode to check parser settings: %v", err)
+		return
+	}
+	flags := make(map[string]bool)
+	for _, flag := range strings.Split(strings.ToUpper(mode), ",") {
+		flags[flag] = true
+	}
+	if flags["ANSI_QUOTES"] != cfg.ANSIQuotes {
+		log.Printf("parser.ansi_quotes is %t but the server's sql_mode (%s) %s ANSI_QUOTES; double-quoted text may be misread", cfg.ANSIQuotes, mode, hasOrLacks(flags["ANSI_QUOTES"]))
+	}
+	if flags["NO_BACKSLASH_ESCAPES"] != cfg.NoBackslashEscapes {
+		log.Printf("parser.no_backslash_escapes is %t but the server's sql_mode (%s) %s NO_BACKSLASH_ESCAPES; strings with backslashes may be misread", cfg.NoBackslashEscapes, mode, hasOrLacks(flags["NO_BACKSLASH_ESCAPES"]))
+	}
+}
+
+func hasOrLacks(has bool) string {
+	if has {
+		return "has"
+	}
+	return "lacks"
+}
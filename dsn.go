@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDriverConfig builds the driver config from either mysql.dsn or the
+// structured host/port/user/password/database/params fields. Mixing the two
+// is an error so it's never ambiguous which one is in effect.
+func mysqlDriverConfig(cfg Config) (*mysql.Config, error) {
+	m := cfg.MySQL
+	structured := m.Host != "" || m.Port != 0 || m.User != "" || m.Password != "" ||
+		m.PasswordFile != "" || m.PasswordEnv != "" || m.Database != "" || len(m.Params) > 0
+	if m.DSN != "" {
+		if structured {
+			return nil, fmt.Errorf("mysql.dsn can't be combined with host, port, user, password, database, or params")
+		}
+		dsnConfig, err := mysql.ParseDSN(m.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("mysql.dsn: %w", err)
+		}
+		if err := applyConnectionOptions(dsnConfig, cfg); err != nil {
+			return nil, err
+		}
+		return dsnConfig, nil
+	}
+	if !structured {
+		return nil, fmt.Errorf("mysql.dsn or mysql.host is required")
+	}
+
+	if m.Host == "" {
+		return nil, fmt.Errorf("mysql.host is required")
+	}
+	if strings.ContainsAny(m.Host, "/@()? \t") {
+		return nil, fmt.Errorf("mysql.host %q is not a valid host name or address", m.Host)
+	}
+	port := m.Port
+	if port == 0 {
+		port = 3306
+	}
+	if port < 1 || port > 65535 {
+		return nil, fmt.Errorf("mysql.port %d is out of range", m.Port)
+	}
+	if m.User == "" {
+		return nil, fmt.Errorf("mysql.user is required")
+	}
+	if strings.ContainsAny(m.Database, "/?") {
+		return nil, fmt.Errorf("mysql.database %q contains '/' or '?'", m.Database)
+	}
+	password, err := mysqlPassword(m.Password, m.PasswordFile, m.PasswordEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	// Params go through ParseDSN so the driver validates its own options
+	// (parseTime, timeout, ...) exactly as it would in a DSN.
+	values := url.Values{}
+	for key, value := range m.Params {
+		values.Set(key, value)
+	}
+	dsnConfig, err := mysql.ParseDSN("/?" + values.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("mysql.params: %w", err)
+	}
+	dsnConfig.Net = "tcp"
+	dsnConfig.Addr = net.JoinHostPort(m.Host, fmt.Sprint(port))
+	dsnConfig.User = m.User
+	dsnConfig.Passwd = password
+	dsnConfig.DBName = m.Database
+	// ParseDSN resolved any tls param against its placeholder address; let
+	// the connector resolve it again so the server name matches Host.
+	dsnConfig.TLS = nil
+	if err := applyConnectionOptions(dsnConfig, cfg); err != nil {
+		return nil, err
+	}
+	return dsnConfig, nil
+}
+
+// applyConnectionOptions applies [mysql.tls], [mysql.iam] (which depends on
+// TLS being configured), connection attributes, and [mysql.ssh], in that
+// order.
+func applyConnectionOptions(dsnConfig *mysql.Config, cfg Config) error {
+	if err := applyTLS(dsnConfig, cfg.MySQL.TLS); err != nil {
+		return err
+	}
+	if err := applyIAM(dsnConfig, cfg.MySQL.IAM); err != nil {
+		return err
+	}
+	if err := applyConnectionAttributes(dsnConfig, cfg); err != nil {
+		return err
+	}
+	return applySSH(dsnConfig, cfg.MySQL.SSH)
+}
+
+// mysqlPassword resolves the password from at most one of the inline value,
+// a file (trailing newlines trimmed), or an environment variable.
+func mysqlPassword(inline, file, env string) (string, error) {
+	sources := 0
+	for _, source := range []string{inline, file, env} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", fmt.Errorf("set only one of mysql.password, mysql.password_file, and mysql.password_env")
+	}
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("mysql.password_file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case env != "":
+		password, ok := os.LookupEnv(env)
+		if !ok {
+			return "", fmt.Errorf("mysql.password_env: environment variable %s is not set", env)
+		}
+		return password, nil
+	}
+	return inline, nil
+}
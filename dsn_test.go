@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMySQLDriverConfigDSN(t *testing.T) {
+	var cfg Config
+	cfg.MySQL.DSN = "user:pass@tcp(localhost:3306)/db?parseTime=true"
+	dsnConfig, err := mysqlDriverConfig(cfg)
+	require.NoError(t, err)
+	require.Equal(t, "localhost:3306", dsnConfig.Addr)
+	require.Equal(t, "db", dsnConfig.DBName)
+	require.True(t, dsnConfig.ParseTime)
+
+	cfg.MySQL.Host = "localhost"
+	_, err = mysqlDriverConfig(cfg)
+	require.ErrorContains(t, err, "can't be combined")
+
+	_, err = mysqlDriverConfig(Config{})
+	require.ErrorContains(t, err, "mysql.dsn or mysql.host is required")
+}
+
+func TestMySQLDriverConfigStructured(t *testing.T) {
+	var cfg Config
+	cfg.MySQL.Host = "db.internal"
+	cfg.MySQL.User = "readonly"
+	cfg.MySQL.Password = "p@ss/word"
+	cfg.MySQL.Database = "shop"
+	cfg.MySQL.Params = map[string]string{"parseTime": "true", "timeout": "5s"}
+
+	dsnConfig, err := mysqlDriverConfig(cfg)
+	require.NoError(t, err)
+	require.Equal(t, "tcp", dsnConfig.Net)
+	require.Equal(t, "db.internal:3306", dsnConfig.Addr)
+	require.Equal(t, "readonly", dsnConfig.User)
+	require.Equal(t, "p@ss/word", dsnConfig.Passwd)
+	require.Equal(t, "shop", dsnConfig.DBName)
+	require.True(t, dsnConfig.ParseTime)
+	require.Equal(t, 5*time.Second, dsnConfig.Timeout)
+
+	cfg.MySQL.Host = "::1"
+	cfg.MySQL.Port = 3307
+	dsnConfig, err = mysqlDriverConfig(cfg)
+	require.NoError(t, err)
+	require.Equal(t, "[::1]:3307", dsnConfig.Addr)
+}
+
+func TestMySQLDriverConfigValidation(t *testing.T) {
+	base := func() Config {
+		var cfg Config
+		cfg.MySQL.Host = "localhost"
+		cfg.MySQL.User = "readonly"
+		return cfg
+	}
+	cases := map[string]struct {
+		mutate func(*Config)
+		err    string
+	}{
+		"missing host":  {func(c *Config) { c.MySQL.Host = "" }, "mysql.host is required"},
+		"bad host":      {func(c *Config) { c.MySQL.Host = "tcp(localhost)" }, "not a valid host"},
+		"bad port":      {func(c *Config) { c.MySQL.Port = 70000 }, "out of range"},
+		"missing user":  {func(c *Config) { c.MySQL.User = "" }, "mysql.user is required"},
+		"bad database":  {func(c *Config) { c.MySQL.Database = "db?x=1" }, "mysql.database"},
+		"bad param":     {func(c *Config) { c.MySQL.Params = map[string]string{"parseTime": "maybe"} }, "mysql.params"},
+		"two passwords": {func(c *Config) { c.MySQL.Password = "a"; c.MySQL.PasswordEnv = "B" }, "only one"},
+		"unset env":     {func(c *Config) { c.MySQL.PasswordEnv = "MYSQLMCP_TEST_UNSET_PASSWORD" }, "is not set"},
+		"missing file":  {func(c *Config) { c.MySQL.PasswordFile = "/nonexistent/password" }, "mysql.password_file"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg := base()
+			tc.mutate(&cfg)
+			_, err := mysqlDriverConfig(cfg)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestMySQLPasswordSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+	password, err := mysqlPassword("", path, "")
+	require.NoError(t, err)
+	require.Equal(t, "from-file", password)
+
+	t.Setenv("MYSQLMCP_TEST_PASSWORD", "from-env")
+	password, err = mysqlPassword("", "", "MYSQLMCP_TEST_PASSWORD")
+	require.NoError(t, err)
+	require.Equal(t, "from-env", password)
+
+	password, err = mysqlPassword("inline", "", "")
+	require.NoError(t, err)
+	require.Equal(t, "inline", password)
+}
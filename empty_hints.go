@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// maxEmptyResultChecks bounds how many WHERE conditions are probed on their
+// own after a query comes back empty.
+const maxEmptyResultChecks = 5
+
+// emptyResultCheck is one probe: a LIMIT 1 query that tells whether the table,
+// or one condition on it, matches any row.
+type emptyResultCheck struct {
+	condition string
+	query     string
+}
+
+// emptyResultPlan lists the probes for a single-table SELECT with a WHERE
+// clause: first whether the table has rows at all, then each top-level AND
+// condition that doesn't use bind parameters or subqueries.
+func emptyResultPlan(stmt sqlparser.Statement) (table string, checks []emptyResultCheck, complete bool) {
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || len(sel.From) != 1 || sel.With != nil || sel.Where == nil {
+		return "", nil, false
+	}
+	aliased, ok := sel.From[0].(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return "", nil, false
+	}
+	name, ok := aliased.Expr.(sqlparser.TableName)
+	if !ok {
+		return "", nil, false
+	}
+	from := sqlparser.String(aliased)
+	checks = append(checks, emptyResultCheck{query: fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", from)})
+
+	conditions := sqlparser.SplitAndExpression(nil, sel.Where.Expr)
+	complete = len(conditions) > 1
+	for _, condition := range conditions {
+		if len(checks) > maxEmptyResultChecks || !probeable(condition) {
+			complete = false
+			continue
+		}
+		text := sqlparser.String(condition)
+		checks = append(checks, emptyResultCheck{
+			condition: text,
+			query:     fmt.Sprintf("SELECT 1 FROM %s WHERE %s LIMIT 1", from, text),
+		})
+	}
+	return sqlparser.String(name), checks, complete
+}
+
+// probeable reports whether condition can run on its own: no bind
+// parameters (their values belong to the whole query) and no subqueries,
+// which could be as expensive as the query itself.
+func probeable(condition sqlparser.Expr) bool {
+	ok := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node.(type) {
+		case *sqlparser.Argument, *sqlparser.Subquery:
+			ok = false
+			return false, nil
+		}
+		return true, nil
+	}, condition)
+	return ok
+}
+
+// emptyResultHints explains an empty result by probing the table and each
+// condition of query on conn, rewritten by filter as the query was. Probe
+// failures are ignored: the hints are best effort.
+func (h *queryHandler) emptyResultHints(ctx context.Context, conn *sql.Conn, filter func(string) (string, error), query string) []string {
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return nil
+	}
+	table, checks, complete := emptyResultPlan(stmt)
+	if len(checks) == 0 {
+		return nil
+	}
+	var hints []string
+	for i, check := range checks {
+		found, err := h.probeRows(ctx, conn, filter, check.query)
+		if err != nil {
+			return hints
+		}
+		switch {
+		case i == 0 && !found:
+			return []string{fmt.Sprintf("%s has no rows", table)}
+		case !found:
+			hints = append(hints, fmt.Sprintf("no rows in %s match %s on its own; check the value", table, check.condition))
+		}
+	}
+	if len(hints) == 0 && complete {
+		hints = append(hints, fmt.Sprintf("each condition matches rows in %s on its own, but no row matches all of them", table))
+	}
+	return hints
+}
+
+func (h *queryHandler) probeRows(ctx context.Context, conn *sql.Conn, filter func(string) (string, error), query string) (bool, error) {
+	query, err := filter(query)
+	if err != nil {
+		return false, err
+	}
+	rows, err := conn.QueryContext(ctx, h.annotateQuery(ctx, query))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	found := rows.Next()
+	return found, rows.Err()
+}
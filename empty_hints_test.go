@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestEmptyResultPlan(t *testing.T) {
+	stmt, err := gate.ParseStatement("SELECT id FROM shop.orders o WHERE o.status = 'actve' AND o.total > 100 AND o.customer_id = ? AND o.id IN (SELECT order_id FROM refunds)")
+	require.NoError(t, err)
+	table, checks, complete := emptyResultPlan(stmt)
+	require.Equal(t, "shop.orders", table)
+	require.Equal(t, []emptyResultCheck{
+		{query: "select 1 from shop.orders as o limit 1"},
+		{condition: "o.`status` = 'actve'", query: "select 1 from shop.orders as o where o.`status` = 'actve' limit 1"},
+		{condition: "o.total > 100", query: "select 1 from shop.orders as o where o.total > 100 limit 1"},
+	}, lowerQueries(checks))
+	require.False(t, complete, "conditions with parameters or subqueries aren't probed")
+
+	stmt, err = gate.ParseStatement("SELECT * FROM orders WHERE status = 'open' AND total > 100")
+	require.NoError(t, err)
+	_, checks, complete = emptyResultPlan(stmt)
+	require.Len(t, checks, 3)
+	require.True(t, complete)
+
+	for _, query := range []string{
+		"SELECT * FROM orders",
+		"SELECT * FROM orders o JOIN customers c ON c.id = o.customer_id WHERE c.name = 'x'",
+		"SELECT 1 UNION SELECT 2",
+	} {
+		stmt, err := gate.ParseStatement(query)
+		require.NoError(t, err)
+		_, checks, _ := emptyResultPlan(stmt)
+		require.Empty(t, checks, query)
+	}
+}
+
+func lowerQueries(checks []emptyResultCheck) []emptyResultCheck {
+	for i := range checks {
+		checks[i].query = strings.ToLower(checks[i].query)
+	}
+	return checks
+}
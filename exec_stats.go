@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// statementStatsQuery reads the most recent SELECT this connection ran from
+// performance_schema's per-thread statement history.
+const statementStatsQuery = `SELECT ROWS_EXAMINED, NO_INDEX_USED
+FROM performance_schema.events_statements_history
+WHERE THREAD_ID = (SELECT THREAD_ID FROM performance_schema.threads WHERE PROCESSLIST_ID = CONNECTION_ID())
+	AND EVENT_NAME = 'statement/sql/select'
+ORDER BY EVENT_ID DESC LIMIT 1`
+
+// ExecutionStats describes how a query ran, so a caller can tell a cheap
+// query from one that scanned far more rows than it returned.
+type ExecutionStats struct {
+	DurationMs   float64 `json:"durationMs" jsonschema:"Milliseconds from sending the query to reading its last row."`
+	RowsExamined *int64  `json:"rowsExamined,omitempty" jsonschema:"Rows MySQL read to produce the result. Present with execution_stats enabled."`
+	IndexUsed    *bool   `json:"indexUsed,omitempty" jsonschema:"False if MySQL read some table without an index (a full scan). Present with execution_stats enabled."`
+}
+
+func newExecutionStats(duration time.Duration) *ExecutionStats {
+	return &ExecutionStats{DurationMs: float64(duration.Microseconds()) / 1000}
+}
+
+// isSelectQuery reports whether query is a SELECT or UNION, which the
+// statement history records as statement/sql/select and EXPLAIN can plan.
+func isSelectQuery(query string) bool {
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return false
+	}
+	switch stmt.(type) {
+	case *sqlparser.Select, *sqlparser.Union:
+		return true
+	}
+	return false
+}
+
+// addStatementStats fills in rows examined and index use for the SELECT
+// that just ran on conn. Without access to performance_schema, or with the
+// statement history consumer disabled, stats keep only the duration.
+func addStatementStats(ctx context.Context, conn *sql.Conn, stats *ExecutionStats) {
+	var rowsExamined int64
+	var noIndexUsed int64
+	if err := conn.QueryRowContext(ctx, statementStatsQuery).Scan(&rowsExamined, &noIndexUsed); err != nil {
+		return
+	}
+	indexUsed := noIndexUsed == 0
+	stats.RowsExamined = &rowsExamined
+	stats.IndexUsed = &indexUsed
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSelectQuery(t *testing.T) {
+	require.True(t, isSelectQuery("SELECT * FROM orders"))
+	require.True(t, isSelectQuery("WITH t AS (SELECT 1) SELECT * FROM t UNION SELECT 2"))
+	require.False(t, isSelectQuery("SHOW TABLES"))
+	require.False(t, isSelectQuery("EXPLAIN SELECT * FROM orders"))
+}
+
+func TestNewExecutionStats(t *testing.T) {
+	stats := newExecutionStats(1500 * time.Microsecond)
+	require.Equal(t, 1.5, stats.DurationMs)
+	require.Nil(t, stats.RowsExamined)
+	require.Nil(t, stats.IndexUsed)
+}
+
+func TestExecutionStatsInStructuredContent(t *testing.T) {
+	stats := &ExecutionStats{DurationMs: 12.4}
+	structured := queryOutputToStructuredContent(QueryOutput{Columns: []string{}, Rows: [][]interface{}{}, Stats: stats})
+	require.Same(t, stats, structured["stats"])
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// readOptions tune one executeReadOnly call.
+type readOptions struct {
+	// db is the pool to run on. Nil means h.readDB: a healthy replica if
+	// there is one, else the primary.
+	db   *sql.DB
+	args []any
+	// run is the statement to run in place of the query, such as a keyset
+	// page of it. Row filters apply to it, while denied columns and PII
+	// scanning go by the query as written.
+	run            string
+	includeDeleted bool
+	// maxRows caps the rows read. Zero means mysql.max_rows, lowered by the
+	// caller's role.
+	maxRows int
+	// timeoutSeconds overrides the query timeout, up to the server's
+	// maximum. Zero means the default.
+	timeoutSeconds int
+	// catalog marks the server's own catalog queries, whose results aren't
+	// scanned for PII.
+	catalog bool
+	// warnings reads SHOW WARNINGS before the commit clears them.
+	warnings bool
+	// afterCommit, if set, runs on the statement's connection once the
+	// transaction is done, before denied columns are stripped, to add to
+	// output what needs the same connection or the whole result.
+	afterCommit func(ctx context.Context, conn *sql.Conn, queryTime time.Duration, output *QueryOutput)
+}
+
+// queryRunError is a failure of the statement itself, rather than of getting
+// a connection or transaction for it, which tools explain to the agent.
+type queryRunError struct {
+	prefix string
+	err    error
+}
+
+func (e *queryRunError) Error() string { return e.prefix + ": " + e.err.Error() }
+func (e *queryRunError) Unwrap() error { return e.err }
+
+// executeReadOnly runs query, which the caller has already put through the
+// read-only gate and its access checks, in a read-only transaction with row
+// filters applied. It returns at most maxRows rows, and no more than
+// mysql.max_result_bytes of them, finished by finishOutput. Tools and
+// resources that read rows go through it, except mysql_batch, whose
+// statements share one transaction but are finished the same way.
+func (h *queryHandler) executeReadOnly(ctx context.Context, query string, opts readOptions) (QueryOutput, error) {
+	live := h.snapshot()
+	run := query
+	if opts.run != "" {
+		run = opts.run
+	}
+	run, err := live.applyFilters(run, opts.includeDeleted)
+	if err != nil {
+		return QueryOutput{}, fmt.Errorf("failed to apply row filters: %w", err)
+	}
+
+	timeout := h.queryTimeout(opts.timeoutSeconds)
+	recursive := isRecursiveQuery(query)
+	var recursionDepth int
+	if recursive {
+		recursionDepth, timeout = h.recursiveLimits(timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	maxRows := opts.maxRows
+	if maxRows <= 0 {
+		maxRows = h.maxRows(ctx)
+	}
+	db := opts.db
+	if db == nil {
+		var release func()
+		db, release = h.readDB(ctx)
+		defer release()
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return QueryOutput{}, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+	connID, err := h.connectionID(ctx, db, conn)
+	if err != nil {
+		return QueryOutput{}, fmt.Errorf("failed to read connection id: %w", err)
+	}
+	restoreRole, err := h.setRole(ctx, conn)
+	if err != nil {
+		return QueryOutput{}, err
+	}
+	defer restoreRole()
+	if recursive {
+		restore, err := limitRecursion(ctx, conn, recursionDepth)
+		if err != nil {
+			return QueryOutput{}, err
+		}
+		defer restore()
+	}
+	defer h.killOnCancel(ctx, db, connID)()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return QueryOutput{}, fmt.Errorf("failed to start read-only transaction: %w", err)
+	}
+
+	queryStart := time.Now()
+	rows, err := tx.QueryContext(ctx, h.annotateQuery(ctx, run), opts.args...)
+	if err != nil {
+		_ = tx.Rollback()
+		return QueryOutput{}, &queryRunError{prefix: "query failed", err: err}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		_ = tx.Rollback()
+		return QueryOutput{}, fmt.Errorf("failed to fetch columns: %w", err)
+	}
+	if columns == nil {
+		columns = []string{}
+	}
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		_ = tx.Rollback()
+		return QueryOutput{}, fmt.Errorf("failed to fetch column types: %w", err)
+	}
+	typeInfo := buildColumnTypes(colTypes)
+
+	results := newResultRows(maxRows)
+	scanner := newRowScanner(typeInfo, live.config.MySQL.OmitBlobs)
+	limit := newByteLimit(live.config.MySQL.MaxResultBytes, live.config.MySQL.MaxCellChars)
+	truncated, bytesTruncated := false, false
+	for rows.Next() {
+		if len(results) >= maxRows {
+			truncated = true
+			break
+		}
+		values, err := scanner.scan(rows)
+		if err != nil {
+			_ = tx.Rollback()
+			return QueryOutput{}, fmt.Errorf("failed to read row: %w", err)
+		}
+		if !limit.fits(values) {
+			truncated, bytesTruncated = true, true
+			break
+		}
+		results = append(results, values)
+	}
+	if err := rows.Err(); err != nil {
+		_ = tx.Rollback()
+		return QueryOutput{}, &queryRunError{prefix: "row iteration failed", err: err}
+	}
+	queryTime := time.Since(queryStart)
+
+	var warnings []QueryWarning
+	if opts.warnings {
+		// Close the result set so its warnings can be read before COMMIT
+		// clears them.
+		_ = rows.Close()
+		warnings = readWarnings(ctx, tx)
+	}
+	if err := tx.Commit(); err != nil {
+		return QueryOutput{}, fmt.Errorf("failed to finish transaction: %w", err)
+	}
+
+	output := QueryOutput{
+		Columns:        columns,
+		Rows:           results,
+		RowCount:       len(results),
+		Truncated:      truncated,
+		BytesTruncated: bytesTruncated,
+		ColumnTypes:    typeInfo,
+		Warnings:       warnings,
+	}
+	if opts.afterCommit != nil {
+		opts.afterCommit(ctx, conn, queryTime, &output)
+	}
+	return live.finishOutput(query, output, opts.catalog), nil
+}
+
+// finishOutput strips the denied columns of query, as written, from output,
+// scans it for PII unless it's a catalog query, and then cuts long values
+// at mysql.max_cell_chars, so a cut never splits a value before masking.
+func (s handlerSettings) finishOutput(query string, output QueryOutput, catalog bool) QueryOutput {
+	output = stripDeniedColumns(output, s.validator.StrippedColumns(query))
+	if !catalog && s.config.PII.scans(query) {
+		output.PII = scanPII(s.config.PII, output.Columns, output.Rows, s.validator.PseudonymizedColumns(query))
+	}
+	output.TruncatedCells = cutLongCells(output.Columns, output.ColumnTypes, output.Rows, s.config.MySQL.MaxCellChars, output.PII)
+	return output
+}
+
+// maxRows returns mysql.max_rows, lowered by the caller's role.
+func (h *queryHandler) maxRows(ctx context.Context) int {
+	maxRows := h.snapshot().config.MySQL.MaxRows
+	if maxRows <= 0 {
+		maxRows = 1000
+	}
+	return h.access.maxRows(ctx, maxRows)
+}
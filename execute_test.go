@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+// execConn is fakeConn with what executeReadOnly needs around the query: a
+// connection ID and read-only transactions.
+type execConn struct{ fakeConn }
+
+func (c execConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	switch {
+	case query == "SELECT CONNECTION_ID()":
+		return &idRows{}, nil
+	case strings.HasPrefix(query, "SHOW WARNINGS"):
+		return nil, errors.New("no warnings here")
+	case query == "SHOW FULL PROCESSLIST":
+		return &processRows{}, nil
+	case strings.Contains(query, "AS BINARY"):
+		return &contactRows{binary: true}, nil
+	case strings.Contains(query, "contacts"):
+		return &contactRows{}, nil
+	case strings.Contains(query, "missing"):
+		return nil, errors.New("Error 1146 (42S02): Table 'shop.missing' doesn't exist")
+	}
+	return c.fakeConn.QueryContext(ctx, query, args)
+}
+
+func (c execConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type idRows struct{ done bool }
+
+func (r *idRows) Columns() []string { return []string{"CONNECTION_ID()"} }
+func (r *idRows) Close() error      { return nil }
+func (r *idRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(7)
+	return nil
+}
+
+type execConnector struct{ rows int }
+
+func (c execConnector) Connect(context.Context) (driver.Conn, error) {
+	return execConn{fakeConn{rows: c.rows}}, nil
+}
+func (c execConnector) Driver() driver.Driver { return nil }
+
+func TestExecuteReadOnly(t *testing.T) {
+	cfg := Config{
+		DeniedColumns: []gate.DeniedColumns{{Table: "users", Columns: []string{"data"}, Action: "strip"}},
+		PII:           PIIConfig{Mode: piiModeDetect},
+	}
+	cfg.MySQL.MaxRows = 2
+	v, err := newValidator(cfg, "shop")
+	require.NoError(t, err)
+	h := &queryHandler{
+		db:          sql.OpenDB(execConnector{rows: 5}),
+		config:      cfg,
+		validator:   v,
+		access:      newAccessControl(AccessConfig{}),
+		connections: newConnectionSet(),
+	}
+
+	var elapsed time.Duration
+	output, err := h.executeReadOnly(context.Background(), "SELECT * FROM users", readOptions{
+		warnings: true,
+		afterCommit: func(ctx context.Context, conn *sql.Conn, queryTime time.Duration, output *QueryOutput) {
+			elapsed = queryTime
+			require.NotNil(t, conn)
+			output.Hints = []string{"seen before stripping: " + strings.Join(output.Columns, ",")}
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "name", "created"}, output.Columns)
+	require.Equal(t, 2, output.RowCount)
+	require.True(t, output.Truncated)
+	require.Equal(t, []interface{}{int64(1), "name-000001", "2024-01-15T10:30:00Z"}, output.Rows[1])
+	require.Equal(t, []string{"seen before stripping: id,name,data,created"}, output.Hints)
+	require.Positive(t, elapsed)
+
+	// Catalog queries aren't scanned for PII, and explicit limits win.
+	output, err = h.executeReadOnly(context.Background(), "SELECT * FROM users", readOptions{maxRows: 10, catalog: true})
+	require.NoError(t, err)
+	require.Equal(t, 5, output.RowCount)
+	require.False(t, output.Truncated)
+
+	// The fake rows take 73 and 46 bytes of JSON in turn, so 150 bytes
+	// hold two of them.
+	h.config.MySQL.MaxResultBytes = 150
+	output, err = h.executeReadOnly(context.Background(), "SELECT id FROM users", readOptions{maxRows: 10})
+	require.NoError(t, err)
+	require.Equal(t, 2, output.RowCount)
+	require.True(t, output.Truncated)
+	require.True(t, output.BytesTruncated)
+	h.config.MySQL.MaxResultBytes = 0
+
+	h.config.MySQL.MaxCellChars = 6
+	output, err = h.executeReadOnly(context.Background(), "SELECT * FROM users", readOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "name-0…", output.Rows[1][1])
+	require.Equal(t, []CellTruncation{{Row: 0, Columns: []string{"name"}}, {Row: 1, Columns: []string{"name"}}}, output.TruncatedCells)
+	h.config.MySQL.MaxCellChars = 0
+
+	_, err = h.executeReadOnly(context.Background(), "SELECT * FROM missing", readOptions{})
+	var runErr *queryRunError
+	require.ErrorAs(t, err, &runErr)
+	require.Equal(t, "query failed", runErr.prefix)
+}
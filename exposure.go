@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// exposure limits the tools and resources the server registers to
+// server.enabled_tools and server.enabled_resources. An empty list enables
+// everything of its kind.
+type exposure struct {
+	tools     map[string]bool
+	resources map[string]bool
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newExposure(tools, resources []string) *exposure {
+	e := &exposure{seen: make(map[string]bool)}
+	if len(tools) > 0 {
+		e.tools = make(map[string]bool)
+		for _, name := range tools {
+			e.tools[name] = true
+		}
+	}
+	if len(resources) > 0 {
+		e.resources = make(map[string]bool)
+		for _, name := range resources {
+			e.resources[name] = true
+		}
+	}
+	return e
+}
+
+func (e *exposure) toolEnabled(name string) bool {
+	if e == nil {
+		return true
+	}
+	return e.enabled(e.tools, "tool:"+name, name)
+}
+
+// resourceEnabled takes a resource or resource template name, such as
+// mysql_schema.
+func (e *exposure) resourceEnabled(name string) bool {
+	if e == nil {
+		return true
+	}
+	return e.enabled(e.resources, "resource:"+name, name)
+}
+
+func (e *exposure) enabled(allowed map[string]bool, key, name string) bool {
+	e.mu.Lock()
+	e.seen[key] = true
+	e.mu.Unlock()
+	return allowed == nil || allowed[name]
+}
+
+// checkNames reports enabled names that match no tool or resource the
+// server offers, which are most likely typos.
+func (e *exposure) checkNames() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var unknown []string
+	for name := range e.tools {
+		if !e.seen["tool:"+name] {
+			unknown = append(unknown, "server.enabled_tools: "+name)
+		}
+	}
+	for name := range e.resources {
+		if !e.seen["resource:"+name] {
+			unknown = append(unknown, "server.enabled_resources: "+name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	slices.Sort(unknown)
+	return fmt.Errorf("no such tool or resource: %s", strings.Join(unknown, ", "))
+}
+
+// addResource registers a resource unless server.enabled_resources leaves it
+// out.
+func addResource(server *mcp.Server, h *queryHandler, resource *mcp.Resource, handler mcp.ResourceHandler) {
+	if h.exposure.resourceEnabled(resource.Name) {
+		server.AddResource(resource, handler)
+	}
+}
+
+// addResourceTemplate registers a resource template unless
+// server.enabled_resources leaves it out.
+func addResourceTemplate(server *mcp.Server, h *queryHandler, template *mcp.ResourceTemplate, handler mcp.ResourceHandler) {
+	if h.exposure.resourceEnabled(template.Name) {
+		server.AddResourceTemplate(template, handler)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestExposure(t *testing.T) {
+	var all *exposure
+	require.True(t, all.toolEnabled("mysql_query"))
+	require.True(t, all.resourceEnabled("mysql_schema"))
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "v0"}, nil)
+	h := &queryHandler{exposure: newExposure(nil, []string{"mysql_schema", "mysql_tables"})}
+	registerTool(server, h, &mcp.Tool{Name: "mysql_query"}, h.runQuery)
+	addResource(server, h, &mcp.Resource{Name: "mysql_databases", URI: "mysql://databases"}, h.readResource)
+	addResourceTemplate(server, h, &mcp.ResourceTemplate{Name: "mysql_schema", URITemplate: "mysql://schema/{db}/{table}"}, h.readResource)
+	require.Equal(t, []string{"mysql_query"}, h.tools, "an empty list enables every tool")
+	require.False(t, h.exposure.resourceEnabled("mysql_databases"))
+	require.ErrorContains(t, h.exposure.checkNames(), "server.enabled_resources: mysql_tables")
+
+	h = &queryHandler{exposure: newExposure([]string{"orders_today"}, nil)}
+	registerTool(server, h, &mcp.Tool{Name: "mysql_query"}, h.runQuery)
+	queries, err := compileSavedQueries([]SavedQueryConfig{
+		{Name: "orders_today", SQL: "SELECT * FROM orders"},
+		{Name: "stale_report", SQL: "SELECT 1"},
+	}, testValidator(t, gate.Options{}))
+	require.NoError(t, err)
+	registerSavedQueries(server, h, queries)
+	require.Equal(t, []string{"orders_today"}, h.tools)
+	require.Equal(t, []string{"orders_today"}, h.savedTools)
+	require.NoError(t, h.exposure.checkNames())
+}
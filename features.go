@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// FeaturesConfig lets callers switch optional mysql_query behaviors per call
+// through its experimental argument, so a behavior can be tried by some
+// agent deployments before its server-wide default changes. Only the flags
+// listed in Overridable can be switched; by default none can.
+type FeaturesConfig struct {
+	Overridable []string `toml:"overridable"`
+}
+
+// featureFlag is an optional behavior whose server-wide default comes from
+// its existing config setting.
+type featureFlag struct {
+	name        string
+	description string
+	enabled     func(Config) bool
+}
+
+var featureFlags = []featureFlag{
+	{
+		name:        "empty_result_hints",
+		description: "Probe the table and each condition when a query returns no rows.",
+		enabled:     func(cfg Config) bool { return cfg.MySQL.EmptyResultHints },
+	},
+	{
+		name:        "execution_stats",
+		description: "Report rows examined and index use from performance_schema.",
+		enabled:     func(cfg Config) bool { return cfg.MySQL.ExecutionStats },
+	},
+}
+
+// FeatureFlag is a flag's state, for mysql://server_info.
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled" jsonschema:"The server-wide default."`
+	Overridable bool   `json:"overridable" jsonschema:"Whether mysql_query's experimental argument may switch it."`
+}
+
+func validateFeatures(cfg FeaturesConfig) error {
+	for _, name := range cfg.Overridable {
+		if !slices.ContainsFunc(featureFlags, func(f featureFlag) bool { return f.name == name }) {
+			return fmt.Errorf("features.overridable: unknown flag %q (known: %s)", name, strings.Join(featureFlagNames(), ", "))
+		}
+	}
+	return nil
+}
+
+func featureFlagNames() []string {
+	names := make([]string, len(featureFlags))
+	for i, f := range featureFlags {
+		names[i] = f.name
+	}
+	return names
+}
+
+// resolveFeatures returns the flags in effect for one call: the config
+// defaults with overrides applied. Overriding an unknown flag, or one the
+// config doesn't make overridable, is an error rather than silently ignored.
+func resolveFeatures(cfg Config, overrides map[string]bool) (map[string]bool, error) {
+	flags := make(map[string]bool, len(featureFlags))
+	for _, f := range featureFlags {
+		flags[f.name] = f.enabled(cfg)
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := flags[name]; !ok {
+			return nil, fmt.Errorf("unknown flag %q (known: %s)", name, strings.Join(featureFlagNames(), ", "))
+		}
+		if !slices.Contains(cfg.Features.Overridable, name) {
+			return nil, fmt.Errorf("flag %q can't be overridden on this server", name)
+		}
+		flags[name] = overrides[name]
+	}
+	return flags, nil
+}
+
+func featureFlagStatus(cfg Config) []FeatureFlag {
+	status := make([]FeatureFlag, len(featureFlags))
+	for i, f := range featureFlags {
+		status[i] = FeatureFlag{
+			Name:        f.name,
+			Description: f.description,
+			Enabled:     f.enabled(cfg),
+			Overridable: slices.Contains(cfg.Features.Overridable, f.name),
+		}
+	}
+	return status
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFeatures(t *testing.T) {
+	var cfg Config
+	cfg.MySQL.EmptyResultHints = true
+	cfg.Features.Overridable = []string{"execution_stats"}
+
+	flags, err := resolveFeatures(cfg, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"empty_result_hints": true, "execution_stats": false}, flags)
+
+	flags, err = resolveFeatures(cfg, map[string]bool{"execution_stats": true})
+	require.NoError(t, err)
+	require.True(t, flags["execution_stats"])
+
+	_, err = resolveFeatures(cfg, map[string]bool{"empty_result_hints": false})
+	require.ErrorContains(t, err, "can't be overridden")
+	_, err = resolveFeatures(cfg, map[string]bool{"auto_limit": true})
+	require.ErrorContains(t, err, "unknown flag")
+}
+
+func TestValidateFeatures(t *testing.T) {
+	require.NoError(t, validateFeatures(FeaturesConfig{Overridable: []string{"empty_result_hints", "execution_stats"}}))
+	require.ErrorContains(t, validateFeatures(FeaturesConfig{Overridable: []string{"columnar_output"}}), "unknown flag")
+}
+
+func TestFeatureFlagStatus(t *testing.T) {
+	var cfg Config
+	cfg.MySQL.ExecutionStats = true
+	cfg.Features.Overridable = []string{"empty_result_hints"}
+	status := featureFlagStatus(cfg)
+	require.Len(t, status, 2)
+	require.Equal(t, "empty_result_hints", status[0].Name)
+	require.False(t, status[0].Enabled)
+	require.True(t, status[0].Overridable)
+	require.True(t, status[1].Enabled)
+	require.False(t, status[1].Overridable)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// normalizeStatement renders stmt with every literal and placeholder replaced
+// by "?" and value lists collapsed to "(?+)", so queries differing only in
+// their values share one normalized form.
+func normalizeStatement(stmt sqlparser.Statement) string {
+	buf := sqlparser.NewTrackedBuffer(func(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode) {
+		switch n := node.(type) {
+		case *sqlparser.Literal, *sqlparser.Argument, sqlparser.ListArg, sqlparser.BoolVal:
+			buf.WriteString("?")
+		case sqlparser.ValTuple:
+			for _, expr := range n {
+				if !isValueExpr(expr) {
+					n.Format(buf)
+					return
+				}
+			}
+			buf.WriteString("(?+)")
+		default:
+			node.Format(buf)
+		}
+	})
+	buf.Myprintf("%v", stmt)
+	return buf.String()
+}
+
+func isValueExpr(expr sqlparser.Expr) bool {
+	switch expr.(type) {
+	case *sqlparser.Literal, *sqlparser.Argument, sqlparser.BoolVal, *sqlparser.NullVal:
+		return true
+	default:
+		return false
+	}
+}
+
+// queryFingerprint returns a short stable identifier for the normalized form
+// of query. Unparseable queries fall back to their whitespace-collapsed,
+// lowercased text.
+func queryFingerprint(query string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if stmt, err := gate.ParseStatement(query); err == nil {
+		normalized = normalizeStatement(stmt)
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
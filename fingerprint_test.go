@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestNormalizeStatement(t *testing.T) {
+	stmt, err := gate.ParseStatement("SELECT name FROM users WHERE id IN (1, 2, 3) AND email = 'a@example.com' AND active = true LIMIT 10")
+	require.NoError(t, err)
+	require.Equal(t, "select `name` from users where id in (?+) and email = ? and active = ? limit ?", normalizeStatement(stmt))
+}
+
+func TestQueryFingerprint(t *testing.T) {
+	a := queryFingerprint("SELECT * FROM users WHERE id = 1")
+	b := queryFingerprint("select *   from users where id = 42;")
+	c := queryFingerprint("SELECT * FROM users WHERE id IN (1, 2)")
+	d := queryFingerprint("SELECT * FROM users WHERE id IN (7, 8, 9, 10)")
+	e := queryFingerprint("SELECT * FROM orders WHERE id = 1")
+
+	require.Len(t, a, 16)
+	require.Equal(t, a, b)
+	require.Equal(t, c, d)
+	require.NotEqual(t, a, e)
+	require.Equal(t, queryFingerprint("not   valid sql"), queryFingerprint("NOT valid SQL"))
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+type FormatSQLInput struct {
+	Query       string `json:"query" jsonschema:"SQL statement to format. It is parsed, never executed."`
+	Fingerprint bool   `json:"fingerprint,omitempty" jsonschema:"Also return the normalized form (literals replaced by ?) and its fingerprint."`
+}
+
+type FormatSQLOutput struct {
+	Formatted   string `json:"formatted"`
+	Normalized  string `json:"normalized,omitempty" jsonschema:"The statement with every literal replaced by ?, shared by queries differing only in their values."`
+	Fingerprint string `json:"fingerprint,omitempty" jsonschema:"Identifier of the normalized form, as used in workload and audit records."`
+}
+
+// sqlKeywords renders node on one line with keywords in upper case.
+func sqlKeywords(node sqlparser.SQLNode) string {
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.SetUpperCase(true)
+	node.Format(buf)
+	return strings.TrimSpace(buf.String())
+}
+
+// formatSQL pretty-prints stmt: one clause per line, one select expression
+// per line, joins and AND conditions on their own indented lines, and CTEs
+// and UNION branches laid out the same way. Statements it doesn't lay out,
+// and any layout that wouldn't parse back to the same statement, are
+// printed on one line.
+func formatSQL(stmt sqlparser.Statement) string {
+	oneLine := sqlKeywords(stmt)
+	formatted, ok := layoutStatement(stmt)
+	if !ok {
+		return oneLine
+	}
+	reparsed, err := gate.ParseStatement(formatted)
+	if err != nil || sqlparser.String(reparsed) != sqlparser.String(stmt) {
+		return oneLine
+	}
+	return formatted
+}
+
+func layoutStatement(stmt sqlparser.Statement) (string, bool) {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select:
+		return layoutSelect(stmt)
+	case *sqlparser.Union:
+		return layoutUnion(stmt)
+	}
+	return "", false
+}
+
+func layoutWith(with *sqlparser.With) (string, bool) {
+	if with == nil || len(with.CTEs) == 0 {
+		return "", true
+	}
+	var b strings.Builder
+	b.WriteString("WITH ")
+	if with.Recursive {
+		b.WriteString("RECURSIVE ")
+	}
+	for i, cte := range with.CTEs {
+		body, ok := layoutStatement(cte.Subquery)
+		if !ok {
+			return "", false
+		}
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		b.WriteString(sqlKeywords(cte.ID))
+		if len(cte.Columns) > 0 {
+			b.WriteString(sqlKeywords(cte.Columns))
+		}
+		b.WriteString(" AS (\n" + indentSQL(body) + "\n)")
+	}
+	b.WriteString("\n")
+	return b.String(), true
+}
+
+func layoutUnion(union *sqlparser.Union) (string, bool) {
+	if union.Lock != sqlparser.NoLock || union.Into != nil {
+		return "", false
+	}
+	with, ok := layoutWith(union.With)
+	if !ok {
+		return "", false
+	}
+	left, ok := layoutStatement(union.Left)
+	if !ok {
+		return "", false
+	}
+	right, ok := layoutStatement(union.Right)
+	if !ok {
+		return "", false
+	}
+	operator := "UNION ALL"
+	if union.Distinct {
+		operator = "UNION"
+	}
+	lines := []string{with + left, operator, right}
+	if len(union.OrderBy) > 0 {
+		lines = append(lines, sqlKeywords(union.OrderBy))
+	}
+	if union.Limit != nil {
+		lines = append(lines, sqlKeywords(union.Limit))
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func layoutSelect(sel *sqlparser.Select) (string, bool) {
+	if sel.HighPriority || sel.StraightJoinHint || sel.SQLSmallResult || sel.SQLBigResult || sel.SQLBufferResult ||
+		sel.SQLCalcFoundRows || sel.Comments.Length() > 0 || sel.Into != nil || len(sel.Windows) > 0 || sel.Lock != sqlparser.NoLock {
+		return "", false
+	}
+	with, ok := layoutWith(sel.With)
+	if !ok {
+		return "", false
+	}
+	keyword := "SELECT"
+	if sel.Distinct {
+		keyword = "SELECT DISTINCT"
+	}
+	var lines []string
+	columns := sel.GetColumns()
+	if len(columns) == 1 {
+		lines = append(lines, keyword+" "+sqlKeywords(columns[0]))
+	} else {
+		lines = append(lines, keyword)
+		for i, column := range columns {
+			line := "  " + sqlKeywords(column)
+			if i < len(columns)-1 {
+				line += ","
+			}
+			lines = append(lines, line)
+		}
+	}
+	from := sel.From
+	if len(from) == 1 && sqlparser.String(from[0]) == "dual" {
+		// The parser adds FROM dual to a SELECT without FROM.
+		from = nil
+	}
+	for i, expr := range from {
+		tables := tableLines(expr)
+		if i == 0 {
+			tables[0] = "FROM " + tables[0]
+		} else {
+			lines[len(lines)-1] += ","
+			tables[0] = "  " + tables[0]
+		}
+		lines = append(lines, tables...)
+	}
+	if sel.Where != nil {
+		for i, condition := range sqlparser.SplitAndExpression(nil, sel.Where.Expr) {
+			text := sqlKeywords(condition)
+			switch condition.(type) {
+			case *sqlparser.OrExpr, *sqlparser.XorExpr:
+				text = "(" + text + ")"
+			}
+			if i == 0 {
+				lines = append(lines, "WHERE "+text)
+			} else {
+				lines = append(lines, "  AND "+text)
+			}
+		}
+	}
+	if sel.GroupBy != nil && len(sel.GroupBy.Exprs) > 0 {
+		lines = append(lines, sqlKeywords(sel.GroupBy))
+	}
+	if sel.Having != nil {
+		lines = append(lines, sqlKeywords(sel.Having))
+	}
+	if len(sel.OrderBy) > 0 {
+		lines = append(lines, sqlKeywords(sel.OrderBy))
+	}
+	if sel.Limit != nil {
+		lines = append(lines, sqlKeywords(sel.Limit))
+	}
+	return with + strings.Join(lines, "\n"), true
+}
+
+// tableLines lays out a FROM item: the first table, then each join on its
+// own indented line.
+func tableLines(expr sqlparser.TableExpr) []string {
+	join, ok := expr.(*sqlparser.JoinTableExpr)
+	if !ok {
+		return []string{sqlKeywords(expr)}
+	}
+	line := "  " + strings.ToUpper(join.Join.ToString()) + " " + sqlKeywords(join.RightExpr)
+	// A join without ON or USING has an empty condition.
+	if condition := sqlKeywords(join.Condition); condition != "" {
+		line += " " + condition
+	}
+	return append(tableLines(join.LeftExpr), line)
+}
+
+func indentSQL(text string) string {
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
+
+func (h *queryHandler) formatSQL(ctx context.Context, req *mcp.CallToolRequest, input FormatSQLInput) (*mcp.CallToolResult, FormatSQLOutput, error) {
+	empty := FormatSQLOutput{}
+	stmt, err := gate.ParseStatement(input.Query)
+	if err != nil {
+		return toolErrorf(empty, "failed to parse query: %v", err)
+	}
+	output := FormatSQLOutput{Formatted: formatSQL(stmt)}
+	if input.Fingerprint {
+		output.Normalized = normalizeStatement(stmt)
+		output.Fingerprint = queryFingerprint(input.Query)
+	}
+	return nil, output, nil
+}
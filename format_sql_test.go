@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestFormatSQL(t *testing.T) {
+	format := func(query string) string {
+		t.Helper()
+		stmt, err := gate.ParseStatement(query)
+		require.NoError(t, err)
+		return formatSQL(stmt)
+	}
+
+	require.Equal(t, `SELECT
+  o.id,
+  c.full_name AS customer
+FROM orders AS o
+  JOIN customers AS c ON o.customer_id = c.id
+  LEFT JOIN refunds AS r ON r.order_id = o.id
+WHERE o.order_state = 'open'
+  AND (o.total > 100 OR c.vip = 1)
+ORDER BY o.id DESC
+LIMIT 10`, format("select o.id, c.full_name as customer from orders o join customers c on o.customer_id = c.id left join refunds r on r.order_id = o.id where o.order_state = 'open' and (o.total > 100 or c.vip = 1) order by o.id desc limit 10"))
+
+	require.Equal(t, `WITH RECURSIVE n AS (
+  SELECT 1 AS i
+  UNION ALL
+  SELECT i + 1
+  FROM n
+  WHERE i < 5
+)
+SELECT *
+FROM n`, format("with recursive n as (select 1 as i union all select i + 1 from n where i < 5) select * from n"))
+
+	require.Equal(t, `SELECT DISTINCT region
+FROM orders,
+  customers
+GROUP BY region
+HAVING count(*) > 1`, format("select distinct region from orders, customers group by region having count(*) > 1"))
+
+	// Statements without a layout stay on one line.
+	require.Equal(t, "SHOW TABLES", format("show tables"))
+	require.Equal(t, "SELECT * FROM orders FOR UPDATE", format("select * from orders for update"))
+}
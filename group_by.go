@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// Server errors for ONLY_FULL_GROUP_BY violations.
+const (
+	erWrongFieldWithGroup = 1055
+	erMixOfGroupFunc      = 1140
+	erFieldNotInGroupBy   = 3029
+)
+
+// GroupByIssue is a column that ONLY_FULL_GROUP_BY rejects: referenced
+// outside an aggregate without being grouped on.
+type GroupByIssue struct {
+	Clause string `json:"clause" jsonschema:"SELECT, HAVING, or ORDER BY."`
+	Column string `json:"column" jsonschema:"The offending column reference."`
+}
+
+func isGroupByError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case erWrongFieldWithGroup, erMixOfGroupFunc, erFieldNotInGroupBy:
+		return true
+	}
+	return false
+}
+
+// groupByIssues finds non-aggregated columns that aren't grouped on in each
+// SELECT of stmt. It ignores functional dependencies (a column of a table
+// whose primary key is grouped on is allowed by MySQL), so it's only used
+// to explain an error the server has already returned.
+func groupByIssues(stmt sqlparser.Statement) []GroupByIssue {
+	var issues []GroupByIssue
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if sel, ok := node.(*sqlparser.Select); ok {
+			issues = append(issues, selectGroupByIssues(sel)...)
+		}
+		return true, nil
+	}, stmt)
+	return issues
+}
+
+func selectGroupByIssues(sel *sqlparser.Select) []GroupByIssue {
+	columns := sel.GetColumns()
+	grouped := sel.GroupBy != nil && len(sel.GroupBy.Exprs) > 0
+	if !grouped {
+		aggregated := false
+		for _, col := range columns {
+			if sqlparser.ContainsAggregation(col) {
+				aggregated = true
+			}
+		}
+		if !aggregated {
+			return nil
+		}
+	}
+
+	// GROUP BY items can name a select expression by position or alias.
+	groupExprs := make(map[string]bool)
+	groupedSelect := make(map[int]bool)
+	aliases := make(map[string]int)
+	for i, col := range columns {
+		if aliased, ok := col.(*sqlparser.AliasedExpr); ok && !aliased.As.IsEmpty() {
+			aliases[aliased.As.Lowered()] = i
+		}
+	}
+	if grouped {
+		for _, expr := range sel.GroupBy.Exprs {
+			if lit, ok := expr.(*sqlparser.Literal); ok && lit.Type == sqlparser.IntVal {
+				if n, err := strconv.Atoi(lit.Val); err == nil {
+					groupedSelect[n-1] = true
+				}
+				continue
+			}
+			if col, ok := expr.(*sqlparser.ColName); ok && col.Qualifier.IsEmpty() {
+				if i, ok := aliases[col.Name.Lowered()]; ok {
+					groupedSelect[i] = true
+					continue
+				}
+			}
+			groupExprs[strings.ToLower(sqlparser.String(expr))] = true
+		}
+	}
+	// A qualified column matches an unqualified GROUP BY item with the same
+	// name and vice versa.
+	isGrouped := func(col *sqlparser.ColName) bool {
+		name := col.Name.Lowered()
+		if groupExprs[strings.ToLower(sqlparser.String(col))] || groupExprs[name] {
+			return true
+		}
+		if col.Qualifier.IsEmpty() {
+			for expr := range groupExprs {
+				if strings.HasSuffix(expr, "."+name) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var issues []GroupByIssue
+	seen := make(map[string]bool)
+	check := func(clause string, expr sqlparser.SQLNode) {
+		if e, ok := expr.(sqlparser.Expr); ok && groupExprs[strings.ToLower(sqlparser.String(e))] {
+			return
+		}
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			switch n := node.(type) {
+			case sqlparser.AggrFunc, *sqlparser.Subquery:
+				return false, nil
+			case *sqlparser.ColName:
+				key := clause + " " + sqlparser.String(n)
+				if !isGrouped(n) && !seen[key] {
+					seen[key] = true
+					issues = append(issues, GroupByIssue{Clause: clause, Column: sqlparser.String(n)})
+				}
+			}
+			return true, nil
+		}, expr)
+	}
+
+	for i, col := range columns {
+		switch c := col.(type) {
+		case *sqlparser.StarExpr:
+			if grouped {
+				issues = append(issues, GroupByIssue{Clause: "SELECT", Column: sqlparser.String(c)})
+			}
+		case *sqlparser.AliasedExpr:
+			if !groupedSelect[i] {
+				check("SELECT", c.Expr)
+			}
+		}
+	}
+	if sel.Having != nil {
+		checkAliased(sel.Having.Expr, aliases, func(expr sqlparser.Expr) { check("HAVING", expr) })
+	}
+	for _, order := range sel.OrderBy {
+		checkAliased(order.Expr, aliases, func(expr sqlparser.Expr) { check("ORDER BY", expr) })
+	}
+	return issues
+}
+
+// checkAliased calls check on expr unless it is a bare reference to a
+// select alias, which is already covered by the SELECT list check.
+func checkAliased(expr sqlparser.Expr, aliases map[string]int, check func(sqlparser.Expr)) {
+	if col, ok := expr.(*sqlparser.ColName); ok && col.Qualifier.IsEmpty() {
+		if _, ok := aliases[col.Name.Lowered()]; ok {
+			return
+		}
+	}
+	if lit, ok := expr.(*sqlparser.Literal); ok && lit.Type == sqlparser.IntVal {
+		return
+	}
+	check(expr)
+}
+
+// groupByAdvice explains a 1055/1140 error from the query's AST, or returns
+// "" if the query can't be analyzed.
+func groupByAdvice(query string) (string, []GroupByIssue) {
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return "", nil
+	}
+	issues := groupByIssues(stmt)
+	if len(issues) == 0 {
+		return "", nil
+	}
+	parts := make([]string, len(issues))
+	for i, issue := range issues {
+		parts[i] = fmt.Sprintf("%s in %s", issue.Column, issue.Clause)
+	}
+	return fmt.Sprintf("ONLY_FULL_GROUP_BY: %s %s neither aggregated nor in GROUP BY; add to GROUP BY, wrap in an aggregate such as MAX() or ANY_VALUE(), or group by the table's primary key",
+		strings.Join(parts, ", "), pluralVerb(len(issues))), issues
+}
+
+func pluralVerb(n int) string {
+	if n == 1 {
+		return "is"
+	}
+	return "are"
+}
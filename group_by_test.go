@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestGroupByIssues(t *testing.T) {
+	cases := []struct {
+		query string
+		want  []GroupByIssue
+	}{
+		{"SELECT a, COUNT(*) FROM t GROUP BY a", nil},
+		{"SELECT t.a, COUNT(*) FROM t GROUP BY a", nil},
+		{"SELECT a AS x, COUNT(*) FROM t GROUP BY x ORDER BY x", nil},
+		{"SELECT a, b, COUNT(*) FROM t GROUP BY 1, 2", nil},
+		{"SELECT a, MAX(b) FROM t GROUP BY a HAVING MAX(b) > 1", nil},
+		{"SELECT COUNT(*) FROM t", nil},
+		{"SELECT a, b FROM t", nil},
+		{"SELECT a + 1, COUNT(*) FROM t GROUP BY a + 1", nil},
+		{"SELECT a, b, COUNT(*) FROM t GROUP BY a", []GroupByIssue{{Clause: "SELECT", Column: "b"}}},
+		{"SELECT a, COUNT(*) FROM t", []GroupByIssue{{Clause: "SELECT", Column: "a"}}},
+		{"SELECT * FROM t GROUP BY a", []GroupByIssue{{Clause: "SELECT", Column: "*"}}},
+		{"SELECT a, COUNT(*) FROM t GROUP BY a HAVING b > 1 ORDER BY c", []GroupByIssue{
+			{Clause: "HAVING", Column: "b"},
+			{Clause: "ORDER BY", Column: "c"},
+		}},
+		{"SELECT x FROM (SELECT a, b AS x, SUM(c) FROM t GROUP BY a) s", []GroupByIssue{{Clause: "SELECT", Column: "b"}}},
+	}
+	for _, tc := range cases {
+		stmt, err := gate.ParseStatement(tc.query)
+		require.NoError(t, err, tc.query)
+		require.Equal(t, tc.want, groupByIssues(stmt), tc.query)
+	}
+}
+
+func TestGroupByAdvice(t *testing.T) {
+	advice, issues := groupByAdvice("SELECT a, b, COUNT(*) FROM t GROUP BY a")
+	require.Len(t, issues, 1)
+	require.Contains(t, advice, "b in SELECT is neither aggregated nor in GROUP BY")
+	require.Contains(t, advice, "ANY_VALUE()")
+
+	advice, issues = groupByAdvice("SELECT a, COUNT(*) FROM t GROUP BY a")
+	require.Empty(t, advice)
+	require.Empty(t, issues)
+}
+
+func TestIsGroupByError(t *testing.T) {
+	require.True(t, isGroupByError(&mysql.MySQLError{Number: 1055}))
+	require.True(t, isGroupByError(&mysql.MySQLError{Number: 1140}))
+	require.False(t, isGroupByError(&mysql.MySQLError{Number: 1054}))
+}
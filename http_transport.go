@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HTTPConfig serves MCP over streamable HTTP instead of stdio. Every request
+// must carry "Authorization: Bearer <token>", where the token is either a key
+// whose SHA-256 hash is listed in APIKeys or APIKeyFile, or an access token
+// OAuth accepts. The token's principal then identifies the caller to access
+// roles and audit events.
+type HTTPConfig struct {
+	// Addr, such as "127.0.0.1:8080", enables the HTTP transport.
+	Addr string `toml:"addr"`
+	// Path is where the MCP endpoint is served; "/mcp" by default.
+	Path        string         `toml:"path"`
+	TLSCertFile string         `toml:"tls_cert_file"`
+	TLSKeyFile  string         `toml:"tls_key_file"`
+	APIKeys     []APIKeyConfig `toml:"api_keys"`
+	// APIKeyFile holds more keys, one "principal sha256" pair per line.
+	APIKeyFile string      `toml:"api_key_file"`
+	OAuth      OAuthConfig `toml:"oauth"`
+}
+
+// APIKeyConfig is one accepted API key, stored as the hex SHA-256 of the key
+// so the config doesn't hold the key itself.
+type APIKeyConfig struct {
+	Principal string `toml:"principal"`
+	SHA256    string `toml:"sha256"`
+}
+
+func validateHTTPConfig(cfg HTTPConfig) error {
+	if cfg.Addr == "" {
+		return nil
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return fmt.Errorf("http.tls_cert_file and http.tls_key_file must be set together")
+	}
+	if cfg.Path != "" && !strings.HasPrefix(cfg.Path, "/") {
+		return fmt.Errorf("http.path must start with /")
+	}
+	if err := validateOAuthConfig(cfg.OAuth); err != nil {
+		return err
+	}
+	keys, err := loadAPIKeys(cfg)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 && !cfg.OAuth.enabled() {
+		return fmt.Errorf("http.addr needs http.api_keys, http.api_key_file, or http.oauth; the HTTP transport doesn't serve unauthenticated clients")
+	}
+	return nil
+}
+
+// loadAPIKeys returns the principal for each accepted key hash.
+func loadAPIKeys(cfg HTTPConfig) (map[string]string, error) {
+	keys := make(map[string]string)
+	add := func(principal, hash, where string) error {
+		hash = strings.ToLower(hash)
+		if principal == "" {
+			return fmt.Errorf("%s: principal is required", where)
+		}
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("%s: sha256 must be 64 hex digits", where)
+		}
+		if other, dup := keys[hash]; dup {
+			return fmt.Errorf("%s: key is already listed for %q", where, other)
+		}
+		keys[hash] = principal
+		return nil
+	}
+	for i, key := range cfg.APIKeys {
+		if err := add(key.Principal, key.SHA256, fmt.Sprintf("http.api_keys[%d]", i)); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.APIKeyFile == "" {
+		return keys, nil
+	}
+	f, err := os.Open(cfg.APIKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("http.api_key_file: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("http.api_key_file line %d: want \"principal sha256\"", n)
+		}
+		if err := add(fields[0], fields[1], fmt.Sprintf("http.api_key_file line %d", n)); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("http.api_key_file: %w", err)
+	}
+	return keys, nil
+}
+
+// bearerVerifier accepts API keys whose hash is in keys and, with jwts set,
+// JWT access tokens. An API key lives as long as the request (the SDK
+// requires an expiration) and holds scopes, the OAuth scopes the endpoint
+// requires, since a configured key grants full access.
+func bearerVerifier(keys map[string]string, jwts *jwtVerifier, scopes []string) auth.TokenVerifier {
+	return func(ctx context.Context, token string, _ *http.Request) (*auth.TokenInfo, error) {
+		if principal, ok := keys[sha256Hex(token)]; ok {
+			return &auth.TokenInfo{UserID: principal, Scopes: scopes, Expiration: time.Now().Add(time.Minute)}, nil
+		}
+		if jwts != nil && strings.Count(token, ".") == 2 {
+			return jwts.verify(ctx, token)
+		}
+		return nil, fmt.Errorf("%w: unknown API key", auth.ErrInvalidToken)
+	}
+}
+
+// principalMiddleware records the principal an HTTP authenticator verified
+// on each request's context, for access roles.
+func principalMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if principal := requestPrincipal(req.GetExtra()); principal != "" {
+			ctx = withPrincipal(ctx, principal)
+		}
+		return next(ctx, method, req)
+	}
+}
+
+// requestPrincipal returns the principal of an authenticated request, or "".
+func requestPrincipal(extra *mcp.RequestExtra) string {
+	if extra == nil || extra.TokenInfo == nil {
+		return ""
+	}
+	return extra.TokenInfo.UserID
+}
+
+// serve runs the server on stdio, or on HTTP when http.addr is set, until
+// the client disconnects or, for HTTP, the process is interrupted. servers
+// holds one server by tenant name, or the only server under "".
+func serve(servers map[string]*mcp.Server, config Config) error {
+	cfg := config.HTTP
+	if cfg.Addr == "" {
+		return servers[""].Run(context.Background(), &mcp.StdioTransport{})
+	}
+	keys, err := loadAPIKeys(cfg)
+	if err != nil {
+		return err
+	}
+	for _, server := range servers {
+		server.AddReceivingMiddleware(principalMiddleware)
+	}
+	route := func(*http.Request) *mcp.Server { return servers[""] }
+	if config.Tenancy.enabled() {
+		route = tenantRouter(config.Tenancy, servers)
+	}
+	handler := mcp.NewStreamableHTTPHandler(route, nil)
+	path := cfg.Path
+	if path == "" {
+		path = "/mcp"
+	}
+	mux := http.NewServeMux()
+	var jwts *jwtVerifier
+	var opts *auth.RequireBearerTokenOptions
+	if cfg.OAuth.enabled() {
+		jwts = newJWTVerifier(cfg.OAuth)
+		opts = &auth.RequireBearerTokenOptions{
+			ResourceMetadataURL: protectedResourceMetadataURL(jwts.cfg.Resource),
+			Scopes:              jwts.cfg.Scopes,
+		}
+		mux.Handle(protectedResourceMetadataPath(jwts.cfg.Resource), auth.ProtectedResourceMetadataHandler(protectedResourceMetadata(jwts.cfg)))
+	}
+	var scopes []string
+	if opts != nil {
+		scopes = opts.Scopes
+	}
+	mux.Handle(path, auth.RequireBearerToken(bearerVerifier(keys, jwts, scopes), opts)(handler))
+	httpServer := &http.Server{Addr: cfg.Addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+	log.Printf("serving MCP on %s%s", cfg.Addr, path)
+	if cfg.TLSCertFile != "" {
+		err = httpServer.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
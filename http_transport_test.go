@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAPIKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(file, []byte("# analysts\nbob "+sha256Hex("bob-key")+"\n\n"), 0o600))
+	keys, err := loadAPIKeys(HTTPConfig{
+		APIKeys:    []APIKeyConfig{{Principal: "alice", SHA256: sha256Hex("alice-key")}},
+		APIKeyFile: file,
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{sha256Hex("alice-key"): "alice", sha256Hex("bob-key"): "bob"}, keys)
+
+	for _, cfg := range []HTTPConfig{
+		{Addr: ":8080"},
+		{Addr: ":8080", APIKeys: []APIKeyConfig{{Principal: "alice", SHA256: "abc"}}},
+		{Addr: ":8080", APIKeys: []APIKeyConfig{{SHA256: sha256Hex("k")}}},
+		{Addr: ":8080", APIKeys: []APIKeyConfig{{Principal: "a", SHA256: sha256Hex("k")}, {Principal: "b", SHA256: sha256Hex("k")}}},
+		{Addr: ":8080", APIKeyFile: filepath.Join(t.TempDir(), "missing")},
+		{Addr: ":8080", APIKeys: []APIKeyConfig{{Principal: "a", SHA256: sha256Hex("k")}}, TLSCertFile: "cert.pem"},
+	} {
+		require.Error(t, validateHTTPConfig(cfg), cfg)
+	}
+	require.NoError(t, validateHTTPConfig(HTTPConfig{}))
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	keys := map[string]string{sha256Hex("alice-key"): "alice"}
+	var principal string
+	handler := auth.RequireBearerToken(bearerVerifier(keys, nil, nil), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = auth.TokenInfoFromContext(r.Context()).UserID
+	}))
+
+	for token, status := range map[string]int{"": http.StatusUnauthorized, "wrong": http.StatusUnauthorized, "alice-key": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, status, rec.Code, token)
+	}
+	require.Equal(t, "alice", principal)
+
+	access := newAccessControl(AccessConfig{Principal: "stdio", Roles: []RoleConfig{{Name: "analyst", Principals: []string{"alice"}}}})
+	name, role := access.role(withPrincipal(context.Background(), principal))
+	require.Equal(t, "alice", name)
+	require.Equal(t, "analyst", role.Name)
+	require.Equal(t, "stdio", access.principalFor(nil))
+}
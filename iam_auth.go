@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	iamProviderRDS      = "rds"
+	iamProviderCloudSQL = "cloudsql"
+
+	// rdsTokenLifetime is how long RDS accepts a generated auth token.
+	rdsTokenLifetime = 15 * time.Minute
+	// iamRefreshMargin is how long before expiry a cached token is replaced.
+	iamRefreshMargin = 2 * time.Minute
+)
+
+// MySQLIAMConfig replaces the password with a short-lived IAM token fetched
+// before each new connection. RDS tokens are signed locally with the AWS
+// credentials from the environment (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN); Cloud SQL tokens come from the GCE metadata server.
+type MySQLIAMConfig struct {
+	Provider string `toml:"provider"`
+	Region   string `toml:"region"`
+}
+
+type awsCredentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+func awsCredentialsFromEnv() (awsCredentials, error) {
+	creds := awsCredentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.accessKeyID == "" || creds.secretAccessKey == "" {
+		return creds, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set for RDS IAM authentication")
+	}
+	return creds, nil
+}
+
+// iamToken fetches a token and reports when it stops being valid.
+type iamToken func(ctx context.Context) (string, time.Time, error)
+
+// cachedIAMToken reuses a token until it is within iamRefreshMargin of
+// expiry, so a burst of new connections doesn't hit the token source.
+type cachedIAMToken struct {
+	fetch iamToken
+	now   func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (c *cachedIAMToken) get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && c.now().Before(c.expires.Add(-iamRefreshMargin)) {
+		return c.token, nil
+	}
+	token, expires, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token, c.expires = token, expires
+	return token, nil
+}
+
+// applyIAM sets up token authentication on dsnConfig. IAM tokens are sent
+// with the cleartext plugin, so TLS is required.
+func applyIAM(dsnConfig *mysql.Config, c MySQLIAMConfig) error {
+	if c.Provider == "" {
+		return nil
+	}
+	if dsnConfig.Passwd != "" {
+		return fmt.Errorf("mysql.iam can't be combined with a password")
+	}
+	if dsnConfig.TLS == nil && (dsnConfig.TLSConfig == "" || dsnConfig.TLSConfig == "false") {
+		return fmt.Errorf("mysql.iam requires TLS; configure [mysql.tls] or tls= in the DSN")
+	}
+
+	var fetch iamToken
+	switch c.Provider {
+	case iamProviderRDS:
+		if dsnConfig.Net != "tcp" {
+			return fmt.Errorf("mysql.iam provider %q needs a tcp address", c.Provider)
+		}
+		region := c.Region
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		if region == "" {
+			return fmt.Errorf("mysql.iam.region (or AWS_REGION) is required for RDS")
+		}
+		if _, err := awsCredentialsFromEnv(); err != nil {
+			return err
+		}
+		addr, user := dsnConfig.Addr, dsnConfig.User
+		fetch = func(ctx context.Context) (string, time.Time, error) {
+			creds, err := awsCredentialsFromEnv()
+			if err != nil {
+				return "", time.Time{}, err
+			}
+			now := time.Now().UTC()
+			return rdsAuthToken(addr, region, user, creds, now), now.Add(rdsTokenLifetime), nil
+		}
+	case iamProviderCloudSQL:
+		client := &http.Client{Timeout: 10 * time.Second}
+		fetch = func(ctx context.Context) (string, time.Time, error) {
+			return metadataAccessToken(ctx, client, metadataTokenURL())
+		}
+	default:
+		return fmt.Errorf("mysql.iam.provider must be %q or %q", iamProviderRDS, iamProviderCloudSQL)
+	}
+
+	dsnConfig.AllowCleartextPasswords = true
+	tokens := &cachedIAMToken{fetch: fetch, now: time.Now}
+	return dsnConfig.Apply(mysql.BeforeConnect(func(ctx context.Context, cfg *mysql.Config) error {
+		token, err := tokens.get(ctx)
+		if err != nil {
+			return fmt.Errorf("mysql iam token: %w", err)
+		}
+		cfg.Passwd = token
+		return nil
+	}))
+}
+
+// rdsAuthToken builds an RDS IAM auth token: a SigV4-presigned
+// "connect" request for user, without the scheme.
+func rdsAuthToken(addr, region, user string, creds awsCredentials, now time.Time) string {
+	query := url.Values{"Action": {"connect"}, "DBUser": {user}}
+	signed := presignV4("GET", addr, "/", query, sha256Hex(""), "rds-db", region, creds, now, rdsTokenLifetime)
+	return addr + "/?" + signed
+}
+
+// presignV4 returns the query string of a SigV4 presigned request, including
+// X-Amz-Signature. Only the host header is signed.
+func presignV4(method, host, path string, query url.Values, payloadHash, service, region string, creds awsCredentials, now time.Time, expires time.Duration) string {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + region + "/" + service + "/aws4_request"
+
+	params := url.Values{}
+	for key, values := range query {
+		params[key] = values
+	}
+	params.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	params.Set("X-Amz-Credential", creds.accessKeyID+"/"+scope)
+	params.Set("X-Amz-Date", amzDate)
+	params.Set("X-Amz-Expires", fmt.Sprint(int(expires/time.Second)))
+	params.Set("X-Amz-SignedHeaders", "host")
+	if creds.sessionToken != "" {
+		params.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+	canonicalQuery := canonicalQueryString(params)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		canonicalQuery,
+		"host:" + host + "\n",
+		"host",
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := []byte("AWS4" + creds.secretAccessKey)
+	for _, part := range []string{amzDate[:8], region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	return canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+// canonicalQueryString encodes params the way SigV4 expects: RFC 3986
+// escaping (%20, not +) and sorted by key.
+func canonicalQueryString(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		for _, value := range params[key] {
+			parts = append(parts, sigV4Escape(key)+"="+sigV4Escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func sigV4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// metadataTokenURL is the GCE metadata server's access token endpoint;
+// GCE_METADATA_HOST overrides the host, as in Google's client libraries.
+func metadataTokenURL() string {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "169.254.169.254"
+	}
+	return "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token"
+}
+
+func metadataAccessToken(ctx context.Context, client *http.Client, tokenURL string) (string, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("metadata server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("metadata server: %s", resp.Status)
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", time.Time{}, fmt.Errorf("metadata server: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("metadata server returned no access token")
+	}
+	return body.AccessToken, time.Now().Add(time.Duration(body.ExpiresIn) * time.Second), nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+type IndexUsageInput struct {
+	Database      string `json:"database" jsonschema:"Database containing the table."`
+	Table         string `json:"table" jsonschema:"Table to analyze."`
+	WindowSeconds int    `json:"windowSeconds,omitempty" jsonschema:"Only consider queries run within this many seconds (default 3600)."`
+}
+
+type IndexUse struct {
+	Index      string `json:"index"`
+	Queries    int    `json:"queries" jsonschema:"Distinct query shapes whose plan uses the index."`
+	Executions int    `json:"executions" jsonschema:"Total executions of those queries."`
+}
+
+type IndexUsageOutput struct {
+	Database        string          `json:"database"`
+	Table           string          `json:"table"`
+	WindowSeconds   int             `json:"windowSeconds"`
+	QueriesAnalyzed int             `json:"queriesAnalyzed" jsonschema:"Distinct query shapes in the window that read the table."`
+	UsedIndexes     []IndexUse      `json:"usedIndexes"`
+	UnusedIndexes   []string        `json:"unusedIndexes" jsonschema:"Indexes no analyzed plan uses."`
+	FullScans       []WorkloadQuery `json:"fullScans" jsonschema:"Queries whose plan scans the whole table."`
+	Failed          []string        `json:"failed" jsonschema:"Fingerprints whose EXPLAIN failed."`
+}
+
+// explainedQuery is a workload query with its EXPLAIN output and the names
+// the analyzed table goes by in it.
+type explainedQuery struct {
+	query   WorkloadQuery
+	aliases map[string]bool
+	plan    QueryOutput
+}
+
+func newIndexUsageOutput(input IndexUsageInput, window int) IndexUsageOutput {
+	return IndexUsageOutput{
+		Database:      input.Database,
+		Table:         input.Table,
+		WindowSeconds: window,
+		UsedIndexes:   []IndexUse{},
+		UnusedIndexes: []string{},
+		FullScans:     []WorkloadQuery{},
+		Failed:        []string{},
+	}
+}
+
+func (h *queryHandler) indexUsage(ctx context.Context, req *mcp.CallToolRequest, input IndexUsageInput) (*mcp.CallToolResult, IndexUsageOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	window := input.WindowSeconds
+	if window <= 0 {
+		window = 3600
+	}
+	output := newIndexUsageOutput(input, window)
+	if !mysqlIdentifierRE.MatchString(input.Database) || !mysqlIdentifierRE.MatchString(input.Table) {
+		return toolErrorf(output, "database and table must be plain identifiers")
+	}
+
+	indexes, err := h.runMetadataQuery(ctx, fmt.Sprintf("SHOW INDEX FROM `%s`.`%s`", input.Database, input.Table))
+	if err != nil {
+		return toolErrorf(output, "failed to read indexes: %v", err)
+	}
+
+	explained := make([]explainedQuery, 0)
+	for _, query := range h.workload.since(time.Now().Add(-time.Duration(window) * time.Second)) {
+		stmt, err := gate.ParseStatement(query.Query)
+		if err != nil {
+			continue
+		}
+		aliases := tableAliases(stmt, input.Database, input.Table, h.defaultSchema)
+		if len(aliases) == 0 {
+			continue
+		}
+		plan, err := h.runQueryForResource(ctx, "EXPLAIN "+query.Query)
+		if err != nil {
+			output.Failed = append(output.Failed, query.Fingerprint)
+			continue
+		}
+		explained = append(explained, explainedQuery{query: query, aliases: aliases, plan: plan})
+	}
+
+	summarizeIndexUsage(&output, buildIndexInfo(indexes), explained)
+	return nil, output, nil
+}
+
+// tableAliases returns the names (aliases, or the bare table name) under
+// which stmt reads schema.table. Unqualified references match when schema is
+// the connection's default database. Only SELECT and UNION are considered.
+func tableAliases(stmt sqlparser.Statement, schema, table, defaultSchema string) map[string]bool {
+	switch stmt.(type) {
+	case *sqlparser.Select, *sqlparser.Union:
+	default:
+		return nil
+	}
+	aliases := make(map[string]bool)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		aliased, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok {
+			return true, nil
+		}
+		name, ok := aliased.Expr.(sqlparser.TableName)
+		if !ok || !strings.EqualFold(name.Name.String(), table) {
+			return true, nil
+		}
+		qualifier := name.Qualifier.String()
+		if qualifier == "" {
+			qualifier = defaultSchema
+		}
+		if !strings.EqualFold(qualifier, schema) {
+			return true, nil
+		}
+		alias := name.Name.String()
+		if !aliased.As.IsEmpty() {
+			alias = aliased.As.String()
+		}
+		aliases[strings.ToLower(alias)] = true
+		return true, nil
+	}, stmt)
+	return aliases
+}
+
+// summarizeIndexUsage fills output from the EXPLAIN plans of the workload:
+// the key each plan row for the table chose, and rows with access type ALL
+// (full table scans).
+func summarizeIndexUsage(output *IndexUsageOutput, indexes []IndexInfo, explained []explainedQuery) {
+	used := make(map[string]*IndexUse)
+	for _, eq := range explained {
+		output.QueriesAnalyzed++
+		tableCol := columnIndex(eq.plan.Columns, "table")
+		typeCol := columnIndex(eq.plan.Columns, "type")
+		keyCol := columnIndex(eq.plan.Columns, "key")
+
+		keys := make(map[string]bool)
+		fullScan := false
+		for _, row := range eq.plan.Rows {
+			if !eq.aliases[strings.ToLower(valueString(rowValue(row, tableCol)))] {
+				continue
+			}
+			if key := valueString(rowValue(row, keyCol)); key != "" {
+				keys[key] = true
+			}
+			if strings.EqualFold(valueString(rowValue(row, typeCol)), "ALL") {
+				fullScan = true
+			}
+		}
+		for key := range keys {
+			if used[key] == nil {
+				used[key] = &IndexUse{Index: key}
+			}
+			used[key].Queries++
+			used[key].Executions += eq.query.Executions
+		}
+		if fullScan {
+			output.FullScans = append(output.FullScans, eq.query)
+		}
+	}
+
+	for _, use := range used {
+		output.UsedIndexes = append(output.UsedIndexes, *use)
+	}
+	sort.Slice(output.UsedIndexes, func(i, j int) bool {
+		if output.UsedIndexes[i].Executions != output.UsedIndexes[j].Executions {
+			return output.UsedIndexes[i].Executions > output.UsedIndexes[j].Executions
+		}
+		return output.UsedIndexes[i].Index < output.UsedIndexes[j].Index
+	})
+	for _, index := range indexes {
+		if used[index.KeyName] == nil {
+			output.UnusedIndexes = append(output.UnusedIndexes, index.KeyName)
+		}
+	}
+}
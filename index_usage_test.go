@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestTableAliases(t *testing.T) {
+	stmt, err := gate.ParseStatement("SELECT * FROM orders o JOIN shop.orders o2 ON o.parent_id = o2.id WHERE o.id IN (SELECT order_id FROM other.orders)")
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"o": true, "o2": true}, tableAliases(stmt, "shop", "orders", "shop"))
+	require.Equal(t, map[string]bool{"o2": true}, tableAliases(stmt, "shop", "orders", "crm"))
+
+	stmt, err = gate.ParseStatement("SELECT id FROM Orders UNION SELECT id FROM items")
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"orders": true}, tableAliases(stmt, "shop", "orders", "shop"))
+
+	stmt, err = gate.ParseStatement("SHOW TABLES")
+	require.NoError(t, err)
+	require.Empty(t, tableAliases(stmt, "shop", "orders", "shop"))
+}
+
+func TestSummarizeIndexUsage(t *testing.T) {
+	planColumns := []string{"id", "select_type", "table", "type", "possible_keys", "key", "rows"}
+	explained := []explainedQuery{
+		{
+			query:   WorkloadQuery{Fingerprint: "a", Query: "SELECT * FROM orders o JOIN customers c ON c.id = o.customer_id WHERE o.id = 1", Executions: 5},
+			aliases: map[string]bool{"o": true},
+			plan: QueryOutput{Columns: planColumns, Rows: [][]interface{}{
+				{int64(1), "SIMPLE", "o", "const", "PRIMARY", "PRIMARY", int64(1)},
+				{int64(1), "SIMPLE", "c", "const", "PRIMARY", "PRIMARY", int64(1)},
+			}},
+		},
+		{
+			query:   WorkloadQuery{Fingerprint: "b", Query: "SELECT * FROM orders WHERE note LIKE '%x%'", Executions: 2},
+			aliases: map[string]bool{"orders": true},
+			plan: QueryOutput{Columns: planColumns, Rows: [][]interface{}{
+				{int64(1), "SIMPLE", "orders", "ALL", nil, nil, int64(1000)},
+			}},
+		},
+		{
+			query:   WorkloadQuery{Fingerprint: "c", Query: "SELECT * FROM orders WHERE customer_id = 3", Executions: 1},
+			aliases: map[string]bool{"orders": true},
+			plan: QueryOutput{Columns: planColumns, Rows: [][]interface{}{
+				{int64(1), "SIMPLE", "orders", "ref", "idx_customer", "idx_customer", int64(4)},
+			}},
+		},
+	}
+	indexes := []IndexInfo{{KeyName: "PRIMARY"}, {KeyName: "idx_customer"}, {KeyName: "idx_status"}}
+
+	output := newIndexUsageOutput(IndexUsageInput{Database: "shop", Table: "orders"}, 3600)
+	summarizeIndexUsage(&output, indexes, explained)
+
+	require.Equal(t, 3, output.QueriesAnalyzed)
+	require.Equal(t, []IndexUse{
+		{Index: "PRIMARY", Queries: 1, Executions: 5},
+		{Index: "idx_customer", Queries: 1, Executions: 1},
+	}, output.UsedIndexes)
+	require.Equal(t, []string{"idx_status"}, output.UnusedIndexes)
+	require.Len(t, output.FullScans, 1)
+	require.Equal(t, "b", output.FullScans[0].Fingerprint)
+}
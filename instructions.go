@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits on the schema summary in the server instructions, which clients
+// usually put in the model's context.
+const (
+	instructionDatabases      = 10
+	instructionTablesPerDB    = 10
+	instructionCatalogTimeout = 5 * time.Second
+)
+
+// catalogSummary is a database's tables, largest first.
+type catalogSummary struct {
+	database string
+	tables   []string
+	total    int
+}
+
+const instructionTablesQuery = `SELECT TABLE_SCHEMA, TABLE_NAME FROM information_schema.TABLES
+WHERE TABLE_SCHEMA NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')
+ORDER BY TABLE_SCHEMA, TABLE_ROWS DESC, TABLE_NAME`
+
+// loadCatalogSummary reads the databases and their tables for the server
+// instructions, keeping the largest tables of the first databases.
+func loadCatalogSummary(ctx context.Context, db *sql.DB) ([]catalogSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, instructionCatalogTimeout)
+	defer cancel()
+	rows, err := db.QueryContext(ctx, instructionTablesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var summaries []catalogSummary
+	for rows.Next() {
+		var schema, table string
+		if err := rows.Scan(&schema, &table); err != nil {
+			return nil, err
+		}
+		if len(summaries) == 0 || summaries[len(summaries)-1].database != schema {
+			summaries = append(summaries, catalogSummary{database: schema})
+		}
+		last := &summaries[len(summaries)-1]
+		last.total++
+		if len(last.tables) < instructionTablesPerDB {
+			last.tables = append(last.tables, table)
+		}
+	}
+	return summaries, rows.Err()
+}
+
+// serverInstructions tells clients at initialization what the server is for,
+// its rules, and what data it can reach, so they needn't list resources
+// first. summaries is nil when the catalog couldn't be read.
+func serverInstructions(cfg Config, summaries []catalogSummary) string {
+	var b strings.Builder
+	if cfg.Server.Instructions != "" {
+		b.WriteString(strings.TrimSpace(cfg.Server.Instructions))
+		b.WriteString("\n\n")
+	}
+	maxRows := cfg.MySQL.MaxRows
+	if maxRows <= 0 {
+		maxRows = 1000
+	}
+	timeout := cfg.MySQL.QueryTimeoutSeconds
+	if timeout <= 0 {
+		timeout = 30
+	}
+	fmt.Fprintf(&b, "Read-only access to MySQL: only %s statements run, results are capped at %d rows, and queries time out after %ds.",
+		strings.ToUpper(strings.Join(cfg.MySQL.AllowStatementPrefixes, "/")), maxRows, timeout)
+	if len(summaries) == 0 {
+		b.WriteString(" Read mysql://databases and mysql://tables/{db} to see what data is available.")
+		return b.String()
+	}
+	b.WriteString("\n\nDatabases and their largest tables:")
+	for i, s := range summaries {
+		if i == instructionDatabases {
+			fmt.Fprintf(&b, "\n- and %d more databases (see mysql://databases)", len(summaries)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n- %s: %s", s.database, strings.Join(s.tables, ", "))
+		if more := s.total - len(s.tables); more > 0 {
+			fmt.Fprintf(&b, ", and %d more (see mysql://tables/%s)", more, s.database)
+		}
+	}
+	b.WriteString("\n\nRead mysql://schema/{db}/{table} for a table's columns before querying it.")
+	return b.String()
+}
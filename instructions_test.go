@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerInstructions(t *testing.T) {
+	var cfg Config
+	cfg.MySQL.AllowStatementPrefixes = []string{"select", "show"}
+	cfg.MySQL.MaxRows = 500
+
+	text := serverInstructions(cfg, nil)
+	require.Equal(t, "Read-only access to MySQL: only SELECT/SHOW statements run, results are capped at 500 rows, and queries time out after 30s. Read mysql://databases and mysql://tables/{db} to see what data is available.", text)
+
+	cfg.Server.Instructions = "Analytics replica for the shop team.\n"
+	text = serverInstructions(cfg, []catalogSummary{
+		{database: "shop", tables: []string{"orders", "customers"}, total: 2},
+		{database: "crm", tables: []string{"contacts"}, total: 12},
+	})
+	require.Equal(t, `Analytics replica for the shop team.
+
+Read-only access to MySQL: only SELECT/SHOW statements run, results are capped at 500 rows, and queries time out after 30s.
+
+Databases and their largest tables:
+- shop: orders, customers
+- crm: contacts, and 11 more (see mysql://tables/crm)
+
+Read mysql://schema/{db}/{table} for a table's columns before querying it.`, text)
+
+	many := make([]catalogSummary, instructionDatabases+2)
+	for i := range many {
+		many[i] = catalogSummary{database: "db", tables: []string{"t"}, total: 1}
+	}
+	require.Contains(t, serverInstructions(cfg, many), "- and 2 more databases (see mysql://databases)")
+}
@@ -0,0 +1,80 @@
+package gate
+
+import (
+	"maps"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// CTEReferences returns the table references in stmt that read a common
+// table expression rather than a base table, following MySQL's scoping: a
+// WITH clause's names are visible in its own statement, including nested
+// subqueries, and in later CTEs of the same clause; with RECURSIVE, in every
+// CTE of the clause. A non-recursive CTE that names itself reads the base
+// table of that name. Only unqualified names can be CTEs, and they match
+// case-sensitively, so a reference that might be either is taken to be a
+// base table and stays subject to row filters and table allowlists.
+func CTEReferences(stmt sqlparser.SQLNode) map[*sqlparser.AliasedTableExpr]bool {
+	refs := make(map[*sqlparser.AliasedTableExpr]bool)
+	collectCTEReferences(stmt, nil, refs)
+	return refs
+}
+
+func collectCTEReferences(node sqlparser.SQLNode, scope map[string]bool, refs map[*sqlparser.AliasedTableExpr]bool) {
+	if with := statementWith(node); with != nil {
+		inner := maps.Clone(scope)
+		if inner == nil {
+			inner = make(map[string]bool)
+		}
+		if with.Recursive {
+			for _, cte := range with.CTEs {
+				inner[cte.ID.String()] = true
+			}
+		}
+		for _, cte := range with.CTEs {
+			collectCTEReferences(cte.Subquery, inner, refs)
+			inner[cte.ID.String()] = true
+		}
+		scope = inner
+	}
+	_ = sqlparser.Walk(func(n sqlparser.SQLNode) (bool, error) {
+		switch n := n.(type) {
+		case *sqlparser.With:
+			// Its CTEs were collected above, each with its own scope.
+			return false, nil
+		case *sqlparser.AliasedTableExpr:
+			if name, ok := n.Expr.(sqlparser.TableName); ok && name.Qualifier.IsEmpty() && scope[name.Name.String()] {
+				refs[n] = true
+			}
+		}
+		if n != node && statementWith(n) != nil {
+			collectCTEReferences(n, scope, refs)
+			return false, nil
+		}
+		return true, nil
+	}, node)
+}
+
+// statementWith returns the WITH clause of a statement or subquery, if any.
+func statementWith(node sqlparser.SQLNode) *sqlparser.With {
+	switch n := node.(type) {
+	case *sqlparser.Select:
+		return n.With
+	case *sqlparser.Union:
+		return n.With
+	case *sqlparser.ValuesStatement:
+		return n.With
+	case *sqlparser.Update:
+		return n.With
+	case *sqlparser.Delete:
+		return n.With
+	}
+	return nil
+}
+
+// IsDualTable reports whether name is the DUAL pseudo-table the parser
+// supplies for a SELECT without FROM.
+func IsDualTable(name sqlparser.TableName) bool {
+	return name.Qualifier.IsEmpty() && strings.EqualFold(name.Name.String(), "dual")
+}
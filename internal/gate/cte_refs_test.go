@@ -0,0 +1,34 @@
+package gate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTablesThroughCTEs(t *testing.T) {
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"SELECT 1", nil},
+		{"WITH a AS (SELECT id FROM orders) SELECT * FROM a JOIN crm.contacts c ON c.id = a.id", []string{"shop.orders", "crm.contacts"}},
+		{"WITH a AS (SELECT 1 AS x), b AS (SELECT x FROM a) SELECT * FROM b", nil},
+		{"WITH b AS (SELECT x FROM a), a AS (SELECT 1 AS x) SELECT * FROM b", []string{"shop.a"}},
+		{"WITH RECURSIVE n AS (SELECT 1 AS i UNION ALL SELECT i + 1 FROM n WHERE i < 5) SELECT * FROM n", nil},
+		{"WITH orders AS (SELECT * FROM orders) SELECT * FROM orders", []string{"shop.orders"}},
+		{"WITH a AS (SELECT 1 AS x) SELECT * FROM A", []string{"shop.A"}},
+		{"SELECT * FROM (WITH a AS (SELECT 1 AS x) SELECT x FROM a) AS d JOIN a ON a.x = d.x", []string{"shop.a"}},
+		{"WITH a AS (SELECT 1 AS x) SELECT * FROM shop.a", []string{"shop.a"}},
+		{"VALUES ROW((SELECT COUNT(*) FROM orders))", []string{"shop.orders"}},
+	}
+	for _, tc := range cases {
+		stmt, err := ParseStatement(tc.query)
+		require.NoError(t, err, tc.query)
+		var got []string
+		for _, table := range QueryTables(stmt, "shop") {
+			got = append(got, table.String())
+		}
+		require.Equal(t, tc.want, got, tc.query)
+	}
+}
@@ -0,0 +1,281 @@
+package gate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+const (
+	deniedColumnsReject = "reject"
+	deniedColumnsStrip  = "strip"
+)
+
+// DeniedColumns hides columns of Table ("db.table", or "table" in the
+// DSN's default database). A query that names one of Columns anywhere, in
+// the select list, WHERE, ORDER BY, or a subquery, is rejected. Action
+// decides what happens to SELECT *: "reject" (the default) rejects it, and
+// "strip" runs it and removes the columns from the result.
+type DeniedColumns struct {
+	Table   string   `toml:"table"`
+	Columns []string `toml:"columns"`
+	Action  string   `toml:"action"`
+}
+
+type deniedTable struct {
+	columns map[string]bool
+	strip   bool
+}
+
+// columnDenylist holds the denied columns by lower-cased "db.table".
+type columnDenylist struct {
+	tables map[string]deniedTable
+}
+
+func newColumnDenylist(configs []DeniedColumns, defaultSchema string) (*columnDenylist, error) {
+	d := &columnDenylist{tables: make(map[string]deniedTable)}
+	for _, cfg := range configs {
+		schema, table, ok := strings.Cut(cfg.Table, ".")
+		if !ok {
+			schema, table = defaultSchema, cfg.Table
+		}
+		if schema == "" {
+			return nil, fmt.Errorf("denied columns for %q: no database given and the DSN has no default database", cfg.Table)
+		}
+		if !identifierRE.MatchString(schema) || !identifierRE.MatchString(table) {
+			return nil, fmt.Errorf("denied columns for %q: table must be db.table or table", cfg.Table)
+		}
+		if len(cfg.Columns) == 0 {
+			return nil, fmt.Errorf("denied columns for %q: columns is required", cfg.Table)
+		}
+		switch cfg.Action {
+		case "", deniedColumnsReject, deniedColumnsStrip:
+		default:
+			return nil, fmt.Errorf("denied columns for %q: action must be %q or %q", cfg.Table, deniedColumnsReject, deniedColumnsStrip)
+		}
+		key := strings.ToLower(schema + "." + table)
+		if _, dup := d.tables[key]; dup {
+			return nil, fmt.Errorf("denied columns for %q: duplicate table", cfg.Table)
+		}
+		entry := deniedTable{columns: make(map[string]bool), strip: cfg.Action == deniedColumnsStrip}
+		for _, column := range cfg.Columns {
+			if !identifierRE.MatchString(column) {
+				return nil, fmt.Errorf("denied columns for %q: invalid column %q", cfg.Table, column)
+			}
+			entry.columns[strings.ToLower(column)] = true
+		}
+		d.tables[key] = entry
+	}
+	return d, nil
+}
+
+// check returns a *QueryRejection if stmt reads a denied column, and
+// otherwise the lower-cased names of denied columns that SELECT * brings into
+// the result, to be stripped from it.
+//
+// Column references are resolved as columnResolver does. SELECT * over a
+// "strip" table is only allowed in the statement's own select list, where
+// result columns keep their table names; elsewhere a derived column list or
+// UNION could rename them.
+func (d *columnDenylist) check(stmt sqlparser.Statement, defaultSchema string) (map[string]bool, error) {
+	if d == nil || len(d.tables) == 0 {
+		return nil, nil
+	}
+	refs := newColumnResolver(stmt, defaultSchema, func(table string) map[string]bool { return d.tables[table].columns })
+	if len(refs.read) == 0 {
+		return nil, nil
+	}
+
+	var rejection error
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		col, ok := node.(*sqlparser.ColName)
+		if !ok {
+			return true, nil
+		}
+		if table := refs.resolve(col); table != "" {
+			rejection = &QueryRejection{Construct: col.Name.String(), Reason: fmt.Sprintf("column %s of %s is denied", col.Name.String(), table)}
+			return false, nil
+		}
+		return true, nil
+	}, stmt)
+	if rejection != nil {
+		return nil, rejection
+	}
+
+	outer, _ := stmt.(*sqlparser.Select)
+	if explain, ok := stmt.(*sqlparser.ExplainStmt); ok {
+		// EXPLAIN returns a plan, not rows, so its statement's stars are as
+		// safe as a top-level one.
+		outer, _ = explain.Statement.(*sqlparser.Select)
+	}
+	var strip map[string]bool
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		sel, ok := node.(*sqlparser.Select)
+		if !ok || rejection != nil {
+			return rejection == nil, nil
+		}
+		if sel.SelectExprs == nil {
+			return true, nil
+		}
+		from := FromTables(sel, defaultSchema)
+		for _, expr := range sel.SelectExprs.Exprs {
+			star, ok := expr.(*sqlparser.StarExpr)
+			if !ok {
+				continue
+			}
+			for alias, table := range from {
+				if !star.TableName.IsEmpty() && !strings.EqualFold(star.TableName.Name.String(), alias) {
+					continue
+				}
+				key := strings.ToLower(table.String())
+				entry, ok := d.tables[key]
+				if !ok {
+					continue
+				}
+				switch {
+				case !entry.strip:
+					rejection = &QueryRejection{Construct: "*", Reason: fmt.Sprintf("* would return denied columns of %s; name the columns instead", key)}
+				case sel != outer:
+					rejection = &QueryRejection{Construct: "*", Reason: fmt.Sprintf("* over %s, which has denied columns, is only allowed in the outermost SELECT", key)}
+				case hasOrdinal(sel):
+					rejection = &QueryRejection{Construct: "*", Reason: fmt.Sprintf("* over %s, which has denied columns, can't be combined with ORDER BY or GROUP BY column positions", key)}
+				default:
+					if strip == nil {
+						strip = make(map[string]bool)
+					}
+					for column := range entry.columns {
+						strip[column] = true
+					}
+				}
+			}
+		}
+		return rejection == nil, nil
+	}, stmt)
+	if rejection != nil {
+		return nil, rejection
+	}
+	return strip, nil
+}
+
+// columnResolver finds which of a set of configured tables the column
+// references of a statement may read. Resolution is conservative. A column
+// qualified by a base table's name or alias is checked against that table;
+// any other column reference matches a configured column of any configured
+// table the statement reads, since it may reach that table through a
+// derived table or CTE.
+type columnResolver struct {
+	// columns returns the lower-cased columns configured for a lower-cased
+	// "db.table", or nil if it has none.
+	columns func(table string) map[string]bool
+	// aliases maps each table name or alias in the statement to the
+	// configured tables it stands for; "" stands for a derived table or CTE.
+	aliases map[string][]string
+	// read holds the configured tables the statement reads, sorted.
+	read []string
+}
+
+func newColumnResolver(stmt sqlparser.Statement, defaultSchema string, columns func(table string) map[string]bool) *columnResolver {
+	r := &columnResolver{columns: columns, aliases: make(map[string][]string)}
+	read := make(map[string]bool)
+	ctes := CTEReferences(stmt)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		aliased, ok := node.(*sqlparser.AliasedTableExpr)
+		if !ok {
+			return true, nil
+		}
+		target := ""
+		if table, ok := aliasedTable(aliased, defaultSchema); ok && !ctes[aliased] {
+			target = strings.ToLower(table.String())
+		}
+		if columns(target) != nil {
+			read[target] = true
+		}
+		alias := aliased.As.String()
+		if name, ok := aliased.Expr.(sqlparser.TableName); ok && alias == "" {
+			alias = name.Name.String()
+		}
+		if alias != "" {
+			alias = strings.ToLower(alias)
+			r.aliases[alias] = append(r.aliases[alias], target)
+		}
+		return true, nil
+	}, stmt)
+	r.read = slices.Sorted(maps.Keys(read))
+	return r
+}
+
+// resolve returns the configured table col may be a configured column of,
+// or "" if there's none.
+func (r *columnResolver) resolve(col *sqlparser.ColName) string {
+	column := col.Name.Lowered()
+	switch {
+	case !col.Qualifier.Qualifier.IsEmpty():
+		key := strings.ToLower(col.Qualifier.Qualifier.String() + "." + col.Qualifier.Name.String())
+		if r.columns(key)[column] {
+			return key
+		}
+	case !col.Qualifier.Name.IsEmpty():
+		targets, known := r.aliases[strings.ToLower(col.Qualifier.Name.String())]
+		if !known {
+			targets = []string{""}
+		}
+		for _, target := range targets {
+			if target == "" {
+				if table := r.anyTable(column); table != "" {
+					return table
+				}
+			} else if r.columns(target)[column] {
+				return target
+			}
+		}
+	default:
+		return r.anyTable(column)
+	}
+	return ""
+}
+
+// anyTable returns the first table the statement reads with column
+// configured.
+func (r *columnResolver) anyTable(column string) string {
+	for _, table := range r.read {
+		if r.columns(table)[column] {
+			return table
+		}
+	}
+	return ""
+}
+
+// hasOrdinal reports whether sel orders or groups by column position, which
+// could sort by a stripped column.
+func hasOrdinal(sel *sqlparser.Select) bool {
+	exprs := make([]sqlparser.Expr, 0, len(sel.OrderBy))
+	for _, order := range sel.OrderBy {
+		exprs = append(exprs, order.Expr)
+	}
+	if sel.GroupBy != nil {
+		exprs = append(exprs, sel.GroupBy.Exprs...)
+	}
+	for _, expr := range exprs {
+		if lit, ok := expr.(*sqlparser.Literal); ok && lit.Type == sqlparser.IntVal {
+			return true
+		}
+	}
+	return false
+}
+
+// StrippedColumns returns the denied columns to remove from query's result,
+// or nil if there are none. query must have passed Validate.
+func (v *Validator) StrippedColumns(query string) map[string]bool {
+	if v.deniedColumns == nil {
+		return nil
+	}
+	stmt, err := v.Parse(query)
+	if err != nil {
+		return nil
+	}
+	strip, _ := v.deniedColumns.check(stmt, v.defaultSchema)
+	return strip
+}
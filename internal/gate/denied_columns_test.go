@@ -0,0 +1,19 @@
+package gate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewColumnDenylistErrors(t *testing.T) {
+	for _, cfg := range []DeniedColumns{
+		{Table: "customers", Columns: []string{"ssn"}},
+		{Table: "shop.customers"},
+		{Table: "shop.customers", Columns: []string{"ssn"}, Action: "mask"},
+		{Table: "shop.customers", Columns: []string{"bad name"}},
+	} {
+		_, err := newColumnDenylist([]DeniedColumns{cfg}, "")
+		require.Error(t, err, cfg)
+	}
+}
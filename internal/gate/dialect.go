@@ -0,0 +1,203 @@
+package gate
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// ParserConfig describes the SQL the target server accepts, so the read-only
+// gate parses queries the way the server will. The settings must match the
+// server: with ansi_quotes set for a server without ANSI_QUOTES, the gate
+// reads "x" as a column while the server reads a string.
+type ParserConfig struct {
+	// MySQLVersion, such as "8.0.36" or "5.7.44", decides which versioned
+	// comments (/*!80017 ... */) count as code. Defaults to the parser's
+	// own (8.0).
+	MySQLVersion string `toml:"mysql_version"`
+	// ANSIQuotes reads double-quoted text as identifiers (sql_mode
+	// ANSI_QUOTES).
+	ANSIQuotes bool `toml:"ansi_quotes"`
+	// NoBackslashEscapes treats backslash as an ordinary character in strings
+	// (sql_mode NO_BACKSLASH_ESCAPES).
+	NoBackslashEscapes bool `toml:"no_backslash_escapes"`
+}
+
+// Dialect parses queries under a ParserConfig. The vitess parser knows
+// neither sql_mode flag, so queries are first rewritten into the default
+// dialect: double-quoted identifiers become backquoted, and backslashes in
+// strings are doubled. Only the parsed copy is rewritten; the server still
+// runs the query as written.
+type Dialect struct {
+	parser             *sqlparser.Parser
+	ansiQuotes         bool
+	noBackslashEscapes bool
+}
+
+var activeDialect atomic.Pointer[Dialect]
+
+// NewDialect returns the dialect cfg describes.
+func NewDialect(cfg ParserConfig) (*Dialect, error) {
+	parser, err := sqlparser.New(sqlparser.Options{MySQLServerVersion: cfg.MySQLVersion})
+	if err != nil {
+		return nil, fmt.Errorf("parser.mysql_version: %w", err)
+	}
+	return &Dialect{parser: parser, ansiQuotes: cfg.ANSIQuotes, noBackslashEscapes: cfg.NoBackslashEscapes}, nil
+}
+
+// SetDialect makes cfg the dialect for every parse. It's set once at startup.
+func SetDialect(cfg ParserConfig) error {
+	d, err := NewDialect(cfg)
+	if err != nil {
+		return err
+	}
+	activeDialect.Store(d)
+	return nil
+}
+
+// CurrentDialect returns the dialect set at startup, or the default one.
+func CurrentDialect() (*Dialect, error) {
+	if d := activeDialect.Load(); d != nil {
+		return d, nil
+	}
+	d, err := NewDialect(ParserConfig{})
+	if err != nil {
+		return nil, err
+	}
+	activeDialect.CompareAndSwap(nil, d)
+	return activeDialect.Load(), nil
+}
+
+// Parse parses query as the server would.
+func (d *Dialect) Parse(query string) (sqlparser.Statement, error) {
+	return d.parser.Parse(d.normalize(query))
+}
+
+// SplitStatements splits batch at the semicolons between statements,
+// returning each statement as written.
+func (d *Dialect) SplitStatements(batch string) ([]string, error) {
+	if !d.ansiQuotes && !d.noBackslashEscapes {
+		return d.parser.SplitStatementToPieces(batch)
+	}
+	// Splitting the rewritten text would return rewritten statements, so
+	// split by hand at the semicolons the rewrite leaves outside quotes and
+	// comments.
+	var pieces []string
+	start := 0
+	d.scan(batch, func(i int, c byte) {
+		if c == ';' {
+			pieces = append(pieces, batch[start:i])
+			start = i + 1
+		}
+	}, nil)
+	return append(pieces, batch[start:]), nil
+}
+
+// normalize rewrites query into the parser's default dialect.
+func (d *Dialect) normalize(query string) string {
+	if !d.ansiQuotes && !d.noBackslashEscapes {
+		return query
+	}
+	var b strings.Builder
+	d.scan(query, func(_ int, c byte) { b.WriteByte(c) }, &b)
+	return b.String()
+}
+
+// scan walks query, calling code for each byte outside strings, quoted
+// identifiers, and comments. If out is non-nil, quoted text and comments are
+// written to it in the default dialect.
+func (d *Dialect) scan(query string, code func(i int, c byte), out *strings.Builder) {
+	write := func(s string) {
+		if out != nil {
+			out.WriteString(s)
+		}
+	}
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' && !d.ansiQuotes:
+			end := d.stringEnd(query, i)
+			write(d.rewriteString(query[i:end]))
+			i = end
+		case c == '`' || c == '"' && d.ansiQuotes:
+			end := quotedEnd(query, i)
+			write(rewriteIdentifier(query[i:end]))
+			i = end
+		case c == '#' || c == '-' && strings.HasPrefix(query[i:], "-- "):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			write(query[i : i+end])
+			i += end
+		case strings.HasPrefix(query[i:], "/*") && !strings.HasPrefix(query[i:], "/*!"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 2
+			} else {
+				end += 2
+			}
+			write(query[i : i+2+end])
+			i += 2 + end
+		default:
+			code(i, c)
+			i++
+		}
+	}
+}
+
+// stringEnd returns the index just past the string literal starting at i.
+func (d *Dialect) stringEnd(query string, i int) int {
+	quote := query[i]
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if !d.noBackslashEscapes {
+				j++
+			}
+		case quote:
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+// quotedEnd returns the index just past the quoted identifier starting at i,
+// where a doubled quote stands for itself.
+func quotedEnd(query string, i int) int {
+	quote := query[i]
+	for j := i + 1; j < len(query); j++ {
+		if query[j] == quote {
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+func (d *Dialect) rewriteString(s string) string {
+	if !d.noBackslashEscapes {
+		return s
+	}
+	return strings.ReplaceAll(s, `\`, `\\`)
+}
+
+// rewriteIdentifier backquotes a "double-quoted" identifier.
+func rewriteIdentifier(s string) string {
+	if s[0] == '`' {
+		return s
+	}
+	inner := strings.TrimPrefix(s, `"`)
+	inner = strings.TrimSuffix(inner, `"`)
+	inner = strings.ReplaceAll(inner, `""`, `"`)
+	return "`" + strings.ReplaceAll(inner, "`", "``") + "`"
+}
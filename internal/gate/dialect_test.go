@@ -0,0 +1,50 @@
+package gate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+func TestDialectNormalize(t *testing.T) {
+	d, err := NewDialect(ParserConfig{})
+	require.NoError(t, err)
+	require.Equal(t, `SELECT "a\"b" FROM t`, d.normalize(`SELECT "a\"b" FROM t`))
+
+	d, err = NewDialect(ParserConfig{ANSIQuotes: true})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT `order id`, `a\"b`, 'it''s \"x\"' FROM `t` -- \"c\"\n", d.normalize(`SELECT "order id", "a""b", 'it''s "x"' FROM `+"`t`"+` -- "c"`+"\n"))
+
+	d, err = NewDialect(ParserConfig{NoBackslashEscapes: true})
+	require.NoError(t, err)
+	require.Equal(t, `SELECT 'C:\\dir\\' AS p, "x\\" AS q`, d.normalize(`SELECT 'C:\dir\' AS p, "x\" AS q`))
+}
+
+func TestDialectParse(t *testing.T) {
+	d, err := NewDialect(ParserConfig{ANSIQuotes: true})
+	require.NoError(t, err)
+	stmt, err := d.Parse(`SELECT "total" FROM "orders"`)
+	require.NoError(t, err)
+	require.Equal(t, "select total from orders", sqlparser.String(stmt))
+
+	// Under NO_BACKSLASH_ESCAPES the string ends at the second quote, so the
+	// INTO OUTFILE that the default parser reads as string content is code.
+	query := `SELECT 'x\' INTO OUTFILE '/tmp/f' -- '`
+	d, err = NewDialect(ParserConfig{NoBackslashEscapes: true})
+	require.NoError(t, err)
+	stmt, err = d.Parse(query)
+	require.NoError(t, err)
+	require.ErrorContains(t, rejectWriteConstructs(stmt), "INTO OUTFILE")
+
+	_, err = NewDialect(ParserConfig{MySQLVersion: "eight"})
+	require.ErrorContains(t, err, "parser.mysql_version")
+}
+
+func TestDialectSplitStatements(t *testing.T) {
+	d, err := NewDialect(ParserConfig{ANSIQuotes: true, NoBackslashEscapes: true})
+	require.NoError(t, err)
+	pieces, err := d.SplitStatements(`SELECT "a;b" FROM t; SELECT 'c:\' AS p; /* ; */ SELECT 1`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`SELECT "a;b" FROM t`, ` SELECT 'c:\' AS p`, ` /* ; */ SELECT 1`}, pieces)
+}
@@ -0,0 +1,288 @@
+package gate
+
+import (
+	"fmt"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// pseudonymColumns holds pii.pseudonymize: the source columns, by
+// lower-cased "db.table", whose values are replaced with pseudonyms.
+type pseudonymColumns struct {
+	tables map[string]map[string]bool
+}
+
+func newPseudonymColumns(names []string, defaultSchema string) (*pseudonymColumns, error) {
+	p := &pseudonymColumns{tables: make(map[string]map[string]bool)}
+	for _, name := range names {
+		parts := strings.Split(name, ".")
+		if len(parts) == 2 {
+			parts = append([]string{defaultSchema}, parts...)
+		}
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("pii.pseudonymize %q: must be db.table.column or table.column", name)
+		}
+		if parts[0] == "" {
+			return nil, fmt.Errorf("pii.pseudonymize %q: no database given and the DSN has no default database", name)
+		}
+		for _, part := range parts {
+			if !identifierRE.MatchString(part) {
+				return nil, fmt.Errorf("pii.pseudonymize %q: must be db.table.column or table.column", name)
+			}
+		}
+		key := strings.ToLower(parts[0] + "." + parts[1])
+		if p.tables[key] == nil {
+			p.tables[key] = make(map[string]bool)
+		}
+		p.tables[key][strings.ToLower(parts[2])] = true
+	}
+	return p, nil
+}
+
+// check returns a *QueryRejection if a pseudonymized column of a table stmt
+// reads could reach its result other than under its own name, and otherwise
+// the lower-cased names of the result columns to pseudonymize.
+//
+// Pseudonyms are applied by result column name, so a pseudonymized column
+// may only be selected as itself, unaliased and outside any expression, or
+// by SELECT *, in the statement's own select list. Anywhere else in a select
+// list, including derived tables, CTEs, and UNIONs, whose columns can be
+// renamed, rejects the statement. Column references are resolved as
+// columnResolver does.
+//
+// Elsewhere a predicate on the column would test its hidden value, as
+// WHERE email LIKE 'a%' does, so the column may only be compared for
+// equality with another column (a join, or col IN a subquery selecting a
+// column), grouped by, or tested with IS NULL; see checkPredicates.
+func (p *pseudonymColumns) check(stmt sqlparser.Statement, defaultSchema string) (map[string]bool, error) {
+	if p == nil || len(p.tables) == 0 {
+		return nil, nil
+	}
+	top, ok := stmt.(sqlparser.TableStatement)
+	if !ok {
+		// EXPLAIN and SHOW return no column values.
+		return nil, nil
+	}
+	refs := newColumnResolver(stmt, defaultSchema, func(table string) map[string]bool { return p.tables[table] })
+	if len(refs.read) == 0 {
+		return nil, nil
+	}
+
+	// mention returns a rejection if node refers to a pseudonymized column.
+	mention := func(node sqlparser.SQLNode) error {
+		var rejection error
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			col, ok := node.(*sqlparser.ColName)
+			if !ok {
+				return true, nil
+			}
+			if table := refs.resolve(col); table != "" {
+				rejection = &QueryRejection{Construct: col.Name.String(), Reason: fmt.Sprintf("column %s of %s is pseudonymized; select it by name, without an alias or expression, in the outermost SELECT", col.Name.String(), table)}
+				return false, nil
+			}
+			return true, nil
+		}, node)
+		return rejection
+	}
+
+	names := make(map[string]bool)
+	var visit func(stmt sqlparser.TableStatement, outer bool) error
+	visitFrom := func(expr sqlparser.TableExpr) error {
+		var err error
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			switch node := node.(type) {
+			case *sqlparser.DerivedTable:
+				err = visit(node.Select, false)
+				return false, nil
+			case *sqlparser.JoinCondition, *sqlparser.IndexHints:
+				return false, nil
+			case *sqlparser.JSONTableExpr:
+				// JSON_TABLE turns its argument into result columns.
+				err = mention(node)
+				return false, nil
+			}
+			return err == nil, nil
+		}, expr)
+		return err
+	}
+	visit = func(stmt sqlparser.TableStatement, outer bool) error {
+		var with *sqlparser.With
+		switch stmt := stmt.(type) {
+		case *sqlparser.Union:
+			with = stmt.With
+			if err := visit(stmt.Left, false); err != nil {
+				return err
+			}
+			if err := visit(stmt.Right, false); err != nil {
+				return err
+			}
+		case *sqlparser.Select:
+			with = stmt.With
+			for _, expr := range stmt.From {
+				if err := visitFrom(expr); err != nil {
+					return err
+				}
+			}
+			if err := p.checkSelectExprs(stmt, outer, defaultSchema, refs, mention, names); err != nil {
+				return err
+			}
+		}
+		if with != nil {
+			for _, cte := range with.CTEs {
+				if err := visit(cte.Subquery, false); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := visit(top, true); err != nil {
+		return nil, err
+	}
+	if err := checkPredicates(top, refs); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// checkPredicates returns a *QueryRejection if a pseudonymized column is
+// used outside the outermost select list other than as an operand of an
+// equality between two columns, or of col IN (SELECT col ...), as a GROUP
+// BY column, or as the operand of IS [NOT] NULL. Comparisons with values,
+// LIKE and REGEXP, functions, and ORDER BY are all rejected: each reveals
+// something about the value the pseudonym hides.
+func checkPredicates(top sqlparser.TableStatement, refs *columnResolver) error {
+	allowed := make(map[*sqlparser.ColName]bool)
+	if sel, ok := top.(*sqlparser.Select); ok && sel.SelectExprs != nil {
+		// Checked by checkSelectExprs.
+		for _, expr := range sel.SelectExprs.Exprs {
+			if aliased, ok := expr.(*sqlparser.AliasedExpr); ok {
+				if col, ok := aliased.Expr.(*sqlparser.ColName); ok {
+					allowed[col] = true
+				}
+			}
+		}
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.ComparisonExpr:
+			left, ok := node.Left.(*sqlparser.ColName)
+			if !ok {
+				break
+			}
+			switch node.Operator {
+			case sqlparser.EqualOp, sqlparser.NullSafeEqualOp:
+				if right, ok := node.Right.(*sqlparser.ColName); ok {
+					allowed[left], allowed[right] = true, true
+				}
+			case sqlparser.InOp:
+				if right := subqueryColumn(node.Right); right != nil {
+					allowed[left], allowed[right] = true, true
+				}
+			}
+		case *sqlparser.IsExpr:
+			if col, ok := node.Left.(*sqlparser.ColName); ok && (node.Right == sqlparser.IsNullOp || node.Right == sqlparser.IsNotNullOp) {
+				allowed[col] = true
+			}
+		case *sqlparser.GroupBy:
+			for _, expr := range node.Exprs {
+				if col, ok := expr.(*sqlparser.ColName); ok {
+					allowed[col] = true
+				}
+			}
+		}
+		return true, nil
+	}, top)
+
+	var rejection error
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		col, ok := node.(*sqlparser.ColName)
+		if !ok || allowed[col] {
+			return rejection == nil, nil
+		}
+		if table := refs.resolve(col); table != "" {
+			rejection = &QueryRejection{Construct: col.Name.String(), Reason: fmt.Sprintf("column %s of %s is pseudonymized; outside the select list it may only be compared for equality with another column, grouped by, or tested with IS NULL", col.Name.String(), table)}
+		}
+		return rejection == nil, nil
+	}, top)
+	return rejection
+}
+
+// subqueryColumn returns the column a subquery selects, if expr is one
+// selecting a single column as itself.
+func subqueryColumn(expr sqlparser.Expr) *sqlparser.ColName {
+	sub, ok := expr.(*sqlparser.Subquery)
+	if !ok {
+		return nil
+	}
+	sel, ok := sub.Select.(*sqlparser.Select)
+	if !ok || sel.SelectExprs == nil || len(sel.SelectExprs.Exprs) != 1 {
+		return nil
+	}
+	aliased, ok := sel.SelectExprs.Exprs[0].(*sqlparser.AliasedExpr)
+	if !ok {
+		return nil
+	}
+	col, _ := aliased.Expr.(*sqlparser.ColName)
+	return col
+}
+
+// checkSelectExprs checks the select list of sel, adding the pseudonymized
+// columns it returns to names if it's the outermost SELECT.
+func (p *pseudonymColumns) checkSelectExprs(sel *sqlparser.Select, outer bool, defaultSchema string, refs *columnResolver, mention func(sqlparser.SQLNode) error, names map[string]bool) error {
+	if sel.SelectExprs == nil {
+		return nil
+	}
+	for _, expr := range sel.SelectExprs.Exprs {
+		switch expr := expr.(type) {
+		case *sqlparser.StarExpr:
+			for alias, table := range FromTables(sel, defaultSchema) {
+				if !expr.TableName.IsEmpty() && !strings.EqualFold(expr.TableName.Name.String(), alias) {
+					continue
+				}
+				key := strings.ToLower(table.String())
+				columns := p.tables[key]
+				if columns == nil {
+					continue
+				}
+				if !outer {
+					return &QueryRejection{Construct: "*", Reason: fmt.Sprintf("* over %s, which has pseudonymized columns, is only allowed in the outermost SELECT", key)}
+				}
+				for column := range columns {
+					names[column] = true
+				}
+			}
+		case *sqlparser.AliasedExpr:
+			if col, ok := expr.Expr.(*sqlparser.ColName); ok && outer && (expr.As.IsEmpty() || expr.As.EqualString(col.Name.String())) {
+				if refs.resolve(col) != "" {
+					names[col.Name.Lowered()] = true
+				}
+				continue
+			}
+			if err := mention(expr); err != nil {
+				return err
+			}
+		default:
+			if err := mention(expr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PseudonymizedColumns returns the lower-cased names of query's result
+// columns to pseudonymize, or nil if there are none. query must have passed
+// Validate.
+func (v *Validator) PseudonymizedColumns(query string) map[string]bool {
+	if v.pseudonyms == nil {
+		return nil
+	}
+	stmt, err := v.Parse(query)
+	if err != nil {
+		return nil
+	}
+	names, _ := v.pseudonyms.check(stmt, v.defaultSchema)
+	return names
+}
@@ -0,0 +1,16 @@
+package gate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPseudonymColumnsErrors(t *testing.T) {
+	for _, names := range [][]string{{"customer_id"}, {"a.b.c.d"}, {"orders.customer id"}} {
+		_, err := newPseudonymColumns(names, "shop")
+		require.Error(t, err, names)
+	}
+	_, err := newPseudonymColumns([]string{"orders.customer_id"}, "")
+	require.ErrorContains(t, err, "no default database")
+}
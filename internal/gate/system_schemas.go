@@ -0,0 +1,46 @@
+package gate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// SystemSchemas hold the server's accounts, grants, and instrumentation.
+// Unless mysql.allow_system_schemas is set, queries may only read their
+// tables through mysql.system_tables. information_schema isn't one of them:
+// it shows only what the account may already see.
+var SystemSchemas = map[string]bool{"mysql": true, "sys": true, "performance_schema": true}
+
+// ValidateSystemTables checks mysql.system_tables patterns.
+func ValidateSystemTables(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("mysql.system_tables %q: %w", pattern, err)
+		}
+		if !strings.Contains(pattern, ".") {
+			return fmt.Errorf("mysql.system_tables %q: patterns must be db.table", pattern)
+		}
+	}
+	return nil
+}
+
+// rejectSystemTables rejects stmt if it reads a table in a system schema
+// that mysql.system_tables doesn't list.
+func (v *Validator) rejectSystemTables(stmt sqlparser.Statement) error {
+	if !v.denySystemSchemas {
+		return nil
+	}
+	for _, table := range QueryTables(stmt, v.defaultSchema) {
+		if !SystemSchemas[strings.ToLower(table.Schema)] || TableMatches(v.systemTables, table.String()) {
+			continue
+		}
+		return &QueryRejection{
+			Construct: table.String(),
+			Reason:    fmt.Sprintf("table %s is in a system schema; list it in mysql.system_tables to allow it", table),
+		}
+	}
+	return nil
+}
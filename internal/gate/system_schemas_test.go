@@ -0,0 +1,49 @@
+package gate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemSchemasDenied(t *testing.T) {
+	opts := Options{
+		SystemTables:  []string{"performance_schema.events_statements_summary_by_digest", "sys.schema_*"},
+		DefaultSchema: "shop",
+	}
+	v, err := New(opts)
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"SELECT user, authentication_string FROM mysql.user",
+		"SELECT * FROM shop.orders o JOIN `MySQL`.`db` d ON d.Db = o.id",
+		"SELECT id FROM shop.orders WHERE id IN (SELECT thread_id FROM performance_schema.threads)",
+		"EXPLAIN SELECT * FROM sys.host_summary",
+	} {
+		var rejection *QueryRejection
+		require.ErrorAs(t, v.Validate(query), &rejection, query)
+		require.Contains(t, rejection.Reason, "system schema", query)
+		require.NoError(t, v.ValidateCatalog(query), query)
+	}
+	for _, query := range []string{
+		"SELECT * FROM orders",
+		"SELECT * FROM information_schema.TABLES",
+		"SELECT DIGEST_TEXT FROM performance_schema.events_statements_summary_by_digest",
+		"SELECT * FROM sys.schema_unused_indexes",
+		"WITH user AS (SELECT 1) SELECT * FROM user",
+	} {
+		require.NoError(t, v.Validate(query), query)
+	}
+
+	opts.DefaultSchema = "mysql"
+	v, err = New(opts)
+	require.NoError(t, err)
+	require.ErrorContains(t, v.Validate("SELECT * FROM user"), "mysql.user")
+
+	opts.DefaultSchema, opts.AllowSystemSchemas = "shop", true
+	v, err = New(opts)
+	require.NoError(t, err)
+	require.NoError(t, v.Validate("SELECT * FROM mysql.user"))
+
+	require.ErrorContains(t, ValidateSystemTables([]string{"user"}), "db.table")
+}
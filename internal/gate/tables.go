@@ -0,0 +1,91 @@
+package gate
+
+import (
+	"path"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// Table is a table a query reads, qualified with the default database.
+type Table struct {
+	Schema, Name string
+}
+
+func (t Table) String() string {
+	return t.Schema + "." + t.Name
+}
+
+// QueryTables lists the base tables stmt names, deduplicated, in order.
+// References to CTEs and DUAL aren't base tables.
+func QueryTables(stmt sqlparser.Statement, defaultSchema string) []Table {
+	var tables []Table
+	seen := make(map[string]bool)
+	ctes := CTEReferences(stmt)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if aliased, ok := node.(*sqlparser.AliasedTableExpr); ok && !ctes[aliased] {
+			if name, ok := aliased.Expr.(sqlparser.TableName); ok && IsDualTable(name) {
+				return true, nil
+			}
+			if table, ok := aliasedTable(aliased, defaultSchema); ok && !seen[strings.ToLower(table.String())] {
+				seen[strings.ToLower(table.String())] = true
+				tables = append(tables, table)
+			}
+		}
+		return true, nil
+	}, stmt)
+	return tables
+}
+
+func aliasedTable(aliased *sqlparser.AliasedTableExpr, defaultSchema string) (Table, bool) {
+	name, ok := aliased.Expr.(sqlparser.TableName)
+	if !ok {
+		return Table{}, false
+	}
+	schema := name.Qualifier.String()
+	if schema == "" {
+		schema = defaultSchema
+	}
+	return Table{Schema: schema, Name: name.Name.String()}, true
+}
+
+// FromTables maps the names a SELECT's FROM clause gives its base tables
+// (alias, or bare table name) to the tables.
+func FromTables(sel *sqlparser.Select, defaultSchema string) map[string]Table {
+	tables := make(map[string]Table)
+	var visit func(expr sqlparser.TableExpr)
+	visit = func(expr sqlparser.TableExpr) {
+		switch expr := expr.(type) {
+		case *sqlparser.AliasedTableExpr:
+			if table, ok := aliasedTable(expr, defaultSchema); ok {
+				alias := table.Name
+				if !expr.As.IsEmpty() {
+					alias = expr.As.String()
+				}
+				tables[strings.ToLower(alias)] = table
+			}
+		case *sqlparser.JoinTableExpr:
+			visit(expr.LeftExpr)
+			visit(expr.RightExpr)
+		case *sqlparser.ParenTableExpr:
+			for _, inner := range expr.Exprs {
+				visit(inner)
+			}
+		}
+	}
+	for _, expr := range sel.From {
+		visit(expr)
+	}
+	return tables
+}
+
+// TableMatches reports whether table, as "db.table", matches one of the
+// glob patterns, ignoring case.
+func TableMatches(patterns []string, table string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(table)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package gate
+
+import (
+	"fmt"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// rejectOtherSchemas rejects stmt, for a tenant, if it names a database
+// other than the tenant's own and information_schema. Catalog queries may
+// also read system schemas.
+func (v *Validator) rejectOtherSchemas(stmt sqlparser.Statement, catalog bool) error {
+	if v.tenantSchemas == nil {
+		return nil
+	}
+	var rejection error
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		var schema string
+		switch node := node.(type) {
+		case sqlparser.TableName:
+			schema = node.Qualifier.String()
+		case *sqlparser.ShowBasic:
+			schema = node.DbName.String()
+		case *sqlparser.FuncExpr:
+			schema = node.Qualifier.String()
+		}
+		if schema != "" && !v.TenantAllows(schema, catalog) {
+			rejection = &QueryRejection{Construct: schema, Reason: fmt.Sprintf("database %s is outside this tenant", schema)}
+			return false, nil
+		}
+		return true, nil
+	}, stmt)
+	return rejection
+}
+
+// TenantAllows reports whether the tenant may name database schema.
+func (v *Validator) TenantAllows(schema string, catalog bool) bool {
+	if v.tenantSchemas == nil {
+		return true
+	}
+	schema = strings.ToLower(schema)
+	return v.tenantSchemas[schema] || schema == "information_schema" || catalog && SystemSchemas[schema]
+}
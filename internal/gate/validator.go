@@ -0,0 +1,428 @@
+// Package gate is the read-only gate: it decides whether a query may run,
+// and which of its result columns are stripped or pseudonymized. It's built
+// from the config once, at startup and on each reload, and shared by every
+// tool that runs SQL.
+package gate
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// QueryRejection explains why a query failed the read-only gate. Construct
+// names the offending statement type or clause when there is one.
+type QueryRejection struct {
+	Construct string `json:"construct,omitempty" jsonschema:"Offending construct, e.g. SELECT ... INTO OUTFILE."`
+	Reason    string `json:"reason" jsonschema:"Human-readable explanation."`
+	// parseErr is set when the query didn't parse, which is a syntax error
+	// rather than a policy decision.
+	parseErr error
+}
+
+func (r *QueryRejection) Error() string {
+	return r.Reason
+}
+
+// ParseError returns the parser's error if the query didn't parse, and nil
+// if it was rejected by policy.
+func (r *QueryRejection) ParseError() error {
+	return r.parseErr
+}
+
+// identifierRE matches the unquoted names config may give tables and columns.
+var identifierRE = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Options configures a Validator. The built-in denied functions always
+// apply, and so does the system schema check unless AllowSystemSchemas is
+// set; the other checks are off when their field is nil.
+type Options struct {
+	DenySubstrings []string
+	// DenyPatterns are RE2 regular expressions; see CompileDenyPatterns.
+	DenyPatterns []string
+	// DeniedFunctions are denied on top of the built-in ones, and may name
+	// stored functions as db.fn.
+	DeniedFunctions []string
+	// AllowSystemSchemas lets queries read system schema tables that
+	// SystemTables doesn't list.
+	AllowSystemSchemas bool
+	SystemTables       []string
+	// DefaultSchema resolves unqualified names.
+	DefaultSchema string
+	DeniedColumns []DeniedColumns
+	// Pseudonymize holds db.table.column or table.column names.
+	Pseudonymize []string
+	// Views is non-nil in views-only mode: queries may only read the views
+	// its "db.view" glob patterns match.
+	Views []string
+	// TenantSchemas is non-nil for a tenant's server: queries may only name
+	// these databases and information_schema.
+	TenantSchemas map[string]bool
+}
+
+// Validator is the read-only gate. Calls share its parser and deny lists
+// instead of rebuilding them.
+type Validator struct {
+	// dialect is the startup dialect when nil.
+	dialect        *Dialect
+	denySubstrings []string
+	denyPatterns   []*regexp.Regexp
+	deniedFuncs    map[string]bool
+	// denySystemSchemas rejects tables in SystemSchemas unless they match
+	// systemTables. defaultSchema resolves unqualified names.
+	denySystemSchemas bool
+	systemTables      []string
+	defaultSchema     string
+	deniedColumns     *columnDenylist
+	pseudonyms        *pseudonymColumns
+	// views is non-nil in views-only mode.
+	views []string
+	// tenantSchemas is non-nil for a tenant's server.
+	tenantSchemas map[string]bool
+}
+
+// New builds a Validator that parses with the current dialect.
+func New(opts Options) (*Validator, error) {
+	dialect, err := CurrentDialect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize parser: %w", err)
+	}
+	patterns, err := CompileDenyPatterns(opts.DenyPatterns)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateSystemTables(opts.SystemTables); err != nil {
+		return nil, err
+	}
+	deniedColumns, err := newColumnDenylist(opts.DeniedColumns, opts.DefaultSchema)
+	if err != nil {
+		return nil, err
+	}
+	pseudonyms, err := newPseudonymColumns(opts.Pseudonymize, opts.DefaultSchema)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{
+		dialect:           dialect,
+		denySubstrings:    normalizeList(opts.DenySubstrings),
+		denyPatterns:      patterns,
+		deniedFuncs:       newFunctionDenylist(opts.DeniedFunctions),
+		denySystemSchemas: !opts.AllowSystemSchemas,
+		systemTables:      opts.SystemTables,
+		defaultSchema:     opts.DefaultSchema,
+		deniedColumns:     deniedColumns,
+		pseudonyms:        pseudonyms,
+		views:             opts.Views,
+		tenantSchemas:     opts.TenantSchemas,
+	}, nil
+}
+
+// CompileDenyPatterns compiles mysql.deny_patterns. They are RE2 regular
+// expressions matched against the query as written, so they see comments
+// and case that the substring list normalizes away; use (?i) to ignore case.
+func CompileDenyPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("mysql.deny_patterns %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Parse parses a single statement, tolerating one trailing semicolon.
+func (v *Validator) Parse(query string) (sqlparser.Statement, error) {
+	if v.dialect == nil {
+		return ParseStatement(query)
+	}
+	return v.dialect.Parse(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";")))
+}
+
+// Validate returns a *QueryRejection describing why query is not an allowed
+// read-only statement, or nil if it is.
+func (v *Validator) Validate(query string) error {
+	return v.check(query, true)
+}
+
+// ValidateCatalog is Validate for the server's own catalog queries, which
+// may read system schemas whatever the config says.
+func (v *Validator) ValidateCatalog(query string) error {
+	return v.check(query, false)
+}
+
+func (v *Validator) check(query string, systemTables bool) error {
+	trimmed := strings.TrimSpace(query)
+	normalized := strings.ToLower(trimmed)
+	if normalized == "" {
+		return &QueryRejection{Reason: "query is empty"}
+	}
+	if strings.Contains(normalized, ";") {
+		if !strings.HasSuffix(normalized, ";") || strings.Count(normalized, ";") != 1 {
+			return &QueryRejection{Construct: "multiple statements", Reason: "only a single statement is allowed; use mysql_batch to run several"}
+		}
+		trimmed = strings.TrimSpace(trimmed[:len(trimmed)-1])
+		normalized = strings.ToLower(trimmed)
+		if normalized == "" {
+			return &QueryRejection{Reason: "query is empty"}
+		}
+	}
+	if err := v.rejectDenied(trimmed); err != nil {
+		return err
+	}
+	stmt, err := v.Parse(trimmed)
+	if err != nil {
+		return &QueryRejection{Reason: fmt.Sprintf("failed to parse query: %v", err), parseErr: err}
+	}
+	// WITH ... SELECT parses as a Select or Union, and a parenthesized
+	// SELECT as its inner statement.
+	switch stmt.(type) {
+	case *sqlparser.Select, *sqlparser.Union, *sqlparser.ValuesStatement, *sqlparser.Show, sqlparser.Explain:
+	default:
+		return &QueryRejection{
+			Construct: strings.ToUpper(sqlparser.ASTToStatementType(stmt).String()),
+			Reason:    "only SELECT, VALUES, SHOW, DESCRIBE, and EXPLAIN statements are allowed",
+		}
+	}
+	if err := rejectWriteConstructs(stmt); err != nil {
+		return err
+	}
+	if err := v.rejectOtherSchemas(stmt, !systemTables); err != nil {
+		return err
+	}
+	if systemTables {
+		if err := v.rejectSystemTables(stmt); err != nil {
+			return err
+		}
+		if _, err := v.deniedColumns.check(stmt, v.defaultSchema); err != nil {
+			return err
+		}
+		if err := v.rejectUnlistedTables(stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := v.pseudonyms.check(stmt, v.defaultSchema); err != nil {
+		return err
+	}
+	return rejectDeniedFunctions(stmt, v.deniedFuncs)
+}
+
+// rejectDenied checks query text against the deny substrings, ignoring
+// case, and the deny patterns.
+func (v *Validator) rejectDenied(query string) error {
+	normalized := strings.ToLower(query)
+	for _, fragment := range v.denySubstrings {
+		if fragment != "" && strings.Contains(normalized, fragment) {
+			return &QueryRejection{Construct: fragment, Reason: fmt.Sprintf("query contains denied fragment %q", fragment)}
+		}
+	}
+	for _, re := range v.denyPatterns {
+		if loc := re.FindStringIndex(query); loc != nil {
+			return &QueryRejection{Construct: query[loc[0]:loc[1]], Reason: fmt.Sprintf("query matches denied pattern %q", re.String())}
+		}
+	}
+	return nil
+}
+
+// CheckWrite returns the parsed statement if statement is a single plain
+// INSERT, UPDATE, or DELETE (no REPLACE) that passes the deny lists, denied
+// functions, and the table rules reads are held to, and a *QueryRejection
+// otherwise. UPDATE and DELETE need a WHERE clause.
+func (v *Validator) CheckWrite(statement string) (sqlparser.Statement, error) {
+	normalized := strings.ToLower(strings.TrimSpace(statement))
+	if strings.TrimSpace(strings.TrimSuffix(normalized, ";")) == "" {
+		return nil, &QueryRejection{Reason: "statement is empty"}
+	}
+	if strings.Contains(strings.TrimSuffix(normalized, ";"), ";") {
+		return nil, &QueryRejection{Construct: "multiple statements", Reason: "only a single statement is allowed"}
+	}
+	if err := v.rejectDenied(statement); err != nil {
+		return nil, err
+	}
+	stmt, err := v.Parse(statement)
+	if err != nil {
+		return nil, &QueryRejection{Reason: fmt.Sprintf("failed to parse statement: %v", err), parseErr: err}
+	}
+	switch stmt := stmt.(type) {
+	case *sqlparser.Insert:
+		if stmt.Action != sqlparser.InsertAct {
+			return nil, &QueryRejection{Construct: "REPLACE", Reason: "only INSERT, UPDATE, and DELETE statements are allowed"}
+		}
+	case *sqlparser.Update:
+		if stmt.Where == nil {
+			return nil, &QueryRejection{Construct: "UPDATE without WHERE", Reason: "UPDATE needs a WHERE clause"}
+		}
+	case *sqlparser.Delete:
+		if stmt.Where == nil {
+			return nil, &QueryRejection{Construct: "DELETE without WHERE", Reason: "DELETE needs a WHERE clause"}
+		}
+	default:
+		return nil, &QueryRejection{
+			Construct: strings.ToUpper(sqlparser.ASTToStatementType(stmt).String()),
+			Reason:    "only INSERT, UPDATE, and DELETE statements are allowed",
+		}
+	}
+	if err := rejectWriteConstructs(stmt); err != nil {
+		return nil, err
+	}
+	if err := rejectDeniedFunctions(stmt, v.deniedFuncs); err != nil {
+		return nil, err
+	}
+	if err := v.rejectSystemTables(stmt); err != nil {
+		return nil, err
+	}
+	if _, err := v.deniedColumns.check(stmt, v.defaultSchema); err != nil {
+		return nil, err
+	}
+	if err := v.rejectUnlistedTables(stmt); err != nil {
+		return nil, err
+	}
+	if err := v.rejectOtherSchemas(stmt, false); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// Summary counts what a Validator denies.
+type Summary struct {
+	// DeniedFunctions is sorted.
+	DeniedFunctions    []string
+	DenySubstrings     int
+	DenyPatterns       int
+	DeniedColumnTables int
+}
+
+// Summary reports what v denies, for mysql://server_info.
+func (v *Validator) Summary() Summary {
+	summary := Summary{
+		DeniedFunctions: slices.Sorted(maps.Keys(v.deniedFuncs)),
+		DenySubstrings:  len(v.denySubstrings),
+		DenyPatterns:    len(v.denyPatterns),
+	}
+	if summary.DeniedFunctions == nil {
+		summary.DeniedFunctions = []string{}
+	}
+	if v.deniedColumns != nil {
+		summary.DeniedColumnTables = len(v.deniedColumns.tables)
+	}
+	return summary
+}
+
+func normalizeList(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		trimmed := strings.TrimSpace(strings.ToLower(value))
+		if trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
+
+// defaultDeniedFunctions can stall or lock server resources, or read files on
+// the database host, even inside a read-only transaction.
+var defaultDeniedFunctions = []string{
+	"sleep", "benchmark", "load_file",
+	"get_lock", "release_lock", "release_all_locks", "is_free_lock", "is_used_lock",
+}
+
+// newFunctionDenylist returns the default denied functions plus extra, which
+// may name stored functions as db.fn.
+func newFunctionDenylist(extra []string) map[string]bool {
+	denied := make(map[string]bool)
+	for _, name := range normalizeList(append(append([]string{}, defaultDeniedFunctions...), extra...)) {
+		denied[name] = true
+	}
+	return denied
+}
+
+// rejectDeniedFunctions finds calls to denied functions anywhere in stmt.
+// Working on the syntax tree means comments or whitespace between the name
+// and its arguments (SLEEP/**/(10)) can't hide a call.
+func rejectDeniedFunctions(stmt sqlparser.Statement, denied map[string]bool) error {
+	var rejection *QueryRejection
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		var names []string
+		switch n := node.(type) {
+		case *sqlparser.FuncExpr:
+			names = append(names, n.Name.Lowered())
+			if !n.Qualifier.IsEmpty() {
+				names = append(names, strings.ToLower(n.Qualifier.String())+"."+n.Name.Lowered())
+			}
+		case *sqlparser.LockingFunc:
+			names = append(names, strings.ToLower(n.Type.ToString()))
+		default:
+			return true, nil
+		}
+		for _, name := range names {
+			if denied[name] {
+				rejection = &QueryRejection{
+					Construct: strings.ToUpper(name) + "()",
+					Reason:    fmt.Sprintf("function %s() is not allowed", strings.ToUpper(name)),
+				}
+				return false, nil
+			}
+		}
+		return true, nil
+	}, stmt)
+	if rejection != nil {
+		return rejection
+	}
+	return nil
+}
+
+// rejectWriteConstructs finds clauses that turn an otherwise read-only
+// statement into a write or a lock: SELECT ... INTO and locking reads,
+// anywhere in the statement (subqueries, UNION branches, EXPLAIN targets).
+func rejectWriteConstructs(stmt sqlparser.Statement) error {
+	var rejection *QueryRejection
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		var lock sqlparser.Lock
+		switch n := node.(type) {
+		case *sqlparser.SelectInto:
+			construct := "SELECT ... INTO"
+			switch n.Type {
+			case sqlparser.IntoOutfile, sqlparser.IntoOutfileS3:
+				construct = "SELECT ... INTO OUTFILE"
+			case sqlparser.IntoDumpfile:
+				construct = "SELECT ... INTO DUMPFILE"
+			case sqlparser.IntoVariables:
+				construct = "SELECT ... INTO @variable"
+			}
+			rejection = &QueryRejection{Construct: construct, Reason: construct + " is not allowed"}
+			return false, nil
+		case *sqlparser.Select:
+			lock = n.Lock
+		case *sqlparser.Union:
+			lock = n.Lock
+		default:
+			return true, nil
+		}
+		if lock != sqlparser.NoLock {
+			construct := strings.ToUpper(strings.TrimSpace(lock.ToString()))
+			rejection = &QueryRejection{Construct: construct, Reason: "locking reads (" + construct + ") are not allowed"}
+			return false, nil
+		}
+		return true, nil
+	}, stmt)
+	if rejection != nil {
+		return rejection
+	}
+	return nil
+}
+
+// ParseStatement parses a single statement, tolerating one trailing semicolon.
+func ParseStatement(query string) (sqlparser.Statement, error) {
+	trimmed := strings.TrimSpace(query)
+	trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, ";"))
+	dialect, err := CurrentDialect()
+	if err != nil {
+		return nil, err
+	}
+	return dialect.Parse(trimmed)
+}
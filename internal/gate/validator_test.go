@@ -0,0 +1,90 @@
+package gate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeList(t *testing.T) {
+	input := []string{"  SELECT ", "", "Show", "  \t", "Describe"}
+	got := normalizeList(input)
+	require.Equal(t, []string{"select", "show", "describe"}, got)
+}
+
+func TestDenyPatterns(t *testing.T) {
+	v, err := New(Options{DenyPatterns: []string{`(?i)\w+_ssn\b`, `/\*[^!]`}})
+	require.NoError(t, err)
+
+	require.NoError(t, v.Validate("SELECT id, ssn_last4 FROM employees"))
+	err = v.Validate("SELECT id, Tax_SSN FROM employees")
+	var rejection *QueryRejection
+	require.ErrorAs(t, err, &rejection)
+	require.Equal(t, "Tax_SSN", rejection.Construct)
+	require.ErrorContains(t, v.Validate("SELECT pass/* x */word FROM users"), "denied pattern")
+	require.NoError(t, v.Validate("SELECT /*!80000 1 */"), "versioned comments don't match")
+
+	_, err = CompileDenyPatterns([]string{"(unclosed"})
+	require.ErrorContains(t, err, `mysql.deny_patterns "(unclosed"`)
+}
+
+func TestValidateRejectsWriteConstructs(t *testing.T) {
+	v, err := New(Options{})
+	require.NoError(t, err)
+	cases := []struct {
+		query     string
+		construct string
+	}{
+		{"SELECT * FROM t INTO   OUTFILE '/tmp/x'", "SELECT ... INTO OUTFILE"},
+		{"select * from t\ninto\tdumpfile '/tmp/x'", "SELECT ... INTO DUMPFILE"},
+		{"SELECT id FROM t LIMIT 1 INTO @id", "SELECT ... INTO @variable"},
+		{"SELECT 1 UNION SELECT 2 INTO OUTFILE '/tmp/x'", "SELECT ... INTO OUTFILE"},
+		{"EXPLAIN SELECT * FROM t INTO OUTFILE '/tmp/x'", "SELECT ... INTO OUTFILE"},
+		{"SELECT * FROM t WHERE id = 1 FOR   UPDATE", "FOR UPDATE"},
+		{"SELECT * FROM t FOR SHARE", "FOR SHARE"},
+		{"SELECT * FROM t LOCK IN SHARE MODE", "LOCK IN SHARE MODE"},
+		{"SELECT * FROM (SELECT * FROM t FOR UPDATE) d", "FOR UPDATE"},
+		{"SELECT id FROM t UNION (SELECT id FROM u FOR UPDATE)", "FOR UPDATE"},
+		{"DELETE FROM t", "DELETE"},
+		{"select 1; select 2", "multiple statements"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			err := v.Validate(tc.query)
+			var rejection *QueryRejection
+			require.ErrorAs(t, err, &rejection)
+			require.Equal(t, tc.construct, rejection.Construct)
+		})
+	}
+
+	require.NoError(t, v.Validate("SELECT 'into outfile', 'for update' FROM t"))
+}
+
+func TestValidateRejectsDeniedFunctions(t *testing.T) {
+	denied, err := New(Options{DeniedFunctions: []string{"UUID", "reporting.expensive_fn"}})
+	require.NoError(t, err)
+	cases := []struct {
+		query     string
+		construct string
+	}{
+		{"SELECT SLEEP/**/ (10)", "SLEEP()"},
+		{"select sleep(1) from dual", "SLEEP()"},
+		{"SELECT * FROM t WHERE id = 1 AND BENCHMARK(1000000, MD5('x'))", "BENCHMARK()"},
+		{"SELECT LOAD_FILE('/etc/passwd')", "LOAD_FILE()"},
+		{"SELECT GET_LOCK('x', 10)", "GET_LOCK()"},
+		{"SELECT id FROM t WHERE id IN (SELECT RELEASE_ALL_LOCKS())", "RELEASE_ALL_LOCKS()"},
+		{"SELECT uuid()", "UUID()"},
+		{"SELECT id FROM t UNION ALL SELECT id FROM u UNION ALL SELECT SLEEP(5)", "SLEEP()"},
+		{"SELECT reporting.expensive_fn(id) FROM t", "REPORTING.EXPENSIVE_FN()"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			err := denied.Validate(tc.query)
+			var rejection *QueryRejection
+			require.ErrorAs(t, err, &rejection)
+			require.Equal(t, tc.construct, rejection.Construct)
+		})
+	}
+
+	require.NoError(t, denied.Validate("SELECT 'sleep(10)', other.expensive_fn(1)"))
+}
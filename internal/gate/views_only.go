@@ -0,0 +1,24 @@
+package gate
+
+import (
+	"fmt"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// rejectUnlistedTables rejects stmt, in views-only mode, if it references a
+// table or view that views_only.views doesn't list.
+func (v *Validator) rejectUnlistedTables(stmt sqlparser.Statement) error {
+	if v.views == nil {
+		return nil
+	}
+	for _, table := range QueryTables(stmt, v.defaultSchema) {
+		if table.Schema == "" {
+			return &QueryRejection{Construct: table.Name, Reason: fmt.Sprintf("%s needs a database name; the DSN has no default database", table.Name)}
+		}
+		if !TableMatches(v.views, table.String()) {
+			return &QueryRejection{Construct: table.String(), Reason: fmt.Sprintf("only the views in views_only.views may be queried, and %s is not one of them", table)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// schemaObjectQueries list non-table schema objects of a database; each takes
+// the database name as its only parameter.
+var schemaObjectQueries = map[string]string{
+	"routines": `SELECT ROUTINE_NAME AS name, ROUTINE_TYPE AS type, DATA_TYPE AS returns,
+	IS_DETERMINISTIC AS deterministic, SQL_DATA_ACCESS AS data_access, SECURITY_TYPE AS security_type,
+	DEFINER AS definer, ROUTINE_COMMENT AS comment, ROUTINE_DEFINITION AS definition
+FROM information_schema.ROUTINES
+WHERE ROUTINE_SCHEMA = ?
+ORDER BY ROUTINE_TYPE, ROUTINE_NAME`,
+	"triggers": `SELECT TRIGGER_NAME AS name, EVENT_OBJECT_TABLE AS table_name, ACTION_TIMING AS timing,
+	EVENT_MANIPULATION AS event, ACTION_ORDER AS action_order, DEFINER AS definer, ACTION_STATEMENT AS statement
+FROM information_schema.TRIGGERS
+WHERE TRIGGER_SCHEMA = ?
+ORDER BY EVENT_OBJECT_TABLE, ACTION_TIMING, EVENT_MANIPULATION, ACTION_ORDER`,
+	"events": `SELECT EVENT_NAME AS name, STATUS AS status, EVENT_TYPE AS type, EXECUTE_AT AS execute_at,
+	INTERVAL_VALUE AS interval_value, INTERVAL_FIELD AS interval_field, STARTS AS starts, ENDS AS ends,
+	LAST_EXECUTED AS last_executed, DEFINER AS definer, EVENT_DEFINITION AS definition
+FROM information_schema.EVENTS
+WHERE EVENT_SCHEMA = ?
+ORDER BY EVENT_NAME`,
+}
+
+type ViewInfo struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+type ViewsOutput struct {
+	Views []ViewInfo `json:"views"`
+}
+
+// readViews lists the views of db and fetches each definition with SHOW
+// CREATE VIEW, which (unlike information_schema.VIEWS) includes the column
+// list, algorithm, and security options.
+func (h *queryHandler) readViews(ctx context.Context, uri, db string) (*mcp.ReadResourceResult, error) {
+	list, err := h.runMetadataQuery(ctx, fmt.Sprintf("SHOW FULL TABLES FROM `%s` WHERE Table_type = 'VIEW'", db))
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]ViewInfo, 0, len(list.Rows))
+	for _, row := range list.Rows {
+		name := valueString(rowValue(row, 0))
+		if !mysqlIdentifierRE.MatchString(name) {
+			views = append(views, ViewInfo{Name: name})
+			continue
+		}
+		out, err := h.runMetadataQuery(ctx, fmt.Sprintf("SHOW CREATE VIEW `%s`.`%s`", db, name))
+		if err != nil {
+			return nil, fmt.Errorf("view %s: %w", name, err)
+		}
+		views = append(views, ViewInfo{Name: name, Definition: createStatement(out)})
+	}
+	return jsonResourceResult(uri, ViewsOutput{Views: views})
+}
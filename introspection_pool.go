@@ -0,0 +1,54 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// IntrospectionConfig opens a separate pool, usually as a user that can only
+// read metadata, for catalog queries: schema resources and tools, the schema
+// cache, and table resource listing. Queries against data stay on the main
+// pool, so catalog scans neither queue behind them nor need the query
+// user's privileges.
+type IntrospectionConfig struct {
+	DSN          string `toml:"dsn"`
+	MaxOpenConns int    `toml:"max_open_conns"`
+}
+
+// newIntrospectionDB opens the introspection pool with the main connection's
+// TLS, IAM, SSH, and init statement settings, or returns nil if none is
+// configured.
+func newIntrospectionDB(cfg Config) (*sql.DB, error) {
+	c := cfg.MySQL.Introspection
+	if c.DSN == "" {
+		return nil, nil
+	}
+	dsnConfig, err := mysql.ParseDSN(c.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("mysql.introspection.dsn: %w", err)
+	}
+	if err := applyConnectionOptions(dsnConfig, cfg); err != nil {
+		return nil, fmt.Errorf("mysql.introspection: %w", err)
+	}
+	connector, err := mysql.NewConnector(dsnConfig)
+	if err != nil {
+		return nil, fmt.Errorf("mysql.introspection.dsn: %w", err)
+	}
+	if c.MaxOpenConns <= 0 {
+		c.MaxOpenConns = 2
+	}
+	db := sql.OpenDB(newInitConnector(connector, cfg.MySQL.InitStatements))
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxOpenConns)
+	return db, nil
+}
+
+// metadataDB is the pool for catalog queries.
+func (h *queryHandler) metadataDB() *sql.DB {
+	if h.meta != nil {
+		return h.meta
+	}
+	return h.db
+}
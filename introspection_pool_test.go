@@ -0,0 +1,31 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIntrospectionDB(t *testing.T) {
+	db, err := newIntrospectionDB(Config{})
+	require.NoError(t, err)
+	require.Nil(t, db)
+
+	var cfg Config
+	cfg.MySQL.Introspection.DSN = "catalog:secret@tcp(db:3306)/"
+	db, err = newIntrospectionDB(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, 2, db.Stats().MaxOpenConnections)
+
+	cfg.MySQL.Introspection.DSN = "not a dsn"
+	_, err = newIntrospectionDB(cfg)
+	require.ErrorContains(t, err, "mysql.introspection.dsn")
+}
+
+func TestMetadataDB(t *testing.T) {
+	primary, meta := &sql.DB{}, &sql.DB{}
+	require.Same(t, primary, (&queryHandler{db: primary}).metadataDB())
+	require.Same(t, meta, (&queryHandler{db: primary, meta: meta}).metadataDB())
+}
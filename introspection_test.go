@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospectionQueriesPassReadOnlyGate(t *testing.T) {
+	queries := []string{
+		"SHOW FULL TABLES FROM `shop` WHERE Table_type = 'VIEW'",
+		fmt.Sprintf("SHOW CREATE VIEW `%s`.`%s`", "shop", "active_orders"),
+	}
+	for _, query := range schemaObjectQueries {
+		queries = append(queries, query)
+	}
+	for _, query := range queries {
+		require.True(t, isReadOnlyQuery(query, nil), query)
+	}
+}
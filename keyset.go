@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// keysetPlan pages a single-table SELECT by its primary key: every page is
+// ordered by the key, and later pages seek past the last key returned
+// (WHERE (pk...) > (...)) instead of skipping rows with OFFSET, so deep pages
+// cost the same as the first.
+type keysetPlan struct {
+	stmt       *sqlparser.Select
+	table      sqlparser.TableName
+	primaryKey []string
+}
+
+// planKeyset returns a plan for stmt if it can be paged by key: a SELECT from
+// one base table with a primary key, without DISTINCT, GROUP BY, HAVING,
+// aggregates, LIMIT, or locking, and either no ORDER BY or one that is
+// exactly the primary key ascending.
+func planKeyset(stmt sqlparser.Statement, lookupPK func(schema, table string) ([]string, bool)) (*keysetPlan, bool) {
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || len(sel.From) != 1 || sel.With != nil || sel.Distinct || sel.GroupBy != nil ||
+		sel.Having != nil || sel.Limit != nil || sel.Into != nil || sel.Lock != sqlparser.NoLock {
+		return nil, false
+	}
+	aliased, ok := sel.From[0].(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return nil, false
+	}
+	name, ok := aliased.Expr.(sqlparser.TableName)
+	if !ok {
+		return nil, false
+	}
+	if sel.SelectExprs != nil {
+		for _, expr := range sel.SelectExprs.Exprs {
+			if sqlparser.ContainsAggregation(expr) {
+				return nil, false
+			}
+		}
+	}
+	pk, ok := lookupPK(name.Qualifier.String(), name.Name.String())
+	if !ok || len(pk) == 0 {
+		return nil, false
+	}
+
+	if len(sel.OrderBy) > 0 {
+		if len(sel.OrderBy) != len(pk) {
+			return nil, false
+		}
+		for i, order := range sel.OrderBy {
+			col, ok := order.Expr.(*sqlparser.ColName)
+			if !ok || order.Direction != sqlparser.AscOrder || !strings.EqualFold(col.Name.String(), pk[i]) {
+				return nil, false
+			}
+		}
+	}
+
+	table := name
+	if !aliased.As.IsEmpty() {
+		table = sqlparser.TableName{Name: aliased.As}
+	}
+	return &keysetPlan{stmt: sel, table: table, primaryKey: pk}, true
+}
+
+// rewrite orders the statement by the primary key and, when after holds the
+// last key of the previous page, seeks past it. The key values are returned
+// as bind arguments for the ? placeholders in the query.
+func (p *keysetPlan) rewrite(after []any) (string, []any) {
+	sel := sqlparser.Clone(p.stmt)
+	sel.OrderBy = nil
+	keyCols := make(sqlparser.ValTuple, len(p.primaryKey))
+	placeholders := make(sqlparser.ValTuple, len(p.primaryKey))
+	for i, col := range p.primaryKey {
+		keyCols[i] = sqlparser.NewColNameWithQualifier(col, p.table)
+		placeholders[i] = sqlparser.NewArgument(fmt.Sprintf("k%d", i))
+		sel.AddOrder(&sqlparser.Order{Expr: sqlparser.NewColNameWithQualifier(col, p.table), Direction: sqlparser.AscOrder})
+	}
+	if after == nil {
+		return formatWithPlaceholders(sel), nil
+	}
+
+	var left, right sqlparser.Expr = keyCols, placeholders
+	if len(p.primaryKey) == 1 {
+		left, right = keyCols[0], placeholders[0]
+	}
+	sel.AddWhere(&sqlparser.ComparisonExpr{Operator: sqlparser.GreaterThanOp, Left: left, Right: right})
+	return formatWithPlaceholders(sel), after
+}
+
+// nextKey extracts the primary key of the last row of output, for the next
+// page's cursor. It fails (false) if a key column isn't selected or holds a
+// value that can't round-trip through a cursor.
+func (p *keysetPlan) nextKey(output QueryOutput) ([]any, bool) {
+	if len(output.Rows) == 0 {
+		return nil, false
+	}
+	last := output.Rows[len(output.Rows)-1]
+	key := make([]any, len(p.primaryKey))
+	for i, col := range p.primaryKey {
+		idx := columnIndex(output.Columns, col)
+		if idx < 0 {
+			return nil, false
+		}
+		if idx < len(output.ColumnTypes) && isTemporalType(output.ColumnTypes[idx].Type) {
+			return nil, false
+		}
+		switch v := rowValue(last, idx).(type) {
+		case int64, uint64, string:
+			key[i] = v
+		default:
+			return nil, false
+		}
+	}
+	return key, true
+}
+
+func isTemporalType(name string) bool {
+	switch name {
+	case "DATE", "DATETIME", "TIMESTAMP", "TIME", "YEAR":
+		return true
+	}
+	return false
+}
+
+type keysetCursor struct {
+	Fingerprint string `json:"f"`
+	Key         []any  `json:"k"`
+}
+
+func encodeCursor(fingerprint string, key []any) string {
+	encoded, _ := json.Marshal(keysetCursor{Fingerprint: fingerprint, Key: key})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeCursor returns the key stored in cursor, which must have been issued
+// for a query with the given fingerprint.
+func decodeCursor(cursor, fingerprint string) ([]any, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var c keysetCursor
+	if err := dec.Decode(&c); err != nil || len(c.Key) == 0 {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	if c.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("cursor was issued for a different query")
+	}
+	for i, v := range c.Key {
+		switch v := v.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				c.Key[i] = n
+			} else {
+				c.Key[i] = v.String()
+			}
+		case string:
+		default:
+			return nil, fmt.Errorf("malformed cursor")
+		}
+	}
+	return c.Key, nil
+}
+
+// keysetPlan returns the paging plan for query, or nil when it can't be paged
+// by key.
+func (h *queryHandler) keysetPlan(ctx context.Context, query string) *keysetPlan {
+	if h.schema == nil {
+		return nil
+	}
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return nil
+	}
+	plan, ok := planKeyset(stmt, func(schema, table string) ([]string, bool) {
+		pk, err := h.schema.primaryKey(ctx, schema, table)
+		return pk, err == nil
+	})
+	if !ok {
+		return nil
+	}
+	return plan
+}
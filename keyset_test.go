@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func pkLookup(schema, table string) ([]string, bool) {
+	switch table {
+	case "orders":
+		return []string{"id"}, true
+	case "order_items":
+		return []string{"order_id", "line"}, true
+	}
+	return nil, true
+}
+
+func TestPlanKeyset(t *testing.T) {
+	eligible := []string{
+		"SELECT * FROM orders",
+		"SELECT id, status FROM shop.orders o WHERE status = 'new'",
+		"SELECT * FROM orders ORDER BY id",
+		"SELECT * FROM order_items ORDER BY order_id, line ASC",
+	}
+	for _, query := range eligible {
+		stmt, err := gate.ParseStatement(query)
+		require.NoError(t, err)
+		_, ok := planKeyset(stmt, pkLookup)
+		require.True(t, ok, query)
+	}
+
+	ineligible := []string{
+		"SELECT * FROM logs",
+		"SELECT * FROM orders LIMIT 10",
+		"SELECT * FROM orders ORDER BY created_at",
+		"SELECT * FROM orders ORDER BY id DESC",
+		"SELECT DISTINCT status FROM orders",
+		"SELECT status, COUNT(*) FROM orders GROUP BY status",
+		"SELECT COUNT(*) FROM orders",
+		"SELECT * FROM orders o JOIN order_items i ON i.order_id = o.id",
+		"SELECT * FROM (SELECT * FROM orders) AS o",
+		"SELECT 1 UNION SELECT 2",
+		"SHOW TABLES",
+	}
+	for _, query := range ineligible {
+		stmt, err := gate.ParseStatement(query)
+		require.NoError(t, err)
+		_, ok := planKeyset(stmt, pkLookup)
+		require.False(t, ok, query)
+	}
+}
+
+func TestKeysetRewrite(t *testing.T) {
+	stmt, err := gate.ParseStatement("SELECT id, status FROM orders o WHERE status = 'new' OR status = 'paid'")
+	require.NoError(t, err)
+	plan, ok := planKeyset(stmt, pkLookup)
+	require.True(t, ok)
+
+	query, args := plan.rewrite(nil)
+	require.Equal(t, "select id, `status` from orders as o where `status` = 'new' or `status` = 'paid' order by o.id asc", query)
+	require.Nil(t, args)
+
+	query, args = plan.rewrite([]any{int64(42)})
+	require.Equal(t, "select id, `status` from orders as o where (`status` = 'new' or `status` = 'paid') and o.id > ? order by o.id asc", query)
+	require.Equal(t, []any{int64(42)}, args)
+
+	stmt, err = gate.ParseStatement("SELECT * FROM order_items")
+	require.NoError(t, err)
+	plan, _ = planKeyset(stmt, pkLookup)
+	query, _ = plan.rewrite([]any{int64(1), int64(3)})
+	require.Equal(t, "select * from order_items where (order_items.order_id, order_items.line) > (?, ?) order by order_items.order_id asc, order_items.line asc", query)
+}
+
+func TestKeysetNextKeyAndCursor(t *testing.T) {
+	stmt, err := gate.ParseStatement("SELECT * FROM order_items")
+	require.NoError(t, err)
+	plan, _ := planKeyset(stmt, pkLookup)
+
+	output := QueryOutput{
+		Columns: []string{"order_id", "line", "sku"},
+		Rows:    [][]interface{}{{int64(1), int64(1), "a"}, {int64(1), int64(2), "b"}},
+	}
+	key, ok := plan.nextKey(output)
+	require.True(t, ok)
+	require.Equal(t, []any{int64(1), int64(2)}, key)
+
+	fingerprint := queryFingerprint("SELECT * FROM order_items")
+	cursor := encodeCursor(fingerprint, key)
+	decoded, err := decodeCursor(cursor, fingerprint)
+	require.NoError(t, err)
+	require.Equal(t, key, decoded)
+
+	_, err = decodeCursor(cursor, queryFingerprint("SELECT * FROM orders"))
+	require.ErrorContains(t, err, "different query")
+	_, err = decodeCursor("not a cursor", fingerprint)
+	require.Error(t, err)
+
+	_, ok = plan.nextKey(QueryOutput{Columns: []string{"sku"}, Rows: [][]interface{}{{"a"}}})
+	require.False(t, ok, "key columns must be selected")
+
+	output.ColumnTypes = []ColumnType{{Type: "DATETIME"}, {Type: "INT"}, {Type: "VARCHAR"}}
+	_, ok = plan.nextKey(output)
+	require.False(t, ok, "temporal keys don't round-trip")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// killTimeout bounds the KILL QUERY issued for an abandoned query.
+const killTimeout = 5 * time.Second
+
+// connectionID returns the MySQL connection ID of conn, taken from db, and
+// remembers it as one of this server's connections.
+func (h *queryHandler) connectionID(ctx context.Context, db *sql.DB, conn *sql.Conn) (int64, error) {
+	var id int64
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id); err != nil {
+		return 0, err
+	}
+	h.connections.add(db, id)
+	return id, nil
+}
+
+// killOnCancel issues KILL QUERY for connID from another connection in db if
+// ctx ends before the returned stop function is called. Cancelling the
+// context only abandons the query client-side; without the KILL the server
+// keeps executing it. stop waits for an in-progress KILL, so callers must
+// call it before releasing the connection.
+func (h *queryHandler) killOnCancel(ctx context.Context, db *sql.DB, connID int64) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-done:
+			// ctx may have ended just as the query finished; killing an
+			// idle connection we still hold is harmless.
+			if ctx.Err() == nil {
+				return
+			}
+		case <-ctx.Done():
+		}
+		killCtx, cancel := context.WithTimeout(context.Background(), killTimeout)
+		defer cancel()
+		if _, err := db.ExecContext(killCtx, fmt.Sprintf("KILL QUERY %d", connID)); err != nil {
+			log.Printf("failed to kill query on connection %d: %v", connID, err)
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKillOnCancel(t *testing.T) {
+	conn := &recordingConn{}
+	h := &queryHandler{db: sql.OpenDB(stubConnector{conn: conn})}
+	defer h.db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := h.killOnCancel(ctx, h.db, 42)
+	stop()
+	cancel()
+	require.Empty(t, conn.executed, "finished queries are not killed")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	stop = h.killOnCancel(ctx, h.db, 42)
+	cancel()
+	stop()
+	require.Equal(t, []string{"KILL QUERY 42"}, conn.executed)
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+const (
+	// lintLargeTableRows is the row estimate above which reading a table
+	// without a WHERE clause is reported.
+	lintLargeTableRows = 100000
+	// lintMaxTables bounds the catalog lookups for one query.
+	lintMaxTables = 10
+)
+
+type LintInput struct {
+	Query string `json:"query" jsonschema:"SQL statement to check. It is parsed, never executed."`
+}
+
+type LintFinding struct {
+	Rule    string `json:"rule" jsonschema:"select_star, no_limit, function_on_indexed_column, implicit_cross_join, or missing_where."`
+	Message string `json:"message"`
+	Table   string `json:"table,omitempty"`
+	Column  string `json:"column,omitempty"`
+}
+
+type LintOutput struct {
+	Findings []LintFinding `json:"findings"`
+}
+
+// lintCatalog is what lintStatement knows about the tables a query reads.
+// Tables missing from it (CTEs, unknown tables) are skipped by the rules
+// that need it.
+type lintCatalog struct {
+	// rows holds row estimates by lower-cased "db.table".
+	rows map[string]int64
+	// indexed holds lower-cased "db.table.column" for indexed columns.
+	indexed map[string]bool
+}
+
+// outerSelects returns the SELECTs whose rows a statement returns: the
+// statement itself, or each side of a UNION.
+func outerSelects(stmt sqlparser.Statement) []*sqlparser.Select {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select:
+		return []*sqlparser.Select{stmt}
+	case *sqlparser.Union:
+		return append(outerSelects(stmt.Left), outerSelects(stmt.Right)...)
+	}
+	return nil
+}
+
+// lintStatement reports the anti-patterns in stmt. Row counts and indexes
+// come from catalog.
+func lintStatement(stmt sqlparser.Statement, defaultSchema string, catalog lintCatalog) []LintFinding {
+	findings := []LintFinding{}
+	outer := outerSelects(stmt)
+	for _, sel := range outer {
+		for _, expr := range sel.GetColumns() {
+			if star, ok := expr.(*sqlparser.StarExpr); ok {
+				findings = append(findings, LintFinding{
+					Rule:    "select_star",
+					Message: fmt.Sprintf("%s reads every column; name the columns you need", sqlparser.String(star)),
+				})
+			}
+		}
+	}
+	if len(outer) > 0 && !hasLimit(stmt) && !singleRow(stmt) {
+		findings = append(findings, LintFinding{Rule: "no_limit", Message: "the query has no LIMIT, so its result size is unbounded"})
+	}
+
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if sel, ok := node.(*sqlparser.Select); ok {
+			findings = append(findings, lintSelect(sel, defaultSchema, catalog)...)
+		}
+		return true, nil
+	}, stmt)
+	return findings
+}
+
+func hasLimit(stmt sqlparser.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select:
+		return stmt.Limit != nil
+	case *sqlparser.Union:
+		return stmt.Limit != nil
+	}
+	return false
+}
+
+// singleRow reports whether stmt is a SELECT returning at most one row:
+// no FROM clause, or aggregates without GROUP BY.
+func singleRow(stmt sqlparser.Statement) bool {
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || (sel.GroupBy != nil && len(sel.GroupBy.Exprs) > 0) {
+		return false
+	}
+	if len(sel.From) == 0 {
+		return true
+	}
+	if len(sel.From) == 1 {
+		if aliased, ok := sel.From[0].(*sqlparser.AliasedTableExpr); ok && sqlparser.String(aliased.Expr) == "dual" {
+			return true
+		}
+	}
+	for _, expr := range sel.GetColumns() {
+		if sqlparser.ContainsAggregation(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintSelect applies the rules that concern one SELECT's FROM and WHERE.
+func lintSelect(sel *sqlparser.Select, defaultSchema string, catalog lintCatalog) []LintFinding {
+	var findings []LintFinding
+	tables := gate.FromTables(sel, defaultSchema)
+
+	if len(sel.From) > 1 && !joinsTables(sel.Where) {
+		findings = append(findings, LintFinding{
+			Rule:    "implicit_cross_join",
+			Message: "comma-separated tables with no WHERE condition relating them return every combination of rows; use JOIN ... ON",
+		})
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Subquery, *sqlparser.DerivedTable:
+			return false, nil
+		case *sqlparser.JoinTableExpr:
+			if node.Condition == nil || (node.Condition.On == nil && len(node.Condition.Using) == 0) {
+				findings = append(findings, LintFinding{
+					Rule:    "implicit_cross_join",
+					Message: fmt.Sprintf("%s is joined without ON or USING, which returns every combination of rows", sqlparser.String(node.RightExpr)),
+				})
+			}
+		}
+		return true, nil
+	}, sqlparser.TableExprs(sel.From))
+
+	if sel.Where == nil && !peek(sel) {
+		reported := make(map[string]bool)
+		for _, table := range sortedTables(tables) {
+			key := strings.ToLower(table.String())
+			if rows, ok := catalog.rows[key]; ok && rows > lintLargeTableRows && !reported[key] {
+				reported[key] = true
+				findings = append(findings, LintFinding{
+					Rule:    "missing_where",
+					Message: fmt.Sprintf("%s has about %d rows and the query reads it without a WHERE clause", table, rows),
+					Table:   table.String(),
+				})
+			}
+		}
+	}
+
+	var conditions []sqlparser.Expr
+	if sel.Where != nil {
+		conditions = append(conditions, sel.Where.Expr)
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Subquery, *sqlparser.DerivedTable:
+			return false, nil
+		case *sqlparser.JoinTableExpr:
+			if node.Condition != nil && node.Condition.On != nil {
+				conditions = append(conditions, node.Condition.On)
+			}
+		}
+		return true, nil
+	}, sqlparser.TableExprs(sel.From))
+	seen := make(map[string]bool)
+	for _, condition := range conditions {
+		for _, column := range wrappedColumns(condition) {
+			for _, table := range columnTables(column, tables) {
+				key := strings.ToLower(table.String() + "." + column.Name.String())
+				if !catalog.indexed[key] || seen[key] {
+					continue
+				}
+				seen[key] = true
+				findings = append(findings, LintFinding{
+					Rule:    "function_on_indexed_column",
+					Message: fmt.Sprintf("%s is indexed, but the condition applies a function or expression to it, so the index can't be used", sqlparser.String(column)),
+					Table:   table.String(),
+					Column:  column.Name.String(),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// peek reports whether sel only reads the first rows it finds: a LIMIT with
+// no sorting, grouping, DISTINCT, or aggregation, which needs no full scan.
+func peek(sel *sqlparser.Select) bool {
+	if sel.Limit == nil || sel.Distinct || len(sel.OrderBy) > 0 || (sel.GroupBy != nil && len(sel.GroupBy.Exprs) > 0) {
+		return false
+	}
+	for _, expr := range sel.GetColumns() {
+		if sqlparser.ContainsAggregation(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// joinsTables reports whether where compares columns of two different
+// tables, as a comma join's join condition does.
+func joinsTables(where *sqlparser.Where) bool {
+	if where == nil {
+		return false
+	}
+	joined := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		cmp, ok := node.(*sqlparser.ComparisonExpr)
+		if !ok {
+			return true, nil
+		}
+		left, leftOK := cmp.Left.(*sqlparser.ColName)
+		right, rightOK := cmp.Right.(*sqlparser.ColName)
+		if leftOK && rightOK && !strings.EqualFold(left.Qualifier.Name.String(), right.Qualifier.Name.String()) {
+			joined = true
+			return false, nil
+		}
+		return true, nil
+	}, where.Expr)
+	return joined
+}
+
+// wrappedColumns returns the columns that a comparison in condition wraps
+// in a function or expression, like YEAR(created_at) = 2024 or id + 1 = 5.
+// Subqueries are left to their own SELECT.
+func wrappedColumns(condition sqlparser.Expr) []*sqlparser.ColName {
+	var columns []*sqlparser.ColName
+	check := func(side sqlparser.Expr) {
+		if _, plain := side.(*sqlparser.ColName); plain {
+			return
+		}
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			switch node := node.(type) {
+			case *sqlparser.Subquery:
+				return false, nil
+			case *sqlparser.ColName:
+				columns = append(columns, node)
+			}
+			return true, nil
+		}, side)
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Subquery:
+			return false, nil
+		case *sqlparser.ComparisonExpr:
+			check(node.Left)
+			check(node.Right)
+			return false, nil
+		case *sqlparser.BetweenExpr:
+			check(node.Left)
+			return false, nil
+		}
+		return true, nil
+	}, condition)
+	return columns
+}
+
+// columnTables returns the tables column may belong to: the one its
+// qualifier names, or every table in scope if it's unqualified.
+func columnTables(column *sqlparser.ColName, tables map[string]gate.Table) []gate.Table {
+	if qualifier := column.Qualifier.Name.String(); qualifier != "" {
+		if table, ok := tables[strings.ToLower(qualifier)]; ok {
+			return []gate.Table{table}
+		}
+		return nil
+	}
+	return sortedTables(tables)
+}
+
+// sortedTables returns the tables in tables by alias order.
+func sortedTables(tables map[string]gate.Table) []gate.Table {
+	sorted := make([]gate.Table, 0, len(tables))
+	for _, alias := range slices.Sorted(maps.Keys(tables)) {
+		sorted = append(sorted, tables[alias])
+	}
+	return sorted
+}
+
+// loadLintCatalog reads row estimates and indexed columns for tables.
+func (h *queryHandler) loadLintCatalog(ctx context.Context, tables []gate.Table) (lintCatalog, error) {
+	catalog := lintCatalog{rows: make(map[string]int64), indexed: make(map[string]bool)}
+	for _, table := range tables[:min(len(tables), lintMaxTables)] {
+		key := strings.ToLower(table.String())
+		size, err := h.runMetadataQuery(ctx, "SELECT TABLE_ROWS AS table_rows FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", table.Schema, table.Name)
+		if err != nil {
+			return lintCatalog{}, err
+		}
+		if len(size.Rows) > 0 {
+			if rows, ok := valueInt64(rowValue(size.Rows[0], 0)); ok {
+				catalog.rows[key] = rows
+			}
+		}
+		indexes, err := h.runMetadataQuery(ctx, "SELECT DISTINCT COLUMN_NAME AS column_name FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", table.Schema, table.Name)
+		if err != nil {
+			return lintCatalog{}, err
+		}
+		for _, row := range indexes.Rows {
+			catalog.indexed[key+"."+strings.ToLower(valueString(rowValue(row, 0)))] = true
+		}
+	}
+	return catalog, nil
+}
+
+func (h *queryHandler) lint(ctx context.Context, req *mcp.CallToolRequest, input LintInput) (*mcp.CallToolResult, LintOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := LintOutput{}
+	stmt, err := gate.ParseStatement(input.Query)
+	if err != nil {
+		return toolErrorf(empty, "failed to parse query: %v", err)
+	}
+	catalog, err := h.loadLintCatalog(ctx, gate.QueryTables(stmt, h.defaultSchema))
+	if err != nil {
+		return toolErrorf(empty, "failed to read table statistics: %v", err)
+	}
+	return nil, LintOutput{Findings: lintStatement(stmt, h.defaultSchema, catalog)}, nil
+}
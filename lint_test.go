@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func lintRules(t *testing.T, query string, catalog lintCatalog) []string {
+	t.Helper()
+	stmt, err := gate.ParseStatement(query)
+	require.NoError(t, err)
+	var rules []string
+	for _, finding := range lintStatement(stmt, "shop", catalog) {
+		rules = append(rules, finding.Rule+" "+finding.Table+" "+finding.Column)
+	}
+	return rules
+}
+
+func TestLintStatement(t *testing.T) {
+	catalog := lintCatalog{
+		rows:    map[string]int64{"shop.orders": 2000000, "shop.customers": 500},
+		indexed: map[string]bool{"shop.orders.created_at": true, "shop.orders.id": true, "shop.customers.id": true},
+	}
+
+	require.Equal(t, []string{
+		"select_star  ",
+		"no_limit  ",
+		"function_on_indexed_column shop.orders created_at",
+	}, lintRules(t, "SELECT * FROM orders WHERE YEAR(created_at) = 2024", catalog))
+
+	require.Empty(t, lintRules(t, "SELECT id FROM orders WHERE created_at >= '2024-01-01' LIMIT 10", catalog))
+	require.Empty(t, lintRules(t, "SELECT COUNT(*) FROM orders WHERE status = 'open'", catalog))
+	require.Empty(t, lintRules(t, "SELECT id FROM orders LIMIT 5", catalog))
+
+	require.Equal(t, []string{"missing_where shop.orders "}, lintRules(t, "SELECT COUNT(*) FROM orders", catalog))
+	require.Equal(t, []string{"no_limit  ", "missing_where shop.orders "}, lintRules(t, "SELECT status, COUNT(*) FROM shop.orders GROUP BY status", catalog))
+
+	require.Equal(t, []string{"implicit_cross_join  "},
+		lintRules(t, "SELECT o.id FROM orders o, customers c WHERE o.total > 10 LIMIT 5", catalog))
+	require.Empty(t, lintRules(t, "SELECT o.id FROM orders o, customers c WHERE o.customer_id = c.id AND o.total > 10 LIMIT 5", catalog))
+	require.Equal(t, []string{"implicit_cross_join  "},
+		lintRules(t, "SELECT o.id FROM orders o JOIN customers c WHERE o.total > 10 LIMIT 5", catalog))
+
+	require.Equal(t, []string{"function_on_indexed_column shop.customers id"},
+		lintRules(t, "SELECT o.id FROM orders o JOIN customers c ON c.id + 0 = o.customer_id WHERE o.id IN (SELECT order_id FROM returns) LIMIT 5", catalog))
+}
+
+func TestQueryTables(t *testing.T) {
+	stmt, err := gate.ParseStatement("SELECT * FROM orders o JOIN crm.customers c ON c.id = o.customer_id WHERE o.id IN (SELECT order_id FROM Orders)")
+	require.NoError(t, err)
+	require.Equal(t, []gate.Table{{Schema: "shop", Name: "orders"}, {Schema: "crm", Name: "customers"}}, gate.QueryTables(stmt, "shop"))
+}
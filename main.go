@@ -1,75 +1,326 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BurntSushi/toml"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
-	"vitess.io/vitess/go/vt/sqlparser"
+	"mysqlmcp/internal/gate"
 )
 
 type Config struct {
 	Server struct {
 		Name    string `toml:"name"`
 		Version string `toml:"version"`
+		// Instructions is prepended to the generated server instructions.
+		Instructions string `toml:"instructions"`
+		// EnabledTools and EnabledResources, when set, are the only tools and
+		// resources (by name) the server offers.
+		EnabledTools     []string `toml:"enabled_tools"`
+		EnabledResources []string `toml:"enabled_resources"`
 	} `toml:"server"`
 	MySQL struct {
-		DSN                    string   `toml:"dsn"`
-		MaxOpenConns           int      `toml:"max_open_conns"`
-		MaxIdleConns           int      `toml:"max_idle_conns"`
-		ConnMaxLifetimeSeconds int      `toml:"conn_max_lifetime_seconds"`
-		ConnMaxIdleTimeSeconds int      `toml:"conn_max_idle_time_seconds"`
-		QueryTimeoutSeconds    int      `toml:"query_timeout_seconds"`
-		AllowStatementPrefixes []string `toml:"allow_statement_prefixes"`
-		DenySubstrings         []string `toml:"deny_substrings"`
-		MaxRows                int      `toml:"max_rows"`
+		DSN string `toml:"dsn"`
+		// Structured alternative to DSN; see mysqlDriverConfig.
+		Host                     string               `toml:"host"`
+		Port                     int                  `toml:"port"`
+		User                     string               `toml:"user"`
+		Password                 string               `toml:"password"`
+		PasswordFile             string               `toml:"password_file"`
+		PasswordEnv              string               `toml:"password_env"`
+		Database                 string               `toml:"database"`
+		Params                   map[string]string    `toml:"params"`
+		ConnectionAttributes     map[string]string    `toml:"connection_attributes"`
+		TLS                      MySQLTLSConfig       `toml:"tls"`
+		IAM                      MySQLIAMConfig       `toml:"iam"`
+		SSH                      MySQLSSHConfig       `toml:"ssh"`
+		PoolAutotune             PoolAutotuneConfig   `toml:"pool_autotune"`
+		Replicas                 ReplicaPoolConfig    `toml:"replicas"`
+		TableResources           TableResourcesConfig `toml:"table_resources"`
+		Introspection            IntrospectionConfig  `toml:"introspection"`
+		MaxOpenConns             int                  `toml:"max_open_conns"`
+		MaxIdleConns             int                  `toml:"max_idle_conns"`
+		ConnMaxLifetimeSeconds   int                  `toml:"conn_max_lifetime_seconds"`
+		ConnMaxIdleTimeSeconds   int                  `toml:"conn_max_idle_time_seconds"`
+		QueryTimeoutSeconds      int                  `toml:"query_timeout_seconds"`
+		MaxQueryTimeoutSeconds   int                  `toml:"max_query_timeout_seconds"`
+		AllowStatementPrefixes   []string             `toml:"allow_statement_prefixes"`
+		DenySubstrings           []string             `toml:"deny_substrings"`
+		DenyPatterns             []string             `toml:"deny_patterns"`
+		AllowSystemSchemas       bool                 `toml:"allow_system_schemas"`
+		SystemTables             []string             `toml:"system_tables"`
+		DeniedFunctions          []string             `toml:"denied_functions"`
+		MaxRows                  int                  `toml:"max_rows"`
+		MaxResultBytes           int                  `toml:"max_result_bytes"`
+		MaxCellChars             int                  `toml:"max_cell_chars"`
+		SchemaCacheTTLSeconds    int                  `toml:"schema_cache_ttl_seconds"`
+		SchemaPollSeconds        int                  `toml:"schema_poll_seconds"`
+		AttributionComments      bool                 `toml:"attribution_comments"`
+		OmitBlobs                bool                 `toml:"omit_blobs"`
+		EmptyResultHints         bool                 `toml:"empty_result_hints"`
+		SafeIntegers             bool                 `toml:"safe_integers"`
+		ExecutionStats           bool                 `toml:"execution_stats"`
+		ConfirmCostThreshold     float64              `toml:"confirm_cost_threshold"`
+		InitStatements           []string             `toml:"init_statements"`
+		PrivilegeCheck           string               `toml:"privilege_check"`
+		ConnectAttempts          int                  `toml:"connect_attempts"`
+		ConnectBackoffMs         int                  `toml:"connect_backoff_ms"`
+		ConnectBackoffMaxMs      int                  `toml:"connect_backoff_max_ms"`
+		LazyConnect              bool                 `toml:"lazy_connect"`
+		BackupLockRetries        int                  `toml:"backup_lock_retries"`
+		BackupLockBackoffSeconds int                  `toml:"backup_lock_backoff_seconds"`
+		TransientRetries         int                  `toml:"transient_retries"`
+		TransientRetryBackoffMs  int                  `toml:"transient_retry_backoff_ms"`
+		BreakerFailureThreshold  int                  `toml:"breaker_failure_threshold"`
+		BreakerOpenSeconds       int                  `toml:"breaker_open_seconds"`
+		// Recursive CTEs run with a lower recursion depth and timeout.
+		RecursiveCTEMaxDepth       int `toml:"recursive_cte_max_depth"`
+		RecursiveCTETimeoutSeconds int `toml:"recursive_cte_timeout_seconds"`
 	} `toml:"mysql"`
+	Audit struct {
+		BufferSize      int               `toml:"buffer_size"`
+		BatchSize       int               `toml:"batch_size"`
+		FlushIntervalMs int               `toml:"flush_interval_ms"`
+		MaxRetries      int               `toml:"max_retries"`
+		RetryBackoffMs  int               `toml:"retry_backoff_ms"`
+		Sinks           []AuditSinkConfig `toml:"sinks"`
+	} `toml:"audit"`
+	ResultStore struct {
+		MaxEntries  int `toml:"max_entries"`
+		MaxRows     int `toml:"max_rows"`
+		TTLSeconds  int `toml:"ttl_seconds"`
+		LinkBytes   int `toml:"link_bytes"`
+		PreviewRows int `toml:"preview_rows"`
+		EmbedBytes  int `toml:"embed_bytes"`
+	} `toml:"result_store"`
+	Analytics     AnalyticsConfig      `toml:"analytics"`
+	Features      FeaturesConfig       `toml:"features"`
+	Queries       []SavedQueryConfig   `toml:"queries"`
+	RowFilters    []RowFilterConfig    `toml:"row_filters"`
+	DeniedColumns []gate.DeniedColumns `toml:"denied_columns"`
+	SoftDelete    []SoftDeleteConfig   `toml:"soft_delete"`
+	Relations     []RelationConfig     `toml:"relations"`
+	Write         WriteConfig          `toml:"write"`
+	Sensitive     SensitiveConfig      `toml:"sensitive"`
+	Parser        gate.ParserConfig    `toml:"parser"`
+	ViewsOnly     ViewsOnlyConfig      `toml:"views_only"`
+	Access        AccessConfig         `toml:"access"`
+	HTTP          HTTPConfig           `toml:"http"`
+	Policy        PolicyConfig         `toml:"policy"`
+	Tenancy       TenancyConfig        `toml:"tenancy"`
+	PII           PIIConfig            `toml:"pii"`
+	Cache         CacheConfig          `toml:"cache"`
+	// tenant is the tenant the config is bound to, if any.
+	tenant *TenantConfig
 }
 
 type QueryInput struct {
-	Query string `json:"query" jsonschema:"Read-only SQL query (SELECT/SHOW/DESCRIBE/EXPLAIN)."`
+	Query  string `json:"query" jsonschema:"Read-only SQL query (SELECT/SHOW/DESCRIBE/EXPLAIN)."`
+	Format string `json:"format,omitempty" jsonschema:"Text rendering of the result: json, markdown, or csv. Structured content is unaffected."`
+	// MaxBytes and MaxTokensApprox let the host state its context budget; the
+	// smaller of the two (tokens count as 4 bytes) wins.
+	MaxBytes        int    `json:"maxBytes,omitempty" jsonschema:"Approximate byte budget for the whole response. Cells and rows are trimmed to fit."`
+	MaxTokensApprox int    `json:"maxTokensApprox,omitempty" jsonschema:"Approximate token budget for the whole response. Cells and rows are trimmed to fit."`
+	TimeoutSeconds  int    `json:"timeoutSeconds,omitempty" jsonschema:"Query timeout for this call, capped by the server's maximum. Defaults to the server's timeout."`
+	Cursor          string `json:"cursor,omitempty" jsonschema:"nextCursor from a previous call with the same query, to fetch the following page."`
+	Params          []any  `json:"params,omitempty" jsonschema:"Values bound to the query's ? placeholders, in order: strings, numbers, booleans, or null."`
+	SafeIntegers    *bool  `json:"safeIntegers,omitempty" jsonschema:"Return integers outside ±2^53-1 as strings so JSON number parsing can't round them. Defaults to the server's safe_integers setting."`
+	ConfirmToken    string `json:"confirmToken,omitempty" jsonschema:"Token from a confirmation response, to run a query whose estimated cost is over the server's threshold."`
+	IncludeDeleted  bool   `json:"includeDeleted,omitempty" jsonschema:"Also read soft-deleted rows of tables the server hides them for. Mandatory row filters still apply."`
+	NoCache         bool   `json:"noCache,omitempty" jsonschema:"Run the query even if the server has a cached result for it. The fresh result replaces the cached one."`
+	// Experimental switches feature flags for this call; see FeaturesConfig.
+	Experimental map[string]bool `json:"experimental,omitempty" jsonschema:"Feature flags to switch for this call, e.g. {\"execution_stats\": true}. Only flags the server lists as overridable in mysql://server_info are accepted."`
 }
 
 type QueryOutput struct {
-	Columns   []string        `json:"columns" jsonschema:"Column names returned by the query."`
-	Rows      [][]interface{} `json:"rows" jsonschema:"Row values for each column."`
-	RowCount  int             `json:"rowCount" jsonschema:"Number of rows returned in this response."`
-	Truncated bool            `json:"truncated" jsonschema:"True if results were truncated by max_rows."`
+	Columns        []string        `json:"columns" jsonschema:"Column names returned by the query."`
+	Rows           [][]interface{} `json:"rows" jsonschema:"Row values for each column."`
+	RowCount       int             `json:"rowCount" jsonschema:"Number of rows returned in this response."`
+	Truncated      bool            `json:"truncated" jsonschema:"True if results were truncated by max_rows or the response budget."`
+	CellsTruncated bool            `json:"cellsTruncated,omitempty" jsonschema:"True if long cell values were shortened to fit the response budget."`
+	BytesTruncated bool            `json:"bytesTruncated,omitempty" jsonschema:"True if rows were cut off because the result reached the server's max_result_bytes."`
+	// TruncatedCells lists, per row, the values max_cell_chars cut.
+	TruncatedCells []CellTruncation `json:"truncatedCells,omitempty" jsonschema:"Rows with text values cut at the server's max_cell_chars, marked with a trailing ellipsis, and which columns were cut."`
+	// ColumnSources parallels Columns when every column's origin could be resolved.
+	ColumnSources []ColumnSource       `json:"columnSources,omitempty" jsonschema:"Source table or expression for each column, when resolvable."`
+	ColumnTypes   []ColumnType         `json:"columnTypes,omitempty" jsonschema:"MySQL type information for each column."`
+	Error         *QueryError          `json:"error,omitempty" jsonschema:"Category, MySQL error code, and remediation hint when the call failed."`
+	Rejection     *gate.QueryRejection `json:"rejection,omitempty" jsonschema:"Why the read-only gate rejected the query."`
+	Blocked       *QueryBlocked        `json:"blocked,omitempty" jsonschema:"Set when the query failed because a backup holds a global lock."`
+	GroupByIssues []GroupByIssue       `json:"groupByIssues,omitempty" jsonschema:"Columns that ONLY_FULL_GROUP_BY rejected, when the query failed for that reason."`
+	Hints         []string             `json:"hints,omitempty" jsonschema:"Why an empty result may be empty: an empty table, or a condition no row matches on its own."`
+	Warnings      []QueryWarning       `json:"warnings,omitempty" jsonschema:"Warnings the query raised (SHOW WARNINGS), such as truncated values or implicit conversions."`
+	Confirmation  *QueryConfirmation   `json:"confirmation,omitempty" jsonschema:"Set instead of rows when the query's estimated cost needs confirmation before it runs."`
+	Stats         *ExecutionStats      `json:"stats,omitempty" jsonschema:"How the query ran: duration, and with execution_stats enabled, rows examined and index use."`
+	Retries       int                  `json:"retries,omitempty" jsonschema:"How many times the query was run again after a deadlock, lock wait timeout, or lost connection."`
+	ResultID      string               `json:"resultId,omitempty" jsonschema:"ID for referencing this result from mysql_query_with_results."`
+	ResourceURI   string               `json:"resourceUri,omitempty" jsonschema:"Resource holding the full result when only a preview is returned inline."`
+	NextCursor    string               `json:"nextCursor,omitempty" jsonschema:"Pass as cursor with the same query to continue after the last row returned."`
+	PII           []PIIDetection       `json:"pii,omitempty" jsonschema:"Columns whose values look like personal data (emails, phone numbers, card numbers), and whether they were masked."`
+	Cache         string               `json:"cache,omitempty" jsonschema:"hit if the result came from the server's result cache, miss if it was run and cached, bypass if noCache skipped the cache. Absent when the query isn't cacheable."`
+}
+
+// ColumnType parallels Columns. Nullable and Length are omitted when the
+// driver can't report them for the column.
+type ColumnType struct {
+	Type     string `json:"type" jsonschema:"MySQL type name, e.g. VARCHAR, BIGINT, DATETIME."`
+	Nullable *bool  `json:"nullable,omitempty" jsonschema:"Whether the column may contain NULL."`
+	Length   *int64 `json:"length,omitempty" jsonschema:"Maximum length for variable-length text and binary types."`
+}
+
+// BinaryValue stands in for the value of a binary column (BINARY, VARBINARY,
+// BLOB, ...), whose bytes may not be valid UTF-8. Base64 is empty when
+// omit_blobs is set.
+type BinaryValue struct {
+	Base64  string `json:"base64,omitempty"`
+	Bytes   int    `json:"bytes"`
+	Omitted bool   `json:"omitted,omitempty"`
+}
+
+type IndexInfo struct {
+	KeyName     string   `json:"keyName"`
+	Columns     []string `json:"columns"`
+	Unique      bool     `json:"unique"`
+	Cardinality *int64   `json:"cardinality"`
+	IndexType   string   `json:"indexType"`
+}
+
+type IndexesOutput struct {
+	Indexes []IndexInfo `json:"indexes"`
+}
+
+type Relation struct {
+	Name              string   `json:"name"`
+	Table             string   `json:"table"`
+	Columns           []string `json:"columns"`
+	ReferencedSchema  string   `json:"referencedSchema"`
+	ReferencedTable   string   `json:"referencedTable"`
+	ReferencedColumns []string `json:"referencedColumns"`
+	OnUpdate          string   `json:"onUpdate"`
+	OnDelete          string   `json:"onDelete"`
+}
+
+type RelationsOutput struct {
+	Relations []Relation `json:"relations"`
 }
 
 type queryHandler struct {
-	db             *sql.DB
-	config         Config
-	denySubstrings []string
+	db *sql.DB
+	// meta, if set, is the introspection pool for catalog queries.
+	meta     *sql.DB
+	exposure *exposure
+	// mu guards config, validator, rowFilters, softDeletes, tools, and
+	// savedTools, which a config reload replaces. Read them via snapshot.
+	mu            sync.RWMutex
+	config        Config
+	validator     *gate.Validator
+	rowFilters    *rowFilters
+	softDeletes   *rowFilters
+	schema        *schemaCache
+	audit         *auditor
+	results       *resultStore
+	cache         *resultCache
+	active        *activeQueries
+	workload      *workloadLog
+	connections   *connectionSet
+	analytics     *clickhouseClient
+	tools         []string
+	savedTools    []string
+	pool          *poolTuner
+	replicas      *replicaPool
+	confirmations *confirmationStore
+	dbReady       *dbState
+	breaker       *circuitBreaker
+	access        *accessControl
+	policy        *policyHook
+	// tenant is the tenant this handler serves, if any.
+	tenant *TenantConfig
+	// defaultSchema is the DSN's database, which unqualified table names
+	// resolve against.
+	defaultSchema string
 }
 
 var mysqlIdentifierRE = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
 
+const relationsQuery = `SELECT k.CONSTRAINT_NAME AS constraint_name, k.TABLE_NAME AS table_name, k.COLUMN_NAME AS column_name,
+	k.REFERENCED_TABLE_SCHEMA AS referenced_schema, k.REFERENCED_TABLE_NAME AS referenced_table,
+	k.REFERENCED_COLUMN_NAME AS referenced_column, r.UPDATE_RULE AS update_rule, r.DELETE_RULE AS delete_rule
+FROM information_schema.KEY_COLUMN_USAGE k
+JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+	ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME AND r.TABLE_NAME = k.TABLE_NAME
+WHERE k.TABLE_SCHEMA = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION`
+
+// queryParams checks mysql_query's bind values: JSON scalars only, with
+// whole numbers bound as integers rather than doubles.
+func queryParams(params []any) ([]any, error) {
+	args := make([]any, len(params))
+	for i, param := range params {
+		switch value := param.(type) {
+		case nil, string, bool:
+			args[i] = value
+		case float64:
+			if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+				args[i] = int64(value)
+			} else {
+				args[i] = value
+			}
+		default:
+			return nil, fmt.Errorf("param %d: expected a string, number, boolean, or null", i+1)
+		}
+	}
+	return args, nil
+}
+
 func toolErrorResultf(format string, args ...any) (*mcp.CallToolResult, QueryOutput) {
+	// The last error among args is the cause the error is classified by.
+	var cause error
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			cause = err
+		}
+	}
+	message := fmt.Sprintf(format, args...)
 	output := QueryOutput{
 		Columns:   []string{},
 		Rows:      [][]interface{}{},
 		RowCount:  0,
 		Truncated: false,
+		Error:     classifyError(message, cause),
 	}
 	return &mcp.CallToolResult{
-		Content:           []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf(format, args...)}},
+		Content:           []mcp.Content{&mcp.TextContent{Text: message}},
 		StructuredContent: queryOutputToStructuredContent(output),
 		IsError:           true,
 	}, output
 }
 
+// toolErrorf builds an error result for tools with their own output type.
+// output must satisfy the tool's output schema (non-nil slices).
+func toolErrorf[Out any](output Out, format string, args ...any) (*mcp.CallToolResult, Out, error) {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf(format, args...)}},
+		IsError: true,
+	}, output, nil
+}
+
 func queryOutputToStructuredContent(output QueryOutput) map[string]any {
 	columns := make([]any, 0, len(output.Columns))
 	for _, col := range output.Columns {
@@ -85,261 +336,335 @@ func queryOutputToStructuredContent(output QueryOutput) map[string]any {
 		rows = append(rows, rowValues)
 	}
 
-	return map[string]any{
+	structured := map[string]any{
 		"columns":   columns,
 		"rows":      rows,
 		"rowCount":  output.RowCount,
 		"truncated": output.Truncated,
 	}
-}
-
-func normalizeList(values []string) []string {
-	out := make([]string, 0, len(values))
-	for _, value := range values {
-		trimmed := strings.TrimSpace(strings.ToLower(value))
-		if trimmed != "" {
-			out = append(out, trimmed)
-		}
+	if len(output.ColumnSources) > 0 {
+		structured["columnSources"] = output.ColumnSources
 	}
-	return out
-}
-
-func isReadOnlyQuery(query string, denySubstrings []string) bool {
-	trimmed := strings.TrimSpace(query)
-	normalized := strings.ToLower(trimmed)
-	if normalized == "" {
-		return false
+	if len(output.ColumnTypes) > 0 {
+		structured["columnTypes"] = output.ColumnTypes
 	}
-	if strings.Contains(normalized, ";") {
-		if !strings.HasSuffix(normalized, ";") {
-			return false
-		}
-		if strings.Count(normalized, ";") != 1 {
-			return false
-		}
-		trimmed = strings.TrimSpace(trimmed[:len(trimmed)-1])
-		normalized = strings.ToLower(trimmed)
-		if normalized == "" {
-			return false
-		}
+	if output.CellsTruncated {
+		structured["cellsTruncated"] = true
 	}
-	for _, fragment := range denySubstrings {
-		if fragment != "" && strings.Contains(normalized, fragment) {
-			return false
-		}
+	if output.BytesTruncated {
+		structured["bytesTruncated"] = true
 	}
-	parser, err := sqlparser.New(sqlparser.Options{})
-	if err != nil {
-		return false
+	if len(output.TruncatedCells) > 0 {
+		structured["truncatedCells"] = output.TruncatedCells
 	}
-	stmt, err := parser.Parse(trimmed)
-	if err != nil {
-		return false
+	if output.Error != nil {
+		structured["error"] = output.Error
 	}
-	switch stmt.(type) {
-	case *sqlparser.Select, *sqlparser.Union, *sqlparser.Show, sqlparser.Explain:
-		return true
-	default:
-		return false
+	if output.Rejection != nil {
+		structured["rejection"] = output.Rejection
+	}
+	if output.Blocked != nil {
+		structured["blocked"] = output.Blocked
+	}
+	if len(output.GroupByIssues) > 0 {
+		structured["groupByIssues"] = output.GroupByIssues
+	}
+	if len(output.Hints) > 0 {
+		structured["hints"] = output.Hints
+	}
+	if len(output.Warnings) > 0 {
+		structured["warnings"] = output.Warnings
+	}
+	if output.Confirmation != nil {
+		structured["confirmation"] = output.Confirmation
+	}
+	if output.Stats != nil {
+		structured["stats"] = output.Stats
+	}
+	if output.Retries > 0 {
+		structured["retries"] = output.Retries
+	}
+	if output.ResultID != "" {
+		structured["resultId"] = output.ResultID
+	}
+	if output.ResourceURI != "" {
+		structured["resourceUri"] = output.ResourceURI
 	}
+	if output.NextCursor != "" {
+		structured["nextCursor"] = output.NextCursor
+	}
+	if output.Cache != "" {
+		structured["cache"] = output.Cache
+	}
+	if len(output.PII) > 0 {
+		structured["pii"] = output.PII
+	}
+	return structured
 }
 
 func (h *queryHandler) runQuery(ctx context.Context, req *mcp.CallToolRequest, input QueryInput) (*mcp.CallToolResult, QueryOutput, error) {
-	if !isReadOnlyQuery(input.Query, h.denySubstrings) {
-		result, output := toolErrorResultf("only read-only queries are allowed")
+	cfg := h.snapshot().config.MySQL
+	return retryTransient(ctx, cfg.TransientRetries, time.Duration(cfg.TransientRetryBackoffMs)*time.Millisecond, func() (*mcp.CallToolResult, QueryOutput, error) {
+		return retryBlocked(ctx, cfg.BackupLockRetries, time.Duration(cfg.BackupLockBackoffSeconds)*time.Second, func() (*mcp.CallToolResult, QueryOutput, error) {
+			return h.runQueryOnce(ctx, req, input)
+		})
+	})
+}
+
+func (h *queryHandler) runQueryOnce(ctx context.Context, req *mcp.CallToolRequest, input QueryInput) (result *mcp.CallToolResult, output QueryOutput, err error) {
+	start := time.Now()
+	rejected := false
+	defer func() {
+		h.auditToolCall(req, "mysql_query", input.Query, rejected, start, result, output)
+	}()
+
+	ctx = withAttribution(ctx, req.Session)
+	live := h.snapshot()
+	if err := live.validator.Validate(input.Query); err != nil {
+		rejected = true
+		result, output := toolErrorResultf("only read-only queries are allowed: %v", err)
+		output.Rejection, _ = err.(*gate.QueryRejection)
+		result.StructuredContent = queryOutputToStructuredContent(output)
 		return result, output, nil
 	}
-
-	timeout := time.Duration(h.config.MySQL.QueryTimeoutSeconds) * time.Second
-	if timeout <= 0 {
-		timeout = 30 * time.Second
+	if err := h.authorize(ctx, input.Query); err != nil {
+		rejected = true
+		result, output := toolErrorResultf("query not allowed: %v", err)
+		output.Rejection, _ = err.(*gate.QueryRejection)
+		result.StructuredContent = queryOutputToStructuredContent(output)
+		return result, output, nil
 	}
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	conn, err := h.db.Conn(ctx)
-	if err != nil {
-		result, output := toolErrorResultf("failed to acquire connection: %v", err)
+	if !validFormat(input.Format) {
+		result, output := toolErrorResultf("unknown format %q: expected json, markdown, or csv", input.Format)
 		return result, output, nil
 	}
-	defer conn.Close()
-
-	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	flags, err := resolveFeatures(live.config, input.Experimental)
 	if err != nil {
-		result, output := toolErrorResultf("failed to start read-only transaction: %v", err)
+		result, output := toolErrorResultf("invalid experimental flags: %v", err)
 		return result, output, nil
 	}
-
-	rows, err := tx.QueryContext(ctx, input.Query)
+	args, err := queryParams(input.Params)
 	if err != nil {
-		_ = tx.Rollback()
-		result, output := toolErrorResultf("query failed: %v", err)
+		result, output := toolErrorResultf("invalid params: %v", err)
 		return result, output, nil
 	}
-	defer rows.Close()
-
-	columns, err := rows.Columns()
-	if err != nil {
-		_ = tx.Rollback()
-		result, output := toolErrorResultf("failed to fetch columns: %v", err)
+	if err := h.checkPolicy(ctx, input.Query, args...); err != nil {
+		rejected = true
+		result, output := toolErrorResultf("query not allowed: %v", err)
+		output.Rejection, _ = err.(*gate.QueryRejection)
+		result.StructuredContent = queryOutputToStructuredContent(output)
 		return result, output, nil
 	}
-	if columns == nil {
-		columns = []string{}
+	if stmt, err := gate.ParseStatement(input.Query); err == nil && readsRows(stmt) {
+		if err := h.confirmSensitive(ctx, input.Query, stmt, false); err != nil {
+			rejected = true
+			result, output := toolErrorResultf("query not run: %v", err)
+			return result, output, nil
+		}
 	}
-
-	maxRows := h.config.MySQL.MaxRows
-	if maxRows <= 0 {
-		maxRows = 1000
+	if threshold := live.config.MySQL.ConfirmCostThreshold; threshold > 0 {
+		if result, output, ok := h.confirmationCheck(ctx, req, input, args, threshold); !ok {
+			return result, output, nil
+		}
 	}
+	defer h.active.begin(ctx, "mysql_query", input.Query)()
 
-	results := make([][]interface{}, 0)
-	rowCount := 0
-	truncated := false
-	for rows.Next() {
-		if rowCount >= maxRows {
-			truncated = true
-			break
+	// The engine applies the timeout to the query itself; this bounds the
+	// catalog lookups before it.
+	ctx, cancel := context.WithTimeout(ctx, h.queryTimeout(input.TimeoutSeconds))
+	defer cancel()
+
+	// Resolve provenance before holding a connection: cache misses query the
+	// catalog through the same pool.
+	sources := h.columnSources(ctx, input.Query)
+
+	var run string
+	plan := h.keysetPlan(ctx, input.Query)
+	if plan != nil {
+		var after []any
+		if input.Cursor != "" {
+			after, err = decodeCursor(input.Cursor, queryFingerprint(input.Query))
+			if err != nil {
+				result, output := toolErrorResultf("invalid cursor: %v", err)
+				return result, output, nil
+			}
 		}
-		values := make([]interface{}, len(columns))
-		dest := make([]interface{}, len(columns))
-		for i := range values {
-			dest[i] = &values[i]
+		// The key predicate is appended to WHERE, and a pageable query has
+		// no ORDER BY, GROUP BY, or LIMIT, so its placeholders come last.
+		var keyArgs []any
+		run, keyArgs = plan.rewrite(after)
+		args = append(args, keyArgs...)
+	} else if input.Cursor != "" {
+		result, output := toolErrorResultf("cursor paging needs a single-table SELECT on a table with a primary key, without LIMIT, GROUP BY, or DISTINCT, ordered by nothing or by the primary key")
+		return result, output, nil
+	}
+	maxRows := h.maxRows(ctx)
+
+	// Execution stats describe one run, so a call asking for them isn't
+	// answered from the cache.
+	var cacheKey, cacheStatus string
+	var cacheReads []watchedTableKey
+	if stmt, err := gate.ParseStatement(input.Query); err == nil && h.cache != nil && cacheable(stmt) && !flags["execution_stats"] {
+		cacheKey = resultCacheKey(cmp.Or(run, input.Query), args, maxRows, input.IncludeDeleted, flags, h.access.mysqlRoles(ctx))
+		cacheReads = cacheTables(stmt, h.defaultSchema)
+		cacheStatus = cacheBypass
+		if !input.NoCache {
+			if cached, ok := h.cache.get(cacheKey); ok {
+				cached.Cache = cacheHit
+				cached.Stats = nil
+				h.workload.record(input.Query, time.Now())
+				return h.queryResponse(req, input, plan, cached)
+			}
+			cacheStatus = cacheMiss
 		}
-		if err := rows.Scan(dest...); err != nil {
-			_ = tx.Rollback()
-			result, output := toolErrorResultf("failed to read row: %v", err)
+	}
+
+	var queryTime time.Duration
+	output, err = h.executeReadOnly(ctx, input.Query, readOptions{
+		args:           args,
+		run:            run,
+		includeDeleted: input.IncludeDeleted,
+		maxRows:        maxRows,
+		timeoutSeconds: input.TimeoutSeconds,
+		warnings:       true,
+		afterCommit: func(ctx context.Context, conn *sql.Conn, elapsed time.Duration, output *QueryOutput) {
+			queryTime = elapsed
+			// Read the statement history before the empty-result probes
+			// add their own SELECTs to it.
+			output.Stats = newExecutionStats(elapsed)
+			if flags["execution_stats"] && isSelectQuery(input.Query) {
+				addStatementStats(ctx, conn, output.Stats)
+			}
+			if output.RowCount == 0 && flags["empty_result_hints"] {
+				output.Hints = h.emptyResultHints(ctx, conn, func(query string) (string, error) {
+					return live.applyFilters(query, input.IncludeDeleted)
+				}, input.Query)
+			}
+			if len(sources) == len(output.Columns) {
+				output.ColumnSources = sources
+			}
+		},
+	})
+	if err != nil {
+		var runErr *queryRunError
+		if errors.As(err, &runErr) {
+			result, output := h.queryFailure(ctx, runErr.prefix, input.Query, runErr.err)
 			return result, output, nil
 		}
-		for i := range values {
-			values[i] = normalizeValue(values[i])
-		}
-		results = append(results, values)
-		rowCount++
-	}
-	if err := rows.Err(); err != nil {
-		_ = tx.Rollback()
-		result, output := toolErrorResultf("row iteration failed: %v", err)
+		result, output := toolErrorResultf("%v", err)
 		return result, output, nil
 	}
-
-	if err := tx.Commit(); err != nil {
-		result, output := toolErrorResultf("failed to finish transaction: %v", err)
-		return result, output, nil
+	if cacheKey != "" {
+		h.cache.put(cacheKey, cacheReads, output)
+		output.Cache = cacheStatus
 	}
+	h.workload.record(input.Query, time.Now())
+	h.pool.observe(queryTime)
+	return h.queryResponse(req, input, plan, output)
+}
 
-	output := QueryOutput{
-		Columns:   columns,
-		Rows:      results,
-		RowCount:  rowCount,
-		Truncated: truncated,
+// queryResponse stores output for mysql_query_with_results and renders it
+// within the call's budget, from a fresh run or the cache.
+func (h *queryHandler) queryResponse(req *mcp.CallToolRequest, input QueryInput, plan *keysetPlan, output QueryOutput) (*mcp.CallToolResult, QueryOutput, error) {
+	output.ResultID = h.results.put(sessionIDFor(req.Session), output)
+	output, extra := h.largeResult(output)
+
+	format := input.Format
+	if budget := budgetBytes(input.MaxBytes, input.MaxTokensApprox); budget > 0 {
+		output, format = fitToBudget(output, format, budget)
 	}
-	if output.Columns == nil {
-		output.Columns = []string{}
+	if plan != nil && output.Truncated {
+		if key, ok := plan.nextKey(output); ok {
+			output.NextCursor = encodeCursor(queryFingerprint(input.Query), key)
+		}
 	}
-	if output.Rows == nil {
-		output.Rows = [][]interface{}{}
+	if h.safeIntegers(input.SafeIntegers) {
+		output = withSafeIntegers(output)
+	}
+	text, err := renderText(output, format)
+	if err != nil {
+		result, output := toolErrorResultf("failed to render result: %v", err)
+		return result, output, nil
+	}
+	content := []mcp.Content{&mcp.TextContent{Text: text}}
+	if len(output.Warnings) > 0 {
+		lines := make([]string, len(output.Warnings))
+		for i, w := range output.Warnings {
+			lines[i] = w.String()
+		}
+		content = append(content, &mcp.TextContent{Text: "Warnings:\n- " + strings.Join(lines, "\n- ")})
+	}
+	if len(output.Hints) > 0 {
+		content = append(content, &mcp.TextContent{Text: "Hints:\n- " + strings.Join(output.Hints, "\n- ")})
 	}
-
 	return &mcp.CallToolResult{
-		Content:           []mcp.Content{&mcp.TextContent{Text: "ok"}},
+		Content:           append(content, extra...),
 		StructuredContent: queryOutputToStructuredContent(output),
 	}, output, nil
 }
 
-func (h *queryHandler) runQueryForResource(ctx context.Context, query string) (QueryOutput, error) {
-	if !isReadOnlyQuery(query, h.denySubstrings) {
-		return QueryOutput{}, fmt.Errorf("only read-only queries are allowed")
-	}
-
-	timeout := time.Duration(h.config.MySQL.QueryTimeoutSeconds) * time.Second
+// queryTimeout returns the timeout for a query. requested (in seconds) is a
+// per-call override, capped at max_query_timeout_seconds; 0 or less means the
+// configured default.
+func (h *queryHandler) queryTimeout(requested int) time.Duration {
+	cfg := h.snapshot().config.MySQL
+	timeout := time.Duration(cfg.QueryTimeoutSeconds) * time.Second
 	if timeout <= 0 {
 		timeout = 30 * time.Second
 	}
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	conn, err := h.db.Conn(ctx)
-	if err != nil {
-		return QueryOutput{}, fmt.Errorf("failed to acquire connection: %w", err)
+	if requested <= 0 {
+		return timeout
 	}
-	defer conn.Close()
-
-	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
-	if err != nil {
-		return QueryOutput{}, fmt.Errorf("failed to start read-only transaction: %w", err)
+	maxTimeout := time.Duration(cfg.MaxQueryTimeoutSeconds) * time.Second
+	if maxTimeout < timeout {
+		maxTimeout = timeout
 	}
+	return min(time.Duration(requested)*time.Second, maxTimeout)
+}
 
-	rows, err := tx.QueryContext(ctx, query)
-	if err != nil {
-		_ = tx.Rollback()
-		return QueryOutput{}, fmt.Errorf("query failed: %w", err)
-	}
-	defer rows.Close()
+func (h *queryHandler) runQueryForResource(ctx context.Context, query string, args ...any) (QueryOutput, error) {
+	return h.runQueryOn(ctx, nil, false, query, args...)
+}
 
-	columns, err := rows.Columns()
-	if err != nil {
-		_ = tx.Rollback()
-		return QueryOutput{}, fmt.Errorf("failed to fetch columns: %w", err)
-	}
-	if columns == nil {
-		columns = []string{}
-	}
+// runMetadataQuery is runQueryForResource for catalog queries, which run on
+// the introspection pool when one is configured and may read system schemas.
+func (h *queryHandler) runMetadataQuery(ctx context.Context, query string, args ...any) (QueryOutput, error) {
+	return h.runQueryOn(ctx, h.metadataDB(), true, query, args...)
+}
 
-	maxRows := h.config.MySQL.MaxRows
-	if maxRows <= 0 {
-		maxRows = 1000
+// runQueryOn puts query through the gate and, unless it's a catalog query,
+// the caller's role and policy, then runs it on db, or with a nil db on a
+// pool readDB picks.
+func (h *queryHandler) runQueryOn(ctx context.Context, db *sql.DB, catalog bool, query string, args ...any) (QueryOutput, error) {
+	live := h.snapshot()
+	validate := live.validator.Validate
+	if catalog {
+		validate = live.validator.ValidateCatalog
 	}
-
-	results := make([][]interface{}, 0)
-	rowCount := 0
-	truncated := false
-	for rows.Next() {
-		if rowCount >= maxRows {
-			truncated = true
-			break
-		}
-		values := make([]interface{}, len(columns))
-		dest := make([]interface{}, len(columns))
-		for i := range values {
-			dest[i] = &values[i]
-		}
-		if err := rows.Scan(dest...); err != nil {
-			_ = tx.Rollback()
-			return QueryOutput{}, fmt.Errorf("failed to read row: %w", err)
+	if err := validate(query); err != nil {
+		return QueryOutput{}, fmt.Errorf("only read-only queries are allowed: %w", err)
+	}
+	if !catalog {
+		if err := h.authorize(ctx, query); err != nil {
+			return QueryOutput{}, fmt.Errorf("query not allowed: %w", err)
 		}
-		for i := range values {
-			values[i] = normalizeValue(values[i])
+		if err := h.checkPolicy(ctx, query, args...); err != nil {
+			return QueryOutput{}, fmt.Errorf("query not allowed: %w", err)
 		}
-		results = append(results, values)
-		rowCount++
-	}
-	if err := rows.Err(); err != nil {
-		_ = tx.Rollback()
-		return QueryOutput{}, fmt.Errorf("row iteration failed: %w", err)
-	}
-
-	if err := tx.Commit(); err != nil {
-		return QueryOutput{}, fmt.Errorf("failed to finish transaction: %w", err)
 	}
-
-	output := QueryOutput{
-		Columns:   columns,
-		Rows:      results,
-		RowCount:  rowCount,
-		Truncated: truncated,
-	}
-	if output.Columns == nil {
-		output.Columns = []string{}
-	}
-	if output.Rows == nil {
-		output.Rows = [][]interface{}{}
+	if stmt, err := gate.ParseStatement(query); err == nil && readsRows(stmt) {
+		if err := h.confirmSensitive(ctx, query, stmt, false); err != nil {
+			return QueryOutput{}, fmt.Errorf("query not run: %w", err)
+		}
 	}
-
-	return output, nil
+	return h.executeReadOnly(ctx, query, readOptions{db: db, args: args, catalog: catalog})
 }
 
 func (h *queryHandler) readResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	ctx = withGTIDScope(withAttribution(ctx, req.Session))
+	if err := h.access.allow(ctx, time.Now()); err != nil {
+		return nil, err
+	}
 	uri := req.Params.URI
 	u, err := url.Parse(uri)
 	if err != nil {
@@ -356,7 +681,11 @@ func (h *queryHandler) readResource(ctx context.Context, req *mcp.ReadResourceRe
 		pathParts = strings.Split(trimmedPath, "/")
 	}
 
+	// db and table name what the resource describes, for the caller's role.
+	var db, table string
 	var query string
+	var args []any
+	transform := func(out QueryOutput) any { return out }
 	switch host {
 	case "databases":
 		if len(pathParts) != 0 {
@@ -367,7 +696,7 @@ func (h *queryHandler) readResource(ctx context.Context, req *mcp.ReadResourceRe
 		if len(pathParts) != 1 {
 			return nil, mcp.ResourceNotFoundError(uri)
 		}
-		db := pathParts[0]
+		db = pathParts[0]
 		if !mysqlIdentifierRE.MatchString(db) {
 			return nil, mcp.ResourceNotFoundError(uri)
 		}
@@ -376,22 +705,103 @@ func (h *queryHandler) readResource(ctx context.Context, req *mcp.ReadResourceRe
 		if len(pathParts) != 2 {
 			return nil, mcp.ResourceNotFoundError(uri)
 		}
-		db := pathParts[0]
-		table := pathParts[1]
+		db = pathParts[0]
+		table = pathParts[1]
 		if !mysqlIdentifierRE.MatchString(db) || !mysqlIdentifierRE.MatchString(table) {
 			return nil, mcp.ResourceNotFoundError(uri)
 		}
 		query = fmt.Sprintf("DESCRIBE `%s`.`%s`", db, table)
+	case "indexes":
+		if len(pathParts) != 2 {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		db = pathParts[0]
+		table = pathParts[1]
+		if !mysqlIdentifierRE.MatchString(db) || !mysqlIdentifierRE.MatchString(table) {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		query = fmt.Sprintf("SHOW INDEX FROM `%s`.`%s`", db, table)
+		transform = func(out QueryOutput) any {
+			return IndexesOutput{Indexes: buildIndexInfo(out)}
+		}
+	case "ddl":
+		if len(pathParts) != 2 {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		db = pathParts[0]
+		table = pathParts[1]
+		if !mysqlIdentifierRE.MatchString(db) || !mysqlIdentifierRE.MatchString(table) {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		query = fmt.Sprintf("SHOW CREATE TABLE `%s`.`%s`", db, table)
+		transform = func(out QueryOutput) any {
+			return ShowCreateOutput{Database: db, Table: table, DDL: createStatement(out)}
+		}
+	case "relations":
+		if len(pathParts) != 1 {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		db = pathParts[0]
+		if !mysqlIdentifierRE.MatchString(db) {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		query = relationsQuery
+		args = []any{db}
+		transform = func(out QueryOutput) any {
+			return RelationsOutput{Relations: buildRelations(out)}
+		}
+	case "server_info":
+		if len(pathParts) != 0 {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		return jsonResourceResult(uri, h.serverInfo(time.Now()))
+	case "active":
+		if len(pathParts) != 0 {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		return jsonResourceResult(uri, ActiveQueriesOutput{Queries: h.active.snapshot(time.Now())})
+	case "results":
+		if len(pathParts) != 1 {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		return h.readStoredResult(uri, sessionIDFor(req.Session), pathParts[0])
+	case "views", "routines", "triggers", "events":
+		if len(pathParts) != 1 {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		db = pathParts[0]
+		if !mysqlIdentifierRE.MatchString(db) {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		if host == "views" {
+			if err := h.access.authorizeTable(ctx, db, ""); err != nil {
+				return nil, err
+			}
+			return h.readViews(ctx, uri, db)
+		}
+		query = schemaObjectQueries[host]
+		args = []any{db}
 	default:
 		return nil, mcp.ResourceNotFoundError(uri)
 	}
 
-	out, err := h.runQueryForResource(ctx, query)
+	if db != "" {
+		if err := h.access.authorizeTable(ctx, db, table); err != nil {
+			return nil, err
+		}
+	}
+	if err := h.dbAvailable(); err != nil {
+		return nil, err
+	}
+	out, err := h.runMetadataQuery(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	return jsonResourceResult(uri, transform(out))
+}
 
-	encoded, err := json.Marshal(out)
+func jsonResourceResult(uri string, value any) (*mcp.ReadResourceResult, error) {
+	encoded, err := json.Marshal(value)
 	if err != nil {
 		return nil, err
 	}
@@ -405,6 +815,186 @@ func (h *queryHandler) readResource(ctx context.Context, req *mcp.ReadResourceRe
 	}, nil
 }
 
+// buildIndexInfo groups SHOW INDEX rows (one per indexed column) into one
+// entry per index, preserving the order MySQL reports them in.
+func buildIndexInfo(out QueryOutput) []IndexInfo {
+	keyCol := columnIndex(out.Columns, "Key_name")
+	seqCol := columnIndex(out.Columns, "Seq_in_index")
+	nameCol := columnIndex(out.Columns, "Column_name")
+	exprCol := columnIndex(out.Columns, "Expression")
+	nonUniqueCol := columnIndex(out.Columns, "Non_unique")
+	cardinalityCol := columnIndex(out.Columns, "Cardinality")
+	typeCol := columnIndex(out.Columns, "Index_type")
+
+	indexes := make([]IndexInfo, 0)
+	positions := make(map[string]int)
+	for _, row := range out.Rows {
+		key := valueString(rowValue(row, keyCol))
+		pos, ok := positions[key]
+		if !ok {
+			nonUnique, _ := valueInt64(rowValue(row, nonUniqueCol))
+			indexes = append(indexes, IndexInfo{
+				KeyName:   key,
+				Columns:   []string{},
+				Unique:    nonUnique == 0,
+				IndexType: valueString(rowValue(row, typeCol)),
+			})
+			pos = len(indexes) - 1
+			positions[key] = pos
+		}
+		column := valueString(rowValue(row, nameCol))
+		if rowValue(row, nameCol) == nil {
+			column = valueString(rowValue(row, exprCol))
+		}
+		indexes[pos].Columns = append(indexes[pos].Columns, column)
+		// Cardinality is reported per column prefix; the last column in the
+		// sequence carries the estimate for the full index.
+		if seq, _ := valueInt64(rowValue(row, seqCol)); seq >= int64(len(indexes[pos].Columns)) {
+			if cardinality, ok := valueInt64(rowValue(row, cardinalityCol)); ok {
+				indexes[pos].Cardinality = &cardinality
+			}
+		}
+	}
+	return indexes
+}
+
+// buildRelations groups foreign key column rows (ordered by table, constraint,
+// and ordinal position) into one entry per constraint.
+func buildRelations(out QueryOutput) []Relation {
+	nameCol := columnIndex(out.Columns, "constraint_name")
+	tableCol := columnIndex(out.Columns, "table_name")
+	columnCol := columnIndex(out.Columns, "column_name")
+	refSchemaCol := columnIndex(out.Columns, "referenced_schema")
+	refTableCol := columnIndex(out.Columns, "referenced_table")
+	refColumnCol := columnIndex(out.Columns, "referenced_column")
+	updateCol := columnIndex(out.Columns, "update_rule")
+	deleteCol := columnIndex(out.Columns, "delete_rule")
+
+	relations := make([]Relation, 0)
+	positions := make(map[string]int)
+	for _, row := range out.Rows {
+		table := valueString(rowValue(row, tableCol))
+		name := valueString(rowValue(row, nameCol))
+		key := table + "." + name
+		pos, ok := positions[key]
+		if !ok {
+			relations = append(relations, Relation{
+				Name:              name,
+				Table:             table,
+				Columns:           []string{},
+				ReferencedSchema:  valueString(rowValue(row, refSchemaCol)),
+				ReferencedTable:   valueString(rowValue(row, refTableCol)),
+				ReferencedColumns: []string{},
+				OnUpdate:          valueString(rowValue(row, updateCol)),
+				OnDelete:          valueString(rowValue(row, deleteCol)),
+			})
+			pos = len(relations) - 1
+			positions[key] = pos
+		}
+		relations[pos].Columns = append(relations[pos].Columns, valueString(rowValue(row, columnCol)))
+		relations[pos].ReferencedColumns = append(relations[pos].ReferencedColumns, valueString(rowValue(row, refColumnCol)))
+	}
+	return relations
+}
+
+func columnIndex(columns []string, name string) int {
+	for i, col := range columns {
+		if strings.EqualFold(col, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func rowValue(row []interface{}, i int) interface{} {
+	if i < 0 || i >= len(row) {
+		return nil
+	}
+	return row[i]
+}
+
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case BinaryValue:
+		b, _ := base64.StdEncoding.DecodeString(v.Base64)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func valueInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// columnTypeInfo is the subset of *sql.ColumnType used to describe columns.
+type columnTypeInfo interface {
+	DatabaseTypeName() string
+	Nullable() (nullable, ok bool)
+	Length() (length int64, ok bool)
+}
+
+func buildColumnTypes[T columnTypeInfo](types []T) []ColumnType {
+	out := make([]ColumnType, 0, len(types))
+	for _, ct := range types {
+		info := ColumnType{Type: ct.DatabaseTypeName()}
+		if nullable, ok := ct.Nullable(); ok {
+			info.Nullable = &nullable
+		}
+		if length, ok := ct.Length(); ok {
+			info.Length = &length
+		}
+		out = append(out, info)
+	}
+	return out
+}
+
+var binaryTypeNames = map[string]bool{
+	"BINARY":     true,
+	"VARBINARY":  true,
+	"TINYBLOB":   true,
+	"BLOB":       true,
+	"MEDIUMBLOB": true,
+	"LONGBLOB":   true,
+	"GEOMETRY":   true,
+	"BIT":        true,
+}
+
+// normalizeColumnValue is normalizeValue for a column of known type: bytes
+// from binary columns are base64 encoded (or omitted) instead of being
+// reinterpreted as text.
+func normalizeColumnValue(value interface{}, colType ColumnType, omitBinary bool) interface{} {
+	b, ok := value.([]byte)
+	if !ok || !binaryTypeNames[colType.Type] {
+		return normalizeValue(value)
+	}
+	if omitBinary {
+		return BinaryValue{Bytes: len(b), Omitted: true}
+	}
+	return BinaryValue{Base64: base64.StdEncoding.EncodeToString(b), Bytes: len(b)}
+}
+
 func normalizeValue(value interface{}) interface{} {
 	switch v := value.(type) {
 	case nil:
@@ -432,32 +1022,166 @@ func loadConfig(path string) (Config, error) {
 	if len(cfg.MySQL.AllowStatementPrefixes) == 0 {
 		cfg.MySQL.AllowStatementPrefixes = []string{"select", "show", "describe", "explain"}
 	}
-	if len(cfg.MySQL.DenySubstrings) == 0 {
-		cfg.MySQL.DenySubstrings = []string{" into outfile", " into dumpfile", " for update", " lock in share mode"}
+	if cfg.Audit.BufferSize <= 0 {
+		cfg.Audit.BufferSize = 1000
+	}
+	if cfg.Audit.BatchSize <= 0 {
+		cfg.Audit.BatchSize = 100
+	}
+	if cfg.Audit.FlushIntervalMs <= 0 {
+		cfg.Audit.FlushIntervalMs = 1000
+	}
+	if cfg.Audit.MaxRetries == 0 {
+		cfg.Audit.MaxRetries = 3
+	}
+	if cfg.Audit.MaxRetries < 0 {
+		cfg.Audit.MaxRetries = 0
+	}
+	if cfg.Audit.RetryBackoffMs <= 0 {
+		cfg.Audit.RetryBackoffMs = 500
+	}
+	switch cfg.MySQL.PrivilegeCheck {
+	case "":
+		cfg.MySQL.PrivilegeCheck = privilegeCheckWarn
+	case privilegeCheckWarn, privilegeCheckRefuse, privilegeCheckOff:
+	default:
+		return cfg, fmt.Errorf("mysql.privilege_check must be %q, %q, or %q", privilegeCheckWarn, privilegeCheckRefuse, privilegeCheckOff)
+	}
+	if cfg.MySQL.BackupLockBackoffSeconds <= 0 {
+		cfg.MySQL.BackupLockBackoffSeconds = 30
+	}
+	if cfg.Write.MaxAffectedRows <= 0 {
+		cfg.Write.MaxAffectedRows = 1000
+	}
+	if err := validateWriteConfig(cfg); err != nil {
+		return cfg, err
+	}
+	if err := validateSensitiveConfig(cfg.Sensitive); err != nil {
+		return cfg, err
+	}
+	if _, err := gate.NewDialect(cfg.Parser); err != nil {
+		return cfg, err
+	}
+	if _, err := gate.CompileDenyPatterns(cfg.MySQL.DenyPatterns); err != nil {
+		return cfg, err
+	}
+	if err := gate.ValidateSystemTables(cfg.MySQL.SystemTables); err != nil {
+		return cfg, err
+	}
+	if err := validateViewsOnlyConfig(cfg.ViewsOnly); err != nil {
+		return cfg, err
+	}
+	if err := validateAccessConfig(cfg.Access); err != nil {
+		return cfg, err
+	}
+	if err := validateHTTPConfig(cfg.HTTP); err != nil {
+		return cfg, err
+	}
+	if err := validatePolicyConfig(cfg.Policy); err != nil {
+		return cfg, err
+	}
+	if err := validateTenancyConfig(cfg); err != nil {
+		return cfg, err
+	}
+	if err := validatePIIConfig(cfg.PII); err != nil {
+		return cfg, err
+	}
+	if err := loadPIIKey(&cfg.PII); err != nil {
+		return cfg, err
+	}
+	if err := validateCacheConfig(cfg.Cache); err != nil {
+		return cfg, err
+	}
+	if cfg.MySQL.TransientRetryBackoffMs <= 0 {
+		cfg.MySQL.TransientRetryBackoffMs = 100
+	}
+	if cfg.MySQL.BreakerOpenSeconds <= 0 {
+		cfg.MySQL.BreakerOpenSeconds = 30
+	}
+	if cfg.MySQL.ConnectAttempts <= 0 {
+		cfg.MySQL.ConnectAttempts = 1
+	}
+	if cfg.MySQL.ConnectBackoffMs <= 0 {
+		cfg.MySQL.ConnectBackoffMs = 500
+	}
+	if cfg.MySQL.ConnectBackoffMaxMs <= 0 {
+		cfg.MySQL.ConnectBackoffMaxMs = 10000
+	}
+	if cfg.ResultStore.MaxEntries <= 0 {
+		cfg.ResultStore.MaxEntries = 100
+	}
+	if cfg.ResultStore.MaxRows <= 0 {
+		cfg.ResultStore.MaxRows = 1000
+	}
+	if cfg.ResultStore.TTLSeconds <= 0 {
+		cfg.ResultStore.TTLSeconds = 900
+	}
+	if cfg.ResultStore.PreviewRows <= 0 {
+		cfg.ResultStore.PreviewRows = 10
 	}
 	return cfg, nil
 }
 
 func main() {
 	configPath := flag.String("config", "config.toml", "path to TOML config")
+	simulateAudit := flag.String("simulate-audit", "", "replay queries from this audit log against -proposed-config, report what changes, and exit")
+	proposedPath := flag.String("proposed-config", "", "proposed TOML config for -simulate-audit")
 	flag.Parse()
 
+	if *simulateAudit != "" {
+		if *proposedPath == "" {
+			fmt.Fprintln(os.Stderr, "-simulate-audit requires -proposed-config")
+			os.Exit(2)
+		}
+		if err := runPolicySimulation(*configPath, *proposedPath, *simulateAudit, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "policy simulation failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	cfg, err := loadConfig(*configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to load config %q: %v\n", *configPath, err)
 		os.Exit(1)
 	}
-
-	if cfg.MySQL.DSN == "" {
-		fmt.Fprintln(os.Stderr, "mysql.dsn is required in config")
+	if err := gate.SetDialect(cfg.Parser); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid parser config: %v\n", err)
 		os.Exit(1)
 	}
 
-	db, err := sql.Open("mysql", cfg.MySQL.DSN)
+	servers, handlers, err := newServers(cfg, *configPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to open mysql connection: %v\n", err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	runErr := serve(servers, cfg)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	for _, h := range handlers {
+		h.audit.close(shutdownCtx)
+	}
+	cancelShutdown()
+
+	if runErr != nil {
+		log.Fatal(runErr)
+	}
+}
+
+// newServer connects to MySQL as cfg says and returns the MCP server, with
+// every tool and resource registered, and its handler. SIGHUP reloads
+// configPath into it.
+func newServer(cfg Config, configPath string) (*mcp.Server, *queryHandler, error) {
+	dsnConfig, err := mysqlDriverConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid mysql connection config: %w", err)
+	}
+	connector, err := mysql.NewConnector(dsnConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open mysql connection: %w", err)
+	}
+	breaker := newCircuitBreaker(cfg.MySQL.BreakerFailureThreshold, time.Duration(cfg.MySQL.BreakerOpenSeconds)*time.Second)
+	db := sql.OpenDB(newBreakerConnector(newInitConnector(connector, cfg.MySQL.InitStatements), breaker))
 
 	if cfg.MySQL.MaxOpenConns > 0 {
 		db.SetMaxOpenConns(cfg.MySQL.MaxOpenConns)
@@ -471,49 +1195,333 @@ func main() {
 	if cfg.MySQL.ConnMaxIdleTimeSeconds > 0 {
 		db.SetConnMaxIdleTime(time.Duration(cfg.MySQL.ConnMaxIdleTimeSeconds) * time.Second)
 	}
+	pool, err := newPoolTuner(db, cfg.MySQL.PoolAutotune, cfg.MySQL.MaxOpenConns)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid pool autotune config: %w", err)
+	}
+	if pool != nil {
+		go pool.run(context.Background())
+	}
+	replicas, err := newReplicaPool(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid replica config: %w", err)
+	}
+	if replicas != nil {
+		go replicas.run(context.Background())
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	if err := db.PingContext(ctx); err != nil {
-		cancel()
-		fmt.Fprintf(os.Stderr, "failed to connect to mysql: %v\n", err)
-		os.Exit(1)
+	backoff := newConnectBackoff(cfg.MySQL.ConnectAttempts, cfg.MySQL.ConnectBackoffMs, cfg.MySQL.ConnectBackoffMaxMs)
+	dbReady := newDBState(!cfg.MySQL.LazyConnect)
+	if cfg.MySQL.LazyConnect {
+		go connectLazily(context.Background(), db, backoff, cfg.MySQL.PrivilegeCheck, dbReady)
+	} else {
+		if err := backoff.retry(context.Background(), pingDB(db), nil); err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to mysql: %w", err)
+		}
+		if err := privilegeGate(context.Background(), db, cfg.MySQL.PrivilegeCheck); err != nil {
+			return nil, nil, err
+		}
+		checkDialect(context.Background(), db, cfg.Parser)
+		checkViewsOnly(context.Background(), db, cfg.ViewsOnly)
+	}
+
+	audit, err := newAuditor(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to configure audit sinks: %w", err)
+	}
+
+	filters, err := newRowFilters(cfg.RowFilters, dsnConfig.DBName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid row filter config: %w", err)
+	}
+	softDeletes, err := newSoftDeleteFilters(cfg.SoftDelete, dsnConfig.DBName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid soft delete config: %w", err)
+	}
+	if _, err := compileRelations(cfg.Relations, dsnConfig.DBName); err != nil {
+		return nil, nil, fmt.Errorf("invalid relation config: %w", err)
+	}
+	if err := validateFeatures(cfg.Features); err != nil {
+		return nil, nil, fmt.Errorf("invalid feature config: %w", err)
+	}
+
+	validator, err := newValidator(cfg, dsnConfig.DBName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid query policy config: %w", err)
+	}
+	savedQueries, err := compileSavedQueries(cfg.Queries, validator)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid saved query config: %w", err)
+	}
+
+	meta, err := newIntrospectionDB(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid introspection connection config: %w", err)
+	}
+	catalogDB := db
+	if meta != nil {
+		catalogDB = meta
+	}
+
+	tableResources, err := newTableResources(catalogDB, cfg.MySQL.TableResources, dsnConfig.DBName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid table resources config: %w", err)
 	}
-	cancel()
 
 	handler := &queryHandler{
-		db:             db,
-		config:         cfg,
-		denySubstrings: normalizeList(cfg.MySQL.DenySubstrings),
+		db:            db,
+		meta:          meta,
+		exposure:      newExposure(cfg.Server.EnabledTools, cfg.Server.EnabledResources),
+		config:        cfg,
+		validator:     validator,
+		schema:        newSchemaCache(catalogDB, dsnConfig.DBName, time.Duration(cfg.MySQL.SchemaCacheTTLSeconds)*time.Second),
+		audit:         audit,
+		rowFilters:    filters,
+		softDeletes:   softDeletes,
+		results:       newResultStore(cfg.ResultStore.MaxEntries, cfg.ResultStore.MaxRows, time.Duration(cfg.ResultStore.TTLSeconds)*time.Second),
+		cache:         newResultCache(cfg.Cache),
+		active:        newActiveQueries(),
+		workload:      newWorkloadLog(workloadMaxFingerprints),
+		connections:   newConnectionSet(),
+		dbReady:       dbReady,
+		breaker:       breaker,
+		access:        newAccessControl(cfg.Access),
+		policy:        newPolicyHook(cfg.Policy),
+		tenant:        cfg.tenant,
+		pool:          pool,
+		replicas:      replicas,
+		confirmations: newConfirmationStore(),
+		defaultSchema: dsnConfig.DBName,
 	}
+	// A column list that changed under the schema cache outdates the
+	// results cached for the table.
+	handler.schema.changed = handler.cache.invalidate
 
-	server := mcp.NewServer(&mcp.Implementation{Name: cfg.Server.Name, Version: cfg.Server.Version}, nil)
-	mcp.AddTool(server, &mcp.Tool{
+	schemaWatch := newSchemaWatcher(handler, time.Duration(cfg.MySQL.SchemaPollSeconds)*time.Second)
+	// With lazy_connect the catalog may not be reachable yet; the
+	// instructions then point at the resources instead.
+	var catalog []catalogSummary
+	if dbReady.check() == nil {
+		catalog, err = loadCatalogSummary(context.Background(), catalogDB)
+		if err != nil {
+			log.Printf("failed to read the catalog for server instructions: %v", err)
+		}
+	}
+	server := mcp.NewServer(&mcp.Implementation{Name: cfg.Server.Name, Version: cfg.Server.Version}, &mcp.ServerOptions{
+		Instructions:       serverInstructions(cfg, catalog),
+		SubscribeHandler:   schemaWatch.subscribe,
+		UnsubscribeHandler: schemaWatch.unsubscribe,
+	})
+	go schemaWatch.run(context.Background(), server)
+	addTool(server, handler, &mcp.Tool{
 		Name:        "mysql_query",
 		Description: "Run a read-only SQL query against MySQL.",
 	}, handler.runQuery)
 
-	server.AddResource(&mcp.Resource{
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_show_create",
+		Description: "Show the full CREATE TABLE statement for a table, including constraints, generated columns, partitioning, and table options.",
+	}, handler.showCreate)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_sample_rows",
+		Description: "Return a few rows of a table, optionally only some columns, to see what its data looks like. Small tables are sampled at random; larger ones return their first rows.",
+	}, handler.sampleRows)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_row_by_pk",
+		Description: "Fetch one row by its primary key, given a value for each primary key column. The key columns are looked up for you.",
+	}, handler.rowByPK)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_profile_column",
+		Description: "Profile a column: null fraction, distinct count, min and max, and the most frequent values, over up to 100,000 rows.",
+	}, handler.profileColumn)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_related_rows",
+		Description: "Fetch a row by primary key together with the rows it references and a few rows referencing it, following declared and configured foreign keys.",
+	}, handler.relatedRows)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_relationship_graph",
+		Description: "Map a database's foreign keys as a graph of tables and relations, with a Mermaid ER diagram of it.",
+	}, handler.relationshipGraph)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_lint",
+		Description: "Check a query for common anti-patterns (SELECT *, no LIMIT, functions on indexed columns, implicit cross joins, no WHERE on large tables) without running it.",
+	}, handler.lint)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_format_sql",
+		Description: "Pretty-print a SQL statement, one clause per line, and optionally return its normalized form and fingerprint for deduplication. The statement is parsed, never run.",
+	}, handler.formatSQL)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_validate",
+		Description: "Check whether mysql_query would accept a query, and for SELECTs get the optimizer's estimated cost and table accesses from EXPLAIN, without running it.",
+	}, handler.validate)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_search",
+		Description: "Find rows whose text columns contain, start with, or equal a term, ignoring case and accents (José, Jose, JOSÉ). Returns the rows and the generated SQL.",
+	}, handler.search)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_batch",
+		Description: "Run up to 10 semicolon-separated read-only statements in one read-only transaction, so they read the same snapshot, and return one result set per statement.",
+	}, handler.batch)
+
+	if cfg.Write.Enabled {
+		addTool(server, handler, &mcp.Tool{
+			Name:        "mysql_execute",
+			Description: "Run one INSERT, UPDATE, or DELETE in its own transaction, on tables the server's write policy allows. UPDATE and DELETE need a WHERE clause; statements changing too many rows are rolled back.",
+		}, handler.execute)
+	}
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_unused_report",
+		Description: "Report indexes never used and columns never referenced by statements since performance_schema statistics were last reset.",
+	}, handler.unusedReport)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_schema_diff",
+		Description: "Compare table and column definitions between two databases. Text content is a markdown table marking each difference with + (only in target), - (only in source), or ~ (changed).",
+	}, handler.schemaDiff)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_collation_order",
+		Description: "Show how a collation orders a sample of a column's values, compared with byte order and utf8mb4_0900_ai_ci, to explain unexpected sorting.",
+	}, handler.collationOrder)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_explain_index_usage",
+		Description: "EXPLAIN the queries agents ran against a table recently and report which of its indexes they use and which queries scan the whole table.",
+	}, handler.indexUsage)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_processlist",
+		Description: "List server connections and what they are running (SHOW FULL PROCESSLIST), optionally only this server's connections and with query text redacted.",
+	}, handler.processlist)
+
+	addTool(server, handler, &mcp.Tool{
+		Name:        "mysql_query_with_results",
+		Description: "Run a read-only SQL query that references earlier results (by resultId) as named CTEs.",
+	}, handler.queryWithResults)
+
+	if cfg.Analytics.URL != "" {
+		analytics, err := newClickhouseClient(cfg.Analytics)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid analytics config: %w", err)
+		}
+		handler.analytics = analytics
+		addTool(server, handler, &mcp.Tool{
+			Name:        "analytics_query",
+			Description: analyticsToolDescription(cfg.Analytics.Extracts),
+		}, handler.analyticsQuery)
+	}
+
+	registerSavedQueries(server, handler, savedQueries)
+	go watchReload(context.Background(), configPath, server, handler)
+
+	addResource(server, handler, &mcp.Resource{
 		Name:        "mysql_databases",
 		URI:         "mysql://databases",
 		Description: "List databases available on this MySQL server.",
 		MIMEType:    "application/json",
 	}, handler.readResource)
 
-	server.AddResourceTemplate(&mcp.ResourceTemplate{
+	addResource(server, handler, &mcp.Resource{
+		Name:        "mysql_server_info",
+		URI:         "mysql://server_info",
+		Description: "Server capabilities and configuration: registered tools, row and timeout limits, row filter and denylist summaries, and schema cache state.",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	addResource(server, handler, &mcp.Resource{
+		Name:        "mysql_active",
+		URI:         "mysql://active",
+		Description: "List tool queries currently executing on this server, with fingerprint, session, and elapsed time.",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
+		Name:        "mysql_results",
+		URITemplate: "mysql://results/{id}",
+		Description: "Full result of an earlier query in this session, by resultId.",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
 		Name:        "mysql_tables",
 		URITemplate: "mysql://tables/{db}",
 		Description: "List tables in the given database.",
 		MIMEType:    "application/json",
 	}, handler.readResource)
 
-	server.AddResourceTemplate(&mcp.ResourceTemplate{
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
 		Name:        "mysql_schema",
 		URITemplate: "mysql://schema/{db}/{table}",
 		Description: "Describe a table's schema (DESCRIBE).",
 		MIMEType:    "application/json",
 	}, handler.readResource)
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
-		log.Fatal(err)
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
+		Name:        "mysql_indexes",
+		URITemplate: "mysql://indexes/{db}/{table}",
+		Description: "List a table's indexes with their columns, uniqueness, and cardinality (SHOW INDEX).",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
+		Name:        "mysql_views",
+		URITemplate: "mysql://views/{db}",
+		Description: "List views in the given database with their CREATE VIEW statements.",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
+		Name:        "mysql_routines",
+		URITemplate: "mysql://routines/{db}",
+		Description: "List stored procedures and functions in the given database.",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
+		Name:        "mysql_triggers",
+		URITemplate: "mysql://triggers/{db}",
+		Description: "List triggers in the given database.",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
+		Name:        "mysql_events",
+		URITemplate: "mysql://events/{db}",
+		Description: "List scheduled events in the given database.",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
+		Name:        "mysql_relations",
+		URITemplate: "mysql://relations/{db}",
+		Description: "List foreign key relationships between tables in the given database.",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	addResourceTemplate(server, handler, &mcp.ResourceTemplate{
+		Name:        "mysql_ddl",
+		URITemplate: "mysql://ddl/{db}/{table}",
+		Description: "Full CREATE TABLE statement for a table (SHOW CREATE TABLE).",
+		MIMEType:    "application/json",
+	}, handler.readResource)
+
+	if err := handler.exposure.checkNames(); err != nil {
+		return nil, nil, err
 	}
+	if tableResources != nil && handler.exposure.resourceEnabled("mysql_schema") {
+		go tableResources.run(context.Background(), server, handler.readResource)
+	}
+
+	return server, handler, nil
 }
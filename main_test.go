@@ -10,12 +10,6 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestNormalizeList(t *testing.T) {
-	input := []string{"  SELECT ", "", "Show", "  \t", "Describe"}
-	got := normalizeList(input)
-	require.Equal(t, []string{"select", "show", "describe"}, got)
-}
-
 func TestIsReadOnlyQuery(t *testing.T) {
 	deny := []string{" into outfile", " for update"}
 
@@ -28,11 +22,17 @@ func TestIsReadOnlyQuery(t *testing.T) {
 		{"show ok", "show tables", true},
 		{"show ok trailing semicolon", "show tables;", true},
 		{"explain ok", "explain select * from users", true},
+		{"cte ok", "with recent as (select * from users) select * from recent", true},
+		{"parenthesized select ok", "(select * from users)", true},
+		{"values ok", "values row(1, 'a'), row(2, 'b')", true},
+		{"cte with delete", "with old as (select id from users) delete from users where id in (select id from old)", false},
+		{"placeholder ok", "select * from users where id = ?", true},
 		{"empty", "   ", false},
 		{"multi statement", "select 1; select 2", false},
 		{"write prefix", "insert into t values (1)", false},
 		{"deny substring", "select * from t for update", false},
 		{"outfile", "select * from t into outfile 'x'", false},
+		{"denied function", "select sleep(1)", false},
 	}
 
 	for _, tc := range cases {
@@ -63,6 +63,26 @@ func TestNormalizeValue(t *testing.T) {
 	}
 }
 
+func TestNormalizeColumnValue(t *testing.T) {
+	blob := ColumnType{Type: "BLOB"}
+	text := ColumnType{Type: "TEXT"}
+
+	require.Equal(t, BinaryValue{Base64: "AP9h", Bytes: 3}, normalizeColumnValue([]byte{0x00, 0xff, 'a'}, blob, false))
+	require.Equal(t, BinaryValue{Bytes: 3, Omitted: true}, normalizeColumnValue([]byte{0x00, 0xff, 'a'}, blob, true))
+	require.Equal(t, "hello", normalizeColumnValue([]byte("hello"), text, true))
+	require.Nil(t, normalizeColumnValue(nil, ColumnType{Type: "VARBINARY"}, false))
+	require.Equal(t, string([]byte{0x00, 0xff, 'a'}), valueString(BinaryValue{Base64: "AP9h", Bytes: 3}))
+}
+
+func TestQueryParams(t *testing.T) {
+	args, err := queryParams([]any{float64(42), 1.5, "shipped", true, nil})
+	require.NoError(t, err)
+	require.Equal(t, []any{int64(42), 1.5, "shipped", true, nil}, args)
+
+	_, err = queryParams([]any{"ok", []any{1, 2}})
+	require.ErrorContains(t, err, "param 2")
+}
+
 func TestLoadConfigDefaults(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.toml")
@@ -76,7 +96,21 @@ dsn = "user:pass@tcp(localhost:3306)/db"
 	require.Equal(t, "mysql-readonly", cfg.Server.Name)
 	require.Equal(t, "v1.0.0", cfg.Server.Version)
 	require.Equal(t, []string{"select", "show", "describe", "explain"}, cfg.MySQL.AllowStatementPrefixes)
-	require.Equal(t, []string{" into outfile", " into dumpfile", " for update", " lock in share mode"}, cfg.MySQL.DenySubstrings)
+	require.Empty(t, cfg.MySQL.DenySubstrings)
+	require.Equal(t, privilegeCheckWarn, cfg.MySQL.PrivilegeCheck)
+}
+
+func TestLoadConfigRejectsUnknownPrivilegeCheck(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[mysql]
+dsn = "user:pass@tcp(localhost:3306)/db"
+privilege_check = "strict"
+`), 0o600))
+
+	_, err := loadConfig(path)
+	require.ErrorContains(t, err, "privilege_check")
 }
 
 func TestLoadConfigOverrides(t *testing.T) {
@@ -157,3 +191,98 @@ func TestToolErrorResultf_StructuredContentSchema(t *testing.T) {
 	_, ok = result.Content[0].(*mcp.TextContent)
 	require.True(t, ok)
 }
+
+func TestBuildIndexInfo(t *testing.T) {
+	out := QueryOutput{
+		Columns: []string{"Table", "Non_unique", "Key_name", "Seq_in_index", "Column_name", "Cardinality", "Index_type", "Expression"},
+		Rows: [][]interface{}{
+			{"orders", int64(0), "PRIMARY", int64(1), "id", int64(1200), "BTREE", nil},
+			{"orders", int64(1), "idx_customer_created", int64(1), "customer_id", int64(80), "BTREE", nil},
+			{"orders", int64(1), "idx_customer_created", int64(2), "created_at", int64(1100), "BTREE", nil},
+			{"orders", "1", "idx_lower_email", "1", nil, nil, "BTREE", "lower(`email`)"},
+		},
+	}
+
+	indexes := buildIndexInfo(out)
+	require.Len(t, indexes, 3)
+
+	require.Equal(t, "PRIMARY", indexes[0].KeyName)
+	require.True(t, indexes[0].Unique)
+	require.Equal(t, []string{"id"}, indexes[0].Columns)
+	require.Equal(t, int64(1200), *indexes[0].Cardinality)
+
+	require.False(t, indexes[1].Unique)
+	require.Equal(t, []string{"customer_id", "created_at"}, indexes[1].Columns)
+	require.Equal(t, int64(1100), *indexes[1].Cardinality)
+	require.Equal(t, "BTREE", indexes[1].IndexType)
+
+	require.Equal(t, []string{"lower(`email`)"}, indexes[2].Columns)
+	require.Nil(t, indexes[2].Cardinality)
+}
+
+func TestBuildRelations(t *testing.T) {
+	out := QueryOutput{
+		Columns: []string{"constraint_name", "table_name", "column_name", "referenced_schema", "referenced_table", "referenced_column", "update_rule", "delete_rule"},
+		Rows: [][]interface{}{
+			{"fk_items_order", "order_items", "order_id", "shop", "orders", "id", "RESTRICT", "CASCADE"},
+			{"fk_items_product", "order_items", "product_id", "shop", "products", "id", "RESTRICT", "RESTRICT"},
+			{"fk_items_product", "order_items", "product_variant", "shop", "products", "variant", "RESTRICT", "RESTRICT"},
+			{"fk_orders_customer", "orders", "customer_id", "shop", "customers", "id", "CASCADE", "SET NULL"},
+		},
+	}
+
+	relations := buildRelations(out)
+	require.Len(t, relations, 3)
+	require.Equal(t, Relation{
+		Name:              "fk_items_product",
+		Table:             "order_items",
+		Columns:           []string{"product_id", "product_variant"},
+		ReferencedSchema:  "shop",
+		ReferencedTable:   "products",
+		ReferencedColumns: []string{"id", "variant"},
+		OnUpdate:          "RESTRICT",
+		OnDelete:          "RESTRICT",
+	}, relations[1])
+	require.Equal(t, "SET NULL", relations[2].OnDelete)
+}
+
+type fakeColumnType struct {
+	name             string
+	nullable, hasNul bool
+	length           int64
+	hasLen           bool
+}
+
+func (f fakeColumnType) DatabaseTypeName() string        { return f.name }
+func (f fakeColumnType) Nullable() (bool, bool)          { return f.nullable, f.hasNul }
+func (f fakeColumnType) Length() (length int64, ok bool) { return f.length, f.hasLen }
+
+func TestBuildColumnTypes(t *testing.T) {
+	got := buildColumnTypes([]fakeColumnType{
+		{name: "BIGINT", nullable: false, hasNul: true},
+		{name: "VARCHAR", nullable: true, hasNul: true, length: 255, hasLen: true},
+		{name: "DATETIME"},
+	})
+
+	require.Len(t, got, 3)
+	require.Equal(t, "BIGINT", got[0].Type)
+	require.NotNil(t, got[0].Nullable)
+	require.False(t, *got[0].Nullable)
+	require.Nil(t, got[0].Length)
+	require.True(t, *got[1].Nullable)
+	require.Equal(t, int64(255), *got[1].Length)
+	require.Equal(t, ColumnType{Type: "DATETIME"}, got[2])
+}
+
+func TestQueryTimeout(t *testing.T) {
+	h := &queryHandler{}
+	require.Equal(t, 30*time.Second, h.queryTimeout(0))
+	require.Equal(t, 5*time.Second, h.queryTimeout(5))
+	require.Equal(t, 30*time.Second, h.queryTimeout(600), "without a maximum the default is the cap")
+
+	h.config.MySQL.QueryTimeoutSeconds = 10
+	h.config.MySQL.MaxQueryTimeoutSeconds = 120
+	require.Equal(t, 10*time.Second, h.queryTimeout(-1))
+	require.Equal(t, 60*time.Second, h.queryTimeout(60))
+	require.Equal(t, 120*time.Second, h.queryTimeout(600))
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/oauthex"
+)
+
+const (
+	defaultOAuthScope = "mysql:read"
+	// jwksMaxAge is how long fetched signing keys are trusted before they are
+	// fetched again; an unknown key ID triggers an earlier fetch.
+	jwksMaxAge = time.Hour
+	// jwksMinRefresh limits fetches caused by unknown key IDs.
+	jwksMinRefresh = time.Minute
+)
+
+// OAuthConfig makes the HTTP transport an OAuth 2.1 resource server, as the
+// MCP authorization spec describes. Bearer tokens are JWT access tokens
+// signed by Issuer's keys, issued for Audience, and granting every scope in
+// Scopes.
+type OAuthConfig struct {
+	Issuer string `toml:"issuer"`
+	// JWKSURL defaults to the jwks_uri in the issuer's metadata.
+	JWKSURL string `toml:"jwks_url"`
+	// Resource is the MCP endpoint's public URL, as clients reach it.
+	Resource string `toml:"resource"`
+	// Audience is the aud tokens must carry; Resource by default.
+	Audience string `toml:"audience"`
+	// Scopes defaults to ["mysql:read"].
+	Scopes []string `toml:"scopes"`
+	// PrincipalClaim names the claim that identifies the caller; "sub" by
+	// default.
+	PrincipalClaim string `toml:"principal_claim"`
+}
+
+func (c OAuthConfig) enabled() bool { return c.Issuer != "" }
+
+func (c OAuthConfig) withDefaults() OAuthConfig {
+	if c.Audience == "" {
+		c.Audience = c.Resource
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{defaultOAuthScope}
+	}
+	if c.PrincipalClaim == "" {
+		c.PrincipalClaim = "sub"
+	}
+	return c
+}
+
+func validateOAuthConfig(cfg OAuthConfig) error {
+	if !cfg.enabled() {
+		if cfg.JWKSURL != "" || cfg.Resource != "" {
+			return fmt.Errorf("http.oauth.issuer is required with the other http.oauth settings")
+		}
+		return nil
+	}
+	for name, value := range map[string]string{"issuer": cfg.Issuer, "resource": cfg.Resource, "jwks_url": cfg.JWKSURL} {
+		if value == "" && name == "jwks_url" {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" || u.Scheme != "https" && u.Scheme != "http" {
+			return fmt.Errorf("http.oauth.%s must be an absolute http(s) URL", name)
+		}
+	}
+	return nil
+}
+
+// protectedResourceMetadataPath returns where RFC 9728 puts resource's
+// metadata: the well-known prefix inserted before its path.
+func protectedResourceMetadataPath(resource string) string {
+	u, err := url.Parse(resource)
+	if err != nil {
+		return "/.well-known/oauth-protected-resource"
+	}
+	return "/.well-known/oauth-protected-resource" + strings.TrimSuffix(u.Path, "/")
+}
+
+// protectedResourceMetadataURL returns the public URL of resource's metadata,
+// which 401 responses point clients to.
+func protectedResourceMetadataURL(resource string) string {
+	u, err := url.Parse(resource)
+	if err != nil {
+		return ""
+	}
+	u.Path = protectedResourceMetadataPath(resource)
+	u.RawQuery = ""
+	return u.String()
+}
+
+func protectedResourceMetadata(cfg OAuthConfig) *oauthex.ProtectedResourceMetadata {
+	return &oauthex.ProtectedResourceMetadata{
+		Resource:               cfg.Resource,
+		AuthorizationServers:   []string{cfg.Issuer},
+		ScopesSupported:        cfg.Scopes,
+		BearerMethodsSupported: []string{"header"},
+	}
+}
+
+// jwtVerifier validates access tokens against the issuer's signing keys,
+// fetching them on first use and again when they age or a token names an
+// unknown key.
+type jwtVerifier struct {
+	cfg    OAuthConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+func newJWTVerifier(cfg OAuthConfig) *jwtVerifier {
+	return &jwtVerifier{cfg: cfg.withDefaults(), client: &http.Client{Timeout: 10 * time.Second}, now: time.Now}
+}
+
+// verify returns the token's principal, scopes, and expiration, or an error
+// wrapping auth.ErrInvalidToken.
+func (v *jwtVerifier) verify(ctx context.Context, token string) (*auth.TokenInfo, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(v.cfg.Issuer),
+		jwt.WithAudience(v.cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+		jwt.WithTimeFunc(v.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidToken, err)
+	}
+	principal, _ := claims[v.cfg.PrincipalClaim].(string)
+	if principal == "" {
+		return nil, fmt.Errorf("%w: token has no %s claim", auth.ErrInvalidToken, v.cfg.PrincipalClaim)
+	}
+	exp, _ := claims.GetExpirationTime()
+	return &auth.TokenInfo{UserID: principal, Scopes: tokenScopes(claims), Expiration: exp.Time, Extra: claims}, nil
+}
+
+// tokenScopes reads the space-separated scope claim (RFC 9068), or the scp
+// array some providers use instead.
+func tokenScopes(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	var scopes []string
+	if scp, ok := claims["scp"].([]any); ok {
+		for _, s := range scp {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+// key returns the signing key with ID kid, or the only key when kid is
+// empty.
+func (v *jwtVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := v.now()
+	_, known := v.keys[kid]
+	if v.keys == nil || now.Sub(v.fetched) > jwksMaxAge || !known && now.Sub(v.fetched) > jwksMinRefresh {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil && v.keys == nil {
+			return nil, err
+		}
+		if err == nil {
+			v.keys, v.fetched = keys, now
+		}
+	}
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no signing key %q in the issuer's JWKS", kid)
+}
+
+func (v *jwtVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	jwksURL := v.cfg.JWKSURL
+	if jwksURL == "" {
+		var err error
+		if jwksURL, err = v.discoverJWKSURL(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, jwksURL, &set); err != nil {
+		return nil, fmt.Errorf("fetching JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of types this server can't use are skipped, not fatal, so
+		// an issuer can publish them alongside its signing keys.
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("the issuer's JWKS has no usable signing keys")
+	}
+	return keys, nil
+}
+
+// discoverJWKSURL reads jwks_uri from the issuer's RFC 8414 metadata, or its
+// OpenID Connect discovery document.
+func (v *jwtVerifier) discoverJWKSURL(ctx context.Context) (string, error) {
+	var errs []error
+	for _, suffix := range []string{"/.well-known/oauth-authorization-server", "/.well-known/openid-configuration"} {
+		var meta struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, strings.TrimSuffix(v.cfg.Issuer, "/")+suffix, &meta); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if meta.Issuer != v.cfg.Issuer {
+			return "", fmt.Errorf("issuer metadata names issuer %q, not %q", meta.Issuer, v.cfg.Issuer)
+		}
+		if meta.JWKSURI == "" {
+			return "", fmt.Errorf("issuer metadata for %q has no jwks_uri", v.cfg.Issuer)
+		}
+		return meta.JWKSURI, nil
+	}
+	return "", fmt.Errorf("discovering the JWKS of %q: %w", v.cfg.Issuer, errors.Join(errs...))
+}
+
+func (v *jwtVerifier) getJSON(ctx context.Context, target string, into any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", target, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(into)
+}
+
+// jsonWebKey is the part of an RFC 7517 public key needed to verify
+// signatures.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := b64(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("bad RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("EC point is not on its curve")
+		}
+		return key, nil
+	case "OKP":
+		x, err := b64(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported OKP key %q", k.Crv)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTVerifier(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	var issuer string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": issuer + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		b64 := base64.RawURLEncoding.EncodeToString
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+			{"kty": "EC", "kid": "k1", "use": "sig", "crv": "P-256", "x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32)))},
+		}})
+	})
+	idp := httptest.NewServer(mux)
+	defer idp.Close()
+	issuer = idp.URL
+
+	cfg := OAuthConfig{Issuer: issuer, Resource: "https://mcp.example.com/mcp"}
+	require.NoError(t, validateOAuthConfig(cfg))
+	v := newJWTVerifier(cfg)
+	sign := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	info, err := v.verify(context.Background(), sign(jwt.MapClaims{"iss": issuer, "aud": cfg.Resource, "sub": "alice", "exp": exp, "scope": "mysql:read profile"}))
+	require.NoError(t, err)
+	require.Equal(t, "alice", info.UserID)
+	require.Equal(t, []string{"mysql:read", "profile"}, info.Scopes)
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"wrong audience": {"iss": issuer, "aud": "https://other.example.com", "sub": "alice", "exp": exp},
+		"wrong issuer":   {"iss": "https://evil.example.com", "aud": cfg.Resource, "sub": "alice", "exp": exp},
+		"expired":        {"iss": issuer, "aud": cfg.Resource, "sub": "alice", "exp": time.Now().Add(-time.Hour).Unix()},
+		"no expiration":  {"iss": issuer, "aud": cfg.Resource, "sub": "alice"},
+		"no subject":     {"iss": issuer, "aud": cfg.Resource, "exp": exp},
+	} {
+		_, err := v.verify(context.Background(), sign(claims))
+		require.ErrorIs(t, err, auth.ErrInvalidToken, name)
+	}
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": issuer, "aud": cfg.Resource, "sub": "alice", "exp": exp}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = v.verify(context.Background(), hmacToken)
+	require.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	// Without mysql:read the endpoint answers 403 and points to the metadata.
+	handler := auth.RequireBearerToken(bearerVerifier(nil, v, v.cfg.Scopes), &auth.RequireBearerTokenOptions{
+		ResourceMetadataURL: protectedResourceMetadataURL(cfg.Resource),
+		Scopes:              v.cfg.Scopes,
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for scope, status := range map[string]int{"mysql:read": http.StatusOK, "profile": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set("Authorization", "Bearer "+sign(jwt.MapClaims{"iss": issuer, "aud": cfg.Resource, "sub": "alice", "exp": exp, "scope": scope}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, status, rec.Code, scope)
+	}
+	require.Equal(t, "https://mcp.example.com/.well-known/oauth-protected-resource/mcp", protectedResourceMetadataURL(cfg.Resource))
+}
+
+func TestValidateOAuthConfig(t *testing.T) {
+	for _, cfg := range []OAuthConfig{
+		{Issuer: "https://idp.example.com"},
+		{Issuer: "idp.example.com", Resource: "https://mcp.example.com/mcp"},
+		{Issuer: "https://idp.example.com", Resource: "https://mcp.example.com/mcp", JWKSURL: "keys.json"},
+		{Resource: "https://mcp.example.com/mcp"},
+	} {
+		require.Error(t, validateOAuthConfig(cfg), cfg)
+	}
+	require.NoError(t, validateHTTPConfig(HTTPConfig{Addr: ":8080", OAuth: OAuthConfig{Issuer: "https://idp.example.com", Resource: "https://mcp.example.com/mcp"}}))
+}
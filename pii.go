@@ -0,0 +1,324 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"mysqlmcp/internal/gate"
+)
+
+const (
+	piiModeOff    = "off"
+	piiModeDetect = "detect"
+	piiModeMask   = "mask"
+
+	piiEmail      = "email"
+	piiPhone      = "phone"
+	piiCreditCard = "credit_card"
+)
+
+// PIIConfig scans the string values of query results for personal data.
+// With mode "detect" the columns holding it are reported in the result and
+// the audit log; with "mask" the matches are masked too, before the result
+// is returned or stored.
+type PIIConfig struct {
+	// Mode is "off" (the default), "detect", or "mask".
+	Mode string `toml:"mode"`
+	// Detectors lists the patterns to look for: "email", "phone", and
+	// "credit_card". Empty means all of them.
+	Detectors []string `toml:"detectors"`
+	// IgnoreColumns lists result column names, case-insensitively, that are never
+	// scanned, such as a support address everyone may see.
+	IgnoreColumns []string `toml:"ignore_columns"`
+	// Pseudonymize lists identifier columns, as "db.table.column" or
+	// "table.column" in the DSN's default database, whose values are
+	// replaced with a keyed HMAC of the value in mask mode. The same value
+	// always gets the same pseudonym, so results can still be joined and
+	// grouped across queries on it. The gate only lets these columns into a
+	// result by their own name; see gate.Validator.PseudonymizedColumns.
+	Pseudonymize []string `toml:"pseudonymize"`
+	// KeyFile or KeyEnv holds the HMAC key, at least 16 bytes. Keep it
+	// secret and stable: a new key changes every pseudonym.
+	KeyFile string `toml:"key_file"`
+	KeyEnv  string `toml:"key_env"`
+
+	// key is the HMAC key loaded by loadPIIKey.
+	key []byte
+}
+
+// PIIDetection reports one result column whose values matched PII patterns.
+type PIIDetection struct {
+	Column string   `json:"column" jsonschema:"Result column name."`
+	Types  []string `json:"types" jsonschema:"Patterns that matched: email, phone, or credit_card."`
+	Cells  int      `json:"cells" jsonschema:"Number of cells in the column that matched, or for a pseudonymized column, that were replaced."`
+	Masked bool     `json:"masked,omitempty" jsonschema:"True if the matches were masked in the result."`
+	// Pseudonymized columns have every value replaced, matching or not.
+	Pseudonymized bool `json:"pseudonymized,omitempty" jsonschema:"True if the column's values were replaced with pseudonyms, which are equal wherever the original values are."`
+}
+
+var (
+	piiEmailRE = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	// Phone numbers need separators or a leading +, so plain integers such
+	// as IDs and timestamps stored as text don't match.
+	piiPhoneRE = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)|\b\d{3})[ .-]\d{3}[ .-]\d{4}\b|\+\d{8,15}\b`)
+	// Card numbers are 13 to 19 digits, optionally grouped by spaces or
+	// dashes, and must pass the Luhn check.
+	piiCardRE = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// piiDetectors are the detectors in the order they run. Cards run before
+// phones so a grouped card number isn't taken for a phone number.
+var piiDetectors = []string{piiEmail, piiCreditCard, piiPhone}
+
+func validatePIIConfig(cfg PIIConfig) error {
+	switch cfg.Mode {
+	case "", piiModeOff, piiModeDetect, piiModeMask:
+	default:
+		return fmt.Errorf("pii.mode must be %q, %q, or %q", piiModeOff, piiModeDetect, piiModeMask)
+	}
+	for _, d := range cfg.Detectors {
+		if !slices.Contains(piiDetectors, d) {
+			return fmt.Errorf("pii.detectors: unknown detector %q; use %q, %q, or %q", d, piiEmail, piiPhone, piiCreditCard)
+		}
+	}
+	if len(cfg.Pseudonymize) > 0 {
+		if cfg.Mode != piiModeMask {
+			return fmt.Errorf("pii.pseudonymize needs pii.mode %q", piiModeMask)
+		}
+		if (cfg.KeyFile == "") == (cfg.KeyEnv == "") {
+			return fmt.Errorf("pii.pseudonymize needs exactly one of pii.key_file and pii.key_env")
+		}
+	}
+	return nil
+}
+
+// minPseudonymKeyBytes is the shortest HMAC key loadPIIKey accepts.
+const minPseudonymKeyBytes = 16
+
+// loadPIIKey reads the pseudonymization key, if cfg pseudonymizes columns.
+func loadPIIKey(cfg *PIIConfig) error {
+	if len(cfg.Pseudonymize) == 0 {
+		return nil
+	}
+	var key string
+	switch {
+	case cfg.KeyFile != "":
+		data, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("pii.key_file: %w", err)
+		}
+		key = strings.TrimRight(string(data), "\r\n")
+	default:
+		var ok bool
+		if key, ok = os.LookupEnv(cfg.KeyEnv); !ok {
+			return fmt.Errorf("pii.key_env: environment variable %s is not set", cfg.KeyEnv)
+		}
+	}
+	if len(key) < minPseudonymKeyBytes {
+		return fmt.Errorf("pii: the pseudonymization key must be at least %d bytes", minPseudonymKeyBytes)
+	}
+	cfg.key = []byte(key)
+	return nil
+}
+
+func (c PIIConfig) enabled() bool {
+	return c.Mode == piiModeDetect || c.Mode == piiModeMask
+}
+
+// detectors returns the configured detectors in piiDetectors order.
+func (c PIIConfig) detectors() []string {
+	if len(c.Detectors) == 0 {
+		return piiDetectors
+	}
+	var detectors []string
+	for _, d := range piiDetectors {
+		if slices.Contains(c.Detectors, d) {
+			detectors = append(detectors, d)
+		}
+	}
+	return detectors
+}
+
+// scans reports whether the result of query is scanned. Only statements
+// that read rows are: EXPLAIN and SHOW output describes the query and the
+// schema, and masking it would only corrupt the plan.
+func (c PIIConfig) scans(query string) bool {
+	if !c.enabled() {
+		return false
+	}
+	stmt, err := gate.ParseStatement(query)
+	return err == nil && readsRows(stmt)
+}
+
+// scanPII looks for PII in the string and binary values of rows, masking the
+// matches in place with mode "mask", and returns the columns that held any,
+// in column order. Binary values are scanned as their bytes, so
+// CAST(email AS BINARY) doesn't hide an address, and stay binary when
+// masked. pseudonymize holds the lower-cased names of the columns to
+// pseudonymize in mask mode, as gate.Validator.PseudonymizedColumns returns.
+func scanPII(cfg PIIConfig, columns []string, rows [][]interface{}, pseudonymize map[string]bool) []PIIDetection {
+	if !cfg.enabled() {
+		return nil
+	}
+	detectors := cfg.detectors()
+	mask := cfg.Mode == piiModeMask
+	var detections []PIIDetection
+	for i, column := range columns {
+		if containsFold(cfg.IgnoreColumns, column) {
+			continue
+		}
+		detection := PIIDetection{Column: column, Masked: mask}
+		if mask && pseudonymize[strings.ToLower(column)] {
+			detection.Pseudonymized = true
+		}
+		for _, row := range rows {
+			if i >= len(row) {
+				continue
+			}
+			if detection.Pseudonymized && row[i] != nil {
+				for _, d := range detectors {
+					if _, found := detectPII(d, valueString(row[i])); found && !slices.Contains(detection.Types, d) {
+						detection.Types = append(detection.Types, d)
+					}
+				}
+				row[i] = pseudonym(cfg.key, row[i])
+				detection.Cells++
+				continue
+			}
+			s, ok := row[i].(string)
+			binary, isBinary := row[i].(BinaryValue)
+			if isBinary && !binary.Omitted {
+				s, ok = valueString(binary), true
+			}
+			if !ok {
+				continue
+			}
+			matched := false
+			for _, d := range detectors {
+				masked, found := detectPII(d, s)
+				if !found {
+					continue
+				}
+				matched = true
+				if !slices.Contains(detection.Types, d) {
+					detection.Types = append(detection.Types, d)
+				}
+				// Later detectors see the masked value, so one match
+				// isn't counted twice.
+				s = masked
+			}
+			if matched {
+				detection.Cells++
+				switch {
+				case mask && isBinary:
+					row[i] = BinaryValue{Base64: base64.StdEncoding.EncodeToString([]byte(s)), Bytes: len(s)}
+				case mask:
+					row[i] = s
+				}
+			}
+		}
+		if detection.Pseudonymized && detection.Types == nil {
+			detection.Types = []string{}
+		}
+		if detection.Cells > 0 {
+			detections = append(detections, detection)
+		}
+	}
+	return detections
+}
+
+func containsFold(names []string, name string) bool {
+	return slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, name) })
+}
+
+// pseudonym returns "pn_" and the first 16 hex digits of the HMAC-SHA256 of
+// value under key. Values are compared as text, so the number 42 and the
+// string "42" get the same pseudonym and join as MySQL would compare them.
+func pseudonym(key []byte, value interface{}) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(valueString(value)))
+	return "pn_" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// detectPII reports whether s holds a match for detector, and returns s with
+// the matches masked.
+func detectPII(detector, s string) (string, bool) {
+	found := false
+	var masked string
+	switch detector {
+	case piiEmail:
+		masked = piiEmailRE.ReplaceAllStringFunc(s, func(m string) string {
+			found = true
+			return maskEmail(m)
+		})
+	case piiPhone:
+		masked = piiPhoneRE.ReplaceAllStringFunc(s, func(m string) string {
+			found = true
+			return maskDigits(m, 2)
+		})
+	case piiCreditCard:
+		masked = piiCardRE.ReplaceAllStringFunc(s, func(m string) string {
+			if !luhnValid(m) {
+				return m
+			}
+			found = true
+			return maskDigits(m, 4)
+		})
+	}
+	return masked, found
+}
+
+// maskEmail keeps the first character of the local part and the domain:
+// "jane.doe@example.com" becomes "j***@example.com".
+func maskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	return email[:1] + "***" + email[at:]
+}
+
+// maskDigits replaces every digit of s but the last keep with '*', leaving
+// separators, so "4111 1111 1111 1111" becomes "**** **** **** 1111".
+func maskDigits(s string, keep int) string {
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if c >= '0' && c <= '9' {
+			if digits > keep {
+				b[i] = '*'
+			}
+			digits--
+		}
+	}
+	return string(b)
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
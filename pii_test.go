@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanPII(t *testing.T) {
+	columns := []string{"id", "email", "note", "card", "support"}
+	rows := func() [][]interface{} {
+		return [][]interface{}{
+			{int64(1), "jane.doe@example.com", "call +1 555-123-4567 after 5", "4111 1111 1111 1111", "help@example.com"},
+			{int64(2), "bob@example.org", "order 20240115103000, shipped 2024-01-15 10:30:00", "4111 1111 1111 1112", "help@example.com"},
+			{int64(3), nil, "(555) 987-6543", "", "help@example.com"},
+		}
+	}
+
+	detect := rows()
+	cfg := PIIConfig{Mode: piiModeDetect, IgnoreColumns: []string{"SUPPORT"}}
+	require.Equal(t, []PIIDetection{
+		{Column: "email", Types: []string{piiEmail}, Cells: 2},
+		{Column: "note", Types: []string{piiPhone}, Cells: 2},
+		{Column: "card", Types: []string{piiCreditCard}, Cells: 1},
+	}, scanPII(cfg, columns, detect, nil))
+	require.Equal(t, rows(), detect)
+
+	masked := rows()
+	cfg.Mode = piiModeMask
+	detections := scanPII(cfg, columns, masked, nil)
+	require.Len(t, detections, 3)
+	require.True(t, detections[0].Masked)
+	require.Equal(t, []interface{}{int64(1), "j***@example.com", "call +* ***-***-**67 after 5", "**** **** **** 1111", "help@example.com"}, masked[0])
+	// A number failing the Luhn check is left alone.
+	require.Equal(t, "4111 1111 1111 1112", masked[1][3])
+	require.Equal(t, "(***) ***-**43", masked[2][2])
+
+	cfg.Detectors = []string{piiCreditCard}
+	require.Equal(t, []PIIDetection{{Column: "card", Types: []string{piiCreditCard}, Cells: 1, Masked: true}}, scanPII(cfg, columns, rows(), nil))
+	require.Nil(t, scanPII(PIIConfig{}, columns, rows(), nil))
+}
+
+func TestPseudonymize(t *testing.T) {
+	t.Setenv("PII_KEY", "0123456789abcdef")
+	cfg := PIIConfig{Mode: piiModeMask, Pseudonymize: []string{"orders.customer_id", "orders.email"}, KeyEnv: "PII_KEY"}
+	require.NoError(t, validatePIIConfig(cfg))
+	require.NoError(t, loadPIIKey(&cfg))
+
+	columns := []string{"customer_id", "email", "total"}
+	orders := [][]interface{}{
+		{int64(42), "jane.doe@example.com", 10.5},
+		{int64(42), "jane.doe@example.com", 3.0},
+		{nil, nil, 1.0},
+	}
+	customers := [][]interface{}{{"42", "other@example.com", nil}}
+	require.Equal(t, []PIIDetection{
+		{Column: "customer_id", Types: []string{}, Cells: 2, Masked: true, Pseudonymized: true},
+		{Column: "email", Types: []string{piiEmail}, Cells: 2, Masked: true, Pseudonymized: true},
+	}, scanPII(cfg, columns, orders, map[string]bool{"customer_id": true, "email": true}))
+	scanPII(cfg, columns, customers, map[string]bool{"customer_id": true})
+
+	id := orders[0][0].(string)
+	require.Regexp(t, `^pn_[0-9a-f]{16}$`, id)
+	require.Equal(t, id, orders[1][0])
+	// The number and the string 42 still join.
+	require.Equal(t, id, customers[0][0])
+	require.NotEqual(t, orders[0][1], customers[0][1])
+	require.Nil(t, orders[2][0])
+
+	require.NotEqual(t, id, pseudonym([]byte("fedcba9876543210"), int64(42)))
+
+	t.Setenv("PII_KEY", "short")
+	require.Error(t, loadPIIKey(&cfg))
+	require.Error(t, validatePIIConfig(PIIConfig{Mode: piiModeDetect, Pseudonymize: []string{"id"}, KeyEnv: "PII_KEY"}))
+	require.Error(t, validatePIIConfig(PIIConfig{Mode: piiModeMask, Pseudonymize: []string{"id"}}))
+}
+
+func TestScanBinaryPII(t *testing.T) {
+	cfg := Config{PII: PIIConfig{Mode: piiModeMask}}
+	v, err := newValidator(cfg, "shop")
+	require.NoError(t, err)
+	h := &queryHandler{
+		db:          sql.OpenDB(execConnector{}),
+		config:      cfg,
+		validator:   v,
+		access:      newAccessControl(AccessConfig{}),
+		connections: newConnectionSet(),
+	}
+
+	output, err := h.executeReadOnly(context.Background(), "SELECT id, CAST(email AS BINARY) AS email, CAST(card AS BINARY) AS card FROM contacts", readOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{
+		int64(1),
+		BinaryValue{Base64: base64.StdEncoding.EncodeToString([]byte("j***@example.com")), Bytes: 16},
+		BinaryValue{Base64: base64.StdEncoding.EncodeToString([]byte("**** **** **** 1111")), Bytes: 19},
+	}, output.Rows[0])
+	require.Equal(t, []PIIDetection{
+		{Column: "email", Types: []string{piiEmail}, Cells: 1, Masked: true},
+		{Column: "card", Types: []string{piiCreditCard}, Cells: 1, Masked: true},
+	}, output.PII)
+
+	// Omitted blobs have no bytes to scan.
+	rows := [][]interface{}{{BinaryValue{Bytes: 20, Omitted: true}}}
+	require.Nil(t, scanPII(cfg.PII, []string{"email"}, rows, nil))
+}
+
+func TestPIIScans(t *testing.T) {
+	cfg := PIIConfig{Mode: piiModeMask}
+	require.True(t, cfg.scans("SELECT email FROM customers"))
+	require.False(t, cfg.scans("EXPLAIN FORMAT=JSON SELECT email FROM customers"))
+	require.False(t, cfg.scans("SHOW TABLES"))
+	require.False(t, PIIConfig{}.scans("SELECT email FROM customers"))
+
+	require.NoError(t, validatePIIConfig(PIIConfig{Mode: piiModeDetect, Detectors: []string{piiEmail, piiPhone}}))
+	require.Error(t, validatePIIConfig(PIIConfig{Mode: "redact"}))
+	require.Error(t, validatePIIConfig(PIIConfig{Mode: piiModeMask, Detectors: []string{"ssn"}}))
+}
+
+func TestPIIInStructuredContent(t *testing.T) {
+	pii := []PIIDetection{{Column: "email", Types: []string{"email"}, Cells: 1, Masked: true}}
+	structured := queryOutputToStructuredContent(QueryOutput{Columns: []string{}, Rows: [][]interface{}{}, PII: pii})
+	require.Equal(t, pii, structured["pii"])
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// PolicyConfig sends each query that passes the read-only gate and access
+// roles to an Open Policy Agent decision endpoint, such as
+// "http://127.0.0.1:8181/v1/data/mysqlmcp/allow", so organization rules can
+// be written in Rego instead of in the validator. The decision is the
+// result of the request: true, false, or an object with "allow" and
+// "reason".
+type PolicyConfig struct {
+	OPAURL string `toml:"opa_url"`
+	// Headers are sent with each request, for example to authenticate to OPA.
+	Headers   map[string]string `toml:"headers"`
+	TimeoutMS int               `toml:"timeout_ms"`
+	// EstimateCost runs EXPLAIN on SELECTs to give the policy the optimizer's
+	// cost estimate.
+	EstimateCost bool `toml:"estimate_cost"`
+	// FailOpen allows queries when OPA can't be reached or answers
+	// malformed; by default they are refused.
+	FailOpen bool `toml:"fail_open"`
+}
+
+const defaultPolicyTimeout = 500 * time.Millisecond
+
+func validatePolicyConfig(cfg PolicyConfig) error {
+	if cfg.OPAURL == "" {
+		return nil
+	}
+	if u, err := url.Parse(cfg.OPAURL); err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("policy.opa_url must be an absolute http(s) URL")
+	}
+	if cfg.TimeoutMS < 0 {
+		return fmt.Errorf("policy.timeout_ms can't be negative")
+	}
+	return nil
+}
+
+// PolicyInput is what the policy decides on, sent to OPA as "input".
+type PolicyInput struct {
+	// Statement is the statement type: "select", "show", "insert", ...
+	Statement string `json:"statement"`
+	Query     string `json:"query"`
+	// Tables are the "db.table" names the statement references.
+	Tables []string `json:"tables"`
+	// Columns are the lower-cased column names the statement references,
+	// qualified as written ("c.email"), and "*" for a star.
+	Columns   []string `json:"columns"`
+	Principal string   `json:"principal,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	// EstimatedCost is the optimizer's query_cost, when policy.estimate_cost
+	// is set and the statement is a SELECT that EXPLAIN could plan.
+	EstimatedCost *float64 `json:"estimatedCost,omitempty"`
+}
+
+// policyHook queries OPA for a decision on each query.
+type policyHook struct {
+	cfg    PolicyConfig
+	client *http.Client
+}
+
+// newPolicyHook returns nil when no policy endpoint is configured.
+func newPolicyHook(cfg PolicyConfig) *policyHook {
+	if cfg.OPAURL == "" {
+		return nil
+	}
+	timeout := defaultPolicyTimeout
+	if cfg.TimeoutMS > 0 {
+		timeout = time.Duration(cfg.TimeoutMS) * time.Millisecond
+	}
+	return &policyHook{cfg: cfg, client: &http.Client{Timeout: timeout}}
+}
+
+// policyInput describes stmt for the policy.
+func policyInput(stmt sqlparser.Statement, query, defaultSchema string) PolicyInput {
+	input := PolicyInput{
+		Statement: strings.ToLower(sqlparser.ASTToStatementType(stmt).String()),
+		Query:     query,
+		Tables:    []string{},
+		Columns:   []string{},
+	}
+	for _, table := range gate.QueryTables(stmt, defaultSchema) {
+		input.Tables = append(input.Tables, table.String())
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.ColName:
+			name := node.Name.Lowered()
+			if !node.Qualifier.Name.IsEmpty() {
+				name = strings.ToLower(sqlparser.String(node.Qualifier)) + "." + name
+			}
+			input.Columns = append(input.Columns, name)
+		case *sqlparser.StarExpr:
+			input.Columns = append(input.Columns, "*")
+		}
+		return true, nil
+	}, stmt)
+	slices.Sort(input.Tables)
+	input.Tables = slices.Compact(input.Tables)
+	slices.Sort(input.Columns)
+	input.Columns = slices.Compact(input.Columns)
+	return input
+}
+
+// decide returns a *gate.QueryRejection if the policy denies input, or an error
+// if it couldn't be asked and policy.fail_open is off.
+func (p *policyHook) decide(ctx context.Context, input PolicyInput) error {
+	allow, reason, err := p.query(ctx, input)
+	if err != nil {
+		if p.cfg.FailOpen {
+			log.Printf("policy check failed, allowing the query: %v", err)
+			return nil
+		}
+		return fmt.Errorf("policy check failed: %w", err)
+	}
+	if !allow {
+		if reason == "" {
+			reason = "denied by policy"
+		}
+		return &gate.QueryRejection{Construct: input.Statement, Reason: reason}
+	}
+	return nil
+}
+
+func (p *policyHook) query(ctx context.Context, input PolicyInput) (bool, string, error) {
+	body, err := json.Marshal(map[string]any{"input": input})
+	if err != nil {
+		return false, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.OPAURL, bytes.NewReader(body))
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("OPA returned %s", resp.Status)
+	}
+	var decision struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return false, "", fmt.Errorf("decoding OPA response: %w", err)
+	}
+	var allow bool
+	if err := json.Unmarshal(decision.Result, &allow); err == nil {
+		return allow, "", nil
+	}
+	var result struct {
+		Allow  *bool  `json:"allow"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(decision.Result, &result); err != nil || result.Allow == nil {
+		// An undefined decision has no result, which usually means the
+		// path or package is wrong.
+		return false, "", fmt.Errorf("OPA decision %s is neither a boolean nor an object with allow", strings.TrimSpace(string(decision.Result)))
+	}
+	return *result.Allow, result.Reason, nil
+}
+
+type policyExemptKey struct{}
+
+// checkPolicy asks the policy hook, if any, whether query may run with args.
+// The EXPLAIN it runs to estimate cost is exempt from the policy.
+func (h *queryHandler) checkPolicy(ctx context.Context, query string, args ...any) error {
+	if h.policy == nil || ctx.Value(policyExemptKey{}) != nil {
+		return nil
+	}
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return nil
+	}
+	input := policyInput(stmt, query, h.defaultSchema)
+	var role *RoleConfig
+	input.Principal, role = h.access.role(ctx)
+	if role != nil {
+		input.Role = role.Name
+	}
+	if h.policy.cfg.EstimateCost && input.Statement == "select" {
+		plan, err := h.runQueryForResource(context.WithValue(ctx, policyExemptKey{}, true), "EXPLAIN FORMAT=JSON "+query, args...)
+		if err == nil && len(plan.Rows) > 0 {
+			input.EstimatedCost, _, _ = explainSummary(valueString(rowValue(plan.Rows[0], 0)))
+		}
+	}
+	return h.policy.decide(ctx, input)
+}
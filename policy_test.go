@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestPolicyInput(t *testing.T) {
+	stmt, err := gate.ParseStatement("SELECT c.Email, o.* FROM customers c JOIN sales.orders o ON o.customer_id = c.id WHERE total > 10")
+	require.NoError(t, err)
+	input := policyInput(stmt, "q", "shop")
+	require.Equal(t, "select", input.Statement)
+	require.Equal(t, []string{"sales.orders", "shop.customers"}, input.Tables)
+	require.Equal(t, []string{"*", "c.email", "c.id", "o.customer_id", "total"}, input.Columns)
+}
+
+func TestPolicyHookDecide(t *testing.T) {
+	var got PolicyInput
+	var decision string
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input PolicyInput `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		got = body.Input
+		require.Equal(t, "secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(decision))
+	}))
+	defer opa.Close()
+
+	hook := newPolicyHook(PolicyConfig{OPAURL: opa.URL, Headers: map[string]string{"Authorization": "secret"}})
+	input := PolicyInput{Statement: "select", Tables: []string{"shop.orders"}, Principal: "alice"}
+
+	decision = `{"result": true}`
+	require.NoError(t, hook.decide(context.Background(), input))
+	require.Equal(t, input.Principal, got.Principal)
+
+	decision = `{"result": {"allow": false, "reason": "orders are off limits after hours"}}`
+	var rejection *gate.QueryRejection
+	require.ErrorAs(t, hook.decide(context.Background(), input), &rejection)
+	require.Equal(t, "orders are off limits after hours", rejection.Reason)
+
+	decision = `{"result": false}`
+	require.ErrorAs(t, hook.decide(context.Background(), input), &rejection)
+	require.Equal(t, "denied by policy", rejection.Reason)
+
+	// An undefined decision fails closed unless fail_open is set.
+	decision = `{}`
+	err := hook.decide(context.Background(), input)
+	require.ErrorContains(t, err, "policy check failed")
+	_, isRejection := err.(*gate.QueryRejection)
+	require.False(t, isRejection)
+	hook.cfg.FailOpen = true
+	require.NoError(t, hook.decide(context.Background(), input))
+
+	require.Nil(t, newPolicyHook(PolicyConfig{}))
+	require.Error(t, validatePolicyConfig(PolicyConfig{OPAURL: "localhost:8181/v1/data/allow"}))
+}
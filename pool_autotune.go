@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// PoolAutotuneConfig lets the server resize its connection pool between
+// bounds: it grows when tool queries wait for a connection and shrinks when
+// connections sit idle or query latency says MySQL is saturated.
+type PoolAutotuneConfig struct {
+	Enabled         bool `toml:"enabled"`
+	MinOpenConns    int  `toml:"min_open_conns"`
+	MaxOpenConns    int  `toml:"max_open_conns"`
+	IntervalSeconds int  `toml:"interval_seconds"`
+	// TargetWaitMs is the average wait for a connection above which the pool grows.
+	TargetWaitMs int `toml:"target_wait_ms"`
+	// MaxLatencyMs, if set, is the average query latency above which the pool
+	// shrinks instead of growing, to stop adding load to a struggling server.
+	MaxLatencyMs int `toml:"max_latency_ms"`
+}
+
+// poolIntervalsBeforeShrink is how many quiet intervals in a row it takes to
+// give back a connection.
+const poolIntervalsBeforeShrink = 3
+
+// poolSample is what the pool did during one interval.
+type poolSample struct {
+	waits      int64
+	waitTime   time.Duration
+	inUse      int
+	queries    int
+	queryTime  time.Duration
+	openLimit  int
+	quietSoFar int
+}
+
+type poolTuner struct {
+	cfg PoolAutotuneConfig
+	db  *sql.DB
+
+	mu        sync.Mutex
+	queries   int
+	queryTime time.Duration
+
+	open  int
+	quiet int
+	last  sql.DBStats
+}
+
+func newPoolTuner(db *sql.DB, cfg PoolAutotuneConfig, initialOpen int) (*poolTuner, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.MinOpenConns <= 0 {
+		cfg.MinOpenConns = 1
+	}
+	if cfg.MaxOpenConns < cfg.MinOpenConns {
+		return nil, fmt.Errorf("mysql.pool_autotune.max_open_conns must be at least min_open_conns (%d)", cfg.MinOpenConns)
+	}
+	if cfg.IntervalSeconds <= 0 {
+		cfg.IntervalSeconds = 10
+	}
+	if cfg.TargetWaitMs <= 0 {
+		cfg.TargetWaitMs = 50
+	}
+	open := min(max(initialOpen, cfg.MinOpenConns), cfg.MaxOpenConns)
+	t := &poolTuner{cfg: cfg, db: db, open: open}
+	t.apply(open)
+	return t, nil
+}
+
+// observe records one query's latency.
+func (t *poolTuner) observe(d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.queries++
+	t.queryTime += d
+	t.mu.Unlock()
+}
+
+// decide returns the open-connection limit for the next interval and why
+// it changed ("" when it didn't).
+func (cfg PoolAutotuneConfig) decide(s poolSample) (open int, quiet int, reason string) {
+	open = s.openLimit
+	var avgLatency time.Duration
+	if s.queries > 0 {
+		avgLatency = s.queryTime / time.Duration(s.queries)
+	}
+	if cfg.MaxLatencyMs > 0 && avgLatency > time.Duration(cfg.MaxLatencyMs)*time.Millisecond {
+		if open > cfg.MinOpenConns {
+			return open - 1, 0, fmt.Sprintf("average query latency %s is above %dms", avgLatency.Round(time.Millisecond), cfg.MaxLatencyMs)
+		}
+		return open, 0, ""
+	}
+	if s.waits > 0 {
+		avgWait := s.waitTime / time.Duration(s.waits)
+		if avgWait > time.Duration(cfg.TargetWaitMs)*time.Millisecond && open < cfg.MaxOpenConns {
+			grown := min(open+max(1, open/4), cfg.MaxOpenConns)
+			return grown, 0, fmt.Sprintf("%d waits for a connection averaging %s", s.waits, avgWait.Round(time.Millisecond))
+		}
+		return open, 0, ""
+	}
+	if s.inUse*2 > open {
+		return open, 0, ""
+	}
+	quiet = s.quietSoFar + 1
+	if quiet >= poolIntervalsBeforeShrink && open > cfg.MinOpenConns {
+		return open - 1, 0, fmt.Sprintf("no waits and at most %d of %d connections in use for %d intervals", s.inUse, open, quiet)
+	}
+	return open, quiet, ""
+}
+
+func (t *poolTuner) apply(open int) {
+	t.db.SetMaxOpenConns(open)
+	t.db.SetMaxIdleConns(open)
+}
+
+// tick takes one interval's sample from the pool and resizes it.
+func (t *poolTuner) tick() {
+	stats := t.db.Stats()
+	t.mu.Lock()
+	queries, queryTime := t.queries, t.queryTime
+	t.queries, t.queryTime = 0, 0
+	t.mu.Unlock()
+
+	open, quiet, reason := t.cfg.decide(poolSample{
+		waits:      stats.WaitCount - t.last.WaitCount,
+		waitTime:   stats.WaitDuration - t.last.WaitDuration,
+		inUse:      stats.InUse,
+		queries:    queries,
+		queryTime:  queryTime,
+		openLimit:  t.open,
+		quietSoFar: t.quiet,
+	})
+	t.last, t.quiet = stats, quiet
+	if open != t.open {
+		fmt.Fprintf(os.Stderr, "pool autotune: max open connections %d -> %d: %s\n", t.open, open, reason)
+		t.open = open
+		t.apply(open)
+	}
+}
+
+func (t *poolTuner) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(t.cfg.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.tick()
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolAutotuneDecide(t *testing.T) {
+	cfg := PoolAutotuneConfig{MinOpenConns: 2, MaxOpenConns: 10, TargetWaitMs: 50, MaxLatencyMs: 500}
+
+	open, quiet, reason := cfg.decide(poolSample{waits: 4, waitTime: 400 * time.Millisecond, inUse: 4, openLimit: 4})
+	require.Equal(t, 5, open)
+	require.Zero(t, quiet)
+	require.Contains(t, reason, "4 waits")
+
+	open, _, _ = cfg.decide(poolSample{waits: 10, waitTime: 10 * time.Second, inUse: 8, openLimit: 8})
+	require.Equal(t, 10, open, "grows by a quarter")
+	open, _, reason = cfg.decide(poolSample{waits: 10, waitTime: 10 * time.Second, inUse: 10, openLimit: 10})
+	require.Equal(t, 10, open, "capped at the maximum")
+	require.Empty(t, reason)
+
+	open, _, reason = cfg.decide(poolSample{waits: 3, waitTime: 30 * time.Millisecond, inUse: 4, openLimit: 4})
+	require.Equal(t, 4, open, "short waits don't grow the pool")
+	require.Empty(t, reason)
+
+	open, _, reason = cfg.decide(poolSample{waits: 10, waitTime: 10 * time.Second, queries: 2, queryTime: 2 * time.Second, inUse: 6, openLimit: 6})
+	require.Equal(t, 5, open, "slow queries shrink the pool even while callers wait")
+	require.Contains(t, reason, "latency")
+
+	open, quiet, _ = cfg.decide(poolSample{inUse: 1, openLimit: 6})
+	require.Equal(t, 6, open)
+	require.Equal(t, 1, quiet)
+	open, quiet, _ = cfg.decide(poolSample{inUse: 1, openLimit: 6, quietSoFar: 1})
+	require.Equal(t, 6, open)
+	require.Equal(t, 2, quiet)
+	open, quiet, reason = cfg.decide(poolSample{inUse: 1, openLimit: 6, quietSoFar: 2})
+	require.Equal(t, 5, open, "shrinks after enough quiet intervals")
+	require.Zero(t, quiet)
+	require.Contains(t, reason, "3 intervals")
+
+	open, quiet, _ = cfg.decide(poolSample{inUse: 4, openLimit: 6, quietSoFar: 2})
+	require.Equal(t, 6, open)
+	require.Zero(t, quiet, "a busy interval resets the count")
+
+	open, _, _ = cfg.decide(poolSample{inUse: 0, openLimit: 2, quietSoFar: 5})
+	require.Equal(t, 2, open, "never below the minimum")
+}
+
+func TestNewPoolTuner(t *testing.T) {
+	tuner, err := newPoolTuner(nil, PoolAutotuneConfig{}, 5)
+	require.NoError(t, err)
+	require.Nil(t, tuner)
+	tuner.observe(time.Second)
+
+	_, err = newPoolTuner(nil, PoolAutotuneConfig{Enabled: true, MinOpenConns: 4, MaxOpenConns: 2}, 5)
+	require.ErrorContains(t, err, "max_open_conns")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Values for mysql.privilege_check.
+const (
+	privilegeCheckWarn   = "warn"
+	privilegeCheckRefuse = "refuse"
+	privilegeCheckOff    = "off"
+)
+
+// writePrivilegeNames are the static privileges that allow changing data,
+// schema, or server state. EXECUTE is included because stored routines may
+// write with their definer's rights.
+var writePrivilegeNames = map[string]bool{
+	"ALL":                     true,
+	"ALL PRIVILEGES":          true,
+	"INSERT":                  true,
+	"UPDATE":                  true,
+	"DELETE":                  true,
+	"CREATE":                  true,
+	"DROP":                    true,
+	"ALTER":                   true,
+	"INDEX":                   true,
+	"CREATE VIEW":             true,
+	"CREATE ROUTINE":          true,
+	"ALTER ROUTINE":           true,
+	"CREATE TEMPORARY TABLES": true,
+	"CREATE TABLESPACE":       true,
+	"CREATE USER":             true,
+	"CREATE ROLE":             true,
+	"DROP ROLE":               true,
+	"EXECUTE":                 true,
+	"EVENT":                   true,
+	"TRIGGER":                 true,
+	"FILE":                    true,
+	"SUPER":                   true,
+	"RELOAD":                  true,
+	"SHUTDOWN":                true,
+	"GRANT OPTION":            true,
+}
+
+// writeGrants returns the write privileges in SHOW GRANTS output, each as
+// "PRIVILEGE ON target". Role grants (GRANT `role` TO ...) carry no ON clause
+// and are not expanded.
+func writeGrants(grants []string) []string {
+	found := make([]string, 0)
+	for _, grant := range grants {
+		upper := strings.ToUpper(grant)
+		if !strings.HasPrefix(upper, "GRANT ") {
+			continue
+		}
+		on := strings.Index(upper, " ON ")
+		if on < 0 {
+			continue
+		}
+		rest := grant[on+len(" ON "):]
+		if to := strings.Index(strings.ToUpper(rest), " TO "); to >= 0 {
+			rest = rest[:to]
+		}
+		for _, priv := range splitPrivileges(upper[len("GRANT "):on]) {
+			if writePrivilegeNames[priv] {
+				found = append(found, priv+" ON "+rest)
+			}
+		}
+		if strings.Contains(upper, " WITH GRANT OPTION") {
+			found = append(found, "GRANT OPTION ON "+rest)
+		}
+	}
+	return found
+}
+
+// splitPrivileges splits a privilege list on commas outside column lists,
+// dropping the column lists ("SELECT (a, b)" becomes "SELECT").
+func splitPrivileges(list string) []string {
+	privs := make([]string, 0)
+	depth := 0
+	var b strings.Builder
+	flush := func() {
+		if priv := strings.Join(strings.Fields(b.String()), " "); priv != "" {
+			privs = append(privs, priv)
+		}
+		b.Reset()
+	}
+	for _, r := range list {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth > 0:
+		case r == ',':
+			flush()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	flush()
+	return privs
+}
+
+// checkPrivileges reads the connected user's grants and reports any write
+// privileges as an error; the caller decides whether to warn or refuse.
+func checkPrivileges(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, "SHOW GRANTS")
+	if err != nil {
+		return fmt.Errorf("failed to read grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := make([]string, 0)
+	for rows.Next() {
+		var grant string
+		if err := rows.Scan(&grant); err != nil {
+			return fmt.Errorf("failed to read grants: %w", err)
+		}
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read grants: %w", err)
+	}
+	if found := writeGrants(grants); len(found) > 0 {
+		return fmt.Errorf("mysql user has write privileges: %s", strings.Join(found, "; "))
+	}
+	return nil
+}
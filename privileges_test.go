@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGrants(t *testing.T) {
+	require.Empty(t, writeGrants([]string{
+		"GRANT USAGE ON *.* TO `reader`@`%`",
+		"GRANT SELECT, SHOW VIEW ON `shop`.* TO `reader`@`%`",
+		"GRANT SELECT (`id`, `name`) ON `shop`.`customers` TO `reader`@`%`",
+		"GRANT `analyst`@`%` TO `reader`@`%`",
+		"GRANT PROCESS, REPLICATION CLIENT ON *.* TO `reader`@`%`",
+	}))
+
+	require.Equal(t, []string{
+		"INSERT ON `shop`.*",
+		"UPDATE ON `shop`.`orders`",
+		"ALL PRIVILEGES ON *.*",
+		"GRANT OPTION ON *.*",
+		"CREATE TEMPORARY TABLES ON `tmp`.*",
+	}, writeGrants([]string{
+		"GRANT SELECT, INSERT ON `shop`.* TO `app`@`%`",
+		"GRANT SELECT (`id`), UPDATE (`status`, `note`) ON `shop`.`orders` TO `app`@`%`",
+		"GRANT ALL PRIVILEGES ON *.* TO `root`@`localhost` WITH GRANT OPTION",
+		"grant create temporary tables on `tmp`.* to `app`@`%`",
+	}))
+}
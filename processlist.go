@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxTrackedConnections bounds connectionSet; MySQL never reuses connection
+// IDs, so dropping the oldest only forgets connections likely long closed.
+const maxTrackedConnections = 4096
+
+// connectionSet remembers the MySQL connection IDs this server has used, per
+// pool: the primary and each replica number their connections separately.
+type connectionSet struct {
+	mu    sync.Mutex
+	ids   map[connectionKey]bool
+	order []connectionKey
+}
+
+type connectionKey struct {
+	db *sql.DB
+	id int64
+}
+
+func newConnectionSet() *connectionSet {
+	return &connectionSet{ids: make(map[connectionKey]bool)}
+}
+
+func (s *connectionSet) add(db *sql.DB, id int64) {
+	key := connectionKey{db: db, id: id}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ids[key] {
+		return
+	}
+	if len(s.order) >= maxTrackedConnections {
+		delete(s.ids, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.ids[key] = true
+	s.order = append(s.order, key)
+}
+
+// owns returns whether id is a connection this server opened on db.
+func (s *connectionSet) owns(db *sql.DB) func(id int64) bool {
+	return func(id int64) bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.ids[connectionKey{db: db, id: id}]
+	}
+}
+
+type ProcesslistInput struct {
+	OwnConnections bool `json:"ownConnections,omitempty" jsonschema:"Only list connections opened by this server."`
+	RedactQueries  bool `json:"redactQueries,omitempty" jsonschema:"Replace query text with its fingerprint. Always on for roles limited to some schemas or tables."`
+}
+
+type ProcessInfo struct {
+	ID          int64  `json:"id"`
+	User        string `json:"user"`
+	Host        string `json:"host"`
+	Database    string `json:"database,omitempty"`
+	Command     string `json:"command"`
+	TimeSeconds int64  `json:"timeSeconds"`
+	State       string `json:"state,omitempty"`
+	Query       string `json:"query,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty" jsonschema:"Fingerprint of the query, when its text is redacted."`
+	Own         bool   `json:"own" jsonschema:"True if this server opened the connection."`
+}
+
+type ProcesslistOutput struct {
+	Processes []ProcessInfo `json:"processes"`
+}
+
+// buildProcesslist converts SHOW FULL PROCESSLIST rows, keeping only the
+// connections in own when ownOnly is set.
+func buildProcesslist(out QueryOutput, own func(int64) bool, ownOnly, redact bool) []ProcessInfo {
+	idCol := columnIndex(out.Columns, "Id")
+	userCol := columnIndex(out.Columns, "User")
+	hostCol := columnIndex(out.Columns, "Host")
+	dbCol := columnIndex(out.Columns, "db")
+	commandCol := columnIndex(out.Columns, "Command")
+	timeCol := columnIndex(out.Columns, "Time")
+	stateCol := columnIndex(out.Columns, "State")
+	infoCol := columnIndex(out.Columns, "Info")
+
+	processes := make([]ProcessInfo, 0, len(out.Rows))
+	for _, row := range out.Rows {
+		id, _ := valueInt64(rowValue(row, idCol))
+		isOwn := own(id)
+		if ownOnly && !isOwn {
+			continue
+		}
+		seconds, _ := valueInt64(rowValue(row, timeCol))
+		process := ProcessInfo{
+			ID:          id,
+			User:        valueString(rowValue(row, userCol)),
+			Host:        valueString(rowValue(row, hostCol)),
+			Database:    valueString(rowValue(row, dbCol)),
+			Command:     valueString(rowValue(row, commandCol)),
+			TimeSeconds: seconds,
+			State:       valueString(rowValue(row, stateCol)),
+			Query:       valueString(rowValue(row, infoCol)),
+			Own:         isOwn,
+		}
+		if redact && process.Query != "" {
+			process.Fingerprint = queryFingerprint(process.Query)
+			process.Query = ""
+		}
+		processes = append(processes, process)
+	}
+	return processes
+}
+
+// processlist lists the primary's connections. It doesn't go through
+// readDB: a replica's process list would show its replication threads and
+// its own connection IDs, not the primary's. Query text can name any
+// table, so principals whose role is limited to some only get
+// fingerprints.
+func (h *queryHandler) processlist(ctx context.Context, req *mcp.CallToolRequest, input ProcesslistInput) (*mcp.CallToolResult, ProcesslistOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	out, err := h.runQueryOn(ctx, h.db, false, "SHOW FULL PROCESSLIST")
+	if err != nil {
+		return toolErrorf(ProcesslistOutput{Processes: []ProcessInfo{}}, "%v", err)
+	}
+	redact := input.RedactQueries || h.access.restricted(ctx)
+	return nil, ProcesslistOutput{
+		Processes: buildProcesslist(out, h.connections.owns(h.db), input.OwnConnections, redact),
+	}, nil
+}
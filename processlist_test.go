@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildProcesslist(t *testing.T) {
+	out := QueryOutput{
+		Columns: []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info"},
+		Rows: [][]interface{}{
+			{int64(7), "mcp", "10.0.0.2:5123", "shop", "Query", int64(12), "Sending data", "SELECT * FROM orders WHERE id = 5"},
+			{int64(9), "app", "10.0.0.3:4411", nil, "Sleep", int64(300), "", nil},
+		},
+	}
+	primary, replica := &sql.DB{}, &sql.DB{}
+	own := newConnectionSet()
+	own.add(primary, 7)
+	own.add(replica, 9)
+
+	all := buildProcesslist(out, own.owns(primary), false, false)
+	require.Len(t, all, 2)
+	require.Equal(t, ProcessInfo{
+		ID: 7, User: "mcp", Host: "10.0.0.2:5123", Database: "shop", Command: "Query",
+		TimeSeconds: 12, State: "Sending data", Query: "SELECT * FROM orders WHERE id = 5", Own: true,
+	}, all[0])
+	require.False(t, all[1].Own, "connection 9 is on the replica")
+	require.Empty(t, all[1].Query)
+
+	mine := buildProcesslist(out, own.owns(primary), true, true)
+	require.Len(t, mine, 1)
+	require.Empty(t, mine[0].Query)
+	require.Equal(t, queryFingerprint("SELECT * FROM orders WHERE id = 1"), mine[0].Fingerprint)
+}
+
+// processRows serves a SHOW FULL PROCESSLIST row running a query.
+type processRows struct{ done bool }
+
+func (r *processRows) Columns() []string {
+	return []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info"}
+}
+func (r *processRows) Close() error { return nil }
+func (r *processRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, []driver.Value{int64(12), []byte("app"), []byte("10.0.0.3:4411"), []byte("hr"), []byte("Query"), int64(3), []byte("executing"), []byte("SELECT * FROM salaries WHERE name = 'Jane'")})
+	return nil
+}
+
+func TestProcesslistRedactsForRestrictedRoles(t *testing.T) {
+	cfg := Config{Access: AccessConfig{Roles: []RoleConfig{{Name: "analyst", Principals: []string{"team-a"}, Schemas: []string{"shop"}}}}}
+	v, err := newValidator(cfg, "shop")
+	require.NoError(t, err)
+	h := &queryHandler{
+		db:          sql.OpenDB(execConnector{}),
+		config:      cfg,
+		validator:   v,
+		access:      newAccessControl(cfg.Access),
+		connections: newConnectionSet(),
+	}
+
+	_, output, err := h.processlist(context.Background(), &mcp.CallToolRequest{}, ProcesslistInput{})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM salaries WHERE name = 'Jane'", output.Processes[0].Query)
+
+	_, output, err = h.processlist(withPrincipal(context.Background(), "team-a"), &mcp.CallToolRequest{}, ProcesslistInput{})
+	require.NoError(t, err)
+	require.Empty(t, output.Processes[0].Query)
+	require.Equal(t, queryFingerprint("SELECT * FROM salaries WHERE name = 'Jane'"), output.Processes[0].Fingerprint)
+}
+
+func TestConnectionSetEvictsOldest(t *testing.T) {
+	db := &sql.DB{}
+	s := newConnectionSet()
+	for i := int64(0); i <= maxTrackedConnections; i++ {
+		s.add(db, i)
+	}
+	require.False(t, s.owns(db)(0))
+	require.True(t, s.owns(db)(maxTrackedConnections))
+}
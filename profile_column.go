@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// profileSampleRows bounds how many rows a column profile reads. Profiles
+// of larger tables cover this many rows in storage order, so their
+// statistics are estimates.
+const profileSampleRows = 100000
+
+type ProfileColumnInput struct {
+	Database string `json:"database" jsonschema:"Database containing the table."`
+	Table    string `json:"table" jsonschema:"Table or view containing the column."`
+	Column   string `json:"column" jsonschema:"Column to profile."`
+	TopK     int    `json:"topK,omitempty" jsonschema:"Number of most frequent values to return (default 10, max 50)."`
+}
+
+type ValueFrequency struct {
+	Value interface{} `json:"value"`
+	Count int64       `json:"count"`
+}
+
+type ProfileColumnOutput struct {
+	Database      string           `json:"database"`
+	Table         string           `json:"table"`
+	Column        string           `json:"column"`
+	RowsScanned   int64            `json:"rowsScanned"`
+	Sampled       bool             `json:"sampled" jsonschema:"True if the table has more rows than were scanned, so the statistics are estimates."`
+	NullFraction  float64          `json:"nullFraction"`
+	DistinctCount int64            `json:"distinctCount" jsonschema:"Distinct non-null values among the rows scanned."`
+	Min           interface{}      `json:"min"`
+	Max           interface{}      `json:"max"`
+	TopValues     []ValueFrequency `json:"topValues" jsonschema:"Most frequent values, NULL included, most frequent first."`
+}
+
+// columnProfileQueries returns the summary and top-K statements for a
+// column, both over the same bounded sample. Identifiers must already be
+// validated.
+func columnProfileQueries(db, table, column string) (summary, top string) {
+	sample := fmt.Sprintf("(SELECT %s AS v FROM %s.%s LIMIT %d) AS sample",
+		quoteIdentifier(column), quoteIdentifier(db), quoteIdentifier(table), profileSampleRows+1)
+	summary = "SELECT COUNT(*) AS total, COUNT(v) AS non_null, COUNT(DISTINCT v) AS distinct_count, MIN(v) AS min_value, MAX(v) AS max_value FROM " + sample
+	top = "SELECT v AS value, COUNT(*) AS frequency FROM " + sample + " GROUP BY v ORDER BY frequency DESC, v LIMIT ?"
+	return summary, top
+}
+
+func buildColumnProfile(summary, top QueryOutput) ProfileColumnOutput {
+	var output ProfileColumnOutput
+	output.TopValues = []ValueFrequency{}
+	if len(summary.Rows) > 0 {
+		row := summary.Rows[0]
+		total, _ := valueInt64(rowValue(row, columnIndex(summary.Columns, "total")))
+		nonNull, _ := valueInt64(rowValue(row, columnIndex(summary.Columns, "non_null")))
+		output.DistinctCount, _ = valueInt64(rowValue(row, columnIndex(summary.Columns, "distinct_count")))
+		output.Min = rowValue(row, columnIndex(summary.Columns, "min_value"))
+		output.Max = rowValue(row, columnIndex(summary.Columns, "max_value"))
+		// The sample reads one extra row to tell a table of exactly
+		// profileSampleRows rows from a larger one.
+		output.Sampled = total > profileSampleRows
+		output.RowsScanned = min(total, profileSampleRows)
+		if total > 0 {
+			output.NullFraction = float64(total-nonNull) / float64(total)
+		}
+	}
+	valueCol := columnIndex(top.Columns, "value")
+	countCol := columnIndex(top.Columns, "frequency")
+	for _, row := range top.Rows {
+		count, _ := valueInt64(rowValue(row, countCol))
+		output.TopValues = append(output.TopValues, ValueFrequency{Value: rowValue(row, valueCol), Count: count})
+	}
+	return output
+}
+
+func (h *queryHandler) profileColumn(ctx context.Context, req *mcp.CallToolRequest, input ProfileColumnInput) (*mcp.CallToolResult, ProfileColumnOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := ProfileColumnOutput{}
+	if !mysqlIdentifierRE.MatchString(input.Database) || !mysqlIdentifierRE.MatchString(input.Table) || !mysqlIdentifierRE.MatchString(input.Column) {
+		return toolErrorf(empty, "database, table, and column must be plain identifiers")
+	}
+	topK := input.TopK
+	if topK <= 0 {
+		topK = 10
+	}
+	topK = min(topK, 50)
+
+	summaryQuery, topQuery := columnProfileQueries(input.Database, input.Table, input.Column)
+	summary, err := h.runQueryForResource(ctx, summaryQuery)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	top, err := h.runQueryForResource(ctx, topQuery, topK)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	output := buildColumnProfile(summary, top)
+	output.Database, output.Table, output.Column = input.Database, input.Table, input.Column
+	return nil, output, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnProfileQueries(t *testing.T) {
+	summary, top := columnProfileQueries("shop", "orders", "status")
+	require.Equal(t, "SELECT COUNT(*) AS total, COUNT(v) AS non_null, COUNT(DISTINCT v) AS distinct_count, MIN(v) AS min_value, MAX(v) AS max_value FROM (SELECT `status` AS v FROM `shop`.`orders` LIMIT 100001) AS sample", summary)
+	require.Equal(t, "SELECT v AS value, COUNT(*) AS frequency FROM (SELECT `status` AS v FROM `shop`.`orders` LIMIT 100001) AS sample GROUP BY v ORDER BY frequency DESC, v LIMIT ?", top)
+}
+
+func TestBuildColumnProfile(t *testing.T) {
+	summary := QueryOutput{
+		Columns: []string{"total", "non_null", "distinct_count", "min_value", "max_value"},
+		Rows:    [][]interface{}{{int64(200), int64(150), int64(3), "cancelled", "shipped"}},
+	}
+	top := QueryOutput{
+		Columns: []string{"value", "frequency"},
+		Rows:    [][]interface{}{{"shipped", int64(100)}, {nil, int64(50)}},
+	}
+	output := buildColumnProfile(summary, top)
+	require.Equal(t, int64(200), output.RowsScanned)
+	require.False(t, output.Sampled)
+	require.InDelta(t, 0.25, output.NullFraction, 1e-9)
+	require.Equal(t, int64(3), output.DistinctCount)
+	require.Equal(t, "cancelled", output.Min)
+	require.Equal(t, []ValueFrequency{{Value: "shipped", Count: 100}, {Value: nil, Count: 50}}, output.TopValues)
+
+	summary.Rows[0][0] = int64(profileSampleRows + 1)
+	output = buildColumnProfile(summary, QueryOutput{})
+	require.True(t, output.Sampled)
+	require.Equal(t, int64(profileSampleRows), output.RowsScanned)
+	require.Equal(t, []ValueFrequency{}, output.TopValues)
+}
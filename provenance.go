@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// ColumnSource describes where an output column comes from: a base table
+// column, or an expression when it can't be traced to a single table.
+type ColumnSource struct {
+	Schema     string `json:"schema,omitempty" jsonschema:"Database of the source table, if qualified in the query."`
+	Table      string `json:"table,omitempty" jsonschema:"Source table name."`
+	Column     string `json:"column,omitempty" jsonschema:"Source column name."`
+	Expression string `json:"expression,omitempty" jsonschema:"SQL expression for computed or ambiguous columns."`
+}
+
+// columnLookup returns the columns of schema.table, or false if unknown.
+type columnLookup func(schema, table string) ([]string, bool)
+
+type sourceTable struct {
+	alias   string
+	schema  string
+	table   string
+	derived bool
+}
+
+// resolveColumnSources maps each select expression of a single SELECT to its
+// source. It returns nil whenever the result can't be aligned one-to-one with
+// the output columns (UNIONs, stars over unknown or derived tables).
+func resolveColumnSources(stmt sqlparser.Statement, lookup columnLookup) []ColumnSource {
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || sel.SelectExprs == nil {
+		return nil
+	}
+
+	tables := make([]sourceTable, 0)
+	for _, expr := range sel.From {
+		tables = collectSourceTables(expr, tables)
+	}
+
+	sources := make([]ColumnSource, 0, len(sel.SelectExprs.Exprs))
+	for _, selectExpr := range sel.SelectExprs.Exprs {
+		switch expr := selectExpr.(type) {
+		case *sqlparser.StarExpr:
+			matched := false
+			for _, t := range tables {
+				if !expr.TableName.IsEmpty() && !matchesTable(t, expr.TableName) {
+					continue
+				}
+				matched = true
+				if t.derived {
+					return nil
+				}
+				columns, ok := lookup(t.schema, t.table)
+				if !ok || len(columns) == 0 {
+					return nil
+				}
+				for _, col := range columns {
+					sources = append(sources, ColumnSource{Schema: t.schema, Table: t.table, Column: col})
+				}
+			}
+			if !matched {
+				return nil
+			}
+		case *sqlparser.AliasedExpr:
+			sources = append(sources, resolveExprSource(expr.Expr, tables, lookup))
+		default:
+			return nil
+		}
+	}
+	return sources
+}
+
+func collectSourceTables(expr sqlparser.TableExpr, tables []sourceTable) []sourceTable {
+	switch t := expr.(type) {
+	case *sqlparser.AliasedTableExpr:
+		switch inner := t.Expr.(type) {
+		case sqlparser.TableName:
+			alias := inner.Name.String()
+			if !t.As.IsEmpty() {
+				alias = t.As.String()
+			}
+			tables = append(tables, sourceTable{
+				alias:  alias,
+				schema: inner.Qualifier.String(),
+				table:  inner.Name.String(),
+			})
+		case *sqlparser.DerivedTable:
+			tables = append(tables, sourceTable{alias: t.As.String(), derived: true})
+		}
+	case *sqlparser.JoinTableExpr:
+		tables = collectSourceTables(t.LeftExpr, tables)
+		tables = collectSourceTables(t.RightExpr, tables)
+	case *sqlparser.ParenTableExpr:
+		for _, inner := range t.Exprs {
+			tables = collectSourceTables(inner, tables)
+		}
+	}
+	return tables
+}
+
+func matchesTable(t sourceTable, name sqlparser.TableName) bool {
+	if !strings.EqualFold(t.alias, name.Name.String()) {
+		return false
+	}
+	return name.Qualifier.IsEmpty() || strings.EqualFold(t.schema, name.Qualifier.String())
+}
+
+func resolveExprSource(expr sqlparser.Expr, tables []sourceTable, lookup columnLookup) ColumnSource {
+	col, ok := expr.(*sqlparser.ColName)
+	if !ok {
+		return ColumnSource{Expression: sqlparser.String(expr)}
+	}
+	name := col.Name.String()
+
+	candidates := make([]sourceTable, 0, 1)
+	if !col.Qualifier.IsEmpty() {
+		for _, t := range tables {
+			if matchesTable(t, col.Qualifier) {
+				candidates = append(candidates, t)
+			}
+		}
+	} else if len(tables) == 1 {
+		candidates = append(candidates, tables[0])
+	} else {
+		for _, t := range tables {
+			if t.derived {
+				continue
+			}
+			columns, ok := lookup(t.schema, t.table)
+			if !ok {
+				continue
+			}
+			for _, c := range columns {
+				if strings.EqualFold(c, name) {
+					candidates = append(candidates, t)
+					break
+				}
+			}
+		}
+	}
+
+	if len(candidates) != 1 || candidates[0].derived {
+		return ColumnSource{Column: name, Expression: sqlparser.String(expr)}
+	}
+	return ColumnSource{Schema: candidates[0].schema, Table: candidates[0].table, Column: name}
+}
+
+// columnSources annotates a tool query's output columns with their source
+// tables, using the schema cache to expand stars and resolve unqualified
+// names in joins. It returns nil when the sources can't be determined; callers
+// must still check the length against the actual result columns.
+func (h *queryHandler) columnSources(ctx context.Context, query string) []ColumnSource {
+	if h.schema == nil {
+		return nil
+	}
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return nil
+	}
+	return resolveColumnSources(stmt, func(schema, table string) ([]string, bool) {
+		columns, err := h.schema.tableColumns(ctx, schema, table)
+		if err != nil {
+			return nil, false
+		}
+		return columns, true
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestResolveColumnSources(t *testing.T) {
+	catalog := map[string][]string{
+		".orders":     {"id", "customer_id", "status"},
+		".customers":  {"id", "name", "status"},
+		"shop.events": {"id", "kind"},
+	}
+	lookup := func(schema, table string) ([]string, bool) {
+		columns, ok := catalog[schema+"."+table]
+		return columns, ok
+	}
+
+	cases := []struct {
+		name  string
+		query string
+		want  []ColumnSource
+	}{
+		{
+			name:  "single table star",
+			query: "SELECT * FROM shop.events",
+			want: []ColumnSource{
+				{Schema: "shop", Table: "events", Column: "id"},
+				{Schema: "shop", Table: "events", Column: "kind"},
+			},
+		},
+		{
+			name:  "join with aliases",
+			query: "SELECT o.status, c.status AS customer_status, name, count(*) FROM orders o JOIN customers c ON c.id = o.customer_id",
+			want: []ColumnSource{
+				{Table: "orders", Column: "status"},
+				{Table: "customers", Column: "status"},
+				{Table: "customers", Column: "name"},
+				{Expression: "count(*)"},
+			},
+		},
+		{
+			name:  "ambiguous unqualified column",
+			query: "SELECT id FROM orders, customers",
+			want:  []ColumnSource{{Column: "id", Expression: "id"}},
+		},
+		{
+			name:  "qualified star in join",
+			query: "SELECT c.*, o.id FROM orders o JOIN customers c ON c.id = o.customer_id;",
+			want: []ColumnSource{
+				{Table: "customers", Column: "id"},
+				{Table: "customers", Column: "name"},
+				{Table: "customers", Column: "status"},
+				{Table: "orders", Column: "id"},
+			},
+		},
+		{"star over unknown table", "SELECT * FROM missing", nil},
+		{"star over derived table", "SELECT * FROM (SELECT 1 AS x) d", nil},
+		{"union", "SELECT id FROM orders UNION SELECT id FROM customers", nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stmt, err := gate.ParseStatement(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, resolveColumnSources(stmt, lookup))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestPseudonymizedColumns(t *testing.T) {
+	cfg := Config{PII: PIIConfig{Mode: piiModeMask, Pseudonymize: []string{"orders.customer_id", "shop.customers.email"}}}
+	v, err := newValidator(cfg, "shop")
+	require.NoError(t, err)
+
+	allowed := map[string][]string{
+		"SELECT customer_id, total FROM orders":                                          {"customer_id"},
+		"SELECT o.customer_id AS customer_id FROM shop.orders o WHERE o.total > 10":      {"customer_id"},
+		"SELECT * FROM orders":                                                           {"customer_id"},
+		"SELECT c.email, o.total FROM customers c JOIN orders o ON o.customer_id = c.id": {"email"},
+		"SELECT customer_id, COUNT(*) FROM orders GROUP BY customer_id ORDER BY 2 DESC":  {"customer_id"},
+		"SELECT total FROM orders WHERE customer_id IN (SELECT customer_id FROM orders)": {},
+		"SELECT total FROM orders o JOIN customers c USING (id) WHERE c.email IS NULL":   {},
+		"SELECT id, name FROM users":                                                     nil,
+		"EXPLAIN SELECT CONCAT(customer_id, '') FROM orders":                             nil,
+	}
+	for query, want := range allowed {
+		require.NoError(t, v.Validate(query), query)
+		names := v.PseudonymizedColumns(query)
+		if want == nil {
+			require.Nil(t, names, query)
+			continue
+		}
+		require.Len(t, names, len(want), query)
+		for _, name := range want {
+			require.True(t, names[name], query)
+		}
+	}
+
+	profileSummary, profileTop := columnProfileQueries("shop", "orders", "customer_id")
+	rejected := []string{
+		"SELECT customer_id AS c FROM orders",
+		"SELECT customer_id + 0 FROM orders",
+		"SELECT CONCAT(customer_id, '') AS customer_id FROM orders",
+		"SELECT LOWER(email) FROM customers",
+		"SELECT c FROM (SELECT customer_id AS c FROM orders) AS t",
+		"SELECT customer_id FROM (SELECT customer_id FROM orders) AS t",
+		"SELECT t.* FROM (SELECT * FROM orders) AS t",
+		"WITH t AS (SELECT customer_id FROM orders) SELECT * FROM t",
+		"SELECT id FROM users UNION SELECT customer_id FROM orders",
+		"SELECT (SELECT MAX(customer_id) FROM orders) AS m",
+		// Predicates on the hidden value are oracles for it.
+		"SELECT id FROM customers WHERE email LIKE 'a%'",
+		"SELECT id FROM customers WHERE email = 'jane@example.com'",
+		"SELECT id FROM customers WHERE email > 'm'",
+		"SELECT id FROM customers WHERE email BETWEEN 'a' AND 'b'",
+		"SELECT id FROM customers WHERE LENGTH(email) = 20",
+		"SELECT id FROM customers WHERE LOCATE('@example.com', email)",
+		"SELECT total FROM orders o JOIN customers c ON c.id = o.id AND c.email REGEXP '^a'",
+		"SELECT id FROM customers ORDER BY email",
+		"SELECT customer_id FROM orders GROUP BY customer_id HAVING customer_id < 100",
+		"SELECT id FROM users WHERE 'jane@example.com' IN (SELECT email FROM customers)",
+		// The server's own tools alias the column.
+		profileSummary,
+		profileTop,
+		collationOrderQuery("shop", "orders", "customer_id", "utf8mb4_sv_0900_ai_ci", "utf8mb4"),
+	}
+	for _, query := range rejected {
+		var rejection *gate.QueryRejection
+		require.ErrorAs(t, v.Validate(query), &rejection, query)
+		require.Contains(t, rejection.Reason, "pseudonymized", query)
+	}
+}
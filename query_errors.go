@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"github.com/go-sql-driver/mysql"
+	"mysqlmcp/internal/gate"
+)
+
+// Error categories, for agents to branch on instead of parsing messages.
+const (
+	errorSyntax         = "syntax"
+	errorSchema         = "schema"
+	errorPermission     = "permission"
+	errorTimeout        = "timeout"
+	errorDeniedByPolicy = "denied_by_policy"
+	errorConnection     = "connection"
+	errorInvalidInput   = "invalid_input"
+	errorServer         = "server"
+)
+
+// MySQL server and client error numbers, besides those in backup_lock.go and
+// group_by.go.
+const (
+	erTooManyConnections   = 1040
+	erDBAccessDenied       = 1044
+	erAccessDenied         = 1045
+	erBadDB                = 1049
+	erServerShutdown       = 1053
+	erBadField             = 1054
+	erParse                = 1064
+	erTableAccessDenied    = 1142
+	erColumnAccessDenied   = 1143
+	erSyntax               = 1149
+	erSpecificAccessDenied = 1227
+	erSPDoesNotExist       = 1305
+	erProcAccessDenied     = 1370
+	crConnectionError      = 2002
+	crConnHostError        = 2003
+	crServerGone           = 2006
+	crServerLost           = 2013
+)
+
+// QueryError classifies a failed tool call. Code and SQLState are set when
+// MySQL reported the error.
+type QueryError struct {
+	Category    string `json:"category" jsonschema:"One of syntax, schema, permission, timeout, denied_by_policy, connection, invalid_input, or server."`
+	Code        uint16 `json:"code,omitempty" jsonschema:"MySQL error number."`
+	SQLState    string `json:"sqlState,omitempty"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation" jsonschema:"What to try next."`
+}
+
+// classifyError builds the QueryError for a tool error with message, caused
+// by err. Errors that don't come from MySQL, the connection, or the read-only
+// gate (including a nil err) are problems with the call's own arguments.
+func classifyError(message string, err error) *QueryError {
+	e := &QueryError{Message: message}
+	var mysqlErr *mysql.MySQLError
+	var rejection *gate.QueryRejection
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errNotConfirmed):
+		e.Category = errorDeniedByPolicy
+		e.Remediation = "The statement needs a person's confirmation, which wasn't given; don't retry it unless the user asks to."
+	case errors.As(err, &rejection):
+		if rejection.ParseError() != nil {
+			e.Category = errorSyntax
+			e.Remediation = "Fix the SQL syntax; mysql_format_sql shows how the server's parser reads the query."
+		} else {
+			e.Category = errorDeniedByPolicy
+			e.Remediation = "The server's read-only policy forbids this query; rewrite it without the rejected construct."
+		}
+	case errors.As(err, &mysqlErr):
+		e.Code = mysqlErr.Number
+		if mysqlErr.SQLState != [5]byte{} {
+			e.SQLState = string(mysqlErr.SQLState[:])
+		}
+		e.Category, e.Remediation = classifyMySQLError(mysqlErr.Number)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		e.Category = errorTimeout
+		e.Remediation = "Narrow the query (filters, LIMIT, indexed columns) or pass a larger timeoutSeconds."
+	case errors.Is(err, errDatabaseUnavailable), errors.Is(err, driver.ErrBadConn), errors.Is(err, mysql.ErrInvalidConn), errors.As(err, &netErr):
+		e.Category = errorConnection
+		e.Remediation = "The database connection failed; retry shortly, and check mysql://server_info if it keeps failing."
+	default:
+		e.Category = errorInvalidInput
+		e.Remediation = "Fix the tool arguments as the message describes."
+	}
+	return e
+}
+
+func classifyMySQLError(number uint16) (category, remediation string) {
+	switch number {
+	case erParse, erSyntax, erWrongFieldWithGroup, erMixOfGroupFunc, erFieldNotInGroupBy:
+		return errorSyntax, "Fix the SQL; the message gives the position or clause MySQL rejected."
+	case erNoSuchTable, erBadField, erBadDB, erSPDoesNotExist:
+		return errorSchema, "Check database, table, column, and function names with the mysql://tables and mysql://schema resources."
+	case erDBAccessDenied, erAccessDenied, erTableAccessDenied, erColumnAccessDenied, erSpecificAccessDenied, erProcAccessDenied:
+		return errorPermission, "The server's MySQL account lacks this privilege; query objects it can read, or ask an administrator for a grant."
+	case erLockWaitTimeout, erQueryInterrupted, erQueryTimeout:
+		return errorTimeout, "Narrow the query (filters, LIMIT, indexed columns) or pass a larger timeoutSeconds; if rows are locked, retry later."
+	case erTooManyConnections, erServerShutdown, crConnectionError, crConnHostError, crServerGone, crServerLost:
+		return errorConnection, "The database connection failed; retry shortly, and check mysql://server_info if it keeps failing."
+	}
+	return errorServer, "Read the MySQL error message; the query or its arguments may need changes."
+}
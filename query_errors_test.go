@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		category string
+		code     uint16
+	}{
+		{"no cause", nil, errorInvalidInput, 0},
+		{"bad argument", errors.New("params[0]: unsupported type"), errorInvalidInput, 0},
+		{"gate rejection", &gate.QueryRejection{Construct: "DELETE", Reason: "only SELECT, SHOW, DESCRIBE, and EXPLAIN statements are allowed"}, errorDeniedByPolicy, 0},
+		{"unparsable query", testValidator(t, gate.Options{}).Validate("SELEC 1"), errorSyntax, 0},
+		{"mysql syntax", &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"}, errorSyntax, 1064},
+		{"unknown column", fmt.Errorf("query failed: %w", &mysql.MySQLError{Number: 1054}), errorSchema, 1054},
+		{"table privilege", &mysql.MySQLError{Number: 1142}, errorPermission, 1142},
+		{"max_execution_time", &mysql.MySQLError{Number: 3024}, errorTimeout, 3024},
+		{"deadline", context.DeadlineExceeded, errorTimeout, 0},
+		{"bad connection", mysql.ErrInvalidConn, errorConnection, 0},
+		{"server gone", &mysql.MySQLError{Number: 2006}, errorConnection, 2006},
+		{"other mysql error", &mysql.MySQLError{Number: 1365}, errorServer, 1365},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := classifyError("boom", tc.err)
+			require.Equal(t, tc.category, got.Category)
+			require.Equal(t, tc.code, got.Code)
+			require.Equal(t, "boom", got.Message)
+			require.NotEmpty(t, got.Remediation)
+		})
+	}
+}
+
+func TestToolErrorResultfClassifiesCause(t *testing.T) {
+	result, output := toolErrorResultf("query failed: %v", &mysql.MySQLError{Number: 1146, SQLState: [5]byte{'4', '2', 'S', '0', '2'}, Message: "Table 'shop.ordrs' doesn't exist"})
+	require.Equal(t, errorSchema, output.Error.Category)
+	require.Equal(t, "42S02", output.Error.SQLState)
+	require.Same(t, output.Error, result.StructuredContent.(map[string]any)["error"])
+}
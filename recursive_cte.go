@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// isRecursiveQuery reports whether query has a WITH RECURSIVE clause at any
+// level. A recursive CTE's cost is inside MySQL, where max_rows can't cap it.
+func isRecursiveQuery(query string) bool {
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return false
+	}
+	recursive := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if with, ok := node.(*sqlparser.With); ok && with.Recursive {
+			recursive = true
+			return false, nil
+		}
+		return true, nil
+	}, stmt)
+	return recursive
+}
+
+// recursiveLimits returns the recursion depth and timeout for recursive
+// queries: recursive_cte_max_depth (default 1000) and
+// recursive_cte_timeout_seconds (default 10), the latter never longer than
+// timeout.
+func (h *queryHandler) recursiveLimits(timeout time.Duration) (depth int, recursiveTimeout time.Duration) {
+	cfg := h.snapshot().config.MySQL
+	depth = cfg.RecursiveCTEMaxDepth
+	if depth <= 0 {
+		depth = 1000
+	}
+	recursiveTimeout = time.Duration(cfg.RecursiveCTETimeoutSeconds) * time.Second
+	if recursiveTimeout <= 0 {
+		recursiveTimeout = 10 * time.Second
+	}
+	return depth, min(recursiveTimeout, timeout)
+}
+
+// limitRecursion sets cte_max_recursion_depth on conn for one query. Call
+// restore before conn goes back to the pool.
+func limitRecursion(ctx context.Context, conn *sql.Conn, depth int) (restore func(), err error) {
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION cte_max_recursion_depth = %d", depth)); err != nil {
+		return nil, fmt.Errorf("failed to limit recursion depth: %w", err)
+	}
+	return func() {
+		// If the query was killed the connection may be unusable, and the
+		// pool discards it anyway.
+		ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+		defer cancel()
+		_, _ = conn.ExecContext(ctx, "SET SESSION cte_max_recursion_depth = DEFAULT")
+	}, nil
+}
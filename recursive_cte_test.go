@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRecursiveQuery(t *testing.T) {
+	require.True(t, isRecursiveQuery("WITH RECURSIVE n AS (SELECT 1 AS i UNION ALL SELECT i + 1 FROM n WHERE i < 10) SELECT * FROM n"))
+	require.True(t, isRecursiveQuery("SELECT * FROM t WHERE id IN (WITH RECURSIVE n AS (SELECT 1 AS i UNION ALL SELECT i + 1 FROM n) SELECT i FROM n)"))
+	require.False(t, isRecursiveQuery("WITH n AS (SELECT 1 AS i) SELECT * FROM n"))
+	require.False(t, isRecursiveQuery("SELECT 'WITH RECURSIVE'"))
+	require.False(t, isRecursiveQuery("not sql"))
+}
+
+func TestRecursiveLimits(t *testing.T) {
+	h := &queryHandler{}
+	depth, timeout := h.recursiveLimits(30 * time.Second)
+	require.Equal(t, 1000, depth)
+	require.Equal(t, 10*time.Second, timeout)
+
+	h.config.MySQL.RecursiveCTEMaxDepth = 50
+	h.config.MySQL.RecursiveCTETimeoutSeconds = 20
+	depth, timeout = h.recursiveLimits(5 * time.Second)
+	require.Equal(t, 50, depth)
+	require.Equal(t, 5*time.Second, timeout)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// relatedMaxRelations bounds how many relations are followed in each
+// direction, so a hub table can't fan a call out into dozens of queries.
+const relatedMaxRelations = 10
+
+// RelationConfig declares a foreign key the schema doesn't have, for
+// mysql_related_rows. Table and References are "db.table", or "table" in the
+// DSN's default database.
+type RelationConfig struct {
+	Name              string   `toml:"name"`
+	Table             string   `toml:"table"`
+	Columns           []string `toml:"columns"`
+	References        string   `toml:"references"`
+	ReferencedColumns []string `toml:"referenced_columns"`
+}
+
+// relationLink is a foreign key, declared or configured: columns of
+// schema.table reference refColumns of refSchema.refTable.
+type relationLink struct {
+	name                string
+	schema, table       string
+	columns             []string
+	refSchema, refTable string
+	refColumns          []string
+}
+
+func splitTableName(name, defaultSchema string) (schema, table string, err error) {
+	schema, table, ok := strings.Cut(name, ".")
+	if !ok {
+		schema, table = defaultSchema, name
+	}
+	if schema == "" {
+		return "", "", fmt.Errorf("%q: no database given and the DSN has no default database", name)
+	}
+	if !mysqlIdentifierRE.MatchString(schema) || !mysqlIdentifierRE.MatchString(table) {
+		return "", "", fmt.Errorf("%q is not a plain db.table name", name)
+	}
+	return schema, table, nil
+}
+
+// compileRelations checks configured relations and resolves their tables.
+func compileRelations(configs []RelationConfig, defaultSchema string) ([]relationLink, error) {
+	links := make([]relationLink, 0, len(configs))
+	for _, cfg := range configs {
+		link := relationLink{name: cfg.Name, columns: cfg.Columns, refColumns: cfg.ReferencedColumns}
+		var err error
+		if link.schema, link.table, err = splitTableName(cfg.Table, defaultSchema); err != nil {
+			return nil, fmt.Errorf("relation %q: table %w", cfg.Name, err)
+		}
+		if link.refSchema, link.refTable, err = splitTableName(cfg.References, defaultSchema); err != nil {
+			return nil, fmt.Errorf("relation %q: references %w", cfg.Name, err)
+		}
+		if len(cfg.Columns) == 0 || len(cfg.Columns) != len(cfg.ReferencedColumns) {
+			return nil, fmt.Errorf("relation %q: columns and referenced_columns must be non-empty and the same length", cfg.Name)
+		}
+		for _, column := range append(append([]string{}, cfg.Columns...), cfg.ReferencedColumns...) {
+			if !mysqlIdentifierRE.MatchString(column) {
+				return nil, fmt.Errorf("relation %q: column %q must be a plain identifier", cfg.Name, column)
+			}
+		}
+		if link.name == "" {
+			link.name = fmt.Sprintf("%s.%s->%s.%s", link.schema, link.table, link.refSchema, link.refTable)
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// linksFromRelations converts the foreign keys declared in db.
+func linksFromRelations(db string, relations []Relation) []relationLink {
+	links := make([]relationLink, len(relations))
+	for i, r := range relations {
+		links[i] = relationLink{
+			name: r.Name, schema: db, table: r.Table, columns: r.Columns,
+			refSchema: r.ReferencedSchema, refTable: r.ReferencedTable, refColumns: r.ReferencedColumns,
+		}
+	}
+	return links
+}
+
+// splitLinks returns the links by which schema.table references a parent,
+// and those by which children reference it.
+func splitLinks(links []relationLink, schema, table string) (parents, children []relationLink) {
+	for _, link := range links {
+		if strings.EqualFold(link.schema, schema) && strings.EqualFold(link.table, table) {
+			parents = append(parents, link)
+		}
+		if strings.EqualFold(link.refSchema, schema) && strings.EqualFold(link.refTable, table) {
+			children = append(children, link)
+		}
+	}
+	return parents, children
+}
+
+type RelatedRowsInput struct {
+	Database   string         `json:"database" jsonschema:"Database containing the table."`
+	Table      string         `json:"table" jsonschema:"Table of the starting row."`
+	Key        map[string]any `json:"key" jsonschema:"Primary key value for every primary key column of the starting row, by column name."`
+	ChildLimit int            `json:"childLimit,omitempty" jsonschema:"Child rows to return per relation (default 5, max 20)."`
+}
+
+type RelatedRecords struct {
+	Relation  string           `json:"relation"`
+	Database  string           `json:"database"`
+	Table     string           `json:"table"`
+	Rows      []map[string]any `json:"rows"`
+	Truncated bool             `json:"truncated,omitempty" jsonschema:"True if there are more child rows than childLimit."`
+}
+
+type RelatedRowsOutput struct {
+	Database string           `json:"database"`
+	Table    string           `json:"table"`
+	Found    bool             `json:"found"`
+	Row      map[string]any   `json:"row,omitempty"`
+	Parents  []RelatedRecords `json:"parents" jsonschema:"Rows the starting row references, one entry per foreign key."`
+	Children []RelatedRecords `json:"children" jsonschema:"Rows referencing the starting row, one entry per foreign key."`
+}
+
+// rowObject returns row i of out keyed by column name.
+func rowObject(out QueryOutput, i int) map[string]any {
+	row := make(map[string]any, len(out.Columns))
+	for j, column := range out.Columns {
+		row[column] = rowValue(out.Rows[i], j)
+	}
+	return row
+}
+
+// relatedQuery selects rows of db.table whose columns equal values.
+// Identifiers must already be validated.
+func relatedQuery(db, table string, columns []string, limit int) string {
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		conditions[i] = quoteIdentifier(column) + " = ?"
+	}
+	return fmt.Sprintf("SELECT * FROM %s.%s WHERE %s LIMIT %d", quoteIdentifier(db), quoteIdentifier(table), strings.Join(conditions, " AND "), limit)
+}
+
+func (h *queryHandler) relatedRows(ctx context.Context, req *mcp.CallToolRequest, input RelatedRowsInput) (*mcp.CallToolResult, RelatedRowsOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := RelatedRowsOutput{}
+	if !mysqlIdentifierRE.MatchString(input.Database) || !mysqlIdentifierRE.MatchString(input.Table) {
+		return toolErrorf(empty, "database and table must be plain identifiers")
+	}
+	childLimit := input.ChildLimit
+	if childLimit <= 0 {
+		childLimit = 5
+	}
+	childLimit = min(childLimit, 20)
+
+	primaryKey, err := h.schema.primaryKey(ctx, input.Database, input.Table)
+	if err != nil {
+		return toolErrorf(empty, "failed to look up primary key: %v", err)
+	}
+	if len(primaryKey) == 0 {
+		return toolErrorf(empty, "%s.%s has no primary key, or doesn't exist", input.Database, input.Table)
+	}
+	query, args, err := rowByPKQuery(input.Database, input.Table, primaryKey, input.Key)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	root, err := h.runQueryForResource(ctx, query, args...)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	output := RelatedRowsOutput{Database: input.Database, Table: input.Table, Parents: []RelatedRecords{}, Children: []RelatedRecords{}}
+	if len(root.Rows) == 0 {
+		return nil, output, nil
+	}
+	output.Found = true
+	row := rowObject(root, 0)
+
+	configured, err := compileRelations(h.snapshot().config.Relations, h.defaultSchema)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	declared, err := h.runMetadataQuery(ctx, relationsQuery, input.Database)
+	if err != nil {
+		return toolErrorf(empty, "failed to read foreign keys: %v", err)
+	}
+	links := append(linksFromRelations(input.Database, buildRelations(declared)), configured...)
+	parents, children := splitLinks(links, input.Database, input.Table)
+
+	follow := func(link relationLink, db, table string, keyColumns, valueColumns []string, limit int) (RelatedRecords, bool, error) {
+		values := make([]any, len(valueColumns))
+		for i, column := range valueColumns {
+			// Omitted binary values can't be matched on.
+			value, ok := lookupColumn(row, column)
+			if binary, isBinary := value.(BinaryValue); !ok || value == nil || (isBinary && binary.Omitted) {
+				return RelatedRecords{}, false, nil
+			}
+			values[i] = resultArg(value)
+		}
+		out, err := h.runQueryForResource(ctx, relatedQuery(db, table, keyColumns, limit+1), values...)
+		if err != nil {
+			return RelatedRecords{}, false, fmt.Errorf("relation %s: %w", link.name, err)
+		}
+		if h.safeIntegers(nil) {
+			out = withSafeIntegers(out)
+		}
+		records := RelatedRecords{Relation: link.name, Database: db, Table: table, Rows: []map[string]any{}}
+		for i := range out.Rows {
+			if i == limit {
+				records.Truncated = true
+				break
+			}
+			records.Rows = append(records.Rows, rowObject(out, i))
+		}
+		return records, true, nil
+	}
+	for _, link := range parents[:min(len(parents), relatedMaxRelations)] {
+		records, ok, err := follow(link, link.refSchema, link.refTable, link.refColumns, link.columns, 1)
+		if err != nil {
+			return toolErrorf(empty, "%v", err)
+		}
+		if ok {
+			output.Parents = append(output.Parents, records)
+		}
+	}
+	for _, link := range children[:min(len(children), relatedMaxRelations)] {
+		records, ok, err := follow(link, link.schema, link.table, link.columns, link.refColumns, childLimit)
+		if err != nil {
+			return toolErrorf(empty, "%v", err)
+		}
+		if ok {
+			output.Children = append(output.Children, records)
+		}
+	}
+
+	if h.safeIntegers(nil) {
+		root = withSafeIntegers(root)
+	}
+	output.Row = rowObject(root, 0)
+	return nil, output, nil
+}
+
+// lookupColumn finds column in row regardless of case.
+func lookupColumn(row map[string]any, column string) (any, bool) {
+	if value, ok := row[column]; ok {
+		return value, true
+	}
+	for name, value := range row {
+		if strings.EqualFold(name, column) {
+			return value, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileRelations(t *testing.T) {
+	links, err := compileRelations([]RelationConfig{{
+		Table: "orders", Columns: []string{"customer_id"}, References: "crm.customers", ReferencedColumns: []string{"id"},
+	}}, "shop")
+	require.NoError(t, err)
+	require.Equal(t, []relationLink{{
+		name: "shop.orders->crm.customers", schema: "shop", table: "orders", columns: []string{"customer_id"},
+		refSchema: "crm", refTable: "customers", refColumns: []string{"id"},
+	}}, links)
+
+	_, err = compileRelations([]RelationConfig{{Table: "orders", Columns: []string{"customer_id"}, References: "customers", ReferencedColumns: []string{"id"}}}, "")
+	require.ErrorContains(t, err, "no database given")
+
+	_, err = compileRelations([]RelationConfig{{Table: "orders", Columns: []string{"a", "b"}, References: "customers", ReferencedColumns: []string{"id"}}}, "shop")
+	require.ErrorContains(t, err, "same length")
+
+	_, err = compileRelations([]RelationConfig{{Table: "orders", Columns: []string{"id; DROP"}, References: "customers", ReferencedColumns: []string{"id"}}}, "shop")
+	require.ErrorContains(t, err, "plain identifier")
+}
+
+func TestSplitLinks(t *testing.T) {
+	links := linksFromRelations("shop", []Relation{
+		{Name: "fk_orders_customer", Table: "orders", Columns: []string{"customer_id"}, ReferencedSchema: "shop", ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+		{Name: "fk_items_order", Table: "order_items", Columns: []string{"order_id"}, ReferencedSchema: "shop", ReferencedTable: "orders", ReferencedColumns: []string{"id"}},
+	})
+	parents, children := splitLinks(links, "shop", "Orders")
+	require.Len(t, parents, 1)
+	require.Equal(t, "fk_orders_customer", parents[0].name)
+	require.Len(t, children, 1)
+	require.Equal(t, "fk_items_order", children[0].name)
+}
+
+func TestRelatedQuery(t *testing.T) {
+	require.Equal(t, "SELECT * FROM `shop`.`order_items` WHERE `order_id` = ? AND `line` = ? LIMIT 6", relatedQuery("shop", "order_items", []string{"order_id", "line"}, 6))
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// graphKeyColumnsQuery reads the primary key and foreign key columns of a
+// database's tables, which are all a relationship diagram shows.
+const graphKeyColumnsQuery = `SELECT c.TABLE_NAME AS table_name, c.COLUMN_NAME AS column_name, c.DATA_TYPE AS data_type,
+	c.IS_NULLABLE AS is_nullable, c.COLUMN_KEY AS column_key
+FROM information_schema.COLUMNS c
+WHERE c.TABLE_SCHEMA = ? AND (c.COLUMN_KEY = 'PRI' OR EXISTS (SELECT 1 FROM information_schema.KEY_COLUMN_USAGE k
+	WHERE k.TABLE_SCHEMA = c.TABLE_SCHEMA AND k.TABLE_NAME = c.TABLE_NAME AND k.COLUMN_NAME = c.COLUMN_NAME
+	AND k.REFERENCED_TABLE_NAME IS NOT NULL))
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`
+
+const graphTablesQuery = `SELECT TABLE_NAME AS table_name FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME`
+
+type RelationshipGraphInput struct {
+	Database string `json:"database" jsonschema:"Database to map."`
+}
+
+type GraphKeyColumn struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	PrimaryKey bool   `json:"primaryKey,omitempty"`
+	ForeignKey bool   `json:"foreignKey,omitempty"`
+}
+
+type GraphTable struct {
+	Database   string           `json:"database"`
+	Name       string           `json:"name"`
+	KeyColumns []GraphKeyColumn `json:"keyColumns" jsonschema:"Primary key and foreign key columns. Empty for tables outside the database."`
+}
+
+type GraphEdge struct {
+	Name               string   `json:"name"`
+	Database           string   `json:"database"`
+	Table              string   `json:"table"`
+	Columns            []string `json:"columns"`
+	ReferencedDatabase string   `json:"referencedDatabase"`
+	ReferencedTable    string   `json:"referencedTable"`
+	ReferencedColumns  []string `json:"referencedColumns"`
+	Optional           bool     `json:"optional" jsonschema:"True if a referencing column is nullable, so a row may have no parent."`
+	Configured         bool     `json:"configured,omitempty" jsonschema:"True for relations from the server config rather than declared foreign keys."`
+}
+
+type RelationshipGraphOutput struct {
+	Database  string       `json:"database"`
+	Tables    []GraphTable `json:"tables"`
+	Edges     []GraphEdge  `json:"edges"`
+	Mermaid   string       `json:"mermaid" jsonschema:"The graph as a Mermaid erDiagram."`
+	Truncated bool         `json:"truncated,omitempty" jsonschema:"True if the catalog had more rows than max_rows, so the graph is incomplete."`
+}
+
+// buildRelationshipGraph assembles the graph of db from its tables, their
+// key columns, and the relations touching it. Tables of other databases that
+// a relation reaches are added without columns.
+func buildRelationshipGraph(db string, tables, keyColumns QueryOutput, declared, configured []relationLink) RelationshipGraphOutput {
+	graph := RelationshipGraphOutput{Database: db, Tables: []GraphTable{}, Edges: []GraphEdge{}}
+	positions := make(map[string]int)
+	addTable := func(schema, name string) int {
+		key := strings.ToLower(schema + "." + name)
+		pos, ok := positions[key]
+		if !ok {
+			graph.Tables = append(graph.Tables, GraphTable{Database: schema, Name: name, KeyColumns: []GraphKeyColumn{}})
+			pos = len(graph.Tables) - 1
+			positions[key] = pos
+		}
+		return pos
+	}
+
+	tableCol := columnIndex(tables.Columns, "table_name")
+	for _, row := range tables.Rows {
+		addTable(db, valueString(rowValue(row, tableCol)))
+	}
+	tableCol = columnIndex(keyColumns.Columns, "table_name")
+	nameCol := columnIndex(keyColumns.Columns, "column_name")
+	typeCol := columnIndex(keyColumns.Columns, "data_type")
+	nullableCol := columnIndex(keyColumns.Columns, "is_nullable")
+	keyCol := columnIndex(keyColumns.Columns, "column_key")
+	foreign := make(map[string]bool)
+	for _, link := range declared {
+		for _, column := range link.columns {
+			foreign[strings.ToLower(link.table+"."+column)] = true
+		}
+	}
+	nullable := make(map[string]bool)
+	for _, row := range keyColumns.Rows {
+		table := valueString(rowValue(row, tableCol))
+		name := valueString(rowValue(row, nameCol))
+		key := strings.ToLower(table + "." + name)
+		column := GraphKeyColumn{
+			Name:       name,
+			Type:       valueString(rowValue(row, typeCol)),
+			Nullable:   valueString(rowValue(row, nullableCol)) == "YES",
+			PrimaryKey: valueString(rowValue(row, keyCol)) == "PRI",
+			ForeignKey: foreign[key],
+		}
+		nullable[key] = column.Nullable
+		pos := addTable(db, table)
+		graph.Tables[pos].KeyColumns = append(graph.Tables[pos].KeyColumns, column)
+	}
+
+	addEdges := func(links []relationLink, configured bool) {
+		for _, link := range links {
+			if !strings.EqualFold(link.schema, db) && !strings.EqualFold(link.refSchema, db) {
+				continue
+			}
+			addTable(link.schema, link.table)
+			addTable(link.refSchema, link.refTable)
+			edge := GraphEdge{
+				Name: link.name, Database: link.schema, Table: link.table, Columns: link.columns,
+				ReferencedDatabase: link.refSchema, ReferencedTable: link.refTable, ReferencedColumns: link.refColumns,
+				Configured: configured,
+			}
+			for _, column := range link.columns {
+				if nullable[strings.ToLower(link.table+"."+column)] && strings.EqualFold(link.schema, db) {
+					edge.Optional = true
+				}
+			}
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+	addEdges(declared, false)
+	addEdges(configured, true)
+
+	graph.Truncated = tables.Truncated || keyColumns.Truncated
+	graph.Mermaid = mermaidER(graph)
+	return graph
+}
+
+// mermaidEntity names a table in a diagram of db: bare for db's own tables,
+// quoted "db.table" for others.
+func mermaidEntity(db, schema, table string) string {
+	if strings.EqualFold(schema, db) && mysqlIdentifierRE.MatchString(table) {
+		return table
+	}
+	return `"` + strings.ReplaceAll(schema+"."+table, `"`, "") + `"`
+}
+
+// mermaidER renders graph as a Mermaid erDiagram: one entity per table with
+// its key columns, and one relationship per edge, parent first.
+func mermaidER(graph RelationshipGraphOutput) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, table := range graph.Tables {
+		name := mermaidEntity(graph.Database, table.Database, table.Name)
+		var attributes []string
+		for _, column := range table.KeyColumns {
+			if !mysqlIdentifierRE.MatchString(column.Name) || !mysqlIdentifierRE.MatchString(column.Type) {
+				continue
+			}
+			var keys []string
+			if column.PrimaryKey {
+				keys = append(keys, "PK")
+			}
+			if column.ForeignKey {
+				keys = append(keys, "FK")
+			}
+			attributes = append(attributes, fmt.Sprintf("        %s %s %s\n", column.Type, column.Name, strings.Join(keys, ", ")))
+		}
+		if len(attributes) == 0 {
+			fmt.Fprintf(&b, "    %s\n", name)
+			continue
+		}
+		fmt.Fprintf(&b, "    %s {\n%s    }\n", name, strings.Join(attributes, ""))
+	}
+	for _, edge := range graph.Edges {
+		cardinality := "||--o{"
+		if edge.Optional {
+			cardinality = "|o--o{"
+		}
+		fmt.Fprintf(&b, "    %s %s %s : %q\n",
+			mermaidEntity(graph.Database, edge.ReferencedDatabase, edge.ReferencedTable), cardinality,
+			mermaidEntity(graph.Database, edge.Database, edge.Table), strings.ReplaceAll(edge.Name, `"`, ""))
+	}
+	return b.String()
+}
+
+func (h *queryHandler) relationshipGraph(ctx context.Context, req *mcp.CallToolRequest, input RelationshipGraphInput) (*mcp.CallToolResult, RelationshipGraphOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := RelationshipGraphOutput{}
+	if !mysqlIdentifierRE.MatchString(input.Database) {
+		return toolErrorf(empty, "database must be a plain identifier")
+	}
+	configured, err := compileRelations(h.snapshot().config.Relations, h.defaultSchema)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	tables, err := h.runMetadataQuery(ctx, graphTablesQuery, input.Database)
+	if err != nil {
+		return toolErrorf(empty, "failed to list tables: %v", err)
+	}
+	keyColumns, err := h.runMetadataQuery(ctx, graphKeyColumnsQuery, input.Database)
+	if err != nil {
+		return toolErrorf(empty, "failed to read key columns: %v", err)
+	}
+	relations, err := h.runMetadataQuery(ctx, relationsQuery, input.Database)
+	if err != nil {
+		return toolErrorf(empty, "failed to read foreign keys: %v", err)
+	}
+	declared := linksFromRelations(input.Database, buildRelations(relations))
+	return nil, buildRelationshipGraph(input.Database, tables, keyColumns, declared, configured), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRelationshipGraph(t *testing.T) {
+	tables := QueryOutput{
+		Columns: []string{"table_name"},
+		Rows:    [][]any{{"audit_log"}, {"customers"}, {"orders"}},
+	}
+	keyColumns := QueryOutput{
+		Columns: []string{"table_name", "column_name", "data_type", "is_nullable", "column_key"},
+		Rows: [][]any{
+			{"customers", "id", "int", "NO", "PRI"},
+			{"orders", "id", "bigint", "NO", "PRI"},
+			{"orders", "customer_id", "int", "YES", "MUL"},
+		},
+	}
+	declared := []relationLink{{
+		name: "fk_orders_customer", schema: "shop", table: "orders", columns: []string{"customer_id"},
+		refSchema: "shop", refTable: "customers", refColumns: []string{"id"},
+	}}
+	configured := []relationLink{
+		{name: "orders_account", schema: "shop", table: "orders", columns: []string{"id"}, refSchema: "crm", refTable: "accounts", refColumns: []string{"order_id"}},
+		{name: "elsewhere", schema: "crm", table: "a", columns: []string{"id"}, refSchema: "crm", refTable: "b", refColumns: []string{"id"}},
+	}
+
+	graph := buildRelationshipGraph("shop", tables, keyColumns, declared, configured)
+	require.Len(t, graph.Tables, 4)
+	require.Equal(t, GraphTable{Database: "crm", Name: "accounts", KeyColumns: []GraphKeyColumn{}}, graph.Tables[3])
+	require.Equal(t, []GraphKeyColumn{
+		{Name: "id", Type: "bigint", PrimaryKey: true},
+		{Name: "customer_id", Type: "int", Nullable: true, ForeignKey: true},
+	}, graph.Tables[2].KeyColumns)
+	require.Len(t, graph.Edges, 2)
+	require.True(t, graph.Edges[0].Optional)
+	require.False(t, graph.Edges[1].Optional)
+	require.True(t, graph.Edges[1].Configured)
+
+	require.Equal(t, `erDiagram
+    audit_log
+    customers {
+        int id PK
+    }
+    orders {
+        bigint id PK
+        int customer_id FK
+    }
+    "crm.accounts"
+    customers |o--o{ orders : "fk_orders_customer"
+    "crm.accounts" ||--o{ orders : "orders_account"
+`, graph.Mermaid)
+}
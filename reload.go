@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+)
+
+// handlerSettings is the part of queryHandler that a config reload replaces.
+type handlerSettings struct {
+	config      Config
+	validator   *gate.Validator
+	rowFilters  *rowFilters
+	softDeletes *rowFilters
+	tools       []string
+}
+
+// snapshot returns the current settings. Handlers take one snapshot per call
+// so a concurrent reload can't mix old and new settings within a query.
+func (h *queryHandler) snapshot() handlerSettings {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return handlerSettings{
+		config:      h.config,
+		validator:   h.validator,
+		rowFilters:  h.rowFilters,
+		softDeletes: h.softDeletes,
+		tools:       slices.Clone(h.tools),
+	}
+}
+
+// reload applies cfg's deny lists, denied functions, row filters, soft
+// deletes, relations, feature flags, limits, write and sensitive tables, and saved queries. Everything is
+// validated first, so a bad config leaves the running one untouched. Connection, pool, audit, result store sizing,
+// result cache sizing, schema cache, and analytics settings only take effect on restart.
+func (h *queryHandler) reload(server *mcp.Server, cfg Config) error {
+	filters, err := newRowFilters(cfg.RowFilters, h.defaultSchema)
+	if err != nil {
+		return fmt.Errorf("invalid row filter config: %w", err)
+	}
+	softDeletes, err := newSoftDeleteFilters(cfg.SoftDelete, h.defaultSchema)
+	if err != nil {
+		return fmt.Errorf("invalid soft delete config: %w", err)
+	}
+	if _, err := compileRelations(cfg.Relations, h.defaultSchema); err != nil {
+		return fmt.Errorf("invalid relation config: %w", err)
+	}
+	if err := validateFeatures(cfg.Features); err != nil {
+		return fmt.Errorf("invalid feature config: %w", err)
+	}
+	validator, err := newValidator(cfg, h.defaultSchema)
+	if err != nil {
+		return fmt.Errorf("invalid query policy config: %w", err)
+	}
+	saved, err := compileSavedQueries(cfg.Queries, validator)
+	if err != nil {
+		return fmt.Errorf("invalid saved query config: %w", err)
+	}
+
+	h.mu.Lock()
+	h.config = cfg
+	h.validator = validator
+	h.rowFilters = filters
+	h.softDeletes = softDeletes
+	oldSaved := h.savedTools
+	h.tools = slices.DeleteFunc(h.tools, func(name string) bool { return slices.Contains(oldSaved, name) })
+	h.savedTools = nil
+	h.mu.Unlock()
+	// Cached results were filtered and masked under the old config.
+	h.cache.clear()
+
+	// Tools that survive the reload are replaced in place rather than
+	// removed, so they never disappear for a moment. Sessions get a
+	// tools/list_changed notification.
+	removed := slices.DeleteFunc(oldSaved, func(name string) bool {
+		return slices.ContainsFunc(saved, func(q *savedQuery) bool { return q.config.Name == name })
+	})
+	server.RemoveTools(removed...)
+	registerSavedQueries(server, h, saved)
+	return nil
+}
+
+// watchReload reloads the config file on SIGHUP until ctx ends. Failed
+// reloads are logged and the running config is kept.
+func watchReload(ctx context.Context, path string, server *mcp.Server, h *queryHandler) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			cfg, err := loadConfig(path)
+			if err == nil {
+				err = h.reload(server, h.tenant.bind(cfg))
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "config reload from %q failed, keeping the current config: %v\n", path, err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "config reloaded from %q\n", path)
+		}
+	}
+}
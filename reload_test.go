@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	var initial Config
+	initial.MySQL.MaxRows = 100
+	initial.MySQL.DenySubstrings = []string{"secret"}
+	initial.Queries = []SavedQueryConfig{
+		{Name: "orders_today", SQL: "SELECT * FROM orders WHERE created_at >= CURDATE()"},
+		{Name: "stale_report", SQL: "SELECT 1"},
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "v0"}, nil)
+	h := &queryHandler{defaultSchema: "shop"}
+	registerTool(server, h, &mcp.Tool{Name: "mysql_query"}, h.runQuery)
+	require.NoError(t, h.reload(server, initial))
+	require.ElementsMatch(t, []string{"mysql_query", "orders_today", "stale_report"}, h.snapshot().tools)
+
+	next := initial
+	next.MySQL.MaxRows = 500
+	next.MySQL.DenySubstrings = []string{"Password"}
+	next.MySQL.DeniedFunctions = []string{"sleep"}
+	next.RowFilters = []RowFilterConfig{{Table: "orders", Predicate: "tenant_id = 7"}}
+	next.Queries = []SavedQueryConfig{
+		{Name: "orders_today", SQL: "SELECT id FROM orders WHERE created_at >= CURDATE()"},
+		{Name: "top_customers", SQL: "SELECT customer_id FROM orders LIMIT 10"},
+	}
+	require.NoError(t, h.reload(server, next))
+
+	live := h.snapshot()
+	require.Equal(t, 500, live.config.MySQL.MaxRows)
+	require.ErrorContains(t, live.validator.Validate("SELECT password FROM users"), `denied fragment "password"`)
+	require.ErrorContains(t, live.validator.Validate("SELECT SLEEP(1)"), "SLEEP()")
+	require.Len(t, live.rowFilters.filters, 1)
+	require.ElementsMatch(t, []string{"mysql_query", "orders_today", "top_customers"}, live.tools)
+	require.ElementsMatch(t, []string{"orders_today", "top_customers"}, h.savedTools)
+
+	bad := next
+	bad.MySQL.MaxRows = 1
+	bad.Queries = []SavedQueryConfig{{Name: "mysql_reserved", SQL: "SELECT 1"}}
+	require.ErrorContains(t, h.reload(server, bad), "saved query")
+	require.Equal(t, 500, h.snapshot().config.MySQL.MaxRows, "a failed reload changes nothing")
+	require.ElementsMatch(t, []string{"orders_today", "top_customers"}, h.savedTools)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Text renderings of a QueryOutput. Structured content is the same for every
+// format; only the TextContent changes.
+const (
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+	formatCSV      = "csv"
+)
+
+func validFormat(format string) bool {
+	switch format {
+	case "", formatJSON, formatMarkdown, formatCSV:
+		return true
+	}
+	return false
+}
+
+// renderText renders output for the TextContent of a tool result. An empty
+// format keeps the plain "ok" acknowledgement.
+func renderText(output QueryOutput, format string) (string, error) {
+	switch format {
+	case "":
+		return "ok", nil
+	case formatJSON:
+		encoded, err := json.Marshal(output)
+		return string(encoded), err
+	case formatMarkdown:
+		return renderMarkdown(output), nil
+	case formatCSV:
+		return renderCSV(output)
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+func renderMarkdown(output QueryOutput) string {
+	var b strings.Builder
+	if len(output.Columns) == 0 {
+		b.WriteString("_No columns._\n")
+		return b.String()
+	}
+
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			b.WriteString(" ")
+			b.WriteString(cell)
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+
+	header := make([]string, len(output.Columns))
+	separator := make([]string, len(output.Columns))
+	for i, col := range output.Columns {
+		header[i] = markdownCell(col)
+		separator[i] = "---"
+	}
+	writeRow(header)
+	writeRow(separator)
+	for _, row := range output.Rows {
+		cells := make([]string, len(output.Columns))
+		for i := range cells {
+			value := rowValue(row, i)
+			if value == nil {
+				cells[i] = "NULL"
+				continue
+			}
+			cells[i] = markdownCell(cellText(value))
+		}
+		writeRow(cells)
+	}
+	if output.Truncated {
+		fmt.Fprintf(&b, "\n_Truncated to %d rows._\n", output.RowCount)
+	}
+	return b.String()
+}
+
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\\\")
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\r\n", "<br>")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// renderCSV writes RFC 4180 CSV with a header row. NULL is an empty field.
+func renderCSV(output QueryOutput) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(output.Columns); err != nil {
+		return "", err
+	}
+	for _, row := range output.Rows {
+		record := make([]string, len(output.Columns))
+		for i := range record {
+			if value := rowValue(row, i); value != nil {
+				record[i] = cellText(value)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func cellText(value interface{}) string {
+	if b, ok := value.(BinaryValue); ok {
+		if b.Omitted {
+			return fmt.Sprintf("(%d bytes omitted)", b.Bytes)
+		}
+		return "base64:" + b.Base64
+	}
+	return valueString(value)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderText(t *testing.T) {
+	output := QueryOutput{
+		Columns:   []string{"id", "note", "data"},
+		Rows:      [][]interface{}{{int64(1), "a|b\nc", BinaryValue{Base64: "AP8=", Bytes: 2}}, {int64(2), nil, BinaryValue{Bytes: 4, Omitted: true}}},
+		RowCount:  2,
+		Truncated: true,
+	}
+
+	text, err := renderText(output, "")
+	require.NoError(t, err)
+	require.Equal(t, "ok", text)
+
+	text, err = renderText(output, formatMarkdown)
+	require.NoError(t, err)
+	require.Equal(t, "| id | note | data |\n"+
+		"| --- | --- | --- |\n"+
+		"| 1 | a\\|b<br>c | base64:AP8= |\n"+
+		"| 2 | NULL | (4 bytes omitted) |\n"+
+		"\n_Truncated to 2 rows._\n", text)
+
+	text, err = renderText(output, formatCSV)
+	require.NoError(t, err)
+	require.Equal(t, "id,note,data\n1,\"a|b\nc\",base64:AP8=\n2,,(4 bytes omitted)\n", text)
+
+	text, err = renderText(output, formatJSON)
+	require.NoError(t, err)
+	require.Contains(t, text, `"rows":[[1,"a|b\nc",{"base64":"AP8=","bytes":2}]`)
+
+	_, err = renderText(output, "xml")
+	require.Error(t, err)
+	require.False(t, validFormat("xml"))
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ReplicaPoolConfig spreads read queries over replicas. Replicas that fail
+// a health check or lag more than MaxLagSeconds behind their source are
+// evicted until they recover; with none healthy, queries use the primary.
+type ReplicaPoolConfig struct {
+	DSNs []string `toml:"dsns"`
+	// Strategy is "round_robin" (the default) or "least_connections".
+	Strategy              string `toml:"strategy"`
+	HealthIntervalSeconds int    `toml:"health_interval_seconds"`
+	MaxLagSeconds         int    `toml:"max_lag_seconds"`
+	// Consistency "gtid" makes each replica read wait, up to
+	// GTIDWaitSeconds, for the transactions the primary had committed when
+	// the read began. The default, "none", accepts up to MaxLagSeconds of
+	// staleness.
+	Consistency     string  `toml:"consistency"`
+	GTIDWaitSeconds float64 `toml:"gtid_wait_seconds"`
+}
+
+const (
+	replicaRoundRobin       = "round_robin"
+	replicaLeastConnections = "least_connections"
+
+	replicaConsistencyNone = "none"
+	replicaConsistencyGTID = "gtid"
+)
+
+// replicaCheckTimeout bounds one replica's health check.
+const replicaCheckTimeout = 5 * time.Second
+
+// ReplicaStatus is a replica's last health check, for mysql://server_info.
+type ReplicaStatus struct {
+	Addr       string `json:"addr"`
+	Healthy    bool   `json:"healthy"`
+	LagSeconds *int64 `json:"lagSeconds,omitempty" jsonschema:"Seconds behind the source at the last check; absent when the server isn't replicating or couldn't be checked."`
+	InFlight   int64  `json:"inFlight"`
+	Error      string `json:"error,omitempty" jsonschema:"Why the replica is evicted."`
+}
+
+type replica struct {
+	addr     string
+	db       *sql.DB
+	inFlight atomic.Int64
+
+	mu      sync.Mutex
+	checked bool
+	healthy bool
+	lag     *int64
+	err     string
+}
+
+type replicaPool struct {
+	cfg      ReplicaPoolConfig
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+// newReplicaPool opens a pool per replica DSN with the primary's connection
+// options (TLS, IAM, SSH, init statements, pool limits). Replicas start
+// evicted until the first health check passes.
+func newReplicaPool(cfg Config) (*replicaPool, error) {
+	c := cfg.MySQL.Replicas
+	if len(c.DSNs) == 0 {
+		return nil, nil
+	}
+	switch c.Strategy {
+	case "":
+		c.Strategy = replicaRoundRobin
+	case replicaRoundRobin, replicaLeastConnections:
+	default:
+		return nil, fmt.Errorf("mysql.replicas.strategy must be %q or %q", replicaRoundRobin, replicaLeastConnections)
+	}
+	switch c.Consistency {
+	case "":
+		c.Consistency = replicaConsistencyNone
+	case replicaConsistencyNone, replicaConsistencyGTID:
+	default:
+		return nil, fmt.Errorf("mysql.replicas.consistency must be %q or %q", replicaConsistencyNone, replicaConsistencyGTID)
+	}
+	if c.GTIDWaitSeconds <= 0 {
+		c.GTIDWaitSeconds = 1
+	}
+	if c.HealthIntervalSeconds <= 0 {
+		c.HealthIntervalSeconds = 5
+	}
+	if c.MaxLagSeconds <= 0 {
+		c.MaxLagSeconds = 30
+	}
+	p := &replicaPool{cfg: c}
+	for i, dsn := range c.DSNs {
+		dsnConfig, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("mysql.replicas.dsns[%d]: %w", i, err)
+		}
+		if err := applyConnectionOptions(dsnConfig, cfg); err != nil {
+			return nil, fmt.Errorf("mysql.replicas.dsns[%d]: %w", i, err)
+		}
+		connector, err := mysql.NewConnector(dsnConfig)
+		if err != nil {
+			return nil, fmt.Errorf("mysql.replicas.dsns[%d]: %w", i, err)
+		}
+		db := sql.OpenDB(newInitConnector(connector, cfg.MySQL.InitStatements))
+		if cfg.MySQL.MaxOpenConns > 0 {
+			db.SetMaxOpenConns(cfg.MySQL.MaxOpenConns)
+		}
+		if cfg.MySQL.MaxIdleConns > 0 {
+			db.SetMaxIdleConns(cfg.MySQL.MaxIdleConns)
+		}
+		if cfg.MySQL.ConnMaxLifetimeSeconds > 0 {
+			db.SetConnMaxLifetime(time.Duration(cfg.MySQL.ConnMaxLifetimeSeconds) * time.Second)
+		}
+		if cfg.MySQL.ConnMaxIdleTimeSeconds > 0 {
+			db.SetConnMaxIdleTime(time.Duration(cfg.MySQL.ConnMaxIdleTimeSeconds) * time.Second)
+		}
+		p.replicas = append(p.replicas, &replica{addr: dsnConfig.Addr, db: db, err: "not checked yet"})
+	}
+	return p, nil
+}
+
+// pick returns a healthy replica by the configured strategy, or nil if none
+// is healthy. The caller must call release on it when done.
+func (p *replicaPool) pick() *replica {
+	if p == nil {
+		return nil
+	}
+	var healthy []*replica
+	for _, r := range p.replicas {
+		r.mu.Lock()
+		if r.healthy {
+			healthy = append(healthy, r)
+		}
+		r.mu.Unlock()
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	chosen := healthy[int(p.next.Add(1)-1)%len(healthy)]
+	if p.cfg.Strategy == replicaLeastConnections {
+		// Start from the round-robin choice so ties rotate.
+		for _, r := range healthy {
+			if r.inFlight.Load() < chosen.inFlight.Load() {
+				chosen = r
+			}
+		}
+	}
+	chosen.inFlight.Add(1)
+	return chosen
+}
+
+func (r *replica) release() {
+	r.inFlight.Add(-1)
+}
+
+// run checks every replica each health interval until ctx ends.
+func (p *replicaPool) run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(p.cfg.HealthIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		p.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *replicaPool) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range p.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+			defer cancel()
+			lag, err := replicationLag(checkCtx, r.db)
+			r.update(lag, replicaHealth(lag, err, p.cfg.MaxLagSeconds))
+		}()
+	}
+	wg.Wait()
+}
+
+// update records a health check, logging evictions and recoveries.
+func (r *replica) update(lag *int64, problem error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lag = lag
+	switch {
+	case problem != nil && (r.healthy || !r.checked):
+		log.Printf("evicting replica %s: %v", r.addr, problem)
+	case problem == nil && !r.healthy:
+		log.Printf("replica %s is healthy", r.addr)
+	}
+	r.checked = true
+	r.healthy = problem == nil
+	r.err = ""
+	if problem != nil {
+		r.err = problem.Error()
+	}
+}
+
+// replicaHealth decides whether a replica can serve reads from its lag
+// check. A server that isn't replicating at all (no replica status) has
+// lag nil and no error, and is healthy.
+func replicaHealth(lag *int64, checkErr error, maxLagSeconds int) error {
+	switch {
+	case errors.Is(checkErr, errReplicationStopped):
+		return checkErr
+	case checkErr != nil:
+		return fmt.Errorf("unreachable: %w", checkErr)
+	case lag != nil && *lag > int64(maxLagSeconds):
+		return fmt.Errorf("%ds behind its source, more than max_lag_seconds (%d)", *lag, maxLagSeconds)
+	}
+	return nil
+}
+
+var errReplicationStopped = errors.New("replication is not running")
+
+// replicationLag returns Seconds_Behind_Source from SHOW REPLICA STATUS,
+// falling back to SHOW SLAVE STATUS before MySQL 8.0.22. It returns nil
+// if the server has no replication configured.
+func replicationLag(ctx context.Context, db *sql.DB) (*int64, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1064 {
+		rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return secondsBehind(columns, values)
+}
+
+// secondsBehind reads the lag column from a replica status row. NULL means
+// the replication threads are stopped.
+func secondsBehind(columns []string, values []sql.RawBytes) (*int64, error) {
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return nil, errReplicationStopped
+		}
+		lag, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", column, err)
+		}
+		return &lag, nil
+	}
+	return nil, fmt.Errorf("replica status has no Seconds_Behind_Source column")
+}
+
+func (p *replicaPool) status() []ReplicaStatus {
+	if p == nil {
+		return nil
+	}
+	statuses := make([]ReplicaStatus, len(p.replicas))
+	for i, r := range p.replicas {
+		r.mu.Lock()
+		statuses[i] = ReplicaStatus{Addr: r.addr, Healthy: r.healthy, LagSeconds: r.lag, InFlight: r.inFlight.Load(), Error: r.err}
+		r.mu.Unlock()
+	}
+	return statuses
+}
+
+// readDB returns the pool a read-only query should run on: a healthy
+// replica if any are configured, else the primary. With GTID consistency, a
+// replica that doesn't catch up with the primary in time is passed over for
+// the primary. Call done afterwards.
+func (h *queryHandler) readDB(ctx context.Context) (db *sql.DB, done func()) {
+	r := h.replicas.pick()
+	if r == nil {
+		return h.db, func() {}
+	}
+	if h.replicas.cfg.Consistency == replicaConsistencyGTID {
+		wait := time.Duration(h.replicas.cfg.GTIDWaitSeconds * float64(time.Second))
+		if err := waitForPrimaryGTIDs(ctx, h.db, r.db, wait); err != nil {
+			r.release()
+			log.Printf("reading from the primary: replica %s %v", r.addr, err)
+			return h.db, func() {}
+		}
+	}
+	return r.db, r.release
+}
+
+type gtidScopeKey struct{}
+
+// gtidScope holds the primary's gtid_executed, read once for every replica
+// read of one tool call or resource read.
+type gtidScope struct {
+	once     sync.Once
+	executed string
+	err      error
+}
+
+// withGTIDScope returns ctx with a scope in which the primary's
+// gtid_executed is read at most once. The reads in it then see at least
+// what the primary had committed when the first of them began.
+func withGTIDScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, gtidScopeKey{}, &gtidScope{})
+}
+
+// primaryGTIDs returns the primary's gtid_executed, read once per scope of
+// ctx, or on every call outside one.
+func primaryGTIDs(ctx context.Context, primary *sql.DB) (string, error) {
+	read := func() (string, error) {
+		var executed string
+		err := primary.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&executed)
+		return executed, err
+	}
+	scope, ok := ctx.Value(gtidScopeKey{}).(*gtidScope)
+	if !ok {
+		return read()
+	}
+	scope.once.Do(func() {
+		scope.executed, scope.err = read()
+	})
+	return scope.executed, scope.err
+}
+
+// waitForPrimaryGTIDs blocks until replica has applied every transaction in
+// the primary's gtid_executed, so a read on it sees at least what a read on
+// the primary would have seen when this was called, or when ctx's GTID
+// scope began. Successive reads are then causally consistent even when
+// they land on different replicas.
+func waitForPrimaryGTIDs(ctx context.Context, primary, replica *sql.DB, wait time.Duration) error {
+	executed, err := primaryGTIDs(ctx, primary)
+	if err != nil {
+		return fmt.Errorf("failed to read the primary's gtid_executed: %w", err)
+	}
+	var timedOut sql.NullInt64
+	err = replica.QueryRowContext(ctx, "SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)", executed, wait.Seconds()).Scan(&timedOut)
+	if err != nil {
+		return fmt.Errorf("failed to wait for GTIDs: %w", err)
+	}
+	if timedOut.Int64 != 0 {
+		return fmt.Errorf("had not applied the primary's transactions after %s", wait)
+	}
+	return nil
+}
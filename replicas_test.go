@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func healthyReplicas(addrs ...string) []*replica {
+	replicas := make([]*replica, len(addrs))
+	for i, addr := range addrs {
+		replicas[i] = &replica{addr: addr, checked: true, healthy: true}
+	}
+	return replicas
+}
+
+func TestReplicaPoolPick(t *testing.T) {
+	p := &replicaPool{cfg: ReplicaPoolConfig{Strategy: replicaRoundRobin}, replicas: healthyReplicas("a", "b", "c")}
+	var picked []string
+	for range 4 {
+		r := p.pick()
+		picked = append(picked, r.addr)
+		r.release()
+	}
+	require.Equal(t, []string{"a", "b", "c", "a"}, picked)
+
+	p.replicas[1].update(nil, errors.New("unreachable"))
+	for range 4 {
+		r := p.pick()
+		require.NotEqual(t, "b", r.addr, "evicted replicas get no queries")
+		r.release()
+	}
+
+	p = &replicaPool{cfg: ReplicaPoolConfig{Strategy: replicaLeastConnections}, replicas: healthyReplicas("a", "b")}
+	first := p.pick()
+	second := p.pick()
+	require.NotEqual(t, first.addr, second.addr)
+	second.release()
+	require.Equal(t, second.addr, p.pick().addr, "the replica with fewer queries in flight wins")
+
+	for _, r := range p.replicas {
+		r.update(nil, errReplicationStopped)
+	}
+	require.Nil(t, p.pick(), "no healthy replica falls back to the primary")
+	var none *replicaPool
+	require.Nil(t, none.pick())
+}
+
+func TestReplicaHealth(t *testing.T) {
+	lag := func(n int64) *int64 { return &n }
+	require.NoError(t, replicaHealth(lag(5), nil, 30))
+	require.NoError(t, replicaHealth(nil, nil, 30), "a server without replication is healthy")
+	require.ErrorContains(t, replicaHealth(lag(31), nil, 30), "31s behind its source")
+	require.ErrorIs(t, replicaHealth(nil, errReplicationStopped, 30), errReplicationStopped)
+	require.ErrorContains(t, replicaHealth(nil, errors.New("connection refused"), 30), "unreachable: connection refused")
+}
+
+func TestSecondsBehind(t *testing.T) {
+	lag, err := secondsBehind([]string{"Replica_IO_State", "Seconds_Behind_Source"}, []sql.RawBytes{[]byte("Waiting"), []byte("12")})
+	require.NoError(t, err)
+	require.Equal(t, int64(12), *lag)
+
+	lag, err = secondsBehind([]string{"Seconds_Behind_Master"}, []sql.RawBytes{[]byte("0")})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), *lag)
+
+	_, err = secondsBehind([]string{"Seconds_Behind_Source"}, []sql.RawBytes{nil})
+	require.ErrorIs(t, err, errReplicationStopped)
+}
+
+func TestNewReplicaPool(t *testing.T) {
+	p, err := newReplicaPool(Config{})
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	var cfg Config
+	cfg.MySQL.Replicas = ReplicaPoolConfig{DSNs: []string{"u:p@tcp(replica1:3306)/app", "u:p@tcp(replica2:3306)/app"}}
+	p, err = newReplicaPool(cfg)
+	require.NoError(t, err)
+	require.Equal(t, replicaRoundRobin, p.cfg.Strategy)
+	require.Equal(t, 30, p.cfg.MaxLagSeconds)
+	require.Equal(t, replicaConsistencyNone, p.cfg.Consistency)
+	require.Equal(t, 1.0, p.cfg.GTIDWaitSeconds)
+	statuses := p.status()
+	require.Len(t, statuses, 2)
+	require.Equal(t, "replica1:3306", statuses[0].Addr)
+	require.False(t, statuses[0].Healthy, "replicas wait for their first health check")
+	require.Nil(t, p.pick())
+
+	cfg.MySQL.Replicas.Strategy = "random"
+	_, err = newReplicaPool(cfg)
+	require.ErrorContains(t, err, "mysql.replicas.strategy")
+
+	cfg.MySQL.Replicas.Strategy = ""
+	cfg.MySQL.Replicas.Consistency = "session"
+	_, err = newReplicaPool(cfg)
+	require.ErrorContains(t, err, "mysql.replicas.consistency")
+}
+
+// gtidConn answers SELECT @@GLOBAL.gtid_executed, counting the reads.
+type gtidConn struct{ reads *int }
+
+func (c gtidConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("unsupported") }
+func (c gtidConn) Close() error                        { return nil }
+func (c gtidConn) Begin() (driver.Tx, error)           { return nil, errors.New("unsupported") }
+func (c gtidConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	*c.reads++
+	return &nameRows{names: []string{"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"}}, nil
+}
+
+type gtidConnector struct{ conn gtidConn }
+
+func (c gtidConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c gtidConnector) Driver() driver.Driver                        { return nil }
+
+func TestPrimaryGTIDsScope(t *testing.T) {
+	var reads int
+	primary := sql.OpenDB(gtidConnector{gtidConn{reads: &reads}})
+
+	ctx := withGTIDScope(context.Background())
+	for range 3 {
+		executed, err := primaryGTIDs(ctx, primary)
+		require.NoError(t, err)
+		require.Equal(t, "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5", executed)
+	}
+	require.Equal(t, 1, reads, "one read per scope")
+
+	_, err := primaryGTIDs(withGTIDScope(context.Background()), primary)
+	require.NoError(t, err)
+	_, err = primaryGTIDs(context.Background(), primary)
+	require.NoError(t, err)
+	require.Equal(t, 3, reads)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// byteLimit caps the approximate JSON size of the rows a result reads, per
+// mysql.max_result_bytes, so a few wide rows can't blow up the response
+// the way max_rows alone allows. A nil byteLimit has no cap.
+type byteLimit struct {
+	max  int
+	used int
+	// maxChars is mysql.max_cell_chars. Values are cut to it only after
+	// PII scanning, so rows are counted as they'll be returned.
+	maxChars int
+}
+
+func newByteLimit(maxBytes, maxCellChars int) *byteLimit {
+	if maxBytes <= 0 {
+		return nil
+	}
+	return &byteLimit{max: maxBytes, maxChars: maxCellChars}
+}
+
+// fits reports whether row fits in what's left of the limit, and if so
+// counts it.
+func (l *byteLimit) fits(row []interface{}) bool {
+	if l == nil {
+		return true
+	}
+	n := rowBytes(row, l.maxChars)
+	if l.used+n > l.max {
+		return false
+	}
+	l.used += n
+	return true
+}
+
+// rowBytes approximates the JSON encoding of row, with strings cut at
+// maxChars characters if it's positive: its values, separators, and
+// brackets. Escapes in strings aren't counted.
+func rowBytes(row []interface{}, maxChars int) int {
+	n := 2
+	for _, v := range row {
+		n += cellBytes(v, maxChars) + 1
+	}
+	return n
+}
+
+func cellBytes(value interface{}, maxChars int) int {
+	switch v := value.(type) {
+	case nil:
+		return len("null")
+	case string:
+		if end, ok := charPrefix(v, maxChars); maxChars > 0 && ok {
+			return end + len("…") + 2
+		}
+		return len(v) + 2
+	case []byte:
+		return len(v) + 2
+	case int64:
+		return len(strconv.FormatInt(v, 10))
+	case uint64:
+		return len(strconv.FormatUint(v, 10))
+	case float64:
+		return len(strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		return len(strconv.FormatBool(v))
+	case time.Time:
+		return len(time.RFC3339Nano) + 2
+	case BinaryValue:
+		return len(v.Base64) + len(`{"base64":"","bytes":}`) + len(strconv.Itoa(v.Bytes))
+	default:
+		return len(fmt.Sprint(v)) + 2
+	}
+}
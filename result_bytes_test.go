@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowBytes(t *testing.T) {
+	row := []interface{}{int64(42), "jane@example.com", nil, 3.25, true, BinaryValue{Base64: "AAEC", Bytes: 3}}
+	data, err := json.Marshal(row)
+	require.NoError(t, err)
+	require.InDelta(t, len(data), rowBytes(row, 0), 8)
+	// Strings count as max_cell_chars will cut them.
+	require.Equal(t, rowBytes([]interface{}{"jane…"}, 0), rowBytes([]interface{}{"jane@example.com"}, 4))
+
+	limit := newByteLimit(2*rowBytes(row, 0), 0)
+	require.True(t, limit.fits(row))
+	require.True(t, limit.fits(row))
+	require.False(t, limit.fits(row))
+
+	var unlimited *byteLimit
+	require.True(t, unlimited.fits(row))
+	require.Nil(t, newByteLimit(0, 0))
+}
+
+func TestBytesTruncatedInStructuredContent(t *testing.T) {
+	structured := queryOutputToStructuredContent(QueryOutput{Columns: []string{}, Rows: [][]interface{}{}, Truncated: true, BytesTruncated: true})
+	require.Equal(t, true, structured["bytesTruncated"])
+	require.Equal(t, true, structured["truncated"])
+}
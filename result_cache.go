@@ -0,0 +1,336 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+const (
+	cacheHit    = "hit"
+	cacheMiss   = "miss"
+	cacheBypass = "bypass"
+
+	defaultCacheMaxBytes = 64 << 20
+)
+
+// CacheConfig caches mysql_query results, so agents repeating a schema or
+// aggregate query within TTLSeconds get the earlier result without another
+// round trip. Zero TTLSeconds disables the cache. Results are dropped early
+// when the schema of a table they read changes: the schema watcher polls
+// those tables, and a result is only served while each table's schema
+// version matches the one it was cached under.
+type CacheConfig struct {
+	TTLSeconds int `toml:"ttl_seconds"`
+	// MaxBytes bounds the approximate memory held by cached results, as
+	// their JSON size. Defaults to 64 MiB.
+	MaxBytes int `toml:"max_bytes"`
+}
+
+func validateCacheConfig(cfg CacheConfig) error {
+	if cfg.TTLSeconds < 0 {
+		return fmt.Errorf("cache.ttl_seconds can't be negative")
+	}
+	if cfg.MaxBytes < 0 {
+		return fmt.Errorf("cache.max_bytes can't be negative")
+	}
+	return nil
+}
+
+// resultCache is an LRU of query results bounded by age and total size.
+// Results are shared by every session of the server: the key holds all
+// that decides the rows, and each call passes the gate, roles, and policy
+// before the cache is consulted.
+type resultCache struct {
+	ttl      time.Duration
+	maxBytes int
+
+	mu      sync.Mutex
+	bytes   int
+	lru     *list.List // of *cachedResult, most recently used first
+	entries map[string]*list.Element
+	// versions holds the last schema checksum seen per table, lower-cased.
+	versions map[watchedTableKey]string
+}
+
+type cachedResult struct {
+	key    string
+	output QueryOutput
+	// tables holds what the statement reads by name, lower-cased, with an
+	// empty table for a whole database. versions holds their schema
+	// versions when the result was cached, empty if not known yet.
+	tables   []watchedTableKey
+	versions []string
+	size     int
+	created  time.Time
+}
+
+// newResultCache returns nil when cfg disables the cache.
+func newResultCache(cfg CacheConfig) *resultCache {
+	if cfg.TTLSeconds <= 0 {
+		return nil
+	}
+	maxBytes := cfg.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultCacheMaxBytes
+	}
+	return &resultCache{
+		ttl:      time.Duration(cfg.TTLSeconds) * time.Second,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		versions: make(map[watchedTableKey]string),
+	}
+}
+
+// resultCacheKey identifies the result of query, or the page of it that's
+// run, with args, the row limit, and whether soft-deleted rows are read.
+// Row filters come from the config, and a reload empties the cache. flags
+// are the feature flags of the call, which change what the output holds,
+// and mysqlRoles the MySQL roles it runs with, whose grants decide whether
+// it may run at all.
+func resultCacheKey(query string, args []any, maxRows int, includeDeleted bool, flags map[string]bool, mysqlRoles []string) string {
+	normalized := strings.Join(strings.Fields(query), " ")
+	if stmt, err := gate.ParseStatement(query); err == nil {
+		normalized = sqlparser.String(stmt)
+	}
+	var enabled []string
+	for name, on := range flags {
+		if on {
+			enabled = append(enabled, name)
+		}
+	}
+	slices.Sort(enabled)
+	params, _ := json.Marshal(args)
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%d\x00%t\x00%s\x00%s", normalized, params, maxRows, includeDeleted, strings.Join(enabled, ","), strings.Join(mysqlRoles, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheable reports whether the result of stmt may be reused. Statements
+// that call functions whose value changes from call to call, read
+// variables, or show server state are run every time, as is EXPLAIN
+// ANALYZE, which reports its own timing.
+func cacheable(stmt sqlparser.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select, *sqlparser.Union, *sqlparser.ValuesStatement, *sqlparser.ExplainTab:
+	case *sqlparser.ExplainStmt:
+		if stmt.Type == sqlparser.AnalyzeType {
+			return false
+		}
+	case *sqlparser.Show:
+		switch show := stmt.Internal.(type) {
+		case *sqlparser.ShowCreate:
+		case *sqlparser.ShowBasic:
+			switch show.Command {
+			case sqlparser.Charset, sqlparser.Collation, sqlparser.Column, sqlparser.Database, sqlparser.Function,
+				sqlparser.Index, sqlparser.Procedure, sqlparser.Table, sqlparser.TableStatus, sqlparser.Trigger:
+			default:
+				return false
+			}
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	ok := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.CurTimeFuncExpr, *sqlparser.Variable:
+			ok = false
+		case *sqlparser.FuncExpr:
+			if volatileFunctions[node.Name.Lowered()] {
+				ok = false
+			}
+		case sqlparser.TableName:
+			schema := strings.ToLower(node.Qualifier.String())
+			if gate.SystemSchemas[schema] || schema == "information_schema" && strings.EqualFold(node.Name.String(), "processlist") {
+				ok = false
+			}
+		}
+		return ok, nil
+	}, stmt)
+	return ok
+}
+
+// cacheTables returns what stmt reads by name, so its cached result can be
+// dropped when their schema changes. Tables without a database can't be
+// matched and are left out.
+func cacheTables(stmt sqlparser.Statement, defaultSchema string) []watchedTableKey {
+	var tables []watchedTableKey
+	for _, ref := range statementReferences(stmt, defaultSchema) {
+		key := watchedTableKey{db: strings.ToLower(ref.Schema), table: strings.ToLower(ref.Name)}
+		if key.db != "" && !slices.Contains(tables, key) {
+			tables = append(tables, key)
+		}
+	}
+	return tables
+}
+
+// volatileFunctions return a different value from call to call, or depend
+// on the connection.
+var volatileFunctions = map[string]bool{
+	"now": true, "sysdate": true, "curdate": true, "curtime": true, "current_date": true, "current_time": true,
+	"current_timestamp": true, "localtime": true, "localtimestamp": true, "unix_timestamp": true,
+	"utc_date": true, "utc_time": true, "utc_timestamp": true, "rand": true, "uuid": true, "uuid_short": true,
+	"connection_id": true, "current_user": true, "user": true, "session_user": true, "system_user": true,
+	"current_role": true, "last_insert_id": true, "found_rows": true, "row_count": true,
+}
+
+// get returns a copy of the cached result for key, if it hasn't expired.
+func (c *resultCache) get(key string) (QueryOutput, bool) {
+	if c == nil {
+		return QueryOutput{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return QueryOutput{}, false
+	}
+	entry := elem.Value.(*cachedResult)
+	if time.Since(entry.created) > c.ttl {
+		c.removeLocked(elem)
+		return QueryOutput{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return copyOutput(entry.output), true
+}
+
+// put caches a copy of output, which reads tables, under key, evicting the
+// least recently used results to stay under the size limit. A result bigger
+// than the whole cache isn't cached.
+func (c *resultCache) put(key string, tables []watchedTableKey, output QueryOutput) {
+	if c == nil {
+		return
+	}
+	data, err := json.Marshal(output)
+	if err != nil || len(data) > c.maxBytes {
+		return
+	}
+	entry := &cachedResult{key: key, output: copyOutput(output), tables: tables, size: len(data), created: time.Now()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.versions = make([]string, len(tables))
+	for i, table := range tables {
+		entry.versions[i] = c.versions[table]
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.removeLocked(elem)
+	}
+	for c.bytes+entry.size > c.maxBytes {
+		c.removeLocked(c.lru.Back())
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += entry.size
+}
+
+// clear drops every cached result, after a write or a config reload.
+func (c *resultCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	clear(c.entries)
+	c.bytes = 0
+}
+
+// invalidate drops the results that read db.table, or with an empty table
+// any table of db, after its schema changed. Results that read the whole
+// database or information_schema go too, since they describe the table.
+func (c *resultCache) invalidate(db, table string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidateLocked(strings.ToLower(db), strings.ToLower(table))
+}
+
+func (c *resultCache) invalidateLocked(db, table string) {
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		for _, read := range elem.Value.(*cachedResult).tables {
+			if read.db == "information_schema" || read.db == db && (read.table == "" || table == "" || read.table == table) {
+				c.removeLocked(elem)
+				break
+			}
+		}
+		elem = next
+	}
+}
+
+// setVersion records version, the checksum of its DDL, as the schema
+// version of db.table, or with an empty table of db's table list. When it
+// differs from the last one recorded the table's results are invalidated.
+// Results cached under another version of it, or before any was known,
+// are dropped either way: the schema may have changed since they ran.
+func (c *resultCache) setVersion(db, table, version string) {
+	if c == nil {
+		return
+	}
+	key := watchedTableKey{db: strings.ToLower(db), table: strings.ToLower(table)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if previous, ok := c.versions[key]; ok && previous != version {
+		c.invalidateLocked(key.db, key.table)
+	}
+	c.versions[key] = version
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*cachedResult)
+		if i := slices.Index(entry.tables, key); i >= 0 && entry.versions[i] != version {
+			c.removeLocked(elem)
+		}
+		elem = next
+	}
+}
+
+// watchedTables returns the tables cached results read, for the schema
+// watcher to poll. information_schema is left out: its results are
+// dropped with any change the watcher sees.
+func (c *resultCache) watchedTables() []watchedTableKey {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var tables []watchedTableKey
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		for _, table := range elem.Value.(*cachedResult).tables {
+			if table.db != "information_schema" && !slices.Contains(tables, table) {
+				tables = append(tables, table)
+			}
+		}
+	}
+	return tables
+}
+
+func (c *resultCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cachedResult)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// copyOutput copies output's rows, which the response path may trim or
+// rewrite in place.
+func copyOutput(output QueryOutput) QueryOutput {
+	rows := make([][]interface{}, len(output.Rows))
+	for i, row := range output.Rows {
+		rows[i] = slices.Clone(row)
+	}
+	output.Rows = rows
+	return output
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestResultCacheKey(t *testing.T) {
+	key := resultCacheKey("SELECT COUNT(*) FROM orders WHERE status = ?", []any{"open"}, 1000, false, nil, nil)
+	require.Equal(t, key, resultCacheKey("select  count(*)\nfrom orders where status = ?", []any{"open"}, 1000, false, map[string]bool{"execution_stats": false}, nil))
+	for _, other := range []string{
+		resultCacheKey("SELECT COUNT(*) FROM orders WHERE status = ?", []any{"paid"}, 1000, false, nil, nil),
+		resultCacheKey("SELECT COUNT(*) FROM orders WHERE status = 'open'", nil, 1000, false, nil, nil),
+		resultCacheKey("SELECT COUNT(*) FROM orders WHERE status = ?", []any{"open"}, 500, false, nil, nil),
+		resultCacheKey("SELECT COUNT(*) FROM orders WHERE status = ?", []any{"open"}, 1000, true, nil, nil),
+		resultCacheKey("SELECT COUNT(*) FROM orders WHERE status = ?", []any{"open"}, 1000, false, map[string]bool{"empty_result_hints": true}, nil),
+		resultCacheKey("SELECT COUNT(*) FROM orders WHERE status = ?", []any{"open"}, 1000, false, nil, []string{"analyst_ro"}),
+	} {
+		require.NotEqual(t, key, other)
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	for query, want := range map[string]bool{
+		"SELECT status, COUNT(*) FROM orders GROUP BY status": true,
+		"SHOW TABLES":                                                    true,
+		"SHOW CREATE TABLE orders":                                       true,
+		"DESCRIBE orders":                                                true,
+		"EXPLAIN SELECT * FROM orders":                                   true,
+		"SELECT TABLE_NAME FROM information_schema.TABLES":               true,
+		"EXPLAIN ANALYZE SELECT * FROM orders":                           false,
+		"SELECT * FROM orders WHERE created_at > NOW() - INTERVAL 1 DAY": false,
+		"SELECT id FROM orders ORDER BY RAND() LIMIT 1":                  false,
+		"SELECT UUID()":                                                  false,
+		"SELECT @@max_connections":                                       false,
+		"SELECT * FROM information_schema.PROCESSLIST":                   false,
+		"SELECT * FROM performance_schema.threads":                       false,
+		"SHOW PROCESSLIST":                                               false,
+		"SHOW GLOBAL STATUS":                                             false,
+	} {
+		stmt, err := gate.ParseStatement(query)
+		require.NoError(t, err, query)
+		require.Equal(t, want, cacheable(stmt), query)
+	}
+}
+
+func TestResultCache(t *testing.T) {
+	output := func(value string) QueryOutput {
+		return QueryOutput{Columns: []string{"v"}, Rows: [][]interface{}{{value}}, RowCount: 1}
+	}
+	data, err := json.Marshal(output("a"))
+	require.NoError(t, err)
+	cache := newResultCache(CacheConfig{TTLSeconds: 60, MaxBytes: 2 * len(data)})
+
+	cache.put("a", nil, output("a"))
+	cache.put("b", nil, output("b"))
+	got, ok := cache.get("a")
+	require.True(t, ok)
+	// Callers may rewrite what they get without touching the cache.
+	got.Rows[0][0] = "changed"
+	got, _ = cache.get("a")
+	require.Equal(t, "a", got.Rows[0][0])
+
+	// "b" is the least recently used, so it makes room for "c".
+	cache.put("c", nil, output("c"))
+	_, ok = cache.get("b")
+	require.False(t, ok)
+	_, ok = cache.get("a")
+	require.True(t, ok)
+
+	cache.entries["a"].Value.(*cachedResult).created = time.Now().Add(-time.Hour)
+	_, ok = cache.get("a")
+	require.False(t, ok)
+
+	cache.clear()
+	_, ok = cache.get("c")
+	require.False(t, ok)
+	require.Zero(t, cache.bytes)
+
+	require.Nil(t, newResultCache(CacheConfig{}))
+	require.Error(t, validateCacheConfig(CacheConfig{TTLSeconds: -1}))
+}
+
+func TestResultCacheInvalidate(t *testing.T) {
+	reads := func(query string) []watchedTableKey {
+		stmt, err := gate.ParseStatement(query)
+		require.NoError(t, err)
+		return cacheTables(stmt, "shop")
+	}
+	require.Equal(t, []watchedTableKey{{"shop", "orders"}, {"crm", "users"}}, reads("SELECT * FROM orders JOIN CRM.Users u ON u.id = orders.user_id JOIN orders o2 ON true"))
+	require.Equal(t, []watchedTableKey{{"information_schema", "columns"}}, reads("SELECT * FROM information_schema.COLUMNS"))
+	require.Equal(t, []watchedTableKey{{"shop", ""}}, reads("SHOW TABLES"))
+
+	cache := newResultCache(CacheConfig{TTLSeconds: 60})
+	output := QueryOutput{Columns: []string{"v"}, Rows: [][]interface{}{{1}}, RowCount: 1}
+	for _, query := range []string{"SELECT * FROM orders", "SELECT * FROM users", "SELECT * FROM crm.orders", "SELECT * FROM information_schema.COLUMNS", "SHOW TABLES", "SELECT 1"} {
+		cache.put(query, reads(query), output)
+	}
+	cache.invalidate("shop", "Orders")
+	for query, cached := range map[string]bool{
+		"SELECT * FROM orders":                     false,
+		"SELECT * FROM users":                      true,
+		"SELECT * FROM crm.orders":                 true,
+		"SELECT * FROM information_schema.COLUMNS": false,
+		"SHOW TABLES":                              false,
+		"SELECT 1":                                 true,
+	} {
+		_, ok := cache.get(query)
+		require.Equal(t, cached, ok, query)
+	}
+	cache.invalidate("crm", "")
+	_, ok := cache.get("SELECT * FROM crm.orders")
+	require.False(t, ok)
+}
+
+func TestResultCacheVersions(t *testing.T) {
+	cache := newResultCache(CacheConfig{TTLSeconds: 60})
+	output := QueryOutput{Columns: []string{"v"}, Rows: [][]interface{}{{1}}, RowCount: 1}
+	orders, users := watchedTableKey{"shop", "orders"}, watchedTableKey{"shop", "users"}
+	cached := func(key string) bool {
+		_, ok := cache.get(key)
+		return ok
+	}
+
+	cache.put("before", []watchedTableKey{orders}, output)
+	cache.put("users", []watchedTableKey{users}, output)
+	require.ElementsMatch(t, []watchedTableKey{orders, users}, cache.watchedTables())
+	cache.setVersion("shop", "orders", "v1")
+	require.False(t, cached("before"), "cached before the version was known")
+	require.True(t, cached("users"))
+
+	cache.put("after", []watchedTableKey{orders}, output)
+	cache.put("tables", []watchedTableKey{{"shop", ""}}, output)
+	cache.setVersion("SHOP", "Orders", "v1")
+	require.True(t, cached("after"))
+	require.True(t, cached("tables"))
+	cache.setVersion("shop", "orders", "v2")
+	require.False(t, cached("after"))
+	require.False(t, cached("tables"), "the database's tables are described by the changed one")
+	require.Equal(t, []watchedTableKey{users}, cache.watchedTables())
+}
+
+func TestResultCacheSchemaChange(t *testing.T) {
+	cfg := Config{Cache: CacheConfig{TTLSeconds: 60}}
+	v, err := newValidator(cfg, "shop")
+	require.NoError(t, err)
+	h := &queryHandler{
+		db:            sql.OpenDB(execConnector{rows: 2}),
+		config:        cfg,
+		validator:     v,
+		defaultSchema: "shop",
+		access:        newAccessControl(AccessConfig{}),
+		connections:   newConnectionSet(),
+		cache:         newResultCache(cfg.Cache),
+		active:        newActiveQueries(),
+		workload:      newWorkloadLog(workloadMaxFingerprints),
+	}
+	query := func() string {
+		_, output, err := h.runQuery(context.Background(), &mcp.CallToolRequest{}, QueryInput{Query: "SELECT * FROM users"})
+		require.NoError(t, err)
+		require.Empty(t, output.Error)
+		return output.Cache
+	}
+	require.Equal(t, cacheMiss, query())
+	require.Equal(t, cacheHit, query())
+
+	// Nothing subscribes to the table: the watcher polls it because a
+	// cached result reads it.
+	ddl := "CREATE TABLE users (id int)"
+	w := newSchemaWatcher(h, 0)
+	w.fetch = func(context.Context, string, string) (string, error) { return schemaChecksum(ddl), nil }
+	w.poll(context.Background(), func(context.Context, string) {})
+	require.Equal(t, cacheMiss, query(), "results cached before the schema version was known are dropped")
+	require.Equal(t, cacheHit, query())
+	w.poll(context.Background(), func(context.Context, string) {})
+	require.Equal(t, cacheHit, query(), "an unchanged schema keeps the result")
+
+	ddl = "CREATE TABLE users (id int, email text)"
+	w.poll(context.Background(), func(context.Context, string) {})
+	require.Equal(t, cacheMiss, query())
+}
+
+func TestCacheInStructuredContent(t *testing.T) {
+	structured := queryOutputToStructuredContent(QueryOutput{Columns: []string{}, Rows: [][]interface{}{}, Cache: cacheHit})
+	require.Equal(t, cacheHit, structured["cache"])
+	structured = queryOutputToStructuredContent(QueryOutput{Columns: []string{}, Rows: [][]interface{}{}})
+	require.NotContains(t, structured, "cache")
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+type QueryWithResultsInput struct {
+	Query   string            `json:"query" jsonschema:"Read-only SQL query that may reference the named results as tables."`
+	Results map[string]string `json:"results" jsonschema:"Map of CTE name to a resultId returned by an earlier call in this session."`
+}
+
+// buildResultCTEs renders stored results as a WITH clause whose rows are bound
+// parameters, so values never need quoting. Names are processed in sorted
+// order to keep the argument order deterministic.
+func buildResultCTEs(results map[string]*storedResult) (string, []any) {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ctes := make([]string, 0, len(names))
+	args := make([]any, 0)
+	for _, name := range names {
+		result := results[name]
+		columns := uniqueColumnNames(result.columns)
+		quoted := make([]string, len(columns))
+		for i, col := range columns {
+			quoted[i] = quoteIdentifier(col)
+		}
+
+		placeholders := "?"
+		if len(columns) > 1 {
+			placeholders = strings.Repeat("?, ", len(columns)-1) + "?"
+		}
+		var body string
+		if len(result.rows) == 0 {
+			nulls := strings.TrimSuffix(strings.Repeat("NULL, ", len(columns)), ", ")
+			body = "SELECT " + nulls + " FROM DUAL WHERE FALSE"
+		} else {
+			selects := make([]string, 0, len(result.rows))
+			for _, row := range result.rows {
+				selects = append(selects, "SELECT "+placeholders)
+				for _, value := range row {
+					args = append(args, resultArg(value))
+				}
+			}
+			body = strings.Join(selects, " UNION ALL ")
+		}
+		ctes = append(ctes, fmt.Sprintf("%s (%s) AS (%s)", quoteIdentifier(name), strings.Join(quoted, ", "), body))
+	}
+	return "WITH " + strings.Join(ctes, ", ") + " SELECT 1", args
+}
+
+// mergeResultCTEs prepends the CTEs of withSQL (a "WITH ... SELECT 1"
+// statement) to query's own WITH clause.
+func mergeResultCTEs(query, withSQL string) (string, error) {
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return "", err
+	}
+	withStmt, err := gate.ParseStatement(withSQL)
+	if err != nil {
+		return "", err
+	}
+	ctes := withStmt.(*sqlparser.Select).With.CTEs
+
+	var with **sqlparser.With
+	switch s := stmt.(type) {
+	case *sqlparser.Select:
+		with = &s.With
+	case *sqlparser.Union:
+		with = &s.With
+	default:
+		return "", fmt.Errorf("only SELECT queries can reference stored results")
+	}
+	if *with == nil {
+		*with = &sqlparser.With{}
+	}
+	for _, existing := range (*with).CTEs {
+		for _, cte := range ctes {
+			if strings.EqualFold(existing.ID.String(), cte.ID.String()) {
+				return "", fmt.Errorf("CTE name %q is already defined by the query", cte.ID.String())
+			}
+		}
+	}
+	(*with).CTEs = append(append([]*sqlparser.CommonTableExpr{}, ctes...), (*with).CTEs...)
+	return formatWithPlaceholders(stmt), nil
+}
+
+// resultArg converts a stored output value back into a bindable argument.
+// Omitted binary values bind as NULL.
+func resultArg(value interface{}) any {
+	if b, ok := value.(BinaryValue); ok {
+		if b.Omitted {
+			return nil
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(b.Base64)
+		return decoded
+	}
+	return value
+}
+
+func uniqueColumnNames(columns []string) []string {
+	seen := make(map[string]int, len(columns))
+	out := make([]string, len(columns))
+	for i, col := range columns {
+		key := strings.ToLower(col)
+		seen[key]++
+		out[i] = col
+		if seen[key] > 1 {
+			out[i] = fmt.Sprintf("%s_%d", col, seen[key])
+		}
+	}
+	return out
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (h *queryHandler) queryWithResults(ctx context.Context, req *mcp.CallToolRequest, input QueryWithResultsInput) (result *mcp.CallToolResult, output QueryOutput, err error) {
+	start := time.Now()
+	rejected := false
+	defer func() {
+		h.auditToolCall(req, "mysql_query_with_results", input.Query, rejected, start, result, output)
+	}()
+	ctx = withAttribution(ctx, req.Session)
+	session := sessionIDFor(req.Session)
+
+	live := h.snapshot()
+	if err := live.validator.Validate(input.Query); err != nil {
+		rejected = true
+		result, output := toolErrorResultf("only read-only queries are allowed: %v", err)
+		output.Rejection, _ = err.(*gate.QueryRejection)
+		result.StructuredContent = queryOutputToStructuredContent(output)
+		return result, output, nil
+	}
+	if len(input.Results) == 0 {
+		result, output := toolErrorResultf("results must name at least one stored result")
+		return result, output, nil
+	}
+
+	stored := make(map[string]*storedResult, len(input.Results))
+	for name, id := range input.Results {
+		if !mysqlIdentifierRE.MatchString(name) {
+			result, output := toolErrorResultf("invalid CTE name %q", name)
+			return result, output, nil
+		}
+		entry, ok := h.results.get(session, id)
+		if !ok {
+			result, output := toolErrorResultf("unknown or expired resultId %q", id)
+			return result, output, nil
+		}
+		stored[name] = entry
+	}
+
+	withSQL, args := buildResultCTEs(stored)
+	query, err := mergeResultCTEs(input.Query, withSQL)
+	if err != nil {
+		result, output := toolErrorResultf("%v", err)
+		return result, output, nil
+	}
+
+	defer h.active.begin(ctx, "mysql_query_with_results", input.Query)()
+	output, err = h.runQueryForResource(ctx, query, args...)
+	if err != nil {
+		result, output := toolErrorResultf("%v", err)
+		return result, output, nil
+	}
+	output.ResultID = h.results.put(session, output)
+	if h.safeIntegers(nil) {
+		output = withSafeIntegers(output)
+	}
+	output, extra := h.largeResult(output)
+	return &mcp.CallToolResult{
+		Content:           append([]mcp.Content{&mcp.TextContent{Text: "ok"}}, extra...),
+		StructuredContent: queryOutputToStructuredContent(output),
+	}, output, nil
+}
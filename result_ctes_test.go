@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildResultCTEs(t *testing.T) {
+	withSQL, args := buildResultCTEs(map[string]*storedResult{
+		"picked": {columns: []string{"id", "id", "name"}, rows: [][]interface{}{{int64(1), int64(2), "a"}, {int64(3), BinaryValue{Base64: "AP8=", Bytes: 2}, "b'c"}}},
+		"empty":  {columns: []string{"x"}, rows: [][]interface{}{}},
+	})
+	require.Equal(t, "WITH `empty` (`x`) AS (SELECT NULL FROM DUAL WHERE FALSE), "+
+		"`picked` (`id`, `id_2`, `name`) AS (SELECT ?, ?, ? UNION ALL SELECT ?, ?, ?) SELECT 1", withSQL)
+	require.Equal(t, []any{int64(1), int64(2), "a", int64(3), []byte{0x00, 0xff}, "b'c"}, args)
+}
+
+func TestMergeResultCTEs(t *testing.T) {
+	withSQL, _ := buildResultCTEs(map[string]*storedResult{
+		"picked": {columns: []string{"id"}, rows: [][]interface{}{{int64(1)}}},
+	})
+
+	merged, err := mergeResultCTEs("WITH o AS (SELECT id FROM orders) SELECT * FROM o JOIN picked USING (id)", withSQL)
+	require.NoError(t, err)
+	require.Equal(t, "with picked(id) as (select ? from dual) , o as (select id from orders) select * from o join picked using (id)", merged)
+
+	merged, err = mergeResultCTEs("SELECT id FROM picked UNION SELECT id FROM orders", withSQL)
+	require.NoError(t, err)
+	require.Contains(t, merged, "with picked(id) as (select ? from dual)")
+
+	_, err = mergeResultCTEs("WITH picked AS (SELECT 1) SELECT * FROM picked", withSQL)
+	require.Error(t, err)
+
+	_, err = mergeResultCTEs("SHOW TABLES", withSQL)
+	require.Error(t, err)
+}
+
+func TestResultStore(t *testing.T) {
+	store := newResultStore(2, 2, time.Minute)
+	out := QueryOutput{Columns: []string{"id"}, Rows: [][]interface{}{{int64(1)}}}
+
+	id := store.put("s1", out)
+	require.NotEmpty(t, id)
+	_, ok := store.get("s1", id)
+	require.True(t, ok)
+	_, ok = store.get("s2", id)
+	require.False(t, ok, "results are scoped to their session")
+
+	require.Empty(t, store.put("s1", QueryOutput{Columns: []string{"id"}, Rows: [][]interface{}{{1}, {2}, {3}}}))
+	require.Empty(t, store.put("s1", QueryOutput{Columns: []string{"id"}, Rows: [][]interface{}{{1}}, Truncated: true}))
+
+	store.put("s1", out)
+	store.put("s1", out)
+	_, ok = store.get("s1", id)
+	require.False(t, ok, "oldest entry is evicted past max entries")
+
+	expired := newResultStore(10, 10, -time.Second)
+	id = expired.put("s1", out)
+	_, ok = expired.get("s1", id)
+	require.False(t, ok)
+}
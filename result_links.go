@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const resultsURIPrefix = "mysql://results/"
+
+// linkLargeResult replaces a stored result whose JSON encoding exceeds
+// threshold bytes with a preview of its first previewRows rows, and returns
+// the content to send alongside it: a link to the full result at
+// mysql://results/{id} and, when the full result is at most embedBytes, the
+// result itself as an embedded resource for hosts that can't follow links.
+// Results that are small, or weren't stored, are returned unchanged with no
+// extra content.
+func linkLargeResult(output QueryOutput, threshold, previewRows, embedBytes int) (QueryOutput, []mcp.Content) {
+	if threshold <= 0 || output.ResultID == "" {
+		return output, nil
+	}
+	encoded, err := json.Marshal(output)
+	if err != nil || len(encoded) <= threshold {
+		return output, nil
+	}
+
+	size := int64(len(encoded))
+	uri := resultsURIPrefix + output.ResultID
+	content := []mcp.Content{&mcp.ResourceLink{
+		URI:         uri,
+		Name:        "result-" + output.ResultID,
+		Description: fmt.Sprintf("Full query result: %d rows, %d bytes.", output.RowCount, size),
+		MIMEType:    "application/json",
+		Size:        &size,
+	}}
+	if embedBytes > 0 && len(encoded) <= embedBytes {
+		content = append(content, &mcp.EmbeddedResource{Resource: &mcp.ResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(encoded),
+		}})
+	}
+
+	preview := withRows(output, output.Rows[:min(previewRows, len(output.Rows))])
+	preview.Truncated = true
+	preview.ResourceURI = uri
+	return preview, content
+}
+
+// largeResult applies linkLargeResult with the [result_store] thresholds.
+func (h *queryHandler) largeResult(output QueryOutput) (QueryOutput, []mcp.Content) {
+	cfg := h.snapshot().config.ResultStore
+	return linkLargeResult(output, cfg.LinkBytes, cfg.PreviewRows, cfg.EmbedBytes)
+}
+
+// readStoredResult serves mysql://results/{id} to the session that produced
+// the result.
+func (h *queryHandler) readStoredResult(uri, session, id string) (*mcp.ReadResourceResult, error) {
+	entry, ok := h.results.get(session, id)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	output := QueryOutput{
+		Columns:  entry.columns,
+		Rows:     entry.rows,
+		RowCount: len(entry.rows),
+		ResultID: entry.id,
+	}
+	if h.safeIntegers(nil) {
+		output = withSafeIntegers(output)
+	}
+	return jsonResourceResult(uri, output)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkLargeResult(t *testing.T) {
+	output := QueryOutput{Columns: []string{"id", "body"}, Rows: [][]interface{}{}, ResultID: "abc"}
+	for i := 0; i < 20; i++ {
+		output.Rows = append(output.Rows, []interface{}{int64(i), strings.Repeat("x", 100)})
+	}
+	output.RowCount = len(output.Rows)
+
+	same, extra := linkLargeResult(output, 0, 5, 0)
+	require.Nil(t, extra)
+	require.Equal(t, output, same)
+
+	same, extra = linkLargeResult(output, 1<<20, 5, 0)
+	require.Nil(t, extra)
+	require.Equal(t, output, same)
+
+	unstored := output
+	unstored.ResultID = ""
+	_, extra = linkLargeResult(unstored, 100, 5, 0)
+	require.Nil(t, extra, "results without an ID can't be linked")
+
+	preview, extra := linkLargeResult(output, 1000, 5, 1000)
+	require.Len(t, extra, 1, "too large to embed")
+	link := extra[0].(*mcp.ResourceLink)
+	require.Equal(t, "mysql://results/abc", link.URI)
+	require.Greater(t, *link.Size, int64(2000))
+	require.Equal(t, 5, preview.RowCount)
+	require.Len(t, preview.Rows, 5)
+	require.True(t, preview.Truncated)
+	require.Equal(t, link.URI, preview.ResourceURI)
+	require.Len(t, output.Rows, 20)
+}
+
+func TestLinkLargeResultEmbedsFullResult(t *testing.T) {
+	output := QueryOutput{Columns: []string{"body"}, Rows: [][]interface{}{}, ResultID: "abc"}
+	for i := 0; i < 20; i++ {
+		output.Rows = append(output.Rows, []interface{}{strings.Repeat("x", 100)})
+	}
+	output.RowCount = len(output.Rows)
+
+	preview, extra := linkLargeResult(output, 1000, 2, 1<<20)
+	require.Len(t, extra, 2)
+	embedded := extra[1].(*mcp.EmbeddedResource)
+	require.Equal(t, "mysql://results/abc", embedded.Resource.URI)
+
+	var full QueryOutput
+	require.NoError(t, json.Unmarshal([]byte(embedded.Resource.Text), &full))
+	require.Equal(t, 20, full.RowCount)
+	require.Equal(t, 2, preview.RowCount)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// resultStore keeps recent query results in memory, scoped to the session
+// that produced them, so later calls can refer back to them by ID.
+type resultStore struct {
+	maxEntries int
+	maxRows    int
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*storedResult
+	order   []string
+}
+
+type storedResult struct {
+	id      string
+	session string
+	columns []string
+	rows    [][]interface{}
+	created time.Time
+}
+
+func newResultStore(maxEntries, maxRows int, ttl time.Duration) *resultStore {
+	return &resultStore{
+		maxEntries: maxEntries,
+		maxRows:    maxRows,
+		ttl:        ttl,
+		entries:    make(map[string]*storedResult),
+	}
+}
+
+// put stores a complete result and returns its ID. Truncated or oversized
+// results are not stored, since reusing them would silently drop rows; put
+// returns "" for those.
+func (s *resultStore) put(session string, out QueryOutput) string {
+	if s == nil || out.Truncated || len(out.Rows) > s.maxRows {
+		return ""
+	}
+	entry := &storedResult{
+		id:      newRandomID(),
+		session: session,
+		columns: out.Columns,
+		rows:    out.Rows,
+		created: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictLocked()
+	for len(s.order) >= s.maxEntries && len(s.order) > 0 {
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.entries[entry.id] = entry
+	s.order = append(s.order, entry.id)
+	return entry.id
+}
+
+// get returns a stored result if it exists, hasn't expired, and belongs to
+// session.
+func (s *resultStore) get(session, id string) (*storedResult, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictLocked()
+	entry, ok := s.entries[id]
+	if !ok || entry.session != session {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (s *resultStore) evictLocked() {
+	cutoff := time.Now().Add(-s.ttl)
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if s.entries[id].created.Before(cutoff) {
+			delete(s.entries, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
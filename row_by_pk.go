@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type RowByPKInput struct {
+	Database string         `json:"database" jsonschema:"Database containing the table."`
+	Table    string         `json:"table" jsonschema:"Table to read."`
+	Key      map[string]any `json:"key" jsonschema:"Primary key value for every primary key column, by column name."`
+}
+
+type RowByPKOutput struct {
+	Database   string         `json:"database"`
+	Table      string         `json:"table"`
+	PrimaryKey []string       `json:"primaryKey"`
+	Found      bool           `json:"found"`
+	Row        map[string]any `json:"row,omitempty" jsonschema:"Column values of the row, when found."`
+}
+
+// rowByPKQuery selects the row of db.table with the given primary key,
+// binding key values in primaryKey order. Identifiers must already be
+// validated; key must name exactly the primary key columns.
+func rowByPKQuery(db, table string, primaryKey []string, key map[string]any) (string, []any, error) {
+	byName := make(map[string]any, len(key))
+	for name, value := range key {
+		byName[strings.ToLower(name)] = value
+	}
+	conditions := make([]string, len(primaryKey))
+	params := make([]any, len(primaryKey))
+	for i, column := range primaryKey {
+		value, ok := byName[strings.ToLower(column)]
+		if !ok {
+			return "", nil, fmt.Errorf("key is missing primary key column %s", column)
+		}
+		delete(byName, strings.ToLower(column))
+		conditions[i] = quoteIdentifier(column) + " = ?"
+		params[i] = value
+	}
+	if len(byName) > 0 {
+		extra := make([]string, 0, len(byName))
+		for name := range byName {
+			extra = append(extra, name)
+		}
+		sort.Strings(extra)
+		return "", nil, fmt.Errorf("key has columns outside the primary key (%s): %s", strings.Join(primaryKey, ", "), strings.Join(extra, ", "))
+	}
+	args, err := queryParams(params)
+	if err != nil {
+		return "", nil, err
+	}
+	query := fmt.Sprintf("SELECT * FROM %s.%s WHERE %s", quoteIdentifier(db), quoteIdentifier(table), strings.Join(conditions, " AND "))
+	return query, args, nil
+}
+
+func (h *queryHandler) rowByPK(ctx context.Context, req *mcp.CallToolRequest, input RowByPKInput) (*mcp.CallToolResult, RowByPKOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := RowByPKOutput{}
+	if !mysqlIdentifierRE.MatchString(input.Database) || !mysqlIdentifierRE.MatchString(input.Table) {
+		return toolErrorf(empty, "database and table must be plain identifiers")
+	}
+	primaryKey, err := h.schema.primaryKey(ctx, input.Database, input.Table)
+	if err != nil {
+		return toolErrorf(empty, "failed to look up primary key: %v", err)
+	}
+	if len(primaryKey) == 0 {
+		return toolErrorf(empty, "%s.%s has no primary key, or doesn't exist", input.Database, input.Table)
+	}
+	query, args, err := rowByPKQuery(input.Database, input.Table, primaryKey, input.Key)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	out, err := h.runQueryForResource(ctx, query, args...)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	if h.safeIntegers(nil) {
+		out = withSafeIntegers(out)
+	}
+	output := RowByPKOutput{Database: input.Database, Table: input.Table, PrimaryKey: primaryKey}
+	if len(out.Rows) > 0 {
+		output.Found = true
+		output.Row = make(map[string]any, len(out.Columns))
+		for i, column := range out.Columns {
+			output.Row[column] = rowValue(out.Rows[0], i)
+		}
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowByPKQuery(t *testing.T) {
+	query, args, err := rowByPKQuery("shop", "order_items", []string{"order_id", "line"}, map[string]any{"LINE": float64(2), "order_id": "A-17"})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM `shop`.`order_items` WHERE `order_id` = ? AND `line` = ?", query)
+	require.Equal(t, []any{"A-17", int64(2)}, args)
+
+	_, _, err = rowByPKQuery("shop", "order_items", []string{"order_id", "line"}, map[string]any{"order_id": "A-17"})
+	require.ErrorContains(t, err, "missing primary key column line")
+
+	_, _, err = rowByPKQuery("shop", "orders", []string{"id"}, map[string]any{"id": 1.0, "status": "open"})
+	require.ErrorContains(t, err, "outside the primary key (id): status")
+
+	_, _, err = rowByPKQuery("shop", "orders", []string{"id"}, map[string]any{"id": []any{1.0}})
+	require.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// RowFilterConfig is a mandatory predicate for every query that reads Table
+// ("db.table", or "table" in the DSN's default database).
+type RowFilterConfig struct {
+	Table     string `toml:"table"`
+	Predicate string `toml:"predicate"`
+}
+
+type rowFilter struct {
+	schema    string
+	table     string
+	predicate string
+}
+
+// rowFilters rewrites queries so that each reference to a filtered table reads
+// from a derived table restricted by the table's predicate instead. Replacing
+// the table reference (rather than appending to WHERE) keeps the filter
+// correct inside outer joins, subqueries, UNION branches, and CTEs.
+type rowFilters struct {
+	defaultSchema string
+	filters       map[string]rowFilter
+}
+
+func newRowFilters(configs []RowFilterConfig, defaultSchema string) (*rowFilters, error) {
+	f := &rowFilters{defaultSchema: strings.ToLower(defaultSchema), filters: make(map[string]rowFilter)}
+	for _, cfg := range configs {
+		schema, table, ok := strings.Cut(cfg.Table, ".")
+		if !ok {
+			schema, table = defaultSchema, cfg.Table
+		}
+		if schema == "" {
+			return nil, fmt.Errorf("row filter for %q: no database given and the DSN has no default database", cfg.Table)
+		}
+		if !mysqlIdentifierRE.MatchString(schema) || !mysqlIdentifierRE.MatchString(table) {
+			return nil, fmt.Errorf("row filter for %q: table must be db.table or table", cfg.Table)
+		}
+		if strings.TrimSpace(cfg.Predicate) == "" {
+			return nil, fmt.Errorf("row filter for %q: predicate is required", cfg.Table)
+		}
+		filter := rowFilter{schema: schema, table: table, predicate: cfg.Predicate}
+		stmt, err := gate.ParseStatement(filter.selectSQL())
+		if err != nil {
+			return nil, fmt.Errorf("row filter for %q: invalid predicate: %w", cfg.Table, err)
+		}
+		if _, ok := stmt.(*sqlparser.Select); !ok {
+			return nil, fmt.Errorf("row filter for %q: predicate must be a single boolean expression", cfg.Table)
+		}
+		key := strings.ToLower(schema + "." + table)
+		if _, dup := f.filters[key]; dup {
+			return nil, fmt.Errorf("row filter for %q: duplicate table", cfg.Table)
+		}
+		f.filters[key] = filter
+	}
+	return f, nil
+}
+
+func (r rowFilter) selectSQL() string {
+	return fmt.Sprintf("SELECT * FROM `%s`.`%s` WHERE %s", r.schema, r.table, r.predicate)
+}
+
+// apply returns query rewritten with the configured row filters. Queries that
+// don't reference a filtered table are returned unchanged.
+func (f *rowFilters) apply(query string) (string, error) {
+	if f == nil || len(f.filters) == 0 {
+		return query, nil
+	}
+	stmt, err := gate.ParseStatement(query)
+	if err != nil {
+		return "", err
+	}
+
+	ctes := gate.CTEReferences(stmt)
+
+	var rewriteErr error
+	changed := false
+	sqlparser.Rewrite(stmt, func(cursor *sqlparser.Cursor) bool {
+		aliased, ok := cursor.Node().(*sqlparser.AliasedTableExpr)
+		if !ok || ctes[aliased] {
+			return true
+		}
+		name, ok := aliased.Expr.(sqlparser.TableName)
+		if !ok {
+			return true
+		}
+		filter, ok := f.lookup(name)
+		if !ok {
+			return true
+		}
+		filtered, err := gate.ParseStatement(filter.selectSQL())
+		if err != nil {
+			rewriteErr = err
+			return false
+		}
+		sel, ok := filtered.(*sqlparser.Select)
+		if !ok {
+			rewriteErr = fmt.Errorf("row filter for %s.%s is not a simple predicate", filter.schema, filter.table)
+			return false
+		}
+		if aliased.As.IsEmpty() {
+			aliased.As = name.Name
+		}
+		aliased.Expr = &sqlparser.DerivedTable{Select: sel}
+		// Index hints and partitions can't apply to a derived table.
+		aliased.Hints = nil
+		aliased.Partitions = nil
+		changed = true
+		return false
+	}, nil)
+	if rewriteErr != nil {
+		return "", rewriteErr
+	}
+	if !changed {
+		return query, nil
+	}
+	return formatWithPlaceholders(stmt), nil
+}
+
+func (f *rowFilters) lookup(name sqlparser.TableName) (rowFilter, bool) {
+	schema := strings.ToLower(name.Qualifier.String())
+	if schema == "" {
+		schema = f.defaultSchema
+	}
+	filter, ok := f.filters[schema+"."+strings.ToLower(name.Name.String())]
+	return filter, ok
+}
+
+// formatWithPlaceholders renders stmt as SQL, printing bind arguments as the
+// positional ? placeholders they were parsed from.
+func formatWithPlaceholders(stmt sqlparser.Statement) string {
+	buf := sqlparser.NewTrackedBuffer(func(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode) {
+		if _, ok := node.(*sqlparser.Argument); ok {
+			buf.WriteString("?")
+			return
+		}
+		node.Format(buf)
+	})
+	buf.Myprintf("%v", stmt)
+	return buf.String()
+}
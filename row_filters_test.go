@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowFiltersApply(t *testing.T) {
+	filters, err := newRowFilters([]RowFilterConfig{
+		{Table: "orders", Predicate: "tenant_id = 42"},
+		{Table: "crm.contacts", Predicate: "tenant_id = 42 AND deleted = 0"},
+	}, "shop")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "untouched query is returned verbatim",
+			query: "SELECT * FROM customers  WHERE id = 1",
+			want:  "SELECT * FROM customers  WHERE id = 1",
+		},
+		{
+			name:  "default schema table",
+			query: "SELECT id FROM orders WHERE status = 'open'",
+			want:  "select id from (select * from shop.orders where tenant_id = 42) as orders where `status` = 'open'",
+		},
+		{
+			name:  "qualified table keeps alias",
+			query: "SELECT o.id FROM shop.orders o LEFT JOIN crm.contacts c ON c.id = o.contact_id",
+			want:  "select o.id from (select * from shop.orders where tenant_id = 42) as o left join (select * from crm.contacts where tenant_id = 42 and deleted = 0) as c on c.id = o.contact_id",
+		},
+		{
+			name:  "subquery and union branches",
+			query: "SELECT id FROM customers WHERE id IN (SELECT customer_id FROM orders) UNION SELECT id FROM crm.contacts",
+			want:  "select id from customers where id in (select customer_id from (select * from shop.orders where tenant_id = 42) as orders) union select id from (select * from crm.contacts where tenant_id = 42 and deleted = 0) as contacts",
+		},
+		{
+			name:  "cte shadowing a filtered table name",
+			query: "WITH orders AS (SELECT 1 AS id) SELECT id FROM orders",
+			want:  "WITH orders AS (SELECT 1 AS id) SELECT id FROM orders",
+		},
+		{
+			name:  "non-recursive cte naming itself reads the base table",
+			query: "WITH orders AS (SELECT id FROM orders) SELECT id FROM orders",
+			want:  "with orders as (select id from (select * from shop.orders where tenant_id = 42) as orders) select id from orders",
+		},
+		{
+			name:  "cte out of scope in a sibling subquery",
+			query: "SELECT (WITH orders AS (SELECT 1 AS id) SELECT id FROM orders) AS a, (SELECT COUNT(*) FROM orders) AS b",
+			want:  "select (with orders as (select 1 as id from dual) select id from orders) as a, (select count(*) from (select * from shop.orders where tenant_id = 42) as orders) as b from dual",
+		},
+		{
+			name:  "placeholders are preserved",
+			query: "SELECT id FROM orders WHERE id = ? AND status = ?",
+			want:  "select id from (select * from shop.orders where tenant_id = 42) as orders where id = ? and `status` = ?",
+		},
+		{
+			name:  "explain",
+			query: "EXPLAIN SELECT * FROM orders",
+			want:  "explain select * from (select * from shop.orders where tenant_id = 42) as orders",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := filters.apply(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNewRowFiltersValidation(t *testing.T) {
+	cases := map[string][]RowFilterConfig{
+		"no default schema": {{Table: "orders", Predicate: "tenant_id = 1"}},
+		"bad table":         {{Table: "shop.orders;x", Predicate: "tenant_id = 1"}},
+		"empty predicate":   {{Table: "shop.orders"}},
+		"bad predicate":     {{Table: "shop.orders", Predicate: "tenant_id = = 1"}},
+		"union predicate":   {{Table: "shop.orders", Predicate: "1 = 1 UNION SELECT * FROM shop.orders"}},
+		"duplicate":         {{Table: "shop.orders", Predicate: "a = 1"}, {Table: "SHOP.orders", Predicate: "a = 2"}},
+	}
+	for name, cfgs := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newRowFilters(cfgs, "")
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import "database/sql"
+
+const (
+	// scanSlabRows is how many rows' worth of values rowScanner allocates
+	// at once.
+	scanSlabRows = 64
+	// scanPreallocRows caps the row slice preallocated for a result, so a
+	// high max_rows doesn't reserve memory a small result never uses.
+	scanPreallocRows = 256
+)
+
+// rowScanner reads result rows with one set of scan destinations for the
+// whole result. Each cell is normalized as it's scanned, so the bytes of a
+// text or binary value are copied once, into its string or BinaryValue,
+// rather than cloned by database/sql first. Rows are carved from shared
+// slabs instead of being allocated one by one.
+type rowScanner struct {
+	cells []cellScanner
+	dest  []interface{}
+	slab  []interface{}
+}
+
+// cellScanner is the scan destination of one column.
+type cellScanner struct {
+	colType   ColumnType
+	omitBlobs bool
+	value     interface{}
+}
+
+// Scan normalizes src, which for []byte is only valid until the next row is
+// read.
+func (c *cellScanner) Scan(src any) error {
+	c.value = normalizeColumnValue(src, c.colType, c.omitBlobs)
+	return nil
+}
+
+func newRowScanner(colTypes []ColumnType, omitBlobs bool) *rowScanner {
+	s := &rowScanner{
+		cells: make([]cellScanner, len(colTypes)),
+		dest:  make([]interface{}, len(colTypes)),
+	}
+	for i := range s.cells {
+		s.cells[i] = cellScanner{colType: colTypes[i], omitBlobs: omitBlobs}
+		s.dest[i] = &s.cells[i]
+	}
+	return s
+}
+
+// scan reads the current row of rows. The returned row has its own values
+// and capacity, so appending to it can't overwrite the next row.
+func (s *rowScanner) scan(rows *sql.Rows) ([]interface{}, error) {
+	if err := rows.Scan(s.dest...); err != nil {
+		return nil, err
+	}
+	n := len(s.cells)
+	if len(s.slab) < n {
+		s.slab = make([]interface{}, n*scanSlabRows)
+	}
+	row := s.slab[:n:n]
+	s.slab = s.slab[n:]
+	for i := range s.cells {
+		row[i] = s.cells[i].value
+		s.cells[i].value = nil
+	}
+	return row, nil
+}
+
+// newResultRows returns an empty row slice with room for the rows a result
+// capped at maxRows is likely to hold.
+func newResultRows(maxRows int) [][]interface{} {
+	return make([][]interface{}, 0, max(0, min(maxRows, scanPreallocRows)))
+}
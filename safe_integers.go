@@ -0,0 +1,63 @@
+package main
+
+import "strconv"
+
+// maxSafeInteger is the largest integer a float64, and so a JavaScript
+// number, represents exactly (Number.MAX_SAFE_INTEGER).
+const maxSafeInteger = 1<<53 - 1
+
+// withSafeIntegers returns output with integers beyond ±maxSafeInteger,
+// including BIGINT UNSIGNED values above the int64 range, replaced by their
+// decimal strings, so hosts that parse JSON numbers as doubles don't round
+// large IDs. Rows are copied when changed, since the originals may be held
+// by the result store.
+func withSafeIntegers(output QueryOutput) QueryOutput {
+	var rows [][]interface{}
+	for i, row := range output.Rows {
+		var safeRow []interface{}
+		for j, value := range row {
+			safe, changed := safeInteger(value)
+			if !changed {
+				continue
+			}
+			if safeRow == nil {
+				safeRow = append([]interface{}(nil), row...)
+			}
+			safeRow[j] = safe
+		}
+		if safeRow == nil {
+			continue
+		}
+		if rows == nil {
+			rows = append([][]interface{}(nil), output.Rows...)
+		}
+		rows[i] = safeRow
+	}
+	if rows != nil {
+		output.Rows = rows
+	}
+	return output
+}
+
+func safeInteger(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case int64:
+		if v > maxSafeInteger || v < -maxSafeInteger {
+			return strconv.FormatInt(v, 10), true
+		}
+	case uint64:
+		if v > maxSafeInteger {
+			return strconv.FormatUint(v, 10), true
+		}
+	}
+	return value, false
+}
+
+// safeIntegers reports whether a call returns large integers as strings: the
+// call's safeIntegers argument if given, else the safe_integers setting.
+func (h *queryHandler) safeIntegers(requested *bool) bool {
+	if requested != nil {
+		return *requested
+	}
+	return h.snapshot().config.MySQL.SafeIntegers
+}
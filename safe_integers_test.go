@@ -0,0 +1,26 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSafeIntegers(t *testing.T) {
+	rows := [][]interface{}{
+		{int64(1), int64(maxSafeInteger), "a"},
+		{int64(maxSafeInteger + 1), uint64(math.MaxUint64), nil},
+		{int64(-maxSafeInteger - 1), 1.5, "b"},
+	}
+	output := withSafeIntegers(QueryOutput{Rows: rows})
+	require.Equal(t, [][]interface{}{
+		{int64(1), int64(maxSafeInteger), "a"},
+		{"9007199254740992", "18446744073709551615", nil},
+		{"-9007199254740992", 1.5, "b"},
+	}, output.Rows)
+	require.Equal(t, int64(maxSafeInteger+1), rows[1][0], "the original rows are untouched")
+
+	small := [][]interface{}{{int64(7)}}
+	require.Equal(t, small, withSafeIntegers(QueryOutput{Rows: small}).Rows)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// sampleRandomMaxRows is the largest estimated table size sampled with
+// ORDER BY RAND(), which reads and sorts the whole table. Larger tables
+// return their first rows instead.
+const sampleRandomMaxRows = 10000
+
+type SampleRowsInput struct {
+	Database string   `json:"database" jsonschema:"Database containing the table."`
+	Table    string   `json:"table" jsonschema:"Table or view to sample."`
+	Columns  []string `json:"columns,omitempty" jsonschema:"Columns to return; all columns when omitted."`
+	Limit    int      `json:"limit,omitempty" jsonschema:"Number of rows (default 10, max 100)."`
+}
+
+type SampleRowsOutput struct {
+	Database string          `json:"database"`
+	Table    string          `json:"table"`
+	Sampling string          `json:"sampling" jsonschema:"random for tables small enough to sample at random, first for the first rows in storage order."`
+	Columns  []string        `json:"columns"`
+	Rows     [][]interface{} `json:"rows"`
+	RowCount int             `json:"rowCount"`
+}
+
+// sampleRowsQuery selects limit rows of db.table, at random when random is
+// set. Identifiers must already be validated.
+func sampleRowsQuery(db, table string, columns []string, random bool) string {
+	selected := "*"
+	if len(columns) > 0 {
+		quoted := make([]string, len(columns))
+		for i, column := range columns {
+			quoted[i] = quoteIdentifier(column)
+		}
+		selected = strings.Join(quoted, ", ")
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s.%s", selected, quoteIdentifier(db), quoteIdentifier(table))
+	if random {
+		query += " ORDER BY RAND()"
+	}
+	return query + " LIMIT ?"
+}
+
+func (h *queryHandler) sampleRows(ctx context.Context, req *mcp.CallToolRequest, input SampleRowsInput) (*mcp.CallToolResult, SampleRowsOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := SampleRowsOutput{}
+	if !mysqlIdentifierRE.MatchString(input.Database) || !mysqlIdentifierRE.MatchString(input.Table) {
+		return toolErrorf(empty, "database and table must be plain identifiers")
+	}
+	for _, column := range input.Columns {
+		if !mysqlIdentifierRE.MatchString(column) {
+			return toolErrorf(empty, "column %q must be a plain identifier", column)
+		}
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	limit = min(limit, 100)
+	if maxRows := h.snapshot().config.MySQL.MaxRows; maxRows > 0 {
+		limit = min(limit, maxRows)
+	}
+
+	size, err := h.runMetadataQuery(ctx, "SELECT TABLE_ROWS AS table_rows FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", input.Database, input.Table)
+	if err != nil {
+		return toolErrorf(empty, "failed to look up table: %v", err)
+	}
+	if len(size.Rows) == 0 {
+		return toolErrorf(empty, "table %s.%s not found", input.Database, input.Table)
+	}
+	// Views have no row estimate, and sorting one at random may be costly.
+	estimate, known := valueInt64(rowValue(size.Rows[0], 0))
+	random := known && estimate <= sampleRandomMaxRows
+
+	out, err := h.runQueryForResource(ctx, sampleRowsQuery(input.Database, input.Table, input.Columns, random), limit)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	if h.safeIntegers(nil) {
+		out = withSafeIntegers(out)
+	}
+	output := SampleRowsOutput{
+		Database: input.Database,
+		Table:    input.Table,
+		Sampling: "first",
+		Columns:  out.Columns,
+		Rows:     out.Rows,
+		RowCount: len(out.Rows),
+	}
+	if random {
+		output.Sampling = "random"
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleRowsQuery(t *testing.T) {
+	require.Equal(t, "SELECT * FROM `shop`.`orders` ORDER BY RAND() LIMIT ?", sampleRowsQuery("shop", "orders", nil, true))
+	require.Equal(t, "SELECT `id`, `status` FROM `shop`.`orders` LIMIT ?", sampleRowsQuery("shop", "orders", []string{"id", "status"}, false))
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+)
+
+// SavedQueryConfig is a curated query exposed as its own tool. SQL refers to
+// parameters as :name; they are bound as values, never spliced into the text.
+type SavedQueryConfig struct {
+	Name        string            `toml:"name"`
+	Description string            `toml:"description"`
+	SQL         string            `toml:"sql"`
+	Params      []SavedQueryParam `toml:"params"`
+}
+
+type SavedQueryParam struct {
+	Name string `toml:"name"`
+	// Type is one of "string", "integer", "number", or "boolean".
+	Type        string `toml:"type"`
+	Description string `toml:"description"`
+	Required    bool   `toml:"required"`
+	Default     any    `toml:"default"`
+}
+
+type savedQuery struct {
+	config SavedQueryConfig
+	// query is SQL with each :name placeholder replaced by ?, and order holds
+	// the parameter name for each ? in sequence.
+	query string
+	order []string
+}
+
+var (
+	toolNameRE        = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	savedParamTypes   = map[string]bool{"string": true, "integer": true, "number": true, "boolean": true}
+	builtinToolPrefix = "mysql_"
+)
+
+// compileSavedQueries validates the configured queries: unique tool names,
+// declared and typed parameters, and SQL that passes the read-only gate.
+func compileSavedQueries(configs []SavedQueryConfig, validator *gate.Validator) ([]*savedQuery, error) {
+	queries := make([]*savedQuery, 0, len(configs))
+	seen := make(map[string]bool)
+	for _, cfg := range configs {
+		if !toolNameRE.MatchString(cfg.Name) {
+			return nil, fmt.Errorf("saved query %q: name must match %s", cfg.Name, toolNameRE)
+		}
+		if strings.HasPrefix(cfg.Name, builtinToolPrefix) {
+			return nil, fmt.Errorf("saved query %q: the %q prefix is reserved for built-in tools", cfg.Name, builtinToolPrefix)
+		}
+		if cfg.Name == "analytics_query" {
+			return nil, fmt.Errorf("saved query %q: the name is reserved for a built-in tool", cfg.Name)
+		}
+		if seen[cfg.Name] {
+			return nil, fmt.Errorf("saved query %q: duplicate name", cfg.Name)
+		}
+		seen[cfg.Name] = true
+
+		declared := make(map[string]bool)
+		for _, param := range cfg.Params {
+			if !mysqlIdentifierRE.MatchString(param.Name) {
+				return nil, fmt.Errorf("saved query %q: invalid parameter name %q", cfg.Name, param.Name)
+			}
+			if !savedParamTypes[param.Type] {
+				return nil, fmt.Errorf("saved query %q: parameter %q has unsupported type %q", cfg.Name, param.Name, param.Type)
+			}
+			if param.Default != nil {
+				if _, err := coerceSavedParam(param, param.Default); err != nil {
+					return nil, fmt.Errorf("saved query %q: default for %q: %w", cfg.Name, param.Name, err)
+				}
+			}
+			declared[param.Name] = true
+		}
+
+		query, order := bindPlaceholders(cfg.SQL)
+		for _, name := range order {
+			if !declared[name] {
+				return nil, fmt.Errorf("saved query %q: placeholder :%s has no matching parameter", cfg.Name, name)
+			}
+		}
+		if err := validator.Validate(query); err != nil {
+			return nil, fmt.Errorf("saved query %q: %w", cfg.Name, err)
+		}
+		queries = append(queries, &savedQuery{config: cfg, query: query, order: order})
+	}
+	return queries, nil
+}
+
+// bindPlaceholders replaces :name placeholders outside of quoted strings,
+// quoted identifiers, and comments with ?, returning the names in order.
+func bindPlaceholders(sql string) (string, []string) {
+	var out strings.Builder
+	order := make([]string, 0)
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(sql) {
+				if sql[end] == '\\' && c != '`' {
+					end += 2
+					continue
+				}
+				if sql[end] == c {
+					break
+				}
+				end++
+			}
+			end = min(end+1, len(sql))
+			out.WriteString(sql[i:end])
+			i = end
+		case c == '#' || (c == '-' && strings.HasPrefix(sql[i:], "-- ")):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			out.WriteString(sql[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i
+			} else {
+				end += 4
+			}
+			out.WriteString(sql[i : i+end])
+			i += end
+		case c == ':' && i+1 < len(sql) && isPlaceholderStart(sql[i+1]):
+			end := i + 1
+			for end < len(sql) && isPlaceholderChar(sql[end]) {
+				end++
+			}
+			order = append(order, sql[i+1:end])
+			out.WriteByte('?')
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String(), order
+}
+
+func isPlaceholderStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isPlaceholderChar(c byte) bool {
+	return isPlaceholderStart(c) || (c >= '0' && c <= '9')
+}
+
+func (q *savedQuery) inputSchema() *jsonschema.Schema {
+	schema := &jsonschema.Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*jsonschema.Schema),
+		AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+	}
+	for _, param := range q.config.Params {
+		schema.Properties[param.Name] = &jsonschema.Schema{Type: param.Type, Description: param.Description}
+		if param.Required {
+			schema.Required = append(schema.Required, param.Name)
+		}
+	}
+	return schema
+}
+
+// bindArgs resolves the value of each placeholder from the tool arguments,
+// falling back to parameter defaults.
+func (q *savedQuery) bindArgs(input map[string]any) ([]any, error) {
+	params := make(map[string]SavedQueryParam, len(q.config.Params))
+	for _, param := range q.config.Params {
+		params[param.Name] = param
+	}
+
+	args := make([]any, 0, len(q.order))
+	for _, name := range q.order {
+		param := params[name]
+		value, ok := input[name]
+		if !ok || value == nil {
+			value = param.Default
+		}
+		if value == nil {
+			if param.Required {
+				return nil, fmt.Errorf("missing required parameter %q", name)
+			}
+			args = append(args, nil)
+			continue
+		}
+		coerced, err := coerceSavedParam(param, value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", name, err)
+		}
+		args = append(args, coerced)
+	}
+	return args, nil
+}
+
+func coerceSavedParam(param SavedQueryParam, value any) (any, error) {
+	switch param.Type {
+	case "string":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case "boolean":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case "integer":
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+				return int64(v), nil
+			}
+		}
+	case "number":
+		switch v := value.(type) {
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %T", param.Type, value)
+}
+
+func (h *queryHandler) savedQueryHandler(q *savedQuery) mcp.ToolHandlerFor[map[string]any, QueryOutput] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input map[string]any) (result *mcp.CallToolResult, output QueryOutput, err error) {
+		start := time.Now()
+		defer func() {
+			h.auditToolCall(req, q.config.Name, q.query, false, start, result, output)
+		}()
+		ctx = withConfirmedTables(withToolCall(withAttribution(ctx, req.Session), q.config.Name))
+
+		args, err := q.bindArgs(input)
+		if err != nil {
+			result, output := toolErrorResultf("%v", err)
+			return result, output, nil
+		}
+		defer h.active.begin(ctx, q.config.Name, q.query)()
+		output, err = h.runQueryForResource(ctx, q.query, args...)
+		if err != nil {
+			result, output := toolErrorResultf("%v", err)
+			return result, output, nil
+		}
+		output.ResultID = h.results.put(sessionIDFor(req.Session), output)
+		if h.safeIntegers(nil) {
+			output = withSafeIntegers(output)
+		}
+		output, extra := h.largeResult(output)
+		return &mcp.CallToolResult{
+			Content:           append([]mcp.Content{&mcp.TextContent{Text: "ok"}}, extra...),
+			StructuredContent: queryOutputToStructuredContent(output),
+		}, output, nil
+	}
+}
+
+func registerSavedQueries(server *mcp.Server, h *queryHandler, queries []*savedQuery) {
+	for _, q := range queries {
+		if !h.exposure.toolEnabled(q.config.Name) {
+			continue
+		}
+		description := q.config.Description
+		if description == "" {
+			description = fmt.Sprintf("Run the saved query %q.", q.config.Name)
+		}
+		addTool(server, h, &mcp.Tool{
+			Name:        q.config.Name,
+			Description: description,
+			InputSchema: q.inputSchema(),
+		}, h.savedQueryHandler(q))
+		h.mu.Lock()
+		h.savedTools = append(h.savedTools, q.config.Name)
+		h.mu.Unlock()
+	}
+}
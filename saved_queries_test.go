@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestBindPlaceholders(t *testing.T) {
+	query, order := bindPlaceholders("SELECT ':skip', `a:b` FROM t /* :c */ WHERE id = :id AND (owner = :owner OR :id = 0) -- :d\n AND @x := 1")
+	require.Equal(t, "SELECT ':skip', `a:b` FROM t /* :c */ WHERE id = ? AND (owner = ? OR ? = 0) -- :d\n AND @x := 1", query)
+	require.Equal(t, []string{"id", "owner", "id"}, order)
+}
+
+func TestCompileSavedQueries(t *testing.T) {
+	valid := SavedQueryConfig{
+		Name: "orders_by_customer",
+		SQL:  "SELECT * FROM orders WHERE customer_id = :customer_id LIMIT :limit",
+		Params: []SavedQueryParam{
+			{Name: "customer_id", Type: "integer", Required: true},
+			{Name: "limit", Type: "integer", Default: int64(50)},
+		},
+	}
+	queries, err := compileSavedQueries([]SavedQueryConfig{valid}, testValidator(t, gate.Options{}))
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	require.Equal(t, "SELECT * FROM orders WHERE customer_id = ? LIMIT ?", queries[0].query)
+
+	schema := queries[0].inputSchema()
+	require.Equal(t, "object", schema.Type)
+	require.Equal(t, []string{"customer_id"}, schema.Required)
+	require.Equal(t, "integer", schema.Properties["limit"].Type)
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "v0"}, nil)
+	require.NotPanics(t, func() { registerSavedQueries(server, &queryHandler{}, queries) })
+
+	cases := map[string]SavedQueryConfig{
+		"bad name":           {Name: "has space", SQL: "SELECT 1"},
+		"reserved prefix":    {Name: "mysql_query", SQL: "SELECT 1"},
+		"undeclared param":   {Name: "q", SQL: "SELECT * FROM t WHERE id = :id"},
+		"bad type":           {Name: "q", SQL: "SELECT :x", Params: []SavedQueryParam{{Name: "x", Type: "date"}}},
+		"bad default":        {Name: "q", SQL: "SELECT :x", Params: []SavedQueryParam{{Name: "x", Type: "integer", Default: "ten"}}},
+		"not read-only":      {Name: "q", SQL: "DELETE FROM t"},
+		"duplicate (second)": valid,
+	}
+	for name, cfg := range cases {
+		t.Run(name, func(t *testing.T) {
+			configs := []SavedQueryConfig{cfg}
+			if name == "duplicate (second)" {
+				configs = append(configs, cfg)
+			}
+			_, err := compileSavedQueries(configs, testValidator(t, gate.Options{}))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSavedQueryBindArgs(t *testing.T) {
+	queries, err := compileSavedQueries([]SavedQueryConfig{{
+		Name: "search",
+		SQL:  "SELECT * FROM users WHERE name = :name AND active = :active AND score > :score LIMIT :limit",
+		Params: []SavedQueryParam{
+			{Name: "name", Type: "string", Required: true},
+			{Name: "active", Type: "boolean"},
+			{Name: "score", Type: "number", Default: 0.5},
+			{Name: "limit", Type: "integer", Default: int64(10)},
+		},
+	}}, testValidator(t, gate.Options{}))
+	require.NoError(t, err)
+	q := queries[0]
+
+	args, err := q.bindArgs(map[string]any{"name": "ada", "limit": float64(5)})
+	require.NoError(t, err)
+	require.Equal(t, []any{"ada", nil, 0.5, int64(5)}, args)
+
+	_, err = q.bindArgs(map[string]any{})
+	require.ErrorContains(t, err, `missing required parameter "name"`)
+
+	_, err = q.bindArgs(map[string]any{"name": "ada", "limit": 2.5})
+	require.ErrorContains(t, err, `parameter "limit"`)
+}
+
+func TestLoadConfigSavedQueries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[mysql]
+dsn = "user:pass@tcp(localhost:3306)/db"
+
+[[queries]]
+name = "recent_orders"
+description = "Most recent orders for a customer."
+sql = "SELECT * FROM orders WHERE customer_id = :customer_id ORDER BY created_at DESC LIMIT :limit"
+
+[[queries.params]]
+name = "customer_id"
+type = "integer"
+required = true
+
+[[queries.params]]
+name = "limit"
+type = "integer"
+default = 20
+`), 0o600))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Queries, 1)
+	require.Len(t, cfg.Queries[0].Params, 2)
+	require.Equal(t, int64(20), cfg.Queries[0].Params[1].Default)
+
+	v, err := newValidator(cfg, "")
+	require.NoError(t, err)
+	_, err = compileSavedQueries(cfg.Queries, v)
+	require.NoError(t, err)
+}
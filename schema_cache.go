@@ -0,0 +1,111 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schemaCache memoizes table column and primary key lists read from
+// information_schema so that per-query annotations don't hit the catalog on
+// every call.
+type schemaCache struct {
+	db  *sql.DB
+	ttl time.Duration
+	// defaultSchema stands in for an empty schema. The catalog pool's own
+	// default database can't: the introspection DSN may not name one.
+	defaultSchema string
+	// changed, if set, is called when a reload finds a table's list
+	// different from the expired one.
+	changed func(schema, table string)
+
+	mu     sync.Mutex
+	tables map[string]cachedTable
+}
+
+type cachedTable struct {
+	columns  []string
+	loadedAt time.Time
+}
+
+// newSchemaCache reads the catalog through db. defaultSchema is the main
+// connection's default database.
+func newSchemaCache(db *sql.DB, defaultSchema string, ttl time.Duration) *schemaCache {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &schemaCache{
+		db:            db,
+		ttl:           ttl,
+		defaultSchema: defaultSchema,
+		tables:        make(map[string]cachedTable),
+	}
+}
+
+// tableColumns returns the column names of schema.table in ordinal order. An
+// empty schema refers to the main connection's default database. Unknown
+// tables yield an empty list.
+func (c *schemaCache) tableColumns(ctx context.Context, schema, table string) ([]string, error) {
+	return c.load(ctx, "columns", "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION", schema, table)
+}
+
+// primaryKey returns the primary key columns of schema.table in key order,
+// or an empty list if the table has none (or doesn't exist).
+func (c *schemaCache) primaryKey(ctx context.Context, schema, table string) ([]string, error) {
+	return c.load(ctx, "pk", "SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY' ORDER BY ORDINAL_POSITION", schema, table)
+}
+
+// load returns the cached name list of kind for schema.table, or reads it
+// with query, an empty schema standing for the default one.
+func (c *schemaCache) load(ctx context.Context, kind, query, schema, table string) ([]string, error) {
+	schema = cmp.Or(schema, c.defaultSchema)
+	key := strings.ToLower(kind + ":" + schema + "." + table)
+
+	c.mu.Lock()
+	entry, ok := c.tables[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.loadedAt) < c.ttl {
+		return entry.columns, nil
+	}
+
+	rows, err := c.db.QueryContext(ctx, query, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.tables[key] = cachedTable{columns: columns, loadedAt: time.Now()}
+	c.mu.Unlock()
+	if ok && schema != "" && c.changed != nil && !slices.Equal(entry.columns, columns) {
+		c.changed(schema, table)
+	}
+	return columns, nil
+}
+
+// info reports the cache's size and the age of its oldest entry.
+func (c *schemaCache) info(now time.Time) SchemaCacheInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info := SchemaCacheInfo{Entries: len(c.tables), TTLSeconds: int64(c.ttl / time.Second)}
+	for _, entry := range c.tables {
+		info.OldestAgeSeconds = max(info.OldestAgeSeconds, int64(now.Sub(entry.loadedAt)/time.Second))
+	}
+	return info
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// catalogConn answers schema cache queries like a connection whose DSN names
+// no database: DATABASE() is NULL, so only a bound schema finds the table.
+type catalogConn struct {
+	columns map[string][]string // by "schema.table"
+}
+
+func (c catalogConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("unsupported") }
+func (c catalogConn) Close() error                        { return nil }
+func (c catalogConn) Begin() (driver.Tx, error)           { return nil, errors.New("unsupported") }
+
+func (c catalogConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) != 2 {
+		return &nameRows{}, nil
+	}
+	return &nameRows{names: c.columns[args[0].Value.(string)+"."+args[1].Value.(string)]}, nil
+}
+
+type catalogConnector struct{ conn catalogConn }
+
+func (c catalogConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c catalogConnector) Driver() driver.Driver                        { return nil }
+
+type nameRows struct{ names []string }
+
+func (r *nameRows) Columns() []string { return []string{"COLUMN_NAME"} }
+func (r *nameRows) Close() error      { return nil }
+func (r *nameRows) Next(dest []driver.Value) error {
+	if len(r.names) == 0 {
+		return io.EOF
+	}
+	dest[0], r.names = r.names[0], r.names[1:]
+	return nil
+}
+
+func TestSchemaCacheDefaultSchema(t *testing.T) {
+	db := sql.OpenDB(catalogConnector{catalogConn{columns: map[string][]string{"shop.orders": {"id", "total"}}}})
+	cache := newSchemaCache(db, "shop", time.Minute)
+
+	columns, err := cache.tableColumns(context.Background(), "", "orders")
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "total"}, columns)
+	pk, err := cache.primaryKey(context.Background(), "shop", "orders")
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "total"}, pk)
+	require.Contains(t, cache.tables, "columns:shop.orders", "unqualified and qualified names share an entry")
+
+	columns, err = cache.tableColumns(context.Background(), "crm", "orders")
+	require.NoError(t, err)
+	require.Empty(t, columns)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type SchemaDiffInput struct {
+	Source string `json:"source" jsonschema:"Database to compare from."`
+	Target string `json:"target" jsonschema:"Database to compare to."`
+	Table  string `json:"table,omitempty" jsonschema:"Optional table to restrict the comparison to."`
+}
+
+// SchemaChange is one difference between the source and target schemas.
+// Column is empty for changes to a whole table.
+type SchemaChange struct {
+	Op     string `json:"op" jsonschema:"+ (only in target), - (only in source), or ~ (definition differs)."`
+	Table  string `json:"table"`
+	Column string `json:"column,omitempty"`
+	Source string `json:"source,omitempty" jsonschema:"Definition in the source database."`
+	Target string `json:"target,omitempty" jsonschema:"Definition in the target database."`
+}
+
+type SchemaDiffOutput struct {
+	Source  string         `json:"source"`
+	Target  string         `json:"target"`
+	Changes []SchemaChange `json:"changes"`
+}
+
+const (
+	diffAdded   = "+"
+	diffRemoved = "-"
+	diffChanged = "~"
+)
+
+const schemaDiffColumnsQuery = `SELECT TABLE_SCHEMA AS table_schema, TABLE_NAME AS table_name, COLUMN_NAME AS column_name,
+	COLUMN_TYPE AS column_type, IS_NULLABLE AS is_nullable, COLUMN_DEFAULT AS column_default, EXTRA AS extra
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA IN (?, ?) AND (? = '' OR TABLE_NAME = ?)
+ORDER BY TABLE_NAME, ORDINAL_POSITION`
+
+func (h *queryHandler) schemaDiff(ctx context.Context, req *mcp.CallToolRequest, input SchemaDiffInput) (*mcp.CallToolResult, SchemaDiffOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := SchemaDiffOutput{Source: input.Source, Target: input.Target, Changes: []SchemaChange{}}
+	if !mysqlIdentifierRE.MatchString(input.Source) || !mysqlIdentifierRE.MatchString(input.Target) ||
+		(input.Table != "" && !mysqlIdentifierRE.MatchString(input.Table)) {
+		return toolErrorf(empty, "source, target, and table must be plain identifiers")
+	}
+
+	columns, err := h.runMetadataQuery(ctx, schemaDiffColumnsQuery, input.Source, input.Target, input.Table, input.Table)
+	if err != nil {
+		return toolErrorf(empty, "failed to read columns: %v", err)
+	}
+	output := empty
+	output.Changes = diffSchemaColumns(columns, input.Source, input.Target)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: renderDiffMarkdown(input.Source, input.Target, output.Changes)}},
+	}, output, nil
+}
+
+type schemaColumns struct {
+	tables  []string
+	columns map[string][]string
+	defs    map[string]map[string]string
+}
+
+// diffSchemaColumns compares the column definitions of the source and target
+// schemas in columns (rows of schemaDiffColumnsQuery). Tables present on only
+// one side are reported once, without their columns. Changes follow table
+// order, then source column order with added columns last.
+func diffSchemaColumns(columns QueryOutput, source, target string) []SchemaChange {
+	src, dst := collectSchemaColumns(columns, source), collectSchemaColumns(columns, target)
+
+	tables := append([]string{}, src.tables...)
+	for _, table := range dst.tables {
+		if _, ok := src.defs[table]; !ok {
+			tables = append(tables, table)
+		}
+	}
+
+	changes := make([]SchemaChange, 0)
+	for _, table := range tables {
+		srcDefs, inSource := src.defs[table]
+		dstDefs, inTarget := dst.defs[table]
+		switch {
+		case !inTarget:
+			changes = append(changes, SchemaChange{Op: diffRemoved, Table: table, Source: "table"})
+			continue
+		case !inSource:
+			changes = append(changes, SchemaChange{Op: diffAdded, Table: table, Target: "table"})
+			continue
+		}
+		for _, column := range src.columns[table] {
+			dstDef, ok := dstDefs[column]
+			switch {
+			case !ok:
+				changes = append(changes, SchemaChange{Op: diffRemoved, Table: table, Column: column, Source: srcDefs[column]})
+			case dstDef != srcDefs[column]:
+				changes = append(changes, SchemaChange{Op: diffChanged, Table: table, Column: column, Source: srcDefs[column], Target: dstDef})
+			}
+		}
+		for _, column := range dst.columns[table] {
+			if _, ok := srcDefs[column]; !ok {
+				changes = append(changes, SchemaChange{Op: diffAdded, Table: table, Column: column, Target: dstDefs[column]})
+			}
+		}
+	}
+	return changes
+}
+
+func collectSchemaColumns(columns QueryOutput, schema string) schemaColumns {
+	schemaCol := columnIndex(columns.Columns, "table_schema")
+	tableCol := columnIndex(columns.Columns, "table_name")
+	nameCol := columnIndex(columns.Columns, "column_name")
+	typeCol := columnIndex(columns.Columns, "column_type")
+	nullableCol := columnIndex(columns.Columns, "is_nullable")
+	defaultCol := columnIndex(columns.Columns, "column_default")
+	extraCol := columnIndex(columns.Columns, "extra")
+
+	out := schemaColumns{columns: make(map[string][]string), defs: make(map[string]map[string]string)}
+	for _, row := range columns.Rows {
+		if valueString(rowValue(row, schemaCol)) != schema {
+			continue
+		}
+		table := valueString(rowValue(row, tableCol))
+		name := valueString(rowValue(row, nameCol))
+		if _, ok := out.defs[table]; !ok {
+			out.tables = append(out.tables, table)
+			out.defs[table] = make(map[string]string)
+		}
+
+		parts := []string{valueString(rowValue(row, typeCol))}
+		if valueString(rowValue(row, nullableCol)) == "NO" {
+			parts = append(parts, "NOT NULL")
+		}
+		if def := rowValue(row, defaultCol); def != nil {
+			parts = append(parts, "DEFAULT "+valueString(def))
+		}
+		if extra := valueString(rowValue(row, extraCol)); extra != "" {
+			parts = append(parts, extra)
+		}
+		out.columns[table] = append(out.columns[table], name)
+		out.defs[table][name] = strings.Join(parts, " ")
+	}
+	return out
+}
+
+// renderDiffMarkdown renders changes as a side-by-side markdown table using
+// +/-/~ markers, for the TextContent of diff-producing tools.
+func renderDiffMarkdown(source, target string, changes []SchemaChange) string {
+	if len(changes) == 0 {
+		return fmt.Sprintf("No differences between `%s` and `%s`.\n", source, target)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "| | object | %s | %s |\n", markdownCell(source), markdownCell(target))
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, change := range changes {
+		object := change.Table
+		if change.Column != "" {
+			object += "." + change.Column
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", change.Op, markdownCell(object), markdownCell(change.Source), markdownCell(change.Target))
+	}
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchemaColumns(t *testing.T) {
+	columns := QueryOutput{
+		Columns: []string{"table_schema", "table_name", "column_name", "column_type", "is_nullable", "column_default", "extra"},
+		Rows: [][]interface{}{
+			{"prod", "legacy", "id", "int", "NO", nil, ""},
+			{"prod", "orders", "id", "bigint", "NO", nil, "auto_increment"},
+			{"prod", "orders", "note", "varchar(255)", "YES", nil, ""},
+			{"prod", "orders", "status", "varchar(16)", "NO", "new", ""},
+			{"staging", "orders", "id", "bigint", "NO", nil, "auto_increment"},
+			{"staging", "orders", "status", "varchar(32)", "NO", "new", ""},
+			{"staging", "orders", "total", "decimal(10,2)", "YES", nil, ""},
+			{"staging", "refunds", "id", "bigint", "NO", nil, ""},
+		},
+	}
+
+	changes := diffSchemaColumns(columns, "prod", "staging")
+	require.Equal(t, []SchemaChange{
+		{Op: diffRemoved, Table: "legacy", Source: "table"},
+		{Op: diffRemoved, Table: "orders", Column: "note", Source: "varchar(255)"},
+		{Op: diffChanged, Table: "orders", Column: "status", Source: "varchar(16) NOT NULL DEFAULT new", Target: "varchar(32) NOT NULL DEFAULT new"},
+		{Op: diffAdded, Table: "orders", Column: "total", Target: "decimal(10,2)"},
+		{Op: diffAdded, Table: "refunds", Target: "table"},
+	}, changes)
+
+	require.Empty(t, diffSchemaColumns(columns, "prod", "prod"))
+}
+
+func TestRenderDiffMarkdown(t *testing.T) {
+	require.Equal(t, "No differences between `a` and `b`.\n", renderDiffMarkdown("a", "b", nil))
+
+	text := renderDiffMarkdown("prod", "staging", []SchemaChange{
+		{Op: diffChanged, Table: "orders", Column: "status", Source: "varchar(16)", Target: "varchar(32)"},
+		{Op: diffAdded, Table: "refunds", Target: "table"},
+	})
+	require.Equal(t, "| | object | prod | staging |\n"+
+		"| --- | --- | --- | --- |\n"+
+		"| ~ | orders.status | varchar(16) | varchar(32) |\n"+
+		"| + | refunds |  | table |\n", text)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// erNoSuchTable is returned by SHOW CREATE TABLE for a dropped table.
+const erNoSuchTable = 1146
+
+// schemaWatcher backs resources/subscribe for per-table resources
+// (mysql://schema, ddl, and indexes). It polls SHOW CREATE TABLE for each
+// subscribed table and sends notifications/resources/updated for its URIs
+// when the statement changes. Tables read by cached query results are
+// polled too, to keep their schema versions current.
+type schemaWatcher struct {
+	interval time.Duration
+	// fetch returns the checksum of a table's DDL, or "dropped". An empty
+	// table stands for the database's table list.
+	fetch func(ctx context.Context, db, table string) (string, error)
+	// cached, if set, returns the tables cached results read.
+	cached func() []watchedTableKey
+	// checked, if set, is called with each checksum fetch returns, the
+	// table's schema version.
+	checked func(db, table, checksum string)
+
+	mu     sync.Mutex
+	tables map[watchedTableKey]*watchedTable
+}
+
+type watchedTableKey struct {
+	db, table string
+}
+
+type watchedTable struct {
+	// uris counts subscriptions per resource URI of the table.
+	uris map[string]int
+	// checksum is empty until the table's DDL has been read once.
+	checksum string
+}
+
+// autoIncrementRE matches the table option SHOW CREATE TABLE reports for the
+// next AUTO_INCREMENT value, which changes with every insert.
+var autoIncrementRE = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+// schemaChecksum fingerprints a CREATE statement, ignoring the AUTO_INCREMENT
+// counter.
+func schemaChecksum(ddl string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(autoIncrementRE.ReplaceAllString(ddl, ""))))
+}
+
+func newSchemaWatcher(h *queryHandler, interval time.Duration) *schemaWatcher {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &schemaWatcher{
+		interval: interval,
+		fetch: func(ctx context.Context, db, table string) (string, error) {
+			if err := h.dbAvailable(); err != nil {
+				return "", err
+			}
+			if table == "" {
+				out, err := h.runMetadataQuery(ctx, "SELECT TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME", db)
+				if err != nil {
+					return "", err
+				}
+				return schemaChecksum(fmt.Sprint(out.Rows)), nil
+			}
+			out, err := h.runMetadataQuery(ctx, fmt.Sprintf("SHOW CREATE TABLE `%s`.`%s`", db, table))
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == erNoSuchTable {
+				return "dropped", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			return schemaChecksum(createStatement(out)), nil
+		},
+		cached:  h.cache.watchedTables,
+		checked: h.cache.setVersion,
+		tables:  make(map[watchedTableKey]*watchedTable),
+	}
+}
+
+// watchedTableFor returns the table a subscribable URI describes.
+func watchedTableFor(uri string) (watchedTableKey, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return watchedTableKey{}, err
+	}
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	switch strings.ToLower(u.Host) {
+	case "schema", "ddl", "indexes":
+	default:
+		return watchedTableKey{}, fmt.Errorf("subscriptions are supported for mysql://schema, mysql://ddl, and mysql://indexes resources, not %q", uri)
+	}
+	if strings.ToLower(u.Scheme) != "mysql" || len(parts) != 2 || !mysqlIdentifierRE.MatchString(parts[0]) || !mysqlIdentifierRE.MatchString(parts[1]) {
+		return watchedTableKey{}, fmt.Errorf("invalid table resource URI %q", uri)
+	}
+	return watchedTableKey{db: parts[0], table: parts[1]}, nil
+}
+
+func (w *schemaWatcher) subscribe(ctx context.Context, req *mcp.SubscribeRequest) error {
+	key, err := watchedTableFor(req.Params.URI)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	table, ok := w.tables[key]
+	if !ok {
+		table = &watchedTable{uris: make(map[string]int)}
+		w.tables[key] = table
+	}
+	table.uris[req.Params.URI]++
+	w.mu.Unlock()
+
+	// Take the baseline now so a change before the first poll is reported.
+	// On failure the first successful poll sets it instead.
+	if !ok {
+		if checksum, err := w.fetch(ctx, key.db, key.table); err == nil {
+			w.mu.Lock()
+			if table.checksum == "" {
+				table.checksum = checksum
+			}
+			w.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+func (w *schemaWatcher) unsubscribe(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+	key, err := watchedTableFor(req.Params.URI)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	table, ok := w.tables[key]
+	if !ok {
+		return nil
+	}
+	if table.uris[req.Params.URI]--; table.uris[req.Params.URI] <= 0 {
+		delete(table.uris, req.Params.URI)
+	}
+	if len(table.uris) == 0 {
+		delete(w.tables, key)
+	}
+	return nil
+}
+
+// poll reads each watched table's DDL once and notifies subscribers of the
+// tables whose checksum changed since the previous poll. Tables that can't
+// be read keep their previous checksum.
+func (w *schemaWatcher) poll(ctx context.Context, notify func(ctx context.Context, uri string)) {
+	var cached []watchedTableKey
+	if w.cached != nil {
+		cached = w.cached()
+	}
+	w.mu.Lock()
+	for key, table := range w.tables {
+		if len(table.uris) == 0 && !slices.Contains(cached, key) {
+			delete(w.tables, key)
+		}
+	}
+	for _, key := range cached {
+		if _, ok := w.tables[key]; !ok {
+			w.tables[key] = &watchedTable{uris: make(map[string]int)}
+		}
+	}
+	keys := make([]watchedTableKey, 0, len(w.tables))
+	for key := range w.tables {
+		keys = append(keys, key)
+	}
+	w.mu.Unlock()
+
+	for _, key := range keys {
+		checksum, err := w.fetch(ctx, key.db, key.table)
+		if err != nil {
+			log.Printf("failed to check schema of %s.%s: %v", key.db, key.table, err)
+			continue
+		}
+		w.mu.Lock()
+		table, ok := w.tables[key]
+		var changed []string
+		if ok {
+			if table.checksum != "" && table.checksum != checksum {
+				for uri := range table.uris {
+					changed = append(changed, uri)
+				}
+			}
+			table.checksum = checksum
+		}
+		w.mu.Unlock()
+		if w.checked != nil {
+			w.checked(key.db, key.table, checksum)
+		}
+		for _, uri := range changed {
+			notify(ctx, uri)
+		}
+	}
+}
+
+// run polls every interval until ctx ends, notifying server's subscribed
+// sessions.
+func (w *schemaWatcher) run(ctx context.Context, server *mcp.Server) {
+	notify := func(ctx context.Context, uri string) {
+		if err := server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: uri}); err != nil {
+			log.Printf("failed to notify subscribers of %s: %v", uri, err)
+		}
+	}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(ctx, notify)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaChecksumIgnoresAutoIncrement(t *testing.T) {
+	before := "CREATE TABLE `orders` (\n  `id` bigint NOT NULL AUTO_INCREMENT\n) ENGINE=InnoDB AUTO_INCREMENT=41 DEFAULT CHARSET=utf8mb4"
+	after := "CREATE TABLE `orders` (\n  `id` bigint NOT NULL AUTO_INCREMENT\n) ENGINE=InnoDB AUTO_INCREMENT=9000 DEFAULT CHARSET=utf8mb4"
+	require.Equal(t, schemaChecksum(before), schemaChecksum(after))
+	require.NotEqual(t, schemaChecksum(before), schemaChecksum(before+" COMMENT='orders'"))
+}
+
+func TestWatchedTableFor(t *testing.T) {
+	key, err := watchedTableFor("mysql://schema/shop/orders")
+	require.NoError(t, err)
+	require.Equal(t, watchedTableKey{db: "shop", table: "orders"}, key)
+	_, err = watchedTableFor("mysql://ddl/shop/orders")
+	require.NoError(t, err)
+
+	_, err = watchedTableFor("mysql://tables/shop")
+	require.ErrorContains(t, err, "subscriptions are supported for")
+	_, err = watchedTableFor("mysql://schema/shop/or-ders")
+	require.ErrorContains(t, err, "invalid table resource URI")
+}
+
+func TestSchemaWatcher(t *testing.T) {
+	ddl := map[string]string{"orders": "v1", "users": "v1"}
+	failing := false
+	w := &schemaWatcher{
+		fetch: func(_ context.Context, db, table string) (string, error) {
+			if failing {
+				return "", errors.New("connection refused")
+			}
+			return ddl[table], nil
+		},
+		tables: make(map[watchedTableKey]*watchedTable),
+	}
+	var notified []string
+	notify := func(_ context.Context, uri string) { notified = append(notified, uri) }
+	subscribe := func(uri string) {
+		require.NoError(t, w.subscribe(context.Background(), &mcp.SubscribeRequest{Params: &mcp.SubscribeParams{URI: uri}}))
+	}
+	unsubscribe := func(uri string) {
+		require.NoError(t, w.unsubscribe(context.Background(), &mcp.UnsubscribeRequest{Params: &mcp.UnsubscribeParams{URI: uri}}))
+	}
+
+	subscribe("mysql://schema/shop/orders")
+	subscribe("mysql://ddl/shop/orders")
+	subscribe("mysql://schema/shop/users")
+	ddl["orders"] = "v2"
+	w.poll(context.Background(), notify)
+	require.ElementsMatch(t, []string{"mysql://schema/shop/orders", "mysql://ddl/shop/orders"}, notified, "a change before the first poll is reported")
+
+	notified = nil
+	w.poll(context.Background(), notify)
+	require.Empty(t, notified)
+
+	failing = true
+	w.poll(context.Background(), notify)
+	require.Empty(t, notified, "failed checks keep the previous checksum")
+	failing = false
+
+	unsubscribe("mysql://schema/shop/orders")
+	ddl["orders"] = "dropped"
+	w.poll(context.Background(), notify)
+	require.Equal(t, []string{"mysql://ddl/shop/orders"}, notified)
+
+	unsubscribe("mysql://ddl/shop/orders")
+	unsubscribe("mysql://schema/shop/users")
+	require.Empty(t, w.tables)
+}
+
+func TestSchemaWatcherPollsCachedTables(t *testing.T) {
+	cached := []watchedTableKey{{"shop", "orders"}, {"shop", ""}}
+	checked := map[watchedTableKey]string{}
+	w := &schemaWatcher{
+		fetch: func(_ context.Context, db, table string) (string, error) {
+			return "v1", nil
+		},
+		cached:  func() []watchedTableKey { return cached },
+		checked: func(db, table, checksum string) { checked[watchedTableKey{db, table}] = checksum },
+		tables:  make(map[watchedTableKey]*watchedTable),
+	}
+	var notified []string
+	notify := func(_ context.Context, uri string) { notified = append(notified, uri) }
+
+	w.poll(context.Background(), notify)
+	require.Equal(t, map[watchedTableKey]string{{"shop", "orders"}: "v1", {"shop", ""}: "v1"}, checked)
+	require.Empty(t, notified)
+
+	// Tables no cached result reads any more stop being polled.
+	cached = nil
+	checked = map[watchedTableKey]string{}
+	w.poll(context.Background(), notify)
+	require.Empty(t, checked)
+	require.Empty(t, w.tables)
+}
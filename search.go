@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Accent- and case-insensitive collations for mysql_search, in order of
+// preference. utf8mb4_0900_ai_ci (MySQL 8) follows Unicode 9 and folds more
+// letters than utf8mb4_unicode_ci, which older servers and MariaDB have.
+var searchCollations = []string{"utf8mb4_0900_ai_ci", "utf8mb4_unicode_ci"}
+
+const (
+	searchContains = "contains"
+	searchPrefix   = "prefix"
+	searchExact    = "exact"
+
+	searchMaxColumns = 5
+)
+
+type SearchInput struct {
+	Database string   `json:"database" jsonschema:"Database containing the table."`
+	Table    string   `json:"table" jsonschema:"Table or view to search."`
+	Columns  []string `json:"columns" jsonschema:"Text columns to match against (at most 5); a row matches if any of them does."`
+	Term     string   `json:"term" jsonschema:"Text to find. Case and accents are ignored: Jose finds José, muller finds Müller."`
+	Match    string   `json:"match,omitempty" jsonschema:"contains (the default), prefix, or exact."`
+	Limit    int      `json:"limit,omitempty" jsonschema:"Maximum rows to return (default 20, max 100)."`
+}
+
+type SearchOutput struct {
+	Query     string          `json:"query" jsonschema:"The generated SQL, to reuse or refine with mysql_query and params."`
+	Params    []any           `json:"params"`
+	Collation string          `json:"collation" jsonschema:"Collation the columns were compared under."`
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	RowCount  int             `json:"rowCount"`
+	Truncated bool            `json:"truncated" jsonschema:"True if more rows may match than were returned."`
+}
+
+// likePattern escapes LIKE wildcards in term with '!' and wraps it for the
+// match mode. '!' is used instead of backslash so the pattern means the same
+// under NO_BACKSLASH_ESCAPES.
+func likePattern(term, match string) string {
+	escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(term)
+	switch match {
+	case searchPrefix:
+		return escaped + "%"
+	case searchExact:
+		return escaped
+	}
+	return "%" + escaped + "%"
+}
+
+// searchQuery builds an accent-insensitive search over columns. Each column
+// is converted to utf8mb4 first, so latin1 and utf8mb3 columns compare under
+// the same collation, and numbers and dates compare as text. The conversion
+// rules out index use, which the LIMIT bounds. The LIMIT is the last
+// placeholder. Identifiers must already be validated.
+func searchQuery(db, table string, columns []string, collation, pattern string, limit int) (string, []any) {
+	conditions := make([]string, len(columns))
+	var args []any
+	for i, column := range columns {
+		conditions[i] = fmt.Sprintf("CONVERT(%s USING utf8mb4) COLLATE %s LIKE ? ESCAPE '!'", quoteIdentifier(column), collation)
+		args = append(args, pattern)
+	}
+	query := fmt.Sprintf("SELECT * FROM %s.%s WHERE %s LIMIT ?",
+		quoteIdentifier(db), quoteIdentifier(table), strings.Join(conditions, " OR "))
+	return query, append(args, limit)
+}
+
+// searchCollation returns the first of searchCollations the server has.
+func (h *queryHandler) searchCollation(ctx context.Context) (string, error) {
+	out, err := h.runMetadataQuery(ctx, "SELECT COLLATION_NAME AS name FROM information_schema.COLLATIONS WHERE COLLATION_NAME IN (?, ?)", searchCollations[0], searchCollations[1])
+	if err != nil {
+		return "", err
+	}
+	available := make(map[string]bool)
+	for _, row := range out.Rows {
+		available[valueString(rowValue(row, 0))] = true
+	}
+	for _, collation := range searchCollations {
+		if available[collation] {
+			return collation, nil
+		}
+	}
+	return "", fmt.Errorf("the server has neither %s nor %s", searchCollations[0], searchCollations[1])
+}
+
+func (h *queryHandler) search(ctx context.Context, req *mcp.CallToolRequest, input SearchInput) (*mcp.CallToolResult, SearchOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := SearchOutput{Params: []any{}, Columns: []string{}, Rows: [][]interface{}{}}
+	if !mysqlIdentifierRE.MatchString(input.Database) || !mysqlIdentifierRE.MatchString(input.Table) {
+		return toolErrorf(empty, "database and table must be plain identifiers")
+	}
+	if len(input.Columns) == 0 || len(input.Columns) > searchMaxColumns {
+		return toolErrorf(empty, "columns must name 1 to %d columns", searchMaxColumns)
+	}
+	for _, column := range input.Columns {
+		if !mysqlIdentifierRE.MatchString(column) {
+			return toolErrorf(empty, "column %q must be a plain identifier", column)
+		}
+	}
+	if strings.TrimSpace(input.Term) == "" {
+		return toolErrorf(empty, "term is required")
+	}
+	match := input.Match
+	switch match {
+	case "":
+		match = searchContains
+	case searchContains, searchPrefix, searchExact:
+	default:
+		return toolErrorf(empty, "unknown match %q: expected contains, prefix, or exact", input.Match)
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	limit = min(limit, 100)
+
+	collation, err := h.searchCollation(ctx)
+	if err != nil {
+		return toolErrorf(empty, "failed to pick a collation: %v", err)
+	}
+	query, args := searchQuery(input.Database, input.Table, input.Columns, collation, likePattern(input.Term, match), limit)
+	// One extra row tells whether more rows match.
+	probeArgs := append(args[:len(args)-1:len(args)-1], limit+1)
+	out, err := h.runQueryForResource(ctx, query, probeArgs...)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	output := SearchOutput{
+		Query:     query,
+		Params:    args,
+		Collation: collation,
+		Columns:   out.Columns,
+		Rows:      out.Rows,
+		Truncated: out.Truncated,
+	}
+	if len(output.Rows) > limit {
+		output.Rows = output.Rows[:limit]
+		output.Truncated = true
+	}
+	output.RowCount = len(output.Rows)
+	return nil, output, nil
+}
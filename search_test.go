@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestLikePattern(t *testing.T) {
+	require.Equal(t, "%José%", likePattern("José", searchContains))
+	require.Equal(t, "Mül%", likePattern("Mül", searchPrefix))
+	require.Equal(t, "50!% off!!", likePattern("50% off!", searchExact))
+	require.Equal(t, "%a!_b%", likePattern("a_b", ""))
+}
+
+func TestSearchQuery(t *testing.T) {
+	query, args := searchQuery("crm", "customers", []string{"first_name", "last_name"}, "utf8mb4_0900_ai_ci", "%jose%", 20)
+	require.Equal(t, "SELECT * FROM `crm`.`customers` WHERE CONVERT(`first_name` USING utf8mb4) COLLATE utf8mb4_0900_ai_ci LIKE ? ESCAPE '!' OR CONVERT(`last_name` USING utf8mb4) COLLATE utf8mb4_0900_ai_ci LIKE ? ESCAPE '!' LIMIT ?", query)
+	require.Equal(t, []any{"%jose%", "%jose%", 20}, args)
+	require.NoError(t, testValidator(t, gate.Options{}).Validate(query))
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// errNotConfirmed is returned when a person declined a sensitive statement,
+// or couldn't be asked.
+var errNotConfirmed = errors.New("not confirmed")
+
+const (
+	sensitiveUnsupportedRefuse = "refuse"
+	sensitiveUnsupportedAllow  = "allow"
+)
+
+// SensitiveConfig lists statements a person must confirm, through MCP
+// elicitation, before they run. The prompt goes to the human using the
+// client rather than to the agent, so an agent can't confirm for itself.
+type SensitiveConfig struct {
+	// Tables holds "db.table" glob patterns; any statement naming a matching
+	// table needs confirmation.
+	Tables []string `toml:"tables"`
+	// Writes makes every mysql_execute statement need confirmation.
+	Writes bool `toml:"writes"`
+	// Unsupported is what happens when the client can't show the prompt:
+	// "refuse" (the default) or "allow".
+	Unsupported string `toml:"unsupported"`
+}
+
+func validateSensitiveConfig(cfg SensitiveConfig) error {
+	for _, pattern := range cfg.Tables {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("sensitive.tables %q: %w", pattern, err)
+		}
+		if !strings.Contains(pattern, ".") {
+			return fmt.Errorf("sensitive.tables %q: patterns must be db.table", pattern)
+		}
+	}
+	switch cfg.Unsupported {
+	case "", sensitiveUnsupportedRefuse, sensitiveUnsupportedAllow:
+	default:
+		return fmt.Errorf("sensitive.unsupported must be %q or %q", sensitiveUnsupportedRefuse, sensitiveUnsupportedAllow)
+	}
+	return nil
+}
+
+// sensitiveTables returns the tables stmt names that match patterns, in
+// order.
+func sensitiveTables(stmt sqlparser.Statement, defaultSchema string, patterns []string) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+	var matched []string
+	for _, table := range gate.QueryTables(stmt, defaultSchema) {
+		if name := table.String(); gate.TableMatches(patterns, name) {
+			matched = append(matched, name)
+		}
+	}
+	return matched
+}
+
+// readsRows reports whether stmt reads table rows: SELECT, UNION, and VALUES
+// (whose rows may hold subqueries), and EXPLAIN ANALYZE, which runs its
+// statement. Other EXPLAINs and SHOW reveal
+// no row data, so they run without confirmation.
+func readsRows(stmt sqlparser.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select, *sqlparser.Union, *sqlparser.ValuesStatement:
+		return true
+	case *sqlparser.ExplainStmt:
+		return stmt.Type == sqlparser.AnalyzeType
+	}
+	return false
+}
+
+// confirmedTables remembers what a person confirmed during one tool call, so
+// a tool that runs several statements on a table asks once.
+type confirmedTables struct {
+	mu     sync.Mutex
+	tables map[string]bool
+}
+
+type confirmedTablesKey struct{}
+
+func withConfirmedTables(ctx context.Context) context.Context {
+	return context.WithValue(ctx, confirmedTablesKey{}, &confirmedTables{tables: make(map[string]bool)})
+}
+
+// confirmationPrompt is the message shown to the person asked to confirm.
+func confirmationPrompt(tool, statement string, tables []string, write bool) string {
+	what := "read from"
+	if write {
+		what = "change"
+	}
+	subject := "a statement that needs confirmation"
+	if len(tables) > 0 {
+		subject = "a statement on sensitive tables " + strings.Join(tables, ", ")
+	}
+	return fmt.Sprintf("%s wants to %s the database with %s:\n\n%s\n\nAllow it to run?", tool, what, subject, statement)
+}
+
+// confirmSensitive asks the person using the client to confirm statement if
+// it names a sensitive table, or is a write and sensitive.writes is set. It
+// returns nil if the statement may run, or an error wrapping errNotConfirmed.
+func (h *queryHandler) confirmSensitive(ctx context.Context, statement string, stmt sqlparser.Statement, write bool) error {
+	cfg := h.snapshot().config.Sensitive
+	tables := sensitiveTables(stmt, h.defaultSchema, cfg.Tables)
+	if len(tables) == 0 && !(write && cfg.Writes) {
+		return nil
+	}
+	confirmed, _ := ctx.Value(confirmedTablesKey{}).(*confirmedTables)
+	if confirmed != nil && !write {
+		confirmed.mu.Lock()
+		all := !slices.ContainsFunc(tables, func(t string) bool { return !confirmed.tables[t] })
+		confirmed.mu.Unlock()
+		if all {
+			return nil
+		}
+	}
+
+	a, _ := ctx.Value(attributionKey{}).(attribution)
+	call, _ := ctx.Value(toolCallKey{}).(toolCall)
+	ss := a.ss
+	if ss == nil || ss.InitializeParams() == nil || ss.InitializeParams().Capabilities == nil || ss.InitializeParams().Capabilities.Elicitation == nil {
+		if cfg.Unsupported == sensitiveUnsupportedAllow {
+			return nil
+		}
+		return fmt.Errorf("%w: this statement needs a person to confirm it, and the client doesn't support elicitation", errNotConfirmed)
+	}
+	res, err := ss.Elicit(ctx, &mcp.ElicitParams{
+		Message:         confirmationPrompt(call.tool, statement, tables, write),
+		RequestedSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: failed to ask for confirmation: %v", errNotConfirmed, err)
+	}
+	if res.Action != "accept" {
+		return fmt.Errorf("%w: the user chose %s", errNotConfirmed, res.Action)
+	}
+	if confirmed != nil {
+		confirmed.mu.Lock()
+		for _, table := range tables {
+			confirmed.tables[table] = true
+		}
+		confirmed.mu.Unlock()
+	}
+	return nil
+}
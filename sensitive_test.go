@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestValidateSensitiveConfig(t *testing.T) {
+	require.NoError(t, validateSensitiveConfig(SensitiveConfig{Tables: []string{"crm.*", "shop.users"}, Unsupported: "allow"}))
+	require.ErrorContains(t, validateSensitiveConfig(SensitiveConfig{Tables: []string{"users"}}), "must be db.table")
+	require.Error(t, validateSensitiveConfig(SensitiveConfig{Tables: []string{"crm.[x"}}))
+	require.ErrorContains(t, validateSensitiveConfig(SensitiveConfig{Unsupported: "ask"}), "sensitive.unsupported")
+}
+
+func TestSensitiveTables(t *testing.T) {
+	stmt, err := gate.ParseStatement("SELECT * FROM users u JOIN crm.Contacts c ON c.user_id = u.id JOIN orders o ON o.user_id = u.id")
+	require.NoError(t, err)
+	require.Equal(t, []string{"shop.users", "crm.Contacts"}, sensitiveTables(stmt, "shop", []string{"shop.users", "crm.*"}))
+	require.Empty(t, sensitiveTables(stmt, "shop", nil))
+}
+
+func TestReadsRows(t *testing.T) {
+	for query, want := range map[string]bool{
+		"SELECT * FROM users":                 true,
+		"SELECT 1 UNION SELECT 2":             true,
+		"EXPLAIN SELECT * FROM users":         false,
+		"EXPLAIN ANALYZE SELECT * FROM users": true,
+		"SHOW COLUMNS FROM users":             false,
+		"DELETE FROM users WHERE id = 1":      false,
+	} {
+		stmt, err := gate.ParseStatement(query)
+		require.NoError(t, err, query)
+		require.Equal(t, want, readsRows(stmt), query)
+	}
+}
+
+func TestConfirmSensitiveWithoutElicitation(t *testing.T) {
+	h := &queryHandler{defaultSchema: "shop"}
+	h.config.Sensitive = SensitiveConfig{Tables: []string{"shop.users"}}
+	ctx := withAttribution(context.Background(), nil)
+
+	stmt, err := gate.ParseStatement("SELECT id FROM orders")
+	require.NoError(t, err)
+	require.NoError(t, h.confirmSensitive(ctx, "SELECT id FROM orders", stmt, false))
+	require.NoError(t, h.confirmSensitive(ctx, "SELECT id FROM orders", stmt, true))
+
+	stmt, err = gate.ParseStatement("SELECT email FROM users")
+	require.NoError(t, err)
+	err = h.confirmSensitive(ctx, "SELECT email FROM users", stmt, false)
+	require.ErrorIs(t, err, errNotConfirmed)
+	require.Equal(t, errorDeniedByPolicy, classifyError(err.Error(), err).Category)
+
+	h.config.Sensitive.Unsupported = sensitiveUnsupportedAllow
+	require.NoError(t, h.confirmSensitive(ctx, "SELECT email FROM users", stmt, false))
+
+	h.config.Sensitive = SensitiveConfig{Writes: true}
+	stmt, err = gate.ParseStatement("DELETE FROM orders WHERE id = 1")
+	require.NoError(t, err)
+	require.ErrorIs(t, h.confirmSensitive(ctx, "DELETE FROM orders WHERE id = 1", stmt, true), errNotConfirmed)
+}
+
+func TestConfirmationPrompt(t *testing.T) {
+	require.Equal(t, "mysql_query wants to read from the database with a statement on sensitive tables shop.users:\n\nSELECT email FROM users\n\nAllow it to run?",
+		confirmationPrompt("mysql_query", "SELECT email FROM users", []string{"shop.users"}, false))
+	require.Equal(t, "mysql_execute wants to change the database with a statement that needs confirmation:\n\nDELETE FROM t WHERE id = 1\n\nAllow it to run?",
+		confirmationPrompt("mysql_execute", "DELETE FROM t WHERE id = 1", nil, true))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// addTool registers a MySQL-backed tool, which fails with a tool error
+// while the database is unavailable (see lazy_connect and
+// breaker_failure_threshold).
+func addTool[In, Out any](server *mcp.Server, h *queryHandler, tool *mcp.Tool, handler mcp.ToolHandlerFor[In, Out]) {
+	registerTool(server, h, tool, func(ctx context.Context, req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		if err := h.dbAvailable(); err != nil {
+			var zero Out
+			return nil, zero, err
+		}
+		if err := h.access.allow(ctx, time.Now()); err != nil {
+			var zero Out
+			return nil, zero, err
+		}
+		if err := h.checkTenantArguments(req); err != nil {
+			var zero Out
+			return nil, zero, err
+		}
+		if err := h.checkRoleArguments(ctx, req); err != nil {
+			var zero Out
+			return nil, zero, err
+		}
+		return handler(withGTIDScope(withConfirmedTables(withToolCall(ctx, tool.Name))), req, input)
+	})
+}
+
+// registerTool registers a tool, unless server.enabled_tools leaves it out,
+// and records its name for mysql://server_info.
+func registerTool[In, Out any](server *mcp.Server, h *queryHandler, tool *mcp.Tool, handler mcp.ToolHandlerFor[In, Out]) {
+	if !h.exposure.toolEnabled(tool.Name) {
+		return
+	}
+	mcp.AddTool(server, tool, handler)
+	h.mu.Lock()
+	h.tools = append(h.tools, tool.Name)
+	h.mu.Unlock()
+}
+
+type ServerLimits struct {
+	MaxRows                int  `json:"maxRows"`
+	QueryTimeoutSeconds    int  `json:"queryTimeoutSeconds"`
+	MaxQueryTimeoutSeconds int  `json:"maxQueryTimeoutSeconds"`
+	ResultStoreEntries     int  `json:"resultStoreEntries"`
+	ResultStoreMaxRows     int  `json:"resultStoreMaxRows"`
+	ResultTTLSeconds       int  `json:"resultTtlSeconds"`
+	ResultLinkBytes        int  `json:"resultLinkBytes,omitempty"`
+	OmitBlobs              bool `json:"omitBlobs"`
+}
+
+type SchemaCacheInfo struct {
+	Entries          int   `json:"entries"`
+	TTLSeconds       int64 `json:"ttlSeconds"`
+	OldestAgeSeconds int64 `json:"oldestAgeSeconds" jsonschema:"Age of the oldest cached entry; 0 when empty."`
+}
+
+// ServerInfo describes the running server's configuration, so clients can
+// discover limits instead of hitting them.
+type ServerInfo struct {
+	Name                string          `json:"name"`
+	Version             string          `json:"version"`
+	StartedAt           time.Time       `json:"startedAt"`
+	Tools               []string        `json:"tools"`
+	Limits              ServerLimits    `json:"limits"`
+	RowFilteredTables   int             `json:"rowFilteredTables"`
+	SoftDeleteTables    int             `json:"softDeleteTables"`
+	DeniedColumnTables  int             `json:"deniedColumnTables" jsonschema:"Number of tables with denied columns."`
+	DeniedFunctions     []string        `json:"deniedFunctions"`
+	DenySubstrings      int             `json:"denySubstrings" jsonschema:"Number of configured deny substrings; the values are not disclosed."`
+	DenyPatterns        int             `json:"denyPatterns" jsonschema:"Number of configured deny patterns; the patterns are not disclosed."`
+	AttributionComments bool            `json:"attributionComments"`
+	AnalyticsEnabled    bool            `json:"analyticsEnabled"`
+	DatabaseError       string          `json:"databaseError,omitempty" jsonschema:"Why MySQL tools are failing, while the database is unavailable."`
+	SchemaCache         SchemaCacheInfo `json:"schemaCache"`
+	Replicas            []ReplicaStatus `json:"replicas,omitempty" jsonschema:"Read replicas and their last health check, when configured."`
+	CircuitBreaker      *BreakerStatus  `json:"circuitBreaker,omitempty" jsonschema:"The primary's circuit breaker, when breaker_failure_threshold is set."`
+	Features            []FeatureFlag   `json:"features" jsonschema:"Optional mysql_query behaviors, their defaults, and whether a call may switch them."`
+}
+
+// serverStartedAt is reported by mysql://server_info.
+var serverStartedAt = time.Now().UTC()
+
+func (h *queryHandler) serverInfo(now time.Time) ServerInfo {
+	live := h.snapshot()
+	cfg := live.config
+	denied := live.validator.Summary()
+	info := ServerInfo{
+		Name:      cfg.Server.Name,
+		Version:   cfg.Server.Version,
+		StartedAt: serverStartedAt,
+		Tools:     live.tools,
+		Limits: ServerLimits{
+			MaxRows:                cfg.MySQL.MaxRows,
+			QueryTimeoutSeconds:    int(h.queryTimeout(0) / time.Second),
+			MaxQueryTimeoutSeconds: int(h.queryTimeout(1<<30) / time.Second),
+			ResultStoreEntries:     cfg.ResultStore.MaxEntries,
+			ResultStoreMaxRows:     cfg.ResultStore.MaxRows,
+			ResultTTLSeconds:       cfg.ResultStore.TTLSeconds,
+			ResultLinkBytes:        cfg.ResultStore.LinkBytes,
+			OmitBlobs:              cfg.MySQL.OmitBlobs,
+		},
+		DeniedFunctions:     denied.DeniedFunctions,
+		DenySubstrings:      denied.DenySubstrings,
+		DenyPatterns:        denied.DenyPatterns,
+		DeniedColumnTables:  denied.DeniedColumnTables,
+		AttributionComments: cfg.MySQL.AttributionComments,
+		AnalyticsEnabled:    h.analytics != nil,
+		Features:            featureFlagStatus(cfg),
+	}
+	if info.Limits.MaxRows <= 0 {
+		info.Limits.MaxRows = 1000
+	}
+	if err := h.dbAvailable(); err != nil {
+		info.DatabaseError = err.Error()
+	}
+	if live.rowFilters != nil {
+		info.RowFilteredTables = len(live.rowFilters.filters)
+	}
+	if live.softDeletes != nil {
+		info.SoftDeleteTables = len(live.softDeletes.filters)
+	}
+	sort.Strings(info.Tools)
+	if h.schema != nil {
+		info.SchemaCache = h.schema.info(now)
+	}
+	info.Replicas = h.replicas.status()
+	info.CircuitBreaker = h.breaker.status()
+	return info
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestServerInfo(t *testing.T) {
+	filters, err := newRowFilters([]RowFilterConfig{{Table: "shop.orders", Predicate: "tenant_id = 42"}}, "shop")
+	require.NoError(t, err)
+
+	h := &queryHandler{
+		validator:  testValidator(t, gate.Options{DenySubstrings: []string{"secret"}, DeniedFunctions: []string{"UUID"}}),
+		rowFilters: filters,
+		schema:     newSchemaCache(nil, "", time.Minute),
+		tools:      []string{"mysql_show_create", "mysql_query"},
+	}
+	h.config.Server.Name = "mysql-readonly"
+	h.config.MySQL.QueryTimeoutSeconds = 10
+	h.config.MySQL.MaxQueryTimeoutSeconds = 60
+	now := time.Now()
+	h.schema.tables["columns:.orders"] = cachedTable{loadedAt: now.Add(-30 * time.Second)}
+
+	info := h.serverInfo(now)
+	require.Equal(t, []string{"mysql_query", "mysql_show_create"}, info.Tools)
+	require.Equal(t, 1000, info.Limits.MaxRows)
+	require.Equal(t, 10, info.Limits.QueryTimeoutSeconds)
+	require.Equal(t, 60, info.Limits.MaxQueryTimeoutSeconds)
+	require.Equal(t, 1, info.RowFilteredTables)
+	require.Subset(t, info.DeniedFunctions, []string{"sleep", "uuid"})
+	require.IsIncreasing(t, info.DeniedFunctions)
+	require.Equal(t, 1, info.DenySubstrings)
+	require.Equal(t, SchemaCacheInfo{Entries: 1, TTLSeconds: 60, OldestAgeSeconds: 30}, info.SchemaCache)
+	require.Equal(t, []string{"mysql_show_create", "mysql_query"}, h.tools, "reporting doesn't reorder the handler's list")
+}
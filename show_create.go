@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type ShowCreateInput struct {
+	Database string `json:"database" jsonschema:"Database containing the table."`
+	Table    string `json:"table" jsonschema:"Table (or view) name."`
+}
+
+type ShowCreateOutput struct {
+	Database string `json:"database"`
+	Table    string `json:"table"`
+	DDL      string `json:"ddl" jsonschema:"CREATE statement as reported by the server."`
+}
+
+func (h *queryHandler) showCreate(ctx context.Context, req *mcp.CallToolRequest, input ShowCreateInput) (*mcp.CallToolResult, ShowCreateOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	if !mysqlIdentifierRE.MatchString(input.Database) || !mysqlIdentifierRE.MatchString(input.Table) {
+		return toolErrorf(ShowCreateOutput{}, "database and table must be plain identifiers")
+	}
+	out, err := h.runMetadataQuery(ctx, fmt.Sprintf("SHOW CREATE TABLE `%s`.`%s`", input.Database, input.Table))
+	if err != nil {
+		return toolErrorf(ShowCreateOutput{}, "%v", err)
+	}
+	ddl := createStatement(out)
+	if ddl == "" {
+		return toolErrorf(ShowCreateOutput{}, "no CREATE statement returned for %s.%s", input.Database, input.Table)
+	}
+	output := ShowCreateOutput{Database: input.Database, Table: input.Table, DDL: ddl}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: ddl}},
+	}, output, nil
+}
+
+// createStatement extracts the "Create Table" / "Create View" column from
+// SHOW CREATE output.
+func createStatement(out QueryOutput) string {
+	if len(out.Rows) == 0 {
+		return ""
+	}
+	for i, col := range out.Columns {
+		if strings.HasPrefix(strings.ToLower(col), "create ") {
+			return valueString(rowValue(out.Rows[0], i))
+		}
+	}
+	return ""
+}
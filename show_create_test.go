@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateStatement(t *testing.T) {
+	table := QueryOutput{
+		Columns: []string{"Table", "Create Table"},
+		Rows:    [][]interface{}{{"t", "CREATE TABLE `t` (\n  `id` int NOT NULL\n)"}},
+	}
+	require.Equal(t, "CREATE TABLE `t` (\n  `id` int NOT NULL\n)", createStatement(table))
+
+	view := QueryOutput{
+		Columns: []string{"View", "Create View", "character_set_client", "collation_connection"},
+		Rows:    [][]interface{}{{"v", "CREATE VIEW `v` AS select 1", "utf8mb4", "utf8mb4_0900_ai_ci"}},
+	}
+	require.Equal(t, "CREATE VIEW `v` AS select 1", createStatement(view))
+
+	require.Equal(t, "", createStatement(QueryOutput{Columns: []string{"Table", "Create Table"}}))
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"mysqlmcp/internal/gate"
+)
+
+// queryPolicy is the part of a config that decides whether a query may run
+// and how many rows it may return.
+type queryPolicy struct {
+	validator *gate.Validator
+	maxRows   int
+}
+
+func policyFromConfig(cfg Config) (queryPolicy, error) {
+	maxRows := cfg.MySQL.MaxRows
+	if maxRows <= 0 {
+		maxRows = 1000
+	}
+	// Audit events don't record the session's database, so only qualified
+	// system tables are recognized.
+	v, err := newValidator(cfg, "")
+	if err != nil {
+		return queryPolicy{}, err
+	}
+	return queryPolicy{validator: v, maxRows: maxRows}, nil
+}
+
+// SimulatedQuery is a distinct audited query whose outcome changes under the
+// proposed policy.
+type SimulatedQuery struct {
+	Query    string `json:"query"`
+	Calls    int    `json:"calls"`
+	RowCount int    `json:"rowCount,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type SimulationReport struct {
+	Events         int              `json:"events"`
+	Queries        int              `json:"queries"`
+	NewlyBlocked   []SimulatedQuery `json:"newlyBlocked"`
+	NewlyAllowed   []SimulatedQuery `json:"newlyAllowed"`
+	NewlyTruncated []SimulatedQuery `json:"newlyTruncated"`
+}
+
+// simulatePolicy replays the queries in events against both policies. Both
+// sides are re-evaluated, so the report reflects the two configs rather than
+// whatever config was live when each event was recorded. Events without a
+// query and analytics_query calls (a different gate) are skipped.
+func simulatePolicy(events []AuditEvent, current, proposed queryPolicy) SimulationReport {
+	type seen struct {
+		calls   int
+		maxRows int
+	}
+	queries := make(map[string]*seen)
+	order := make([]string, 0)
+	report := SimulationReport{NewlyBlocked: []SimulatedQuery{}, NewlyAllowed: []SimulatedQuery{}, NewlyTruncated: []SimulatedQuery{}}
+	for _, event := range events {
+		if event.Query == "" || event.Tool == "analytics_query" {
+			continue
+		}
+		report.Events++
+		q, ok := queries[event.Query]
+		if !ok {
+			q = &seen{}
+			queries[event.Query] = q
+			order = append(order, event.Query)
+		}
+		q.calls++
+		if event.Type == auditQueryExecuted {
+			q.maxRows = max(q.maxRows, event.RowCount)
+		}
+	}
+	report.Queries = len(order)
+
+	for _, query := range order {
+		q := queries[query]
+		currentErr := current.validator.Validate(query)
+		proposedErr := proposed.validator.Validate(query)
+		switch {
+		case currentErr == nil && proposedErr != nil:
+			report.NewlyBlocked = append(report.NewlyBlocked, SimulatedQuery{Query: query, Calls: q.calls, Reason: proposedErr.Error()})
+		case currentErr != nil && proposedErr == nil:
+			report.NewlyAllowed = append(report.NewlyAllowed, SimulatedQuery{Query: query, Calls: q.calls, Reason: currentErr.Error()})
+		case proposedErr == nil && q.maxRows > proposed.maxRows && q.maxRows <= current.maxRows:
+			report.NewlyTruncated = append(report.NewlyTruncated, SimulatedQuery{Query: query, Calls: q.calls, RowCount: q.maxRows})
+		}
+	}
+	for _, list := range [][]SimulatedQuery{report.NewlyBlocked, report.NewlyAllowed, report.NewlyTruncated} {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Calls > list[j].Calls })
+	}
+	return report
+}
+
+// readAuditEvents reads audit events as written by the sinks: one JSON event
+// per line (syslog, kafka) or JSON arrays of events (webhook batches), in any
+// mix.
+func readAuditEvents(r io.Reader) ([]AuditEvent, error) {
+	events := make([]AuditEvent, 0)
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("reading audit events: %w", err)
+		}
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+			var batch []AuditEvent
+			if err := json.Unmarshal(raw, &batch); err != nil {
+				return nil, fmt.Errorf("reading audit events: %w", err)
+			}
+			events = append(events, batch...)
+			continue
+		}
+		var event AuditEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return nil, fmt.Errorf("reading audit events: %w", err)
+		}
+		events = append(events, event)
+	}
+}
+
+// runPolicySimulation loads both configs and the audit log and writes the
+// report as indented JSON to w.
+func runPolicySimulation(currentPath, proposedPath, auditPath string, w io.Writer) error {
+	current, err := loadConfig(currentPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config %q: %w", currentPath, err)
+	}
+	proposed, err := loadConfig(proposedPath)
+	if err != nil {
+		return fmt.Errorf("failed to load proposed config %q: %w", proposedPath, err)
+	}
+	// Parser settings describe the server, so the current config's apply.
+	if err := gate.SetDialect(current.Parser); err != nil {
+		return err
+	}
+	f, err := os.Open(auditPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	events, err := readAuditEvents(f)
+	if err != nil {
+		return err
+	}
+
+	currentPolicy, err := policyFromConfig(current)
+	if err != nil {
+		return err
+	}
+	proposedPolicy, err := policyFromConfig(proposed)
+	if err != nil {
+		return err
+	}
+	report := simulatePolicy(events, currentPolicy, proposedPolicy)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
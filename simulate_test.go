@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestReadAuditEvents(t *testing.T) {
+	input := `{"type":"query_executed","tool":"mysql_query","query":"SELECT 1","rowCount":1}
+[{"type":"query_rejected","tool":"mysql_query","query":"SELECT SLEEP(1)"},{"type":"query_executed","tool":"mysql_query","query":"SELECT 1","rowCount":1}]
+`
+	events, err := readAuditEvents(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, auditQueryRejected, events[1].Type)
+
+	_, err = readAuditEvents(strings.NewReader("{not json"))
+	require.Error(t, err)
+}
+
+func TestSimulatePolicy(t *testing.T) {
+	events := []AuditEvent{
+		{Type: auditQueryExecuted, Tool: "mysql_query", Query: "SELECT * FROM orders", RowCount: 800},
+		{Type: auditQueryExecuted, Tool: "mysql_query", Query: "SELECT * FROM orders", RowCount: 500},
+		{Type: auditQueryExecuted, Tool: "mysql_query", Query: "SELECT email FROM customers", RowCount: 3},
+		{Type: auditQueryRejected, Tool: "mysql_query", Query: "SELECT UUID()"},
+		{Type: auditQueryExecuted, Tool: "analytics_query", Query: "SELECT email FROM customers"},
+		{Type: auditQueryExecuted, Tool: "mysql_query"},
+	}
+	current := queryPolicy{validator: testValidator(t, gate.Options{DeniedFunctions: []string{"uuid"}}), maxRows: 1000}
+	proposed := queryPolicy{
+		validator: testValidator(t, gate.Options{DenySubstrings: []string{"email"}}),
+		maxRows:   100,
+	}
+
+	report := simulatePolicy(events, current, proposed)
+	require.Equal(t, 4, report.Events)
+	require.Equal(t, 3, report.Queries)
+	require.Len(t, report.NewlyBlocked, 1)
+	require.Equal(t, "SELECT email FROM customers", report.NewlyBlocked[0].Query)
+	require.Len(t, report.NewlyAllowed, 1)
+	require.Equal(t, "SELECT UUID()", report.NewlyAllowed[0].Query)
+	require.Equal(t, []SimulatedQuery{{Query: "SELECT * FROM orders", Calls: 2, RowCount: 800}}, report.NewlyTruncated)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SoftDeleteConfig marks the rows of Table that are deleted but still stored.
+// Queries read only live rows unless the call sets includeDeleted. Column
+// names a deletion timestamp (live rows have it NULL); Predicate selects live
+// rows directly, for schemas that use a flag instead.
+type SoftDeleteConfig struct {
+	Table     string `toml:"table"`
+	Column    string `toml:"column"`
+	Predicate string `toml:"predicate"`
+}
+
+// newSoftDeleteFilters compiles configs into row filters that keep only live
+// rows.
+func newSoftDeleteFilters(configs []SoftDeleteConfig, defaultSchema string) (*rowFilters, error) {
+	filters := make([]RowFilterConfig, 0, len(configs))
+	for _, cfg := range configs {
+		predicate := cfg.Predicate
+		switch {
+		case cfg.Column != "" && cfg.Predicate != "":
+			return nil, fmt.Errorf("soft delete for %q: set column or predicate, not both", cfg.Table)
+		case cfg.Column != "":
+			if !mysqlIdentifierRE.MatchString(cfg.Column) {
+				return nil, fmt.Errorf("soft delete for %q: invalid column %q", cfg.Table, cfg.Column)
+			}
+			predicate = quoteIdentifier(cfg.Column) + " IS NULL"
+		case strings.TrimSpace(cfg.Predicate) == "":
+			return nil, fmt.Errorf("soft delete for %q: column or predicate is required", cfg.Table)
+		}
+		filters = append(filters, RowFilterConfig{Table: cfg.Table, Predicate: predicate})
+	}
+	return newRowFilters(filters, defaultSchema)
+}
+
+// applyFilters returns query rewritten to hide soft-deleted rows, unless
+// includeDeleted, and then with the mandatory row filters, which always apply.
+// A table with both reads through the row filter first.
+func (s handlerSettings) applyFilters(query string, includeDeleted bool) (string, error) {
+	if !includeDeleted {
+		var err error
+		if query, err = s.softDeletes.apply(query); err != nil {
+			return "", err
+		}
+	}
+	return s.rowFilters.apply(query)
+}
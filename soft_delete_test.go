@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSoftDeleteFilters(t *testing.T) {
+	_, err := newSoftDeleteFilters([]SoftDeleteConfig{{Table: "orders", Column: "deleted_at", Predicate: "is_deleted = 0"}}, "shop")
+	require.ErrorContains(t, err, "not both")
+	_, err = newSoftDeleteFilters([]SoftDeleteConfig{{Table: "orders"}}, "shop")
+	require.ErrorContains(t, err, "column or predicate is required")
+	_, err = newSoftDeleteFilters([]SoftDeleteConfig{{Table: "orders", Column: "deleted at"}}, "shop")
+	require.ErrorContains(t, err, "invalid column")
+	_, err = newSoftDeleteFilters([]SoftDeleteConfig{{Table: "orders", Column: "deleted_at"}, {Table: "shop.orders", Column: "removed_at"}}, "shop")
+	require.ErrorContains(t, err, "duplicate table")
+}
+
+func TestApplyFilters(t *testing.T) {
+	filters, err := newRowFilters([]RowFilterConfig{{Table: "orders", Predicate: "tenant_id = 42"}}, "shop")
+	require.NoError(t, err)
+	softDeletes, err := newSoftDeleteFilters([]SoftDeleteConfig{
+		{Table: "orders", Column: "deleted_at"},
+		{Table: "crm.contacts", Predicate: "is_deleted = 0"},
+	}, "shop")
+	require.NoError(t, err)
+	live := handlerSettings{rowFilters: filters, softDeletes: softDeletes}
+
+	got, err := live.applyFilters("SELECT id FROM crm.contacts", false)
+	require.NoError(t, err)
+	require.Equal(t, "select id from (select * from crm.contacts where is_deleted = 0) as contacts", got)
+
+	got, err = live.applyFilters("SELECT id FROM crm.contacts", true)
+	require.NoError(t, err)
+	require.Equal(t, "SELECT id FROM crm.contacts", got)
+
+	got, err = live.applyFilters("SELECT id FROM orders", false)
+	require.NoError(t, err)
+	require.Equal(t, "select id from (select * from (select * from shop.orders where tenant_id = 42) as orders where deleted_at is null) as orders", got)
+
+	got, err = live.applyFilters("SELECT id FROM orders", true)
+	require.NoError(t, err)
+	require.Equal(t, "select id from (select * from shop.orders where tenant_id = 42) as orders", got)
+}
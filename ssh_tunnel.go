@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshNetwork is the driver network name connections through [mysql.ssh] use.
+const sshNetwork = "mysqlmcp+ssh"
+
+// MySQLSSHConfig reaches MySQL through an SSH bastion: every pooled
+// connection is a direct-tcpip channel over one shared SSH connection.
+type MySQLSSHConfig struct {
+	Host string `toml:"host"`
+	User string `toml:"user"`
+	// KeyFile is a private key in OpenSSH or PEM format.
+	KeyFile          string `toml:"key_file"`
+	KeyPassphraseEnv string `toml:"key_passphrase_env"`
+	// KnownHostsFile defaults to ~/.ssh/known_hosts.
+	KnownHostsFile        string `toml:"known_hosts_file"`
+	InsecureIgnoreHostKey bool   `toml:"insecure_ignore_host_key"`
+}
+
+// sshTunnel holds the SSH connection to the bastion, reconnecting when it
+// drops.
+type sshTunnel struct {
+	addr   string
+	config *ssh.ClientConfig
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func newSSHTunnel(c MySQLSSHConfig) (*sshTunnel, error) {
+	if c.Host == "" || c.User == "" || c.KeyFile == "" {
+		return nil, fmt.Errorf("mysql.ssh needs host, user, and key_file")
+	}
+	addr := c.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	pem, err := os.ReadFile(c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("mysql.ssh.key_file: %w", err)
+	}
+	var signer ssh.Signer
+	if c.KeyPassphraseEnv != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(pem, []byte(os.Getenv(c.KeyPassphraseEnv)))
+	} else {
+		signer, err = ssh.ParsePrivateKey(pem)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mysql.ssh.key_file: %w", err)
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !c.InsecureIgnoreHostKey {
+		knownHosts := c.KnownHostsFile
+		if knownHosts == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("mysql.ssh: no known_hosts_file and no home directory: %w", err)
+			}
+			knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+		}
+		hostKeyCallback, err = knownhosts.New(knownHosts)
+		if err != nil {
+			return nil, fmt.Errorf("mysql.ssh.known_hosts_file: %w", err)
+		}
+	}
+
+	return &sshTunnel{
+		addr: addr,
+		config: &ssh.ClientConfig{
+			User:            c.User,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         15 * time.Second,
+		},
+	}, nil
+}
+
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		return t.client, nil
+	}
+	dialer := net.Dialer{Timeout: t.config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w", t.addr, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, t.addr, t.config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh %s: %w", t.addr, err)
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	t.client = client
+	go func() {
+		// Forget the client once the bastion connection ends, so the next
+		// dial reconnects.
+		client.Wait()
+		t.mu.Lock()
+		if t.client == client {
+			t.client = nil
+		}
+		t.mu.Unlock()
+	}()
+	return client, nil
+}
+
+// dial opens a connection to addr through the bastion, retrying once on a
+// fresh SSH connection if the current one has gone stale.
+func (t *sshTunnel) dial(ctx context.Context, addr string) (net.Conn, error) {
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		client, err := t.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := client.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		client.Close()
+		t.mu.Lock()
+		if t.client == client {
+			t.client = nil
+		}
+		t.mu.Unlock()
+	}
+	return nil, fmt.Errorf("ssh tunnel to %s: %w", addr, lastErr)
+}
+
+// applySSH routes dsnConfig's TCP connections through [mysql.ssh]. The
+// address is resolved by the bastion, so it can be a private host name.
+func applySSH(dsnConfig *mysql.Config, c MySQLSSHConfig) error {
+	if c == (MySQLSSHConfig{}) {
+		return nil
+	}
+	if dsnConfig.Net != "tcp" {
+		return fmt.Errorf("mysql.ssh needs a tcp address, not %q", dsnConfig.Net)
+	}
+	tunnel, err := newSSHTunnel(c)
+	if err != nil {
+		return err
+	}
+	mysql.RegisterDialContext(sshNetwork, tunnel.dial)
+	dsnConfig.Net = sshNetwork
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startBastion runs an SSH server that accepts clientKey and forwards
+// direct-tcpip channels, returning its address.
+func startBastion(t *testing.T, hostKey ssh.Signer, clientKey ssh.PublicKey) string {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "tunnel" && string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					var target struct {
+						Host       string
+						Port       uint32
+						OriginHost string
+						OriginPort uint32
+					}
+					if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+						newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, fmt.Sprint(target.Port)))
+					if err != nil {
+						newChannel.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					channel, chReqs, err := newChannel.Accept()
+					if err != nil {
+						upstream.Close()
+						continue
+					}
+					go ssh.DiscardRequests(chReqs)
+					go func() { io.Copy(channel, upstream); channel.Close() }()
+					go func() { io.Copy(upstream, channel); upstream.Close() }()
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func startEcho(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() { io.Copy(conn, conn); conn.Close() }()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func newTestKey(t *testing.T) (ssh.Signer, []byte) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(private, "")
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+	return signer, pem.EncodeToMemory(block)
+}
+
+func TestSSHTunnelDial(t *testing.T) {
+	dir := t.TempDir()
+	hostKey, _ := newTestKey(t)
+	clientKey, clientPEM := newTestKey(t)
+	keyFile := filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, clientPEM, 0o600))
+
+	bastion := startBastion(t, hostKey, clientKey.PublicKey())
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{knownhosts.Normalize(bastion)}, hostKey.PublicKey())+"\n"), 0o600))
+
+	tunnel, err := newSSHTunnel(MySQLSSHConfig{Host: bastion, User: "tunnel", KeyFile: keyFile, KnownHostsFile: knownHostsFile})
+	require.NoError(t, err)
+
+	echo := startEcho(t)
+	conn, err := tunnel.dial(context.Background(), echo)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buf))
+
+	// An unknown host key is refused.
+	otherKey, _ := newTestKey(t)
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{knownhosts.Normalize(bastion)}, otherKey.PublicKey())+"\n"), 0o600))
+	strict, err := newSSHTunnel(MySQLSSHConfig{Host: bastion, User: "tunnel", KeyFile: keyFile, KnownHostsFile: knownHostsFile})
+	require.NoError(t, err)
+	_, err = strict.dial(context.Background(), echo)
+	require.ErrorContains(t, err, "key mismatch")
+}
+
+func TestApplySSH(t *testing.T) {
+	dsnConfig, err := mysql.ParseDSN("user@tcp(10.0.0.5:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, applySSH(dsnConfig, MySQLSSHConfig{}))
+	require.Equal(t, "tcp", dsnConfig.Net)
+
+	require.ErrorContains(t, applySSH(dsnConfig, MySQLSSHConfig{Host: "bastion"}), "needs host, user, and key_file")
+
+	dir := t.TempDir()
+	_, clientPEM := newTestKey(t)
+	keyFile := filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, clientPEM, 0o600))
+	require.NoError(t, applySSH(dsnConfig, MySQLSSHConfig{Host: "bastion", User: "tunnel", KeyFile: keyFile, InsecureIgnoreHostKey: true}))
+	require.Equal(t, sshNetwork, dsnConfig.Net)
+	_, err = mysql.NewConnector(dsnConfig)
+	require.NoError(t, err)
+}
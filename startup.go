@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// errDBStarting is reported by tools until the first successful connection
+// in lazy mode.
+var errDBStarting = errors.New("the database is not available yet; the server is still connecting")
+
+// dbState tracks whether the database has been reached. Outside lazy mode it
+// is ready before the server starts.
+type dbState struct {
+	mu    sync.Mutex
+	ready bool
+	err   error
+}
+
+func newDBState(ready bool) *dbState {
+	return &dbState{ready: ready, err: errDBStarting}
+}
+
+// check returns nil once the database is usable, or why it isn't.
+func (s *dbState) check() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+	return s.err
+}
+
+func (s *dbState) set(ready bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ready, s.err = ready, err
+}
+
+// connectBackoff is the retry schedule for reaching the database.
+type connectBackoff struct {
+	// attempts <= 0 retries until ctx ends.
+	attempts int
+	initial  time.Duration
+	max      time.Duration
+	sleep    func(context.Context, time.Duration) error
+}
+
+func newConnectBackoff(attempts, initialMs, maxMs int) connectBackoff {
+	return connectBackoff{
+		attempts: attempts,
+		initial:  time.Duration(initialMs) * time.Millisecond,
+		max:      time.Duration(maxMs) * time.Millisecond,
+		sleep:    sleepContext,
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retry calls connect until it succeeds, doubling the delay between
+// attempts up to max. Each failure is logged to stderr and passed to
+// onFailure.
+func (b connectBackoff) retry(ctx context.Context, connect func(context.Context) error, onFailure func(error)) error {
+	delay := b.initial
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx)
+		if err == nil {
+			return nil
+		}
+		if onFailure != nil {
+			onFailure(err)
+		}
+		if b.attempts > 0 && attempt >= b.attempts {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "mysql connection attempt %d failed: %v; retrying in %s\n", attempt, err, delay)
+		if err := b.sleep(ctx, delay); err != nil {
+			return err
+		}
+		delay = min(delay*2, b.max)
+	}
+}
+
+// pingDB is one connection attempt.
+func pingDB(db *sql.DB) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return db.PingContext(ctx)
+	}
+}
+
+// privilegeGate runs the startup privilege check; a non-nil error means the
+// server must not serve queries.
+func privilegeGate(ctx context.Context, db *sql.DB, mode string) error {
+	if mode == privilegeCheckOff {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := checkPrivileges(ctx, db); err != nil {
+		if mode == privilegeCheckRefuse {
+			return fmt.Errorf("refusing to start: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return nil
+}
+
+// connectLazily retries in the background, reporting each failure through
+// state so tools can explain why they fail, and marks the database ready
+// once it answers and passes the privilege check.
+func connectLazily(ctx context.Context, db *sql.DB, backoff connectBackoff, privilegeCheck string, state *dbState) {
+	backoff.attempts = 0
+	err := backoff.retry(ctx, pingDB(db), func(err error) {
+		state.set(false, fmt.Errorf("the database is not available: %w", err))
+	})
+	if err != nil {
+		return
+	}
+	if err := privilegeGate(ctx, db, privilegeCheck); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		state.set(false, err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "mysql connection established")
+	state.set(true, nil)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectBackoffRetry(t *testing.T) {
+	var delays []time.Duration
+	backoff := connectBackoff{
+		attempts: 5,
+		initial:  100 * time.Millisecond,
+		max:      300 * time.Millisecond,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		},
+	}
+
+	calls := 0
+	var failures []error
+	err := backoff.retry(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 4 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, func(err error) { failures = append(failures, err) })
+	require.NoError(t, err)
+	require.Equal(t, 4, calls)
+	require.Len(t, failures, 3)
+	require.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}, delays)
+
+	calls = 0
+	err = backoff.retry(context.Background(), func(context.Context) error {
+		calls++
+		return errors.New("connection refused")
+	}, nil)
+	require.ErrorContains(t, err, "connection refused")
+	require.Equal(t, 5, calls, "gives up after the configured attempts")
+}
+
+func TestConnectBackoffStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	backoff := connectBackoff{
+		initial: time.Millisecond,
+		max:     time.Millisecond,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			cancel()
+			return sleepContext(ctx, d)
+		},
+	}
+	err := backoff.retry(ctx, func(context.Context) error { return errors.New("down") }, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDBState(t *testing.T) {
+	var unset *dbState
+	require.NoError(t, unset.check())
+
+	state := newDBState(false)
+	require.ErrorIs(t, state.check(), errDBStarting)
+	state.set(false, errors.New("the database is not available: refused"))
+	require.EqualError(t, state.check(), "the database is not available: refused")
+	state.set(true, nil)
+	require.NoError(t, state.check())
+	require.NoError(t, newDBState(true).check())
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TableResourcesConfig lists concrete mysql://schema/{db}/{table} resources
+// in resources/list, for clients that don't expand resource templates.
+type TableResourcesConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Databases to list tables from. Defaults to the DSN's database, or to
+	// every non-system database when the DSN has none.
+	Databases []string `toml:"databases"`
+	// Exclude holds glob patterns matched against "db.table".
+	Exclude        []string `toml:"exclude"`
+	MaxTables      int      `toml:"max_tables"`
+	RefreshSeconds int      `toml:"refresh_seconds"`
+}
+
+// catalogTable is a table or view found in information_schema.
+type catalogTable struct {
+	schema  string
+	name    string
+	comment string
+}
+
+func (t catalogTable) uri() string {
+	return fmt.Sprintf("mysql://schema/%s/%s", t.schema, t.name)
+}
+
+// tableResources keeps the server's concrete table resources in step with
+// the catalog.
+type tableResources struct {
+	cfg  TableResourcesConfig
+	list func(context.Context) ([]catalogTable, error)
+
+	mu         sync.Mutex
+	registered map[string]bool
+	truncated  bool
+}
+
+func newTableResources(db *sql.DB, cfg TableResourcesConfig, defaultSchema string) (*tableResources, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	for _, pattern := range cfg.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("mysql.table_resources.exclude %q: %w", pattern, err)
+		}
+	}
+	if cfg.MaxTables <= 0 {
+		cfg.MaxTables = 200
+	}
+	if cfg.RefreshSeconds <= 0 {
+		cfg.RefreshSeconds = 300
+	}
+	if len(cfg.Databases) == 0 && defaultSchema != "" {
+		cfg.Databases = []string{defaultSchema}
+	}
+	databases := cfg.Databases
+	return &tableResources{
+		cfg: cfg,
+		list: func(ctx context.Context) ([]catalogTable, error) {
+			return listCatalogTables(ctx, db, databases)
+		},
+		registered: make(map[string]bool),
+	}, nil
+}
+
+// listCatalogTables reads the tables of databases, or of every non-system
+// database if none are given, in name order.
+func listCatalogTables(ctx context.Context, db *sql.DB, databases []string) ([]catalogTable, error) {
+	query := "SELECT TABLE_SCHEMA, TABLE_NAME, TABLE_COMMENT FROM information_schema.TABLES WHERE TABLE_SCHEMA NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys') ORDER BY TABLE_SCHEMA, TABLE_NAME"
+	var args []any
+	if len(databases) > 0 {
+		query = "SELECT TABLE_SCHEMA, TABLE_NAME, TABLE_COMMENT FROM information_schema.TABLES WHERE TABLE_SCHEMA IN (?" + strings.Repeat(", ?", len(databases)-1) + ") ORDER BY TABLE_SCHEMA, TABLE_NAME"
+		for _, name := range databases {
+			args = append(args, name)
+		}
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []catalogTable
+	for rows.Next() {
+		var t catalogTable
+		if err := rows.Scan(&t.schema, &t.name, &t.comment); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// selectTables drops excluded tables and names mysql:// URIs can't carry,
+// and caps the rest at MaxTables.
+func (c TableResourcesConfig) selectTables(tables []catalogTable) (selected []catalogTable, truncated bool) {
+	for _, t := range tables {
+		if !mysqlIdentifierRE.MatchString(t.schema) || !mysqlIdentifierRE.MatchString(t.name) {
+			continue
+		}
+		qualified := t.schema + "." + t.name
+		excluded := false
+		for _, pattern := range c.Exclude {
+			if ok, _ := path.Match(pattern, qualified); ok {
+				excluded = true
+				break
+			}
+		}
+		if excluded {
+			continue
+		}
+		if len(selected) == c.MaxTables {
+			return selected, true
+		}
+		selected = append(selected, t)
+	}
+	return selected, false
+}
+
+// sync registers resources for new tables and removes those for tables
+// that are gone. The SDK notifies subscribed clients of each change.
+func (r *tableResources) sync(ctx context.Context, server *mcp.Server, handler mcp.ResourceHandler) error {
+	tables, err := r.list(ctx)
+	if err != nil {
+		return err
+	}
+	selected, truncated := r.cfg.selectTables(tables)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if truncated && !r.truncated {
+		log.Printf("listing only the first %d tables as resources; raise mysql.table_resources.max_tables or add exclude patterns", r.cfg.MaxTables)
+	}
+	r.truncated = truncated
+	current := make(map[string]bool, len(selected))
+	for _, t := range selected {
+		uri := t.uri()
+		current[uri] = true
+		if r.registered[uri] {
+			continue
+		}
+		description := fmt.Sprintf("Schema of %s.%s (DESCRIBE).", t.schema, t.name)
+		if t.comment != "" {
+			description += " " + t.comment
+		}
+		server.AddResource(&mcp.Resource{
+			Name:        t.schema + "." + t.name,
+			URI:         uri,
+			Description: description,
+			MIMEType:    "application/json",
+		}, handler)
+	}
+	var stale []string
+	for uri := range r.registered {
+		if !current[uri] {
+			stale = append(stale, uri)
+		}
+	}
+	if len(stale) > 0 {
+		server.RemoveResources(stale...)
+	}
+	r.registered = current
+	return nil
+}
+
+// run syncs every refresh interval until ctx ends. Failures are logged and
+// leave the previous listing in place.
+func (r *tableResources) run(ctx context.Context, server *mcp.Server, handler mcp.ResourceHandler) {
+	ticker := time.NewTicker(time.Duration(r.cfg.RefreshSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := r.sync(ctx, server, handler); err != nil {
+			log.Printf("failed to list tables for resources: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableResourcesSelect(t *testing.T) {
+	cfg := TableResourcesConfig{Exclude: []string{"app.tmp_*", "audit.*"}, MaxTables: 2}
+	tables := []catalogTable{
+		{schema: "app", name: "orders"},
+		{schema: "app", name: "tmp_import"},
+		{schema: "app", name: "weird-name"},
+		{schema: "audit", name: "events"},
+		{schema: "app", name: "users"},
+	}
+	selected, truncated := cfg.selectTables(tables)
+	require.Equal(t, []catalogTable{{schema: "app", name: "orders"}, {schema: "app", name: "users"}}, selected)
+	require.False(t, truncated)
+
+	cfg.MaxTables = 1
+	selected, truncated = cfg.selectTables(tables)
+	require.Len(t, selected, 1)
+	require.True(t, truncated)
+}
+
+func TestTableResourcesSync(t *testing.T) {
+	catalog := []catalogTable{{schema: "app", name: "orders", comment: "One row per order."}, {schema: "app", name: "users"}}
+	r, err := newTableResources(nil, TableResourcesConfig{Enabled: true}, "app")
+	require.NoError(t, err)
+	require.Equal(t, []string{"app"}, r.cfg.Databases)
+	r.list = func(context.Context) ([]catalogTable, error) { return catalog, nil }
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "v0"}, nil)
+	handler := func(context.Context, *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) { return nil, nil }
+	require.NoError(t, r.sync(context.Background(), server, handler))
+	require.Equal(t, map[string]bool{"mysql://schema/app/orders": true, "mysql://schema/app/users": true}, r.registered)
+
+	catalog = []catalogTable{{schema: "app", name: "orders"}, {schema: "app", name: "payments"}}
+	require.NoError(t, r.sync(context.Background(), server, handler))
+	require.Equal(t, map[string]bool{"mysql://schema/app/orders": true, "mysql://schema/app/payments": true}, r.registered)
+
+	_, err = newTableResources(nil, TableResourcesConfig{Enabled: true, Exclude: []string{"["}}, "")
+	require.ErrorContains(t, err, "mysql.table_resources.exclude")
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	tenantFromHeader = "header"
+	tenantFromClaim  = "claim"
+	tenantFromInit   = "init"
+
+	// tenantInitMeta is the _meta key of the initialize request that names
+	// the tenant when tenancy.source is "init".
+	tenantInitMeta = "mysqlmcp/tenant"
+)
+
+// TenancyConfig serves several tenants from one HTTP endpoint. Each session
+// is bound, when it's created, to the tenant that Source names: an HTTP
+// header, a claim of the OAuth access token, or the initialize request's
+// _meta. The tenant's sessions run on their own server, connected with the
+// tenant's DSN, and can't name any database but the tenant's.
+type TenancyConfig struct {
+	Source string `toml:"source"`
+	// Header defaults to "X-MCP-Tenant".
+	Header string `toml:"header"`
+	// Claim defaults to "tenant".
+	Claim   string         `toml:"claim"`
+	Tenants []TenantConfig `toml:"tenants"`
+}
+
+// TenantConfig is one tenant. DSN replaces the [mysql] connection and must
+// name the tenant's database; Schemas lists any more databases it may read.
+// Principals lists who may open sessions for the tenant, and is required
+// when the client picks the tenant by header or initialize option.
+type TenantConfig struct {
+	Name       string   `toml:"name"`
+	DSN        string   `toml:"dsn"`
+	Schemas    []string `toml:"schemas"`
+	Principals []string `toml:"principals"`
+}
+
+func (c TenancyConfig) enabled() bool { return len(c.Tenants) > 0 }
+
+func validateTenancyConfig(cfg Config) error {
+	c := cfg.Tenancy
+	if !c.enabled() {
+		return nil
+	}
+	if cfg.HTTP.Addr == "" {
+		return fmt.Errorf("tenancy needs http.addr; over stdio, run one server per tenant")
+	}
+	switch c.Source {
+	case tenantFromHeader, tenantFromInit:
+	case tenantFromClaim:
+		if !cfg.HTTP.OAuth.enabled() {
+			return fmt.Errorf("tenancy.source %q needs http.oauth", c.Source)
+		}
+	default:
+		return fmt.Errorf("tenancy.source must be %q, %q, or %q", tenantFromHeader, tenantFromClaim, tenantFromInit)
+	}
+	if cfg.Analytics.URL != "" {
+		// Every tenant would query the same ClickHouse database.
+		return fmt.Errorf("tenancy can't be combined with analytics, whose extracts all tenants would share")
+	}
+	names := make(map[string]bool)
+	for _, t := range c.Tenants {
+		if t.Name == "" {
+			return fmt.Errorf("tenancy.tenants: every tenant needs a name")
+		}
+		if names[t.Name] {
+			return fmt.Errorf("tenancy.tenants %q: duplicate tenant", t.Name)
+		}
+		names[t.Name] = true
+		dsn, err := mysql.ParseDSN(t.DSN)
+		if err != nil {
+			return fmt.Errorf("tenancy.tenants %q: dsn: %w", t.Name, err)
+		}
+		if dsn.DBName == "" {
+			return fmt.Errorf("tenancy.tenants %q: dsn must name the tenant's database", t.Name)
+		}
+		for _, schema := range t.Schemas {
+			if !mysqlIdentifierRE.MatchString(schema) {
+				return fmt.Errorf("tenancy.tenants %q: invalid schema %q", t.Name, schema)
+			}
+		}
+		if len(t.Principals) == 0 && c.Source != tenantFromClaim {
+			return fmt.Errorf("tenancy.tenants %q: principals is required when the client picks the tenant", t.Name)
+		}
+	}
+	return nil
+}
+
+// bind returns cfg connected as tenant t. A nil t returns cfg unchanged.
+func (t *TenantConfig) bind(cfg Config) Config {
+	if t == nil {
+		return cfg
+	}
+	mysqlCfg := cfg.MySQL
+	mysqlCfg.DSN = t.DSN
+	mysqlCfg.Host, mysqlCfg.Port, mysqlCfg.User, mysqlCfg.Database = "", 0, "", ""
+	mysqlCfg.Password, mysqlCfg.PasswordFile, mysqlCfg.PasswordEnv = "", "", ""
+	mysqlCfg.Params = nil
+	// Replicas and the introspection pool connect as the shared account.
+	mysqlCfg.Replicas.DSNs = nil
+	mysqlCfg.Introspection.DSN = ""
+	// Table resources list the tenant's database, not the configured ones.
+	mysqlCfg.TableResources.Databases = nil
+	cfg.MySQL = mysqlCfg
+	cfg.tenant = t
+	return cfg
+}
+
+// schemas returns the lower-cased databases t may name.
+func (t *TenantConfig) schemas() map[string]bool {
+	schemas := make(map[string]bool)
+	if dsn, err := mysql.ParseDSN(t.DSN); err == nil {
+		schemas[strings.ToLower(dsn.DBName)] = true
+	}
+	for _, schema := range t.Schemas {
+		schemas[strings.ToLower(schema)] = true
+	}
+	return schemas
+}
+
+// newServers builds the server, or with tenancy one server per tenant.
+func newServers(cfg Config, configPath string) (map[string]*mcp.Server, []*queryHandler, error) {
+	servers := make(map[string]*mcp.Server)
+	var handlers []*queryHandler
+	if !cfg.Tenancy.enabled() {
+		server, handler, err := newServer(cfg, configPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		servers[""] = server
+		return servers, append(handlers, handler), nil
+	}
+	for i := range cfg.Tenancy.Tenants {
+		tenant := &cfg.Tenancy.Tenants[i]
+		server, handler, err := newServer(tenant.bind(cfg), configPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tenant %q: %w", tenant.Name, err)
+		}
+		servers[tenant.Name] = server
+		handlers = append(handlers, handler)
+	}
+	return servers, handlers, nil
+}
+
+// tenantRouter returns the server of the tenant a new session asks for, or
+// nil, which the SDK answers with 400 Bad Request, if there's no such tenant
+// or the caller's principal isn't one of its principals. Later requests
+// stay on the session's server whatever they carry.
+func tenantRouter(cfg TenancyConfig, servers map[string]*mcp.Server) func(*http.Request) *mcp.Server {
+	tenants := make(map[string]*TenantConfig)
+	for i := range cfg.Tenants {
+		tenants[cfg.Tenants[i].Name] = &cfg.Tenants[i]
+	}
+	return func(req *http.Request) *mcp.Server {
+		info := auth.TokenInfoFromContext(req.Context())
+		name := requestTenant(cfg, req, info)
+		tenant, ok := tenants[name]
+		if !ok {
+			log.Printf("refusing session: unknown tenant %q", name)
+			return nil
+		}
+		var principal string
+		if info != nil {
+			principal = info.UserID
+		}
+		if len(tenant.Principals) > 0 && !slices.Contains(tenant.Principals, principal) {
+			log.Printf("refusing session: principal %q may not use tenant %q", principal, name)
+			return nil
+		}
+		return servers[name]
+	}
+}
+
+// requestTenant returns the tenant name req carries, or "".
+func requestTenant(cfg TenancyConfig, req *http.Request, info *auth.TokenInfo) string {
+	switch cfg.Source {
+	case tenantFromHeader:
+		header := cfg.Header
+		if header == "" {
+			header = "X-MCP-Tenant"
+		}
+		return req.Header.Get(header)
+	case tenantFromClaim:
+		claim := cfg.Claim
+		if claim == "" {
+			claim = "tenant"
+		}
+		if info != nil {
+			name, _ := info.Extra[claim].(string)
+			return name
+		}
+	case tenantFromInit:
+		// The SDK reads the body after this, so it's put back.
+		body, err := io.ReadAll(io.LimitReader(req.Body, 1<<20))
+		if err != nil {
+			return ""
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		var msg struct {
+			Method string `json:"method"`
+			Params struct {
+				Meta map[string]any `json:"_meta"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(body, &msg) != nil || msg.Method != "initialize" {
+			return ""
+		}
+		name, _ := msg.Params.Meta[tenantInitMeta].(string)
+		return name
+	}
+	return ""
+}
+
+// databaseArguments are the tool arguments that name databases.
+var databaseArguments = []string{"database", "source", "target"}
+
+// checkTenantArguments rejects a tool call, for a tenant, whose arguments
+// name a database outside the tenant.
+func (h *queryHandler) checkTenantArguments(req *mcp.CallToolRequest) error {
+	if h.tenant == nil || req == nil || req.Params == nil {
+		return nil
+	}
+	var args map[string]any
+	if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+		return nil
+	}
+	validator := h.snapshot().validator
+	for _, key := range databaseArguments {
+		if name, ok := args[key].(string); ok && name != "" && !validator.TenantAllows(name, false) {
+			return fmt.Errorf("database %s is outside this tenant", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestTenantSchemaIsolation(t *testing.T) {
+	tenant := &TenantConfig{Name: "acme", DSN: "acme:pw@tcp(db:3306)/acme", Schemas: []string{"shared"}}
+	shared := Config{}
+	shared.MySQL.TableResources.Databases = []string{"globex"}
+	cfg := tenant.bind(shared)
+	require.Equal(t, tenant.DSN, cfg.MySQL.DSN)
+	require.Empty(t, cfg.MySQL.TableResources.Databases)
+	v, err := newValidator(cfg, "acme")
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"SELECT * FROM orders",
+		"SELECT o.id FROM acme.orders o JOIN shared.currencies c ON c.code = o.currency",
+		"SELECT TABLE_NAME FROM information_schema.TABLES",
+		"SHOW TABLES",
+		"SHOW TABLES FROM acme",
+		"DESCRIBE acme.orders",
+	} {
+		require.NoError(t, v.Validate(query), query)
+	}
+	for _, query := range []string{
+		"SELECT * FROM globex.orders",
+		"SELECT id FROM orders WHERE id IN (SELECT order_id FROM globex.refunds)",
+		"SELECT globex.orders.id FROM orders",
+		"SELECT globex.total(id) FROM orders",
+		"SHOW TABLES FROM globex",
+		"SHOW CREATE TABLE globex.orders",
+		"DESCRIBE globex.orders",
+	} {
+		var rejection *gate.QueryRejection
+		require.ErrorAs(t, v.Validate(query), &rejection, query)
+		require.Contains(t, rejection.Reason, "outside this tenant", query)
+	}
+	// The server's own catalog queries may read system schemas, but still
+	// not another tenant's tables.
+	require.NoError(t, v.ValidateCatalog("SELECT * FROM performance_schema.table_io_waits_summary_by_index_usage"))
+	require.Error(t, v.ValidateCatalog("SHOW INDEX FROM globex.orders"))
+
+	h := &queryHandler{tenant: tenant, validator: v}
+	for args, ok := range map[string]bool{
+		`{"database": "acme", "table": "orders"}`:   true,
+		`{"database": "globex", "table": "orders"}`: false,
+		`{"source": "acme", "target": "globex"}`:    false,
+	} {
+		req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Arguments: json.RawMessage(args)}}
+		require.Equal(t, ok, h.checkTenantArguments(req) == nil, args)
+	}
+}
+
+func TestTenantRouter(t *testing.T) {
+	acme := mcp.NewServer(&mcp.Implementation{Name: "acme"}, nil)
+	globex := mcp.NewServer(&mcp.Implementation{Name: "globex"}, nil)
+	servers := map[string]*mcp.Server{"acme": acme, "globex": globex}
+	tenants := []TenantConfig{
+		{Name: "acme", DSN: "u@tcp(db)/acme", Principals: []string{"alice"}},
+		{Name: "globex", DSN: "u@tcp(db)/globex", Principals: []string{"bob"}},
+	}
+	// route sends a request with header as the tenant header and claim,
+	// authenticated as principal, through router.
+	route := func(router func(*http.Request) *mcp.Server, principal, header, body string) (server *mcp.Server, rest string) {
+		verifier := func(context.Context, string, *http.Request) (*auth.TokenInfo, error) {
+			return &auth.TokenInfo{UserID: principal, Expiration: time.Now().Add(time.Minute), Extra: map[string]any{"org": header}}, nil
+		}
+		handler := auth.RequireBearerToken(verifier, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			server = router(r)
+			b, _ := io.ReadAll(r.Body)
+			rest = string(b)
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		if header != "" {
+			req.Header.Set("X-MCP-Tenant", header)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return server, rest
+	}
+
+	byHeader := tenantRouter(TenancyConfig{Source: tenantFromHeader, Tenants: tenants}, servers)
+	server, _ := route(byHeader, "alice", "acme", "")
+	require.Same(t, acme, server)
+	server, _ = route(byHeader, "alice", "globex", "")
+	require.Nil(t, server)
+	server, _ = route(byHeader, "alice", "", "")
+	require.Nil(t, server)
+
+	byClaim := tenantRouter(TenancyConfig{Source: tenantFromClaim, Claim: "org", Tenants: tenants}, servers)
+	server, _ = route(byClaim, "bob", "globex", "")
+	require.Same(t, globex, server)
+
+	// The initialize request is still readable after the router peeked at it.
+	byInit := tenantRouter(TenancyConfig{Source: tenantFromInit, Tenants: tenants}, servers)
+	init := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"_meta": {"mysqlmcp/tenant": "globex"}}}`
+	server, rest := route(byInit, "bob", "", init)
+	require.Same(t, globex, server)
+	require.Equal(t, init, rest)
+}
+
+func TestValidateTenancyConfig(t *testing.T) {
+	httpCfg := HTTPConfig{Addr: ":8080", APIKeys: []APIKeyConfig{{Principal: "alice", SHA256: sha256Hex("k")}}}
+	valid := TenancyConfig{Source: tenantFromHeader, Tenants: []TenantConfig{{Name: "acme", DSN: "u@tcp(db)/acme", Principals: []string{"alice"}}}}
+	require.NoError(t, validateTenancyConfig(Config{HTTP: httpCfg, Tenancy: valid}))
+
+	for name, c := range map[string]Config{
+		"stdio":           {Tenancy: valid},
+		"bad source":      {HTTP: httpCfg, Tenancy: TenancyConfig{Source: "cookie", Tenants: valid.Tenants}},
+		"claim w/o oauth": {HTTP: httpCfg, Tenancy: TenancyConfig{Source: tenantFromClaim, Tenants: valid.Tenants}},
+		"no database":     {HTTP: httpCfg, Tenancy: TenancyConfig{Source: tenantFromHeader, Tenants: []TenantConfig{{Name: "acme", DSN: "u@tcp(db)/", Principals: []string{"alice"}}}}},
+		"no principals":   {HTTP: httpCfg, Tenancy: TenancyConfig{Source: tenantFromHeader, Tenants: []TenantConfig{{Name: "acme", DSN: "u@tcp(db)/acme"}}}},
+		"analytics":       {HTTP: httpCfg, Tenancy: valid, Analytics: AnalyticsConfig{URL: "http://clickhouse:8123"}},
+	} {
+		require.Error(t, validateTenancyConfig(c), name)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// tlsConfigName is the name [mysql.tls] is registered under with the driver.
+const tlsConfigName = "mysqlmcp"
+
+type MySQLTLSConfig struct {
+	Enabled    bool   `toml:"enabled"`
+	CAFile     string `toml:"ca_file"`
+	CertFile   string `toml:"cert_file"`
+	KeyFile    string `toml:"key_file"`
+	ServerName string `toml:"server_name"`
+	SkipVerify bool   `toml:"skip_verify"`
+}
+
+// configured reports whether [mysql.tls] asks for TLS at all; setting any
+// field other than enabled implies it.
+func (c MySQLTLSConfig) configured() bool {
+	return c.Enabled || c.CAFile != "" || c.CertFile != "" || c.KeyFile != "" || c.ServerName != "" || c.SkipVerify
+}
+
+// buildTLSConfig turns [mysql.tls] into a tls.Config. Without ca_file the
+// system roots are used.
+func buildTLSConfig(c MySQLTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.SkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("mysql.tls.ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("mysql.tls.ca_file %s: no PEM certificates found", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, fmt.Errorf("mysql.tls.cert_file and mysql.tls.key_file must be set together")
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("mysql.tls client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// applyTLS registers [mysql.tls] with the driver and points dsnConfig at it.
+// A DSN that already chooses a tls mode conflicts with the table.
+func applyTLS(dsnConfig *mysql.Config, c MySQLTLSConfig) error {
+	if !c.configured() {
+		return nil
+	}
+	if dsnConfig.TLSConfig != "" && dsnConfig.TLSConfig != "false" {
+		return fmt.Errorf("[mysql.tls] can't be combined with tls=%s in the DSN or params", dsnConfig.TLSConfig)
+	}
+	tlsConfig, err := buildTLSConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := mysql.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
+		return fmt.Errorf("register mysql tls config: %w", err)
+	}
+	// TLS is resolved from TLSConfig when the connector is created.
+	dsnConfig.TLSConfig = tlsConfigName
+	dsnConfig.TLS = nil
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCert writes a self-signed certificate and its key as PEM files.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mysql.internal"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	tlsConfig, err := buildTLSConfig(MySQLTLSConfig{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "mysql.internal"})
+	require.NoError(t, err)
+	require.NotNil(t, tlsConfig.RootCAs)
+	require.Len(t, tlsConfig.Certificates, 1)
+	require.Equal(t, "mysql.internal", tlsConfig.ServerName)
+	require.False(t, tlsConfig.InsecureSkipVerify)
+
+	_, err = buildTLSConfig(MySQLTLSConfig{CertFile: certFile})
+	require.ErrorContains(t, err, "must be set together")
+
+	_, err = buildTLSConfig(MySQLTLSConfig{CAFile: keyFile})
+	require.ErrorContains(t, err, "no PEM certificates")
+
+	_, err = buildTLSConfig(MySQLTLSConfig{CAFile: filepath.Join(dir, "missing.pem")})
+	require.ErrorContains(t, err, "mysql.tls.ca_file")
+}
+
+func TestMySQLDriverConfigTLS(t *testing.T) {
+	var cfg Config
+	cfg.MySQL.DSN = "user:pass@tcp(db.internal:3306)/db"
+	cfg.MySQL.TLS.SkipVerify = true
+	dsnConfig, err := mysqlDriverConfig(cfg)
+	require.NoError(t, err)
+	require.Equal(t, tlsConfigName, dsnConfig.TLSConfig)
+	_, err = mysql.NewConnector(dsnConfig)
+	require.NoError(t, err, "the registered name resolves when the connector is built")
+
+	cfg.MySQL.DSN = "user:pass@tcp(db.internal:3306)/db?tls=true"
+	_, err = mysqlDriverConfig(cfg)
+	require.ErrorContains(t, err, "can't be combined with tls=true")
+}
+
+func TestMySQLDriverConfigTLSParamUsesHost(t *testing.T) {
+	var cfg Config
+	cfg.MySQL.Host = "db.internal"
+	cfg.MySQL.User = "readonly"
+	cfg.MySQL.Params = map[string]string{"tls": "true"}
+	dsnConfig, err := mysqlDriverConfig(cfg)
+	require.NoError(t, err)
+	require.Nil(t, dsnConfig.TLS)
+	require.Equal(t, "true", dsnConfig.TLSConfig)
+	require.Contains(t, dsnConfig.FormatDSN(), "tcp(db.internal:3306)")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// erLockDeadlock is MySQL's error for a transaction rolled back to break a
+// deadlock.
+const erLockDeadlock = 1213
+
+// isTransient reports whether a failed call may succeed if simply run again:
+// a deadlock, a lock wait timeout, or a lost or refused connection. Lock
+// waits a backup explains are left to retryBlocked.
+func isTransient(output QueryOutput) bool {
+	if output.Error == nil || output.Blocked != nil {
+		return false
+	}
+	switch output.Error.Code {
+	case erLockDeadlock, erLockWaitTimeout:
+		return true
+	}
+	return output.Error.Category == errorConnection
+}
+
+// retryTransient runs attempt again while it fails with a transient error,
+// up to retries more times, doubling backoff after each wait. The returned
+// output's Retries counts the extra attempts.
+func retryTransient(ctx context.Context, retries int, backoff time.Duration, attempt func() (*mcp.CallToolResult, QueryOutput, error)) (*mcp.CallToolResult, QueryOutput, error) {
+	for i := 0; ; i++ {
+		result, output, err := attempt()
+		done := err != nil || !isTransient(output) || i >= retries
+		if !done && sleepContext(ctx, backoff) != nil {
+			done = true
+		}
+		if !done {
+			backoff *= 2
+			continue
+		}
+		output.Retries = i
+		if i > 0 && result != nil {
+			result.StructuredContent = queryOutputToStructuredContent(output)
+		}
+		return result, output, err
+	}
+}
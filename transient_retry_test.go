@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransient(t *testing.T) {
+	failed := func(err error) QueryOutput {
+		_, output := toolErrorResultf("query failed: %v", err)
+		return output
+	}
+	require.True(t, isTransient(failed(&mysql.MySQLError{Number: 1213})))
+	require.True(t, isTransient(failed(&mysql.MySQLError{Number: 1205})))
+	require.True(t, isTransient(failed(mysql.ErrInvalidConn)))
+	require.False(t, isTransient(failed(&mysql.MySQLError{Number: 1064})))
+	require.False(t, isTransient(failed(errors.New("invalid params"))))
+	require.False(t, isTransient(QueryOutput{RowCount: 1}))
+
+	blocked := failed(&mysql.MySQLError{Number: 1205})
+	blocked.Blocked = &QueryBlocked{Reason: blockedBackupInProgress}
+	require.False(t, isTransient(blocked), "backup locks are retried by retryBlocked")
+}
+
+func TestRetryTransient(t *testing.T) {
+	result, deadlock := toolErrorResultf("query failed: %v", &mysql.MySQLError{Number: 1213})
+	attempts := 0
+	got, output, err := retryTransient(context.Background(), 2, time.Millisecond, func() (*mcp.CallToolResult, QueryOutput, error) {
+		attempts++
+		if attempts < 3 {
+			return result, deadlock, nil
+		}
+		return &mcp.CallToolResult{}, QueryOutput{Columns: []string{}, Rows: [][]interface{}{}, RowCount: 1}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, 2, output.Retries)
+	require.Equal(t, 2, got.StructuredContent.(map[string]any)["retries"])
+
+	attempts = 0
+	_, output, _ = retryTransient(context.Background(), 1, time.Millisecond, func() (*mcp.CallToolResult, QueryOutput, error) {
+		attempts++
+		return result, deadlock, nil
+	})
+	require.Equal(t, 2, attempts, "gives up after the configured retries")
+	require.Equal(t, 1, output.Retries)
+	require.NotNil(t, output.Error)
+
+	attempts = 0
+	_, output, _ = retryTransient(context.Background(), 5, time.Millisecond, func() (*mcp.CallToolResult, QueryOutput, error) {
+		attempts++
+		_, output := toolErrorResultf("query failed: %v", &mysql.MySQLError{Number: 1146})
+		return nil, output, nil
+	})
+	require.Equal(t, 1, attempts, "other failures aren't retried")
+	require.Zero(t, output.Retries)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type UnusedReportInput struct {
+	Database string `json:"database" jsonschema:"Database to analyze."`
+	Table    string `json:"table,omitempty" jsonschema:"Optional table to restrict the report to."`
+}
+
+type UnusedIndex struct {
+	Table  string `json:"table"`
+	Index  string `json:"index"`
+	Unique bool   `json:"unique" jsonschema:"Unique indexes also enforce constraints and usually must be kept."`
+}
+
+type UnusedColumn struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+type UnusedReportOutput struct {
+	ObservationWindowSeconds int64          `json:"observationWindowSeconds" jsonschema:"Server uptime; performance_schema counters cover at most this window."`
+	UnusedIndexes            []UnusedIndex  `json:"unusedIndexes" jsonschema:"Secondary indexes with no recorded reads or writes."`
+	UnusedColumns            []UnusedColumn `json:"unusedColumns" jsonschema:"Columns never mentioned by statement digests that touch their table."`
+	UntouchedTables          []string       `json:"untouchedTables" jsonschema:"Tables no statement digest refers to at all."`
+}
+
+const unusedIndexesQuery = `SELECT DISTINCT t.OBJECT_NAME AS table_name, t.INDEX_NAME AS index_name, s.NON_UNIQUE AS non_unique
+FROM performance_schema.table_io_waits_summary_by_index_usage t
+JOIN information_schema.STATISTICS s
+	ON s.TABLE_SCHEMA = t.OBJECT_SCHEMA AND s.TABLE_NAME = t.OBJECT_NAME AND s.INDEX_NAME = t.INDEX_NAME
+WHERE t.OBJECT_SCHEMA = ? AND (? = '' OR t.OBJECT_NAME = ?)
+	AND t.INDEX_NAME IS NOT NULL AND t.INDEX_NAME <> 'PRIMARY' AND t.COUNT_STAR = 0
+ORDER BY t.OBJECT_NAME, t.INDEX_NAME`
+
+const tableColumnsQuery = `SELECT TABLE_NAME AS table_name, COLUMN_NAME AS column_name
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = ? AND (? = '' OR TABLE_NAME = ?)
+ORDER BY TABLE_NAME, ORDINAL_POSITION`
+
+const statementDigestsQuery = `SELECT DIGEST_TEXT AS digest_text
+FROM performance_schema.events_statements_summary_by_digest
+WHERE DIGEST_TEXT IS NOT NULL`
+
+var digestIdentifierRE = regexp.MustCompile("[A-Za-z0-9_$]+")
+
+func newUnusedReportOutput() UnusedReportOutput {
+	return UnusedReportOutput{
+		UnusedIndexes:   []UnusedIndex{},
+		UnusedColumns:   []UnusedColumn{},
+		UntouchedTables: []string{},
+	}
+}
+
+func (h *queryHandler) unusedReport(ctx context.Context, req *mcp.CallToolRequest, input UnusedReportInput) (*mcp.CallToolResult, UnusedReportOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	if !mysqlIdentifierRE.MatchString(input.Database) || (input.Table != "" && !mysqlIdentifierRE.MatchString(input.Table)) {
+		return toolErrorf(newUnusedReportOutput(), "database and table must be plain identifiers")
+	}
+	output := newUnusedReportOutput()
+
+	uptime, err := h.runMetadataQuery(ctx, "SHOW GLOBAL STATUS LIKE 'Uptime'")
+	if err != nil {
+		return toolErrorf(newUnusedReportOutput(), "failed to read uptime: %v", err)
+	}
+	if len(uptime.Rows) > 0 {
+		output.ObservationWindowSeconds, _ = valueInt64(rowValue(uptime.Rows[0], columnIndex(uptime.Columns, "Value")))
+	}
+
+	indexes, err := h.runMetadataQuery(ctx, unusedIndexesQuery, input.Database, input.Table, input.Table)
+	if err != nil {
+		return toolErrorf(newUnusedReportOutput(), "failed to read index usage: %v", err)
+	}
+	tableCol := columnIndex(indexes.Columns, "table_name")
+	indexCol := columnIndex(indexes.Columns, "index_name")
+	nonUniqueCol := columnIndex(indexes.Columns, "non_unique")
+	for _, row := range indexes.Rows {
+		nonUnique, _ := valueInt64(rowValue(row, nonUniqueCol))
+		output.UnusedIndexes = append(output.UnusedIndexes, UnusedIndex{
+			Table:  valueString(rowValue(row, tableCol)),
+			Index:  valueString(rowValue(row, indexCol)),
+			Unique: nonUnique == 0,
+		})
+	}
+
+	columns, err := h.runMetadataQuery(ctx, tableColumnsQuery, input.Database, input.Table, input.Table)
+	if err != nil {
+		return toolErrorf(newUnusedReportOutput(), "failed to read columns: %v", err)
+	}
+	digests, err := h.runMetadataQuery(ctx, statementDigestsQuery)
+	if err != nil {
+		return toolErrorf(newUnusedReportOutput(), "failed to read statement digests: %v", err)
+	}
+	digestTexts := make([]string, 0, len(digests.Rows))
+	for _, row := range digests.Rows {
+		digestTexts = append(digestTexts, valueString(rowValue(row, 0)))
+	}
+	output.UnusedColumns, output.UntouchedTables = findUnusedColumns(columns, digestTexts)
+
+	return nil, output, nil
+}
+
+// findUnusedColumns reports, for each table in columns (table_name,
+// column_name rows), the columns no digest mentioning that table refers to.
+// Tables that no digest mentions are reported separately rather than
+// flagging every one of their columns. Matching is by identifier token, so
+// a column sharing its name with another table's column counts as used.
+func findUnusedColumns(columns QueryOutput, digests []string) ([]UnusedColumn, []string) {
+	tokenSets := make([]map[string]bool, 0, len(digests))
+	for _, digest := range digests {
+		tokens := make(map[string]bool)
+		for _, token := range digestIdentifierRE.FindAllString(digest, -1) {
+			tokens[strings.ToLower(token)] = true
+		}
+		tokenSets = append(tokenSets, tokens)
+	}
+
+	tableCol := columnIndex(columns.Columns, "table_name")
+	columnCol := columnIndex(columns.Columns, "column_name")
+	tableColumns := make(map[string][]string)
+	tables := make([]string, 0)
+	for _, row := range columns.Rows {
+		table := valueString(rowValue(row, tableCol))
+		if _, ok := tableColumns[table]; !ok {
+			tables = append(tables, table)
+		}
+		tableColumns[table] = append(tableColumns[table], valueString(rowValue(row, columnCol)))
+	}
+
+	unused := make([]UnusedColumn, 0)
+	untouched := make([]string, 0)
+	for _, table := range tables {
+		touching := make([]map[string]bool, 0)
+		for _, tokens := range tokenSets {
+			if tokens[strings.ToLower(table)] {
+				touching = append(touching, tokens)
+			}
+		}
+		if len(touching) == 0 {
+			untouched = append(untouched, table)
+			continue
+		}
+		for _, column := range tableColumns[table] {
+			used := false
+			for _, tokens := range touching {
+				if tokens[strings.ToLower(column)] {
+					used = true
+					break
+				}
+			}
+			if !used {
+				unused = append(unused, UnusedColumn{Table: table, Column: column})
+			}
+		}
+	}
+	return unused, untouched
+}
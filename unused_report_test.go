@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindUnusedColumns(t *testing.T) {
+	columns := QueryOutput{
+		Columns: []string{"table_name", "column_name"},
+		Rows: [][]interface{}{
+			{"users", "id"},
+			{"users", "email"},
+			{"users", "legacy_flag"},
+			{"audit", "id"},
+			{"audit", "payload"},
+		},
+	}
+	digests := []string{
+		"SELECT `id` , `email` FROM `users` WHERE `id` = ?",
+		"SELECT `legacy_flag` FROM `accounts`",
+	}
+
+	unused, untouched := findUnusedColumns(columns, digests)
+	require.Equal(t, []UnusedColumn{{Table: "users", Column: "legacy_flag"}}, unused)
+	require.Equal(t, []string{"audit"}, untouched)
+}
+
+func TestUnusedReportQueriesPassReadOnlyGate(t *testing.T) {
+	for _, query := range []string{unusedIndexesQuery, tableColumnsQuery, statementDigestsQuery, "SHOW GLOBAL STATUS LIKE 'Uptime'"} {
+		require.True(t, isReadOnlyQuery(query, nil), query)
+	}
+}
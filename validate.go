@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+type ValidateInput struct {
+	Query  string `json:"query" jsonschema:"SQL statement to check. It is explained, never executed."`
+	Params []any  `json:"params,omitempty" jsonschema:"Values for the query's ? placeholders, as for mysql_query."`
+	// IncludeDeleted matches mysql_query's option, so the plan is the one
+	// the query would run with.
+	IncludeDeleted bool `json:"includeDeleted,omitempty" jsonschema:"Check the query as mysql_query would run it with includeDeleted."`
+}
+
+// PlannedTable is one table access in an EXPLAIN FORMAT=JSON plan.
+type PlannedTable struct {
+	Table        string `json:"table"`
+	AccessType   string `json:"accessType" jsonschema:"Join type, e.g. const, ref, range, index, or ALL (full scan)."`
+	Key          string `json:"key,omitempty" jsonschema:"Index the optimizer chose."`
+	RowsExamined int64  `json:"rowsExamined" jsonschema:"Estimated rows read per scan."`
+}
+
+type ValidateOutput struct {
+	Allowed       bool                 `json:"allowed" jsonschema:"Whether mysql_query would accept the query."`
+	Rejection     *gate.QueryRejection `json:"rejection,omitempty"`
+	RowFiltered   bool                 `json:"rowFiltered,omitempty" jsonschema:"True if row filters rewrite the query before it runs."`
+	EstimatedCost *float64             `json:"estimatedCost,omitempty" jsonschema:"Optimizer cost (query_cost) of the query as it would run."`
+	Tables        []PlannedTable       `json:"tables,omitempty"`
+	ExplainError  string               `json:"explainError,omitempty" jsonschema:"Why EXPLAIN failed, e.g. an unknown table or column: the query would fail the same way."`
+}
+
+// explainSummary reads the estimated cost and table accesses from an
+// EXPLAIN FORMAT=JSON document.
+func explainSummary(doc string) (cost *float64, tables []PlannedTable, err error) {
+	var plan map[string]any
+	if err := json.Unmarshal([]byte(doc), &plan); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if block, ok := plan["query_block"].(map[string]any); ok {
+		if info, ok := block["cost_info"].(map[string]any); ok {
+			if value, err := strconv.ParseFloat(fmt.Sprint(info["query_cost"]), 64); err == nil {
+				cost = &value
+			}
+		}
+	}
+	var visit func(node any)
+	visit = func(node any) {
+		switch node := node.(type) {
+		case map[string]any:
+			if name, ok := node["table_name"].(string); ok {
+				if access, ok := node["access_type"].(string); ok {
+					table := PlannedTable{Table: name, AccessType: access}
+					table.Key, _ = node["key"].(string)
+					if rows, ok := node["rows_examined_per_scan"].(float64); ok {
+						table.RowsExamined = int64(rows)
+					}
+					tables = append(tables, table)
+				}
+			}
+			// Keys in sorted order keep the result stable; join order is
+			// in nested_loop arrays, which keep theirs.
+			for _, key := range slices.Sorted(maps.Keys(node)) {
+				visit(node[key])
+			}
+		case []any:
+			for _, child := range node {
+				visit(child)
+			}
+		}
+	}
+	visit(plan)
+	return cost, tables, nil
+}
+
+func (h *queryHandler) validate(ctx context.Context, req *mcp.CallToolRequest, input ValidateInput) (*mcp.CallToolResult, ValidateOutput, error) {
+	ctx = withAttribution(ctx, req.Session)
+	empty := ValidateOutput{}
+	live := h.snapshot()
+	if err := live.validator.Validate(input.Query); err != nil {
+		rejection, ok := err.(*gate.QueryRejection)
+		if !ok {
+			rejection = &gate.QueryRejection{Reason: err.Error()}
+		}
+		return nil, ValidateOutput{Rejection: rejection}, nil
+	}
+	args, err := queryParams(input.Params)
+	if err != nil {
+		return toolErrorf(empty, "invalid params: %v", err)
+	}
+	filtered, err := live.applyFilters(input.Query, input.IncludeDeleted)
+	if err != nil {
+		return nil, ValidateOutput{Rejection: &gate.QueryRejection{Reason: fmt.Sprintf("failed to apply row filters: %v", err)}}, nil
+	}
+	output := ValidateOutput{Allowed: true, RowFiltered: filtered != input.Query}
+
+	// SHOW, DESCRIBE, and EXPLAIN have no plan of their own.
+	stmt, err := gate.ParseStatement(input.Query)
+	if err != nil {
+		return toolErrorf(empty, "failed to parse query: %v", err)
+	}
+	switch stmt.(type) {
+	case *sqlparser.Select, *sqlparser.Union:
+	default:
+		return nil, output, nil
+	}
+	plan, err := h.runQueryForResource(ctx, "EXPLAIN FORMAT=JSON "+input.Query, args...)
+	if err != nil {
+		output.ExplainError = err.Error()
+		return nil, output, nil
+	}
+	if len(plan.Rows) == 0 {
+		return nil, output, nil
+	}
+	output.EstimatedCost, output.Tables, err = explainSummary(valueString(rowValue(plan.Rows[0], 0)))
+	if err != nil {
+		output.ExplainError = err.Error()
+	}
+	return nil, output, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestExplainSummary(t *testing.T) {
+	cost, tables, err := explainSummary(`{
+  "query_block": {
+    "select_id": 1,
+    "cost_info": {"query_cost": "12.50"},
+    "nested_loop": [
+      {"table": {"table_name": "o", "access_type": "ALL", "rows_examined_per_scan": 1000}},
+      {"table": {"table_name": "c", "access_type": "eq_ref", "key": "PRIMARY", "rows_examined_per_scan": 1}}
+    ]
+  }
+}`)
+	require.NoError(t, err)
+	require.NotNil(t, cost)
+	require.InDelta(t, 12.5, *cost, 1e-9)
+	require.Equal(t, []PlannedTable{
+		{Table: "o", AccessType: "ALL", RowsExamined: 1000},
+		{Table: "c", AccessType: "eq_ref", Key: "PRIMARY", RowsExamined: 1},
+	}, tables)
+
+	cost, tables, err = explainSummary(`{"query_block": {"select_id": 1, "message": "No tables used"}}`)
+	require.NoError(t, err)
+	require.Nil(t, cost)
+	require.Empty(t, tables)
+
+	_, _, err = explainSummary("not json")
+	require.Error(t, err)
+
+	// mysql_validate's EXPLAIN goes through the same read-only gate.
+	require.NoError(t, testValidator(t, gate.Options{}).Validate("EXPLAIN FORMAT=JSON SELECT * FROM t WHERE id = ?"))
+}
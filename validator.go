@@ -0,0 +1,36 @@
+package main
+
+import "mysqlmcp/internal/gate"
+
+// newValidator builds the read-only gate from the config. It's built once,
+// at startup and on each reload.
+func newValidator(cfg Config, defaultSchema string) (*gate.Validator, error) {
+	if err := validateViewsOnlyConfig(cfg.ViewsOnly); err != nil {
+		return nil, err
+	}
+	var views []string
+	if cfg.ViewsOnly.Enabled {
+		views = cfg.ViewsOnly.Views
+	}
+	var tenantSchemas map[string]bool
+	if cfg.tenant != nil {
+		tenantSchemas = cfg.tenant.schemas()
+	}
+	return gate.New(gate.Options{
+		DenySubstrings:     cfg.MySQL.DenySubstrings,
+		DenyPatterns:       cfg.MySQL.DenyPatterns,
+		DeniedFunctions:    cfg.MySQL.DeniedFunctions,
+		AllowSystemSchemas: cfg.MySQL.AllowSystemSchemas,
+		SystemTables:       cfg.MySQL.SystemTables,
+		DefaultSchema:      defaultSchema,
+		DeniedColumns:      cfg.DeniedColumns,
+		Pseudonymize:       cfg.PII.Pseudonymize,
+		Views:              views,
+		TenantSchemas:      tenantSchemas,
+	})
+}
+
+func isReadOnlyQuery(query string, denySubstrings []string) bool {
+	v, err := gate.New(gate.Options{DenySubstrings: denySubstrings, AllowSystemSchemas: true})
+	return err == nil && v.Validate(query) == nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestNewValidator(t *testing.T) {
+	var cfg Config
+	cfg.MySQL.DenySubstrings = []string{"Password"}
+	cfg.MySQL.DeniedFunctions = []string{"UUID"}
+	v, err := newValidator(cfg, "")
+	require.NoError(t, err)
+
+	require.NoError(t, v.Validate("SELECT id FROM users"))
+	require.ErrorContains(t, v.Validate("SELECT password FROM users"), `denied fragment "password"`)
+	require.ErrorContains(t, v.Validate("SELECT UUID()"), "UUID()")
+	require.ErrorContains(t, v.Validate("SELECT SLEEP(1)"), "SLEEP()", "built-in denied functions still apply")
+	require.ErrorContains(t, v.Validate("DELETE FROM users"), "only SELECT")
+
+	stmt, err := v.Parse("SELECT 1;")
+	require.NoError(t, err)
+	require.True(t, readsRows(stmt))
+}
+
+// testValidator builds a gate from opts for handlers under test.
+func testValidator(t *testing.T, opts gate.Options) *gate.Validator {
+	t.Helper()
+	v, err := gate.New(opts)
+	require.NoError(t, err)
+	return v
+}
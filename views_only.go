@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"mysqlmcp/internal/gate"
+)
+
+// ViewsOnlyConfig restricts queries to curated views. With Enabled set, every
+// table a statement references must match Views, so base tables are out of
+// reach even when the account could read them. The gate can't tell a view
+// from a table by name; listing only views is up to the config, and startup
+// logs any base table that matches.
+type ViewsOnlyConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Views holds "db.view" glob patterns.
+	Views []string `toml:"views"`
+}
+
+func validateViewsOnlyConfig(cfg ViewsOnlyConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if len(cfg.Views) == 0 {
+		return fmt.Errorf("views_only.views must list the views queries may read")
+	}
+	for _, pattern := range cfg.Views {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("views_only.views %q: %w", pattern, err)
+		}
+		if !strings.Contains(pattern, ".") {
+			return fmt.Errorf("views_only.views %q: patterns must be db.view", pattern)
+		}
+	}
+	return nil
+}
+
+// checkViewsOnly warns about base tables that views_only.views matches,
+// since queries could read them directly.
+func checkViewsOnly(ctx context.Context, db *sql.DB, cfg ViewsOnlyConfig) {
+	if !cfg.Enabled {
+		return
+	}
+	rows, err := db.QueryContext(ctx, "SELECT TABLE_SCHEMA, TABLE_NAME FROM information_schema.TABLES WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')")
+	if err != nil {
+		log.Printf("failed to list tables to check views_only.views: %v", err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var schema, name string
+		if err := rows.Scan(&schema, &name); err != nil {
+			log.Printf("failed to list tables to check views_only.views: %v", err)
+			return
+		}
+		if table := schema + "." + name; gate.TableMatches(cfg.Views, table) {
+			log.Printf("views_only.views matches %s, which is a base table, not a view", table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("failed to list tables to check views_only.views: %v", err)
+	}
+}
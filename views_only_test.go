@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewsOnly(t *testing.T) {
+	var cfg Config
+	cfg.ViewsOnly = ViewsOnlyConfig{Enabled: true, Views: []string{"shop.active_orders", "reports.*"}}
+	v, err := newValidator(cfg, "shop")
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"SELECT * FROM active_orders",
+		"SELECT a.id, r.total FROM shop.active_orders a JOIN reports.daily r ON r.day = a.day",
+		"WITH recent AS (SELECT * FROM active_orders) SELECT * FROM recent",
+		"SELECT 1",
+		"SHOW TABLES",
+	} {
+		require.NoError(t, v.Validate(query), query)
+	}
+	for _, query := range []string{
+		"SELECT * FROM orders",
+		"SELECT * FROM active_orders WHERE id IN (SELECT order_id FROM shop.refunds)",
+		"EXPLAIN SELECT * FROM orders",
+		"SELECT * FROM information_schema.TABLES",
+	} {
+		require.ErrorContains(t, v.Validate(query), "views_only.views", query)
+		require.NoError(t, v.ValidateCatalog(query), query)
+	}
+
+	v, err = newValidator(cfg, "")
+	require.NoError(t, err)
+	require.ErrorContains(t, v.Validate("SELECT * FROM active_orders"), "needs a database name")
+
+	cfg.ViewsOnly.Views = nil
+	require.ErrorContains(t, validateViewsOnlyConfig(cfg.ViewsOnly), "must list")
+	cfg.ViewsOnly.Views = []string{"active_orders"}
+	require.ErrorContains(t, validateViewsOnlyConfig(cfg.ViewsOnly), "db.view")
+}
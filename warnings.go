@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// maxQueryWarnings bounds the warnings attached to a result.
+const maxQueryWarnings = 20
+
+// QueryWarning is one row of SHOW WARNINGS.
+type QueryWarning struct {
+	Level   string `json:"level" jsonschema:"Note, Warning, or Error."`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (w QueryWarning) String() string {
+	return fmt.Sprintf("%s %d: %s", w.Level, w.Code, w.Message)
+}
+
+// readWarnings fetches the warnings the previous statement in tx raised,
+// such as truncated values or implicit conversions. It must run before any
+// other statement, which would reset them. Failures are ignored.
+func readWarnings(ctx context.Context, tx *sql.Tx) []QueryWarning {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SHOW WARNINGS LIMIT %d", maxQueryWarnings))
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var warnings []QueryWarning
+	for rows.Next() {
+		var w QueryWarning
+		if err := rows.Scan(&w.Level, &w.Code, &w.Message); err != nil {
+			return warnings
+		}
+		warnings = append(warnings, w)
+	}
+	return warnings
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryWarningString(t *testing.T) {
+	w := QueryWarning{Level: "Warning", Code: 1292, Message: "Truncated incorrect DOUBLE value: 'abc'"}
+	require.Equal(t, "Warning 1292: Truncated incorrect DOUBLE value: 'abc'", w.String())
+}
+
+func TestWarningsInStructuredContent(t *testing.T) {
+	warnings := []QueryWarning{{Level: "Warning", Code: 1292, Message: "Truncated incorrect DOUBLE value: 'abc'"}}
+	structured := queryOutputToStructuredContent(QueryOutput{Columns: []string{}, Rows: [][]interface{}{}, Warnings: warnings})
+	require.Equal(t, warnings, structured["warnings"])
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// workloadMaxFingerprints bounds the workload log; the least recently seen
+// fingerprint is dropped first.
+const workloadMaxFingerprints = 500
+
+// WorkloadQuery is one distinct query shape run through mysql_query, with the
+// most recent text seen for it.
+type WorkloadQuery struct {
+	Fingerprint string    `json:"fingerprint"`
+	Query       string    `json:"query" jsonschema:"Most recent query text with this fingerprint."`
+	Executions  int       `json:"executions"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// workloadLog records the queries agents have run, grouped by fingerprint, so
+// tools can analyze the actual workload.
+type workloadLog struct {
+	max int
+
+	mu      sync.Mutex
+	entries map[string]*WorkloadQuery
+}
+
+func newWorkloadLog(max int) *workloadLog {
+	return &workloadLog{max: max, entries: make(map[string]*WorkloadQuery)}
+}
+
+func (w *workloadLog) record(query string, at time.Time) {
+	fingerprint := queryFingerprint(query)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	entry, ok := w.entries[fingerprint]
+	if !ok {
+		if len(w.entries) >= w.max {
+			w.evictOldestLocked()
+		}
+		entry = &WorkloadQuery{Fingerprint: fingerprint}
+		w.entries[fingerprint] = entry
+	}
+	entry.Query = query
+	entry.Executions++
+	entry.LastSeen = at
+}
+
+func (w *workloadLog) evictOldestLocked() {
+	oldest := ""
+	for fingerprint, entry := range w.entries {
+		if oldest == "" || entry.LastSeen.Before(w.entries[oldest].LastSeen) {
+			oldest = fingerprint
+		}
+	}
+	delete(w.entries, oldest)
+}
+
+// since returns the queries last seen at or after t, most executed first.
+func (w *workloadLog) since(t time.Time) []WorkloadQuery {
+	w.mu.Lock()
+	out := make([]WorkloadQuery, 0, len(w.entries))
+	for _, entry := range w.entries {
+		if !entry.LastSeen.Before(t) {
+			out = append(out, *entry)
+		}
+	}
+	w.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Executions != out[j].Executions {
+			return out[i].Executions > out[j].Executions
+		}
+		return out[i].Fingerprint < out[j].Fingerprint
+	})
+	return out
+}
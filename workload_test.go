@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkloadLog(t *testing.T) {
+	w := newWorkloadLog(2)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	w.record("SELECT * FROM orders WHERE id = 1", start)
+	w.record("SELECT * FROM orders WHERE id = 2", start.Add(time.Minute))
+	w.record("SELECT COUNT(*) FROM customers", start.Add(2*time.Minute))
+
+	got := w.since(start)
+	require.Len(t, got, 2)
+	require.Equal(t, 2, got[0].Executions)
+	require.Equal(t, "SELECT * FROM orders WHERE id = 2", got[0].Query, "latest text is kept")
+	require.Len(t, w.since(start.Add(90*time.Second)), 1)
+
+	w.record("SELECT 1", start.Add(3*time.Minute))
+	got = w.since(start)
+	require.Len(t, got, 2)
+	for _, q := range got {
+		require.NotEqual(t, queryFingerprint("SELECT * FROM orders WHERE id = 1"), q.Fingerprint, "least recently seen entry is evicted")
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"mysqlmcp/internal/gate"
+)
+
+// WriteConfig enables mysql_execute, the one tool that changes data. It is
+// off unless Enabled is set, and even then writes are limited to tables
+// matching Tables.
+type WriteConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Tables holds "db.table" glob patterns, such as "scratch.*". Every table
+	// a statement references, including those it only reads, must match.
+	Tables []string `toml:"tables"`
+	// MaxAffectedRows rolls back statements that change more rows.
+	MaxAffectedRows int64 `toml:"max_affected_rows"`
+}
+
+func validateWriteConfig(cfg Config) error {
+	w := cfg.Write
+	if !w.Enabled {
+		return nil
+	}
+	if len(w.Tables) == 0 {
+		return fmt.Errorf("write.tables must list the tables mysql_execute may write")
+	}
+	for _, pattern := range w.Tables {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("write.tables %q: %w", pattern, err)
+		}
+		if !strings.Contains(pattern, ".") {
+			return fmt.Errorf("write.tables %q: patterns must be db.table", pattern)
+		}
+	}
+	if cfg.MySQL.PrivilegeCheck == privilegeCheckRefuse {
+		return fmt.Errorf("write.enabled needs an account with write privileges, which mysql.privilege_check = %q refuses", privilegeCheckRefuse)
+	}
+	return nil
+}
+
+type ExecuteInput struct {
+	Statement      string `json:"statement" jsonschema:"One INSERT, UPDATE, or DELETE statement. UPDATE and DELETE need a WHERE clause."`
+	Params         []any  `json:"params,omitempty" jsonschema:"Values bound to the statement's ? placeholders, in order."`
+	TimeoutSeconds int    `json:"timeoutSeconds,omitempty" jsonschema:"Timeout for this call, capped by the server's maximum."`
+}
+
+type ExecuteOutput struct {
+	AffectedRows int64    `json:"affectedRows"`
+	LastInsertID int64    `json:"lastInsertId,omitempty"`
+	Tables       []string `json:"tables" jsonschema:"Tables the statement references."`
+}
+
+// validateWriteStatement checks statement against the write policy and
+// returns the tables it references. Only plain INSERT, UPDATE, and DELETE
+// are allowed (no REPLACE), UPDATE and DELETE need a WHERE clause, and every
+// table must match a write.tables pattern. Deny substrings and denied
+// functions apply as they do to reads.
+func validateWriteStatement(statement string, cfg WriteConfig, defaultSchema string, validator *gate.Validator) ([]string, error) {
+	stmt, err := validator.CheckWrite(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0)
+	for _, table := range gate.QueryTables(stmt, defaultSchema) {
+		if table.Schema == "" {
+			return nil, &gate.QueryRejection{Construct: table.Name, Reason: fmt.Sprintf("table %s needs a database name; the DSN has no default database", table.Name)}
+		}
+		name := table.String()
+		if !gate.TableMatches(cfg.Tables, name) {
+			return nil, &gate.QueryRejection{Construct: name, Reason: fmt.Sprintf("table %s is not in write.tables", name)}
+		}
+		tables = append(tables, name)
+	}
+	return tables, nil
+}
+
+// execute runs one write statement on the primary in its own transaction,
+// and rolls it back if it changed more than max_affected_rows rows.
+func (h *queryHandler) execute(ctx context.Context, req *mcp.CallToolRequest, input ExecuteInput) (result *mcp.CallToolResult, output ExecuteOutput, err error) {
+	start := time.Now()
+	rejected := false
+	defer func() {
+		h.auditToolCall(req, "mysql_execute", input.Statement, rejected, start, result, QueryOutput{RowCount: int(output.AffectedRows)})
+	}()
+
+	ctx = withAttribution(ctx, req.Session)
+	empty := ExecuteOutput{Tables: []string{}}
+	live := h.snapshot()
+	// A reload can turn write mode off; turning it on needs a restart.
+	if !live.config.Write.Enabled {
+		rejected = true
+		return toolErrorf(empty, "write mode is disabled")
+	}
+	tables, err := validateWriteStatement(input.Statement, live.config.Write, h.defaultSchema, live.validator)
+	if err != nil {
+		rejected = true
+		return toolErrorf(empty, "statement not allowed: %v", err)
+	}
+	empty.Tables = tables
+	if err := h.authorize(ctx, input.Statement); err != nil {
+		rejected = true
+		return toolErrorf(empty, "statement not allowed: %v", err)
+	}
+	args, err := queryParams(input.Params)
+	if err != nil {
+		return toolErrorf(empty, "invalid params: %v", err)
+	}
+	if err := h.checkPolicy(ctx, input.Statement, args...); err != nil {
+		rejected = true
+		return toolErrorf(empty, "statement not allowed: %v", err)
+	}
+	if stmt, err := gate.ParseStatement(input.Statement); err == nil {
+		if err := h.confirmSensitive(ctx, input.Statement, stmt, true); err != nil {
+			rejected = true
+			return toolErrorf(empty, "statement not run: %v", err)
+		}
+	}
+	defer h.active.begin(ctx, "mysql_execute", input.Statement)()
+
+	ctx, cancel := context.WithTimeout(ctx, h.queryTimeout(input.TimeoutSeconds))
+	defer cancel()
+	conn, err := h.db.Conn(ctx)
+	if err != nil {
+		return toolErrorf(empty, "failed to acquire connection: %v", err)
+	}
+	defer conn.Close()
+	connID, err := h.connectionID(ctx, h.db, conn)
+	if err != nil {
+		return toolErrorf(empty, "failed to read connection id: %v", err)
+	}
+	restoreRole, err := h.setRole(ctx, conn)
+	if err != nil {
+		return toolErrorf(empty, "%v", err)
+	}
+	defer restoreRole()
+	defer h.killOnCancel(ctx, h.db, connID)()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return toolErrorf(empty, "failed to start transaction: %v", err)
+	}
+	res, err := tx.ExecContext(ctx, h.annotateQuery(ctx, input.Statement), args...)
+	if err != nil {
+		_ = tx.Rollback()
+		return toolErrorf(empty, "statement failed: %v", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return toolErrorf(empty, "failed to read affected rows: %v", err)
+	}
+	if limit := live.config.Write.MaxAffectedRows; affected > limit {
+		_ = tx.Rollback()
+		return toolErrorf(empty, "statement changed %d rows, more than write.max_affected_rows (%d); it was rolled back", affected, limit)
+	}
+	if err := tx.Commit(); err != nil {
+		return toolErrorf(empty, "failed to commit: %v", err)
+	}
+	// Cached results may include the rows just written.
+	h.cache.clear()
+	output = ExecuteOutput{AffectedRows: affected, Tables: tables}
+	output.LastInsertID, _ = res.LastInsertId()
+	return nil, output, nil
+}
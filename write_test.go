@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"mysqlmcp/internal/gate"
+)
+
+func TestValidateWriteConfig(t *testing.T) {
+	var cfg Config
+	require.NoError(t, validateWriteConfig(cfg))
+
+	cfg.Write.Enabled = true
+	require.ErrorContains(t, validateWriteConfig(cfg), "must list the tables")
+	cfg.Write.Tables = []string{"scratch"}
+	require.ErrorContains(t, validateWriteConfig(cfg), "must be db.table")
+	cfg.Write.Tables = []string{"scratch.[a"}
+	require.Error(t, validateWriteConfig(cfg))
+	cfg.Write.Tables = []string{"scratch.*"}
+	require.NoError(t, validateWriteConfig(cfg))
+	cfg.MySQL.PrivilegeCheck = privilegeCheckRefuse
+	require.ErrorContains(t, validateWriteConfig(cfg), "privilege_check")
+}
+
+func TestValidateWriteStatement(t *testing.T) {
+	cfg := WriteConfig{Enabled: true, Tables: []string{"scratch.*", "shop.notes"}}
+	v := testValidator(t, gate.Options{})
+
+	tables, err := validateWriteStatement("INSERT INTO scratch.t (a) VALUES (?)", cfg, "shop", v)
+	require.NoError(t, err)
+	require.Equal(t, []string{"scratch.t"}, tables)
+
+	tables, err = validateWriteStatement("UPDATE notes SET body = ? WHERE id = ?;", cfg, "shop", v)
+	require.NoError(t, err)
+	require.Equal(t, []string{"shop.notes"}, tables)
+
+	tables, err = validateWriteStatement("INSERT INTO scratch.copy SELECT * FROM scratch.src WHERE id < 10", cfg, "shop", v)
+	require.NoError(t, err)
+	require.Equal(t, []string{"scratch.copy", "scratch.src"}, tables)
+
+	rejections := map[string]string{
+		"":                                          "empty",
+		"DELETE FROM scratch.t":                     "WHERE clause",
+		"UPDATE scratch.t SET a = 1":                "WHERE clause",
+		"REPLACE INTO scratch.t (a) VALUES (1)":     "only INSERT, UPDATE, and DELETE",
+		"SELECT * FROM scratch.t":                   "only INSERT, UPDATE, and DELETE",
+		"DROP TABLE scratch.t":                      "only INSERT, UPDATE, and DELETE",
+		"DELETE FROM orders WHERE id = 1":           "not in write.tables",
+		"INSERT INTO scratch.t SELECT * FROM users": "not in write.tables",
+		"DELETE FROM scratch.t WHERE id = 1; DELETE FROM scratch.u WHERE id = 1": "single statement",
+		"DELETE FROM scratch.t WHERE id = sleep(5)":                              "SLEEP()",
+	}
+	for statement, want := range rejections {
+		_, err := validateWriteStatement(statement, cfg, "shop", v)
+		require.ErrorContains(t, err, want, statement)
+	}
+
+	_, err = validateWriteStatement("DELETE FROM scratch.t WHERE note = 'x'", cfg, "shop", testValidator(t, gate.Options{DenySubstrings: []string{"note"}}))
+	require.ErrorContains(t, err, "denied fragment")
+	_, err = validateWriteStatement("DELETE FROM t WHERE id = 1", cfg, "", v)
+	require.ErrorContains(t, err, "needs a database name")
+	_, err = validateWriteStatement("DELETE FROM mysql.user WHERE user = 'x'", WriteConfig{Enabled: true, Tables: []string{"*.*"}}, "shop", v)
+	require.ErrorContains(t, err, "system schema")
+}